@@ -0,0 +1,11 @@
+// Package api embeds the generated OpenAPI document so it ships inside the binary
+package api
+
+import _ "embed"
+
+// OpenAPI holds the OpenAPI 3 document of the REST API, generated from the
+// route annotations in internal/handlers/openapi.go
+//
+//go:generate go run .. openapi -o openapi.json
+//go:embed openapi.json
+var OpenAPI []byte
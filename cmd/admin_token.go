@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runCreateAdminToken handles "create-admin-token"
+func runCreateAdminToken(configPath string, args []string) {
+	fs := flag.NewFlagSet("create-admin-token", flag.ExitOnError)
+	subject := fs.String("subject", "admin", "who the token is issued to, recorded in the sub claim")
+	ttl := fs.Duration("ttl", 24*time.Hour, "token lifetime")
+	fs.Parse(args)
+
+	cfg := loadConfig(configPath)
+
+	// Токен подписывается тем же секретом, что и пользовательские JWT,
+	// поэтому база данных не нужна
+	userService := services.NewUserService(nil, cfg.JWT.Secret)
+	token, err := userService.GenerateAdminJWT(*subject, *ttl)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create admin token")
+	}
+
+	fmt.Println(token)
+}
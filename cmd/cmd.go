@@ -2,158 +2,238 @@ package cmd
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"sync-photo-backend/internal/config"
-	"sync-photo-backend/internal/handlers"
-	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/redact"
 	"sync-photo-backend/internal/repository"
-	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/tracing"
 
-	"github.com/go-chi/chi/v5"
-	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+const usage = `Usage: server [-config config.yaml] [-dev] <command> [arguments]
+
+Commands:
+  serve                         start the HTTP/WebSocket server (default); with -dev
+                                data is kept in memory and photos on local disk
+  migrate up|down|status|force  manage the database schema
+  create-admin-token            print a signed token for /api/v1/admin endpoints
+  seed                          create a sample pair with photos for local development
+  openapi [-o file]             print the OpenAPI document of the REST API
+  ws-loadtest [-url -pairs ...] simulate pairs against a running server and report latencies
+  ws-bench [-conns -op ...]     measure hub throughput and latency in process
+`
+
+// Run parses the command line and executes the selected subcommand
 func Run() {
-	// Load configuration
-	cfg, err := config.Load("config.yaml")
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "path to the config file")
+	dev := fs.Bool("dev", false, "run without PostgreSQL, S3 and APNs for demos and frontend development")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	fs.Parse(os.Args[1:])
+
+	command, args := "serve", []string(nil)
+	if fs.NArg() > 0 {
+		command, args = fs.Arg(0), fs.Args()[1:]
+	}
+
+	switch command {
+	case "serve":
+		runServe(*configPath, *dev)
+	case "migrate":
+		runMigrate(*configPath, args)
+	case "create-admin-token":
+		runCreateAdminToken(*configPath, args)
+	case "seed":
+		runSeed(*configPath, args)
+	case "openapi":
+		runOpenAPI(args)
+	case "ws-loadtest":
+		runWSLoadtest(args)
+	case "ws-bench":
+		runWSBench(args)
+	case "help", "-h", "--help":
+		fs.Usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// loadConfig loads the configuration and sets up the logger; shared by all subcommands
+func loadConfig(path string) *config.Config {
+	cfg, err := config.Load(path)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
 
-	// Setup logger
-	setupLogger(cfg.Log.Level)
+	setupLogger(cfg.Log)
 
-	// Connect to database
-	db, err := pgxpool.New(context.Background(), cfg.Database.DSN())
+	return cfg
+}
+
+// loadDevConfig is loadConfig for dev mode, see config.LoadDev
+func loadDevConfig(path string) *config.Config {
+	cfg, err := config.LoadDev(path)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	setupLogger(cfg.Log)
+
+	return cfg
+}
+
+// connectDB opens the primary database pool and verifies the connection
+func connectDB(ctx context.Context, cfg *config.Config) *pgxpool.Pool {
+	var db *pgxpool.Pool
+	err := retryStartup(ctx, cfg.Startup, "database", func(ctx context.Context) error {
+		var err error
+		db, err = openPool(ctx, cfg.Database.DSN(), cfg)
+		return err
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
-	defer db.Close()
-
-	// Test database connection
-	if err := db.Ping(context.Background()); err != nil {
-		log.Fatal().Err(err).Msg("Failed to ping database")
-	}
-	log.Info().Msg("Database connection established")
-
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	pairRepo := repository.NewPairRepository(db)
-	photoRepo := repository.NewPhotoRepository(db)
-
-	// Initialize services
-	userService := services.NewUserService(userRepo, cfg.JWT.Secret)
-	pairService := services.NewPairService(pairRepo, userRepo)
-	photoService, err := services.NewPhotoService(
-		photoRepo,
-		pairRepo,
-		cfg.AWS.Region,
-		cfg.AWS.S3Bucket,
-		cfg.AWS.AccessKey,
-		cfg.AWS.SecretKey,
-		cfg.AWS.Endpoint,
-	)
+	poolConfig := db.Config()
+	log.Info().
+		Int32("max_conns", poolConfig.MaxConns).
+		Int32("min_conns", poolConfig.MinConns).
+		Dur("max_conn_lifetime", poolConfig.MaxConnLifetime).
+		Dur("max_conn_idle_time", poolConfig.MaxConnIdleTime).
+		Dur("health_check_period", poolConfig.HealthCheckPeriod).
+		Msg("Database connection established")
+
+	return db
+}
+
+// syncApps registers the configured apps so users and pairs can reference them
+func syncApps(ctx context.Context, db *pgxpool.Pool, cfg *config.Config) {
+	appRepo := repository.NewAppRepository(db)
+	for id, app := range cfg.Apps {
+		if err := appRepo.Upsert(ctx, id, app.Name); err != nil {
+			log.Fatal().Err(err).Msg("Failed to register apps")
+		}
+	}
+	log.Info().Int("apps", len(cfg.Apps)).Msg("Apps registered")
+}
+
+// connectReplica opens the read replica pool if one is configured; returns nil otherwise
+func connectReplica(ctx context.Context, cfg *config.Config) *pgxpool.Pool {
+	if cfg.Database.ReplicaDSN == "" {
+		return nil
+	}
+
+	var replica *pgxpool.Pool
+	err := retryStartup(ctx, cfg.Startup, "database replica", func(ctx context.Context) error {
+		var err error
+		replica, err = openPool(ctx, cfg.Database.ReplicaDSN, cfg)
+		return err
+	})
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create photo service")
+		log.Fatal().Err(err).Msg("Failed to connect to database replica")
 	}
-	wsHub := services.NewWSHub(pairService)
+	log.Info().Msg("Database replica connection established")
+
+	return replica
+}
 
-	pushService, err := services.NewPushService(cfg.APNs)
+// openPool creates a pgx pool for dsn and pings it
+func openPool(ctx context.Context, dsn string, cfg *config.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create push service")
-	}
-
-	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
-	pairHandler := handlers.NewPairHandler(pairService, userService, pushService, wsHub)
-	photoHandler := handlers.NewPhotoHandler(photoService)
-	wsHandler := handlers.NewWebSocketHandler(wsHub, userService, pairService, photoService, pushService)
-
-	// Setup router
-	r := chi.NewRouter()
-
-	// Middleware
-	r.Use(chiMiddleware.RequestID)
-	r.Use(chiMiddleware.RealIP)
-	r.Use(chiMiddleware.Logger)
-	r.Use(chiMiddleware.Recoverer)
-	r.Use(corsMiddleware)
-
-	// Routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Public routes
-		r.Post("/users", userHandler.CreateUser)
-
-		// Protected routes
-		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(userService))
-			r.Put("/users/push-token", userHandler.UpdatePushToken)
-			r.Post("/pairs", pairHandler.CreatePair)
-			r.Delete("/pairs/{pair_id}", pairHandler.DeletePair)
-			r.Get("/photos", photoHandler.GetPhotos)
-			r.Post("/photos/upload", photoHandler.UploadPhoto)
-		})
-	})
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+	applyPoolSettings(poolConfig, &cfg.Database)
+	repository.ConfigureUTC(poolConfig.ConnConfig)
+	poolConfig.AfterConnect = repository.RegisterUTC
+	poolConfig.ConnConfig.Tracer = multitracer.New(
+		tracing.PgxTracer{},
+		repository.NewQueryTracer(cfg.Database.SlowQueryThreshold),
+	)
 
-	// WebSocket route
-	r.Get("/ws", wsHandler.HandleWebSocket)
-
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	// Start server in goroutine
-	go func() {
-		log.Info().
-			Str("host", cfg.Server.Host).
-			Int("port", cfg.Server.Port).
-			Msg("Starting server")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal().Err(err).Msg("Server failed to start")
-		}
-	}()
+	// Пул прогревает min_conns в фоне с этим контекстом, поэтому он не
+	// должен истекать вместе с попыткой подключения
+	db, err := pgxpool.NewWithConfig(context.WithoutCancel(ctx), poolConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	// Wait for interrupt signal for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	if err := db.Ping(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
 
-	log.Info().Msg("Shutting down server...")
+	return db, nil
+}
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+// applyPoolSettings overrides pgxpool defaults with values set in the config
+func applyPoolSettings(poolConfig *pgxpool.Config, c *config.DatabaseConfig) {
+	if c.MaxConns > 0 {
+		poolConfig.MaxConns = c.MaxConns
+	}
+	if c.MinConns > 0 {
+		poolConfig.MinConns = c.MinConns
+	}
+	if c.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = c.MaxConnLifetime
+	}
+	if c.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = c.MaxConnIdleTime
+	}
+	if c.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = c.HealthCheckPeriod
+	}
+}
 
-	// Close WebSocket connections
-	// Note: wsHub doesn't have a Close method, connections will be closed when server shuts down
+// connectRedis connects to Redis if it is configured; returns nil otherwise
+func connectRedis(ctx context.Context, cfg *config.Config) *redis.Client {
+	if cfg.Redis.Addr == "" {
+		return nil
+	}
 
-	// Shutdown HTTP server
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error().Err(err).Msg("Server forced to shutdown")
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	err := retryStartup(ctx, cfg.Startup, "redis", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	if err != nil {
+		log.Fatal().Err(err).Str("addr", cfg.Redis.Addr).Msg("Failed to connect to Redis")
 	}
+	log.Info().Str("addr", cfg.Redis.Addr).Msg("Redis connection established")
 
-	log.Info().Msg("Server exited")
+	return client
 }
 
 // setupLogger configures zerolog logger
-func setupLogger(level string) {
+func setupLogger(cfg config.LogConfig) {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	// Секреты вырезаются из JSON события до форматирования
+	if cfg.Format == "json" {
+		log.Logger = zerolog.New(redact.Writer(os.Stderr)).With().Timestamp().Logger()
+	} else {
+		log.Logger = log.Output(redact.Writer(zerolog.ConsoleWriter{Out: os.Stderr}))
+	}
+
+	// log.Ctx(ctx) falls back to the global logger outside of HTTP requests
+	zerolog.DefaultContextLogger = &log.Logger
+
+	setLogLevel(cfg.Level)
+}
 
+// setLogLevel sets the global log level; safe to call at runtime
+func setLogLevel(level string) {
 	switch level {
 	case "debug":
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
@@ -167,19 +247,3 @@ func setupLogger(level string) {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
 }
-
-// corsMiddleware handles CORS
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,440 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/handlers"
+	"sync-photo-backend/internal/services"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// Operations measured by ws-loadtest, in report order
+const (
+	opCreateUser = "create_user"
+	opCreatePair = "create_pair"
+	opConnect    = "ws_connect"
+	opTrigger    = "trigger"
+	opPresign    = "presign"
+	opUpload     = "upload"
+	opConfirm    = "confirm"
+	opWSError    = "ws_error"
+)
+
+var loadtestOps = []string{opCreateUser, opCreatePair, opConnect, opTrigger, opPresign, opUpload, opConfirm, opWSError}
+
+// loadtestOptions holds the ws-loadtest flags
+type loadtestOptions struct {
+	baseURL  string
+	pairs    int
+	cycles   int
+	interval time.Duration
+	rampUp   time.Duration
+	timeout  time.Duration
+	upload   bool
+}
+
+// runWSLoadtest handles "ws-loadtest": simulates pairs that connect, trigger
+// a photo and confirm an upload against a running server, then prints
+// latency percentiles per operation
+func runWSLoadtest(args []string) {
+	fs := flag.NewFlagSet("ws-loadtest", flag.ExitOnError)
+	opts := loadtestOptions{}
+	fs.StringVar(&opts.baseURL, "url", "http://localhost:8080", "base URL of the server under test")
+	fs.IntVar(&opts.pairs, "pairs", 10, "number of simulated pairs")
+	fs.IntVar(&opts.cycles, "cycles", 10, "trigger/upload cycles per pair")
+	fs.DurationVar(&opts.interval, "interval", time.Second, "pause between cycles of a pair")
+	fs.DurationVar(&opts.rampUp, "ramp-up", 5*time.Second, "time over which pairs are started")
+	fs.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of a single operation")
+	fs.BoolVar(&opts.upload, "upload", false, "PUT a sample image to the pre-signed URL before confirming; without it the server rejects confirmations as not uploaded")
+	fs.Parse(args)
+
+	// Конфигурация сервера не нужна, нагрузка идет через публичный API
+	setupLogger(config.LogConfig{Level: "info"})
+
+	if opts.pairs <= 0 || opts.cycles <= 0 {
+		log.Fatal().Msg("-pairs and -cycles must be positive")
+	}
+	if _, err := url.Parse(opts.baseURL); err != nil {
+		log.Fatal().Err(err).Msg("Invalid -url")
+	}
+
+	stats := newLoadStats(loadtestOps)
+	client := &http.Client{Timeout: opts.timeout}
+	image := sampleImage(0)
+
+	log.Info().
+		Str("url", opts.baseURL).
+		Int("pairs", opts.pairs).
+		Int("cycles", opts.cycles).
+		Msg("Starting WebSocket load test")
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.pairs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sp := &simulatedPair{opts: opts, client: client, stats: stats, image: image}
+			if err := sp.run(); err != nil {
+				log.Warn().Err(err).Int("pair", i).Msg("Simulated pair stopped")
+			}
+		}()
+		// Равномерный разгон, чтобы не упереться в rate limit создания пользователей
+		time.Sleep(opts.rampUp / time.Duration(opts.pairs))
+	}
+	wg.Wait()
+
+	fmt.Printf("\n%d pairs, %d cycles each, %s\n\n", opts.pairs, opts.cycles, time.Since(start).Round(time.Millisecond))
+	stats.print(os.Stdout)
+}
+
+// simulatedPair drives two clients through the pairing and photo flow
+type simulatedPair struct {
+	opts   loadtestOptions
+	client *http.Client
+	stats  *loadStats
+	image  []byte
+}
+
+func (sp *simulatedPair) run() error {
+	ctx := context.Background()
+
+	var users [2]handlers.CreateUserResponse
+	for i := range users {
+		err := sp.stats.measure(opCreateUser, func() error {
+			return sp.postJSON(ctx, "/api/v1/users", "", nil, &users[i])
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := sp.stats.measure(opCreatePair, func() error {
+		return sp.postJSON(ctx, "/api/v1/pairs", users[0].Token, map[string]string{"partner_code": users[1].Code}, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	var clients [2]*loadClient
+	for i, user := range users {
+		err := sp.stats.measure(opConnect, func() error {
+			var err error
+			clients[i], err = dialLoadClient(sp.opts, user.Token, sp.stats)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		defer clients[i].close()
+	}
+	initiator, partner := clients[0], clients[1]
+
+	for cycle := 0; cycle < sp.opts.cycles; cycle++ {
+		if cycle > 0 {
+			time.Sleep(sp.opts.interval)
+		}
+
+		// Задержка триггера — от отправки до получения take_photo партнером
+		err := sp.stats.measure(opTrigger, func() error {
+			if err := initiator.send(services.WSMessage{Type: "trigger_photo"}); err != nil {
+				return err
+			}
+			return partner.waitTakePhoto(sp.opts.timeout)
+		})
+		if err != nil {
+			continue
+		}
+
+		var upload services.UploadResponse
+		err = sp.stats.measure(opPresign, func() error {
+			return sp.postJSON(ctx, "/api/v1/photos/upload", users[1].Token,
+				services.UploadRequest{Filename: "loadtest.jpg", ContentType: "image/jpeg", SizeBytes: int64(len(sp.image))}, &upload)
+		})
+		if err != nil {
+			continue
+		}
+
+		if sp.opts.upload {
+			err = sp.stats.measure(opUpload, func() error {
+				return sp.put(ctx, upload.UploadURL)
+			})
+			if err != nil {
+				continue
+			}
+		}
+
+		// Сервер не подтверждает photo_uploaded, поэтому измеряется только отправка
+		sp.stats.measure(opConfirm, func() error {
+			return partner.send(services.WSMessage{Type: "photo_uploaded", PhotoID: upload.PhotoID})
+		})
+	}
+	return nil
+}
+
+// postJSON sends body to the API and decodes the response into out
+func (sp *simulatedPair) postJSON(ctx context.Context, path, token string, body, out interface{}) error {
+	var payload io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(sp.opts.baseURL, "/")+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := sp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s: %s", path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// put uploads the sample image to a pre-signed URL
+func (sp *simulatedPair) put(ctx context.Context, uploadURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(sp.image))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+
+	resp, err := sp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("PUT upload URL: %s", resp.Status)
+	}
+	return nil
+}
+
+// loadClient is one simulated WebSocket client
+type loadClient struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	ready     chan struct{} // closed on pair_status, sent once the hub registered the connection
+	takePhoto chan struct{}
+	done      chan struct{}
+}
+
+func dialLoadClient(opts loadtestOptions, token string, stats *loadStats) (*loadClient, error) {
+	wsURL, err := url.Parse(strings.TrimSuffix(opts.baseURL, "/") + "/ws")
+	if err != nil {
+		return nil, err
+	}
+	if wsURL.Scheme == "https" {
+		wsURL.Scheme = "wss"
+	} else {
+		wsURL.Scheme = "ws"
+	}
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: opts.timeout,
+		// Токен в подпротоколе, а не в URL: ?token= может быть отключен.
+		// Клиент читает кадры v1, токен принимается только с версией.
+		Subprotocols: []string{services.WSSubprotocolV1, services.WSTokenSubprotocolPrefix + token},
+	}
+	conn, _, err := dialer.Dial(wsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &loadClient{
+		conn:      conn,
+		ready:     make(chan struct{}),
+		takePhoto: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	go c.read(stats)
+
+	// До регистрации в хабе триггер партнера вернул бы "Partner is offline"
+	select {
+	case <-c.ready:
+		return c, nil
+	case <-c.done:
+		c.conn.Close()
+		return nil, errors.New("connection closed before pair_status")
+	case <-time.After(opts.timeout):
+		c.close()
+		return nil, errors.New("pair_status not received")
+	}
+}
+
+// read dispatches incoming messages until the connection is closed
+func (c *loadClient) read(stats *loadStats) {
+	defer close(c.done)
+	ready := false
+	for {
+		var msg services.WSMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "pair_status":
+			if !ready {
+				ready = true
+				close(c.ready)
+			}
+		case "take_photo":
+			select {
+			case c.takePhoto <- struct{}{}:
+			default:
+			}
+		case "error":
+			stats.fail(opWSError, errors.New(msg.Message))
+		}
+	}
+}
+
+func (c *loadClient) send(msg services.WSMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(msg)
+}
+
+// waitTakePhoto waits for the next take_photo message
+func (c *loadClient) waitTakePhoto(timeout time.Duration) error {
+	select {
+	case <-c.takePhoto:
+		return nil
+	case <-c.done:
+		return errors.New("connection closed")
+	case <-time.After(timeout):
+		return errors.New("take_photo not received")
+	}
+}
+
+func (c *loadClient) close() {
+	c.writeMu.Lock()
+	c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	c.conn.Close()
+	<-c.done
+}
+
+// loadStats collects latencies and errors per operation
+type loadStats struct {
+	mu sync.Mutex
+	// ops are the reported operations, in report order
+	ops       []string
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	lastError map[string]string
+}
+
+func newLoadStats(ops []string) *loadStats {
+	return &loadStats{
+		ops:       ops,
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		lastError: make(map[string]string),
+	}
+}
+
+// measure runs fn and records its latency, or its error
+func (s *loadStats) measure(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		s.fail(op, err)
+		return err
+	}
+
+	s.record(op, time.Since(start))
+	return nil
+}
+
+// record adds a latency measured by the caller
+func (s *loadStats) record(op string, latency time.Duration) {
+	s.mu.Lock()
+	s.latencies[op] = append(s.latencies[op], latency)
+	s.mu.Unlock()
+}
+
+func (s *loadStats) fail(op string, err error) {
+	s.mu.Lock()
+	s.errors[op]++
+	s.lastError[op] = err.Error()
+	s.mu.Unlock()
+}
+
+// print writes a table of latency percentiles and the last error per operation
+func (s *loadStats) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "op\tok\terrors\tp50\tp90\tp99\tmax\t")
+	for _, op := range s.ops {
+		latencies := s.latencies[op]
+		if len(latencies) == 0 && s.errors[op] == 0 {
+			continue
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", op, len(latencies), s.errors[op],
+			percentile(latencies, 0.5), percentile(latencies, 0.9), percentile(latencies, 0.99), percentile(latencies, 1))
+	}
+	tw.Flush()
+
+	for _, op := range s.ops {
+		if msg, ok := s.lastError[op]; ok {
+			fmt.Fprintf(w, "\nlast %s error: %s", op, msg)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// percentile returns the q-th percentile of sorted latencies
+func percentile(sorted []time.Duration, q float64) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	latency := sorted[max(i, 0)]
+	// Вызовы хаба в ws-bench занимают микросекунды
+	if latency < time.Millisecond {
+		return latency.Round(time.Microsecond).String()
+	}
+	return latency.Round(100 * time.Microsecond).String()
+}
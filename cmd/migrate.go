@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"sync-photo-backend/db"
+	"sync-photo-backend/internal/migrate"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runMigrate handles "migrate up|down|status|force"
+func runMigrate(configPath string, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back (down only)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: server migrate up | down [-steps N] | status | force VERSION")
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+
+	cfg := loadConfig(configPath)
+	ctx := context.Background()
+
+	pool := connectDB(ctx, cfg)
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, db.Migrations, "migrations")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load migrations")
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Info().Int("version", m.Version).Str("name", m.Name).Msg("Migration applied")
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msg("Migration failed")
+		}
+		if len(applied) == 0 {
+			log.Info().Msg("Database is up to date")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(ctx, *steps)
+		for _, m := range rolledBack {
+			log.Info().Int("version", m.Version).Str("name", m.Name).Msg("Migration rolled back")
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msg("Rollback failed")
+		}
+	case "status":
+		applied, err := migrator.Applied(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get migration status")
+		}
+		for _, m := range migrator.Migrations() {
+			status := "pending"
+			if applied[m.Version] {
+				status = "applied"
+			}
+			fmt.Printf("%03d_%s\t%s\n", m.Version, m.Name, status)
+		}
+	case "force":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		version, err := strconv.Atoi(fs.Arg(0))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid version")
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			log.Fatal().Err(err).Msg("Failed to force migration version")
+		}
+		log.Info().Int("version", version).Msg("Migration version forced")
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"sync-photo-backend/internal/handlers"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runOpenAPI handles "openapi": prints the OpenAPI document built from the
+// route annotations; used by go generate to refresh api/openapi.json
+func runOpenAPI(args []string) {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	output := fs.String("o", "", "write the document to this file instead of stdout")
+	fs.Parse(args)
+
+	// Конфигурация не нужна: документ строится только из аннотаций
+	doc, err := json.MarshalIndent(handlers.OpenAPIDocument(), "", "  ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to encode OpenAPI document")
+	}
+	doc = append(doc, '\n')
+
+	if *output == "" {
+		fmt.Print(string(doc))
+		return
+	}
+	if err := os.WriteFile(*output, doc, 0o644); err != nil {
+		log.Fatal().Err(err).Msg("Failed to write OpenAPI document")
+	}
+}
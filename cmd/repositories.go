@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"sync-photo-backend/internal/cache"
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/repository"
+	"sync-photo-backend/internal/repository/memory"
+	"sync-photo-backend/internal/services"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// repositories are the storage implementations the services are wired with
+type repositories struct {
+	uow          services.UnitOfWork
+	user         services.UserRepository
+	pair         services.PairRepository
+	photo        services.PhotoRepository
+	composite    services.CompositeRepository
+	syncSession  services.SyncSessionRepository
+	reaction     services.ReactionRepository
+	prompt       services.PromptRepository
+	pairSettings services.PairSettingsRepository
+	refreshToken services.RefreshTokenRepository
+	idempotency  services.IdempotencyRepository
+	job          services.JobRepository
+	outbox       services.OutboxRepository
+	userEvent    services.UserEventRepository
+	stats        services.StatsRepository
+	event        services.EventRepository
+	webhook      services.WebhookRepository
+	domainEvent  services.DomainEventRepository
+}
+
+// newPostgresRepositories creates the PostgreSQL repositories; replica and
+// redisClient may be nil
+func newPostgresRepositories(cfg *config.Config, db, replica *pgxpool.Pool, redisClient *redis.Client) *repositories {
+	userRepo := repository.NewUserRepository(db).WithReadReplica(replica)
+	pairRepo := repository.NewPairRepository(db)
+	if cfg.Cache.Enabled {
+		var lookupCache cache.Cache
+		if redisClient != nil {
+			lookupCache = cache.NewRedisCache(redisClient, "cache")
+		} else {
+			lookupCache = cache.NewMemoryCache()
+		}
+		userRepo.WithCache(lookupCache, cfg.Cache.TTL)
+		pairRepo.WithCache(lookupCache, cfg.Cache.TTL)
+	}
+
+	return &repositories{
+		uow:          repository.NewTxManager(db),
+		user:         userRepo,
+		pair:         pairRepo,
+		photo:        repository.NewPhotoRepository(db).WithReadReplica(replica),
+		composite:    repository.NewCompositeRepository(db),
+		syncSession:  repository.NewSyncSessionRepository(db),
+		reaction:     repository.NewReactionRepository(db),
+		prompt:       repository.NewPromptRepository(db),
+		pairSettings: repository.NewPairSettingsRepository(db),
+		refreshToken: repository.NewRefreshTokenRepository(db),
+		idempotency:  repository.NewIdempotencyRepository(db),
+		job:          repository.NewJobRepository(db),
+		outbox:       repository.NewOutboxRepository(db),
+		userEvent:    repository.NewUserEventRepository(db),
+		stats:        repository.NewStatsRepository(db),
+		event:        repository.NewEventRepository(db),
+		webhook:      repository.NewWebhookRepository(db),
+		domainEvent:  repository.NewDomainEventRepository(db),
+	}
+}
+
+// newMemoryRepositories creates repositories sharing one in-memory store for dev mode
+func newMemoryRepositories() *repositories {
+	store := memory.NewStore()
+	return &repositories{
+		uow:          memory.NewUnitOfWork(),
+		user:         memory.NewUserRepository(store),
+		pair:         memory.NewPairRepository(store),
+		photo:        memory.NewPhotoRepository(store),
+		composite:    memory.NewCompositeRepository(store),
+		syncSession:  memory.NewSyncSessionRepository(store),
+		reaction:     memory.NewReactionRepository(store),
+		prompt:       memory.NewPromptRepository(store),
+		pairSettings: memory.NewPairSettingsRepository(store),
+		refreshToken: memory.NewRefreshTokenRepository(store),
+		idempotency:  memory.NewIdempotencyRepository(store),
+		job:          memory.NewJobRepository(store),
+		outbox:       memory.NewOutboxRepository(store),
+		userEvent:    memory.NewUserEventRepository(store),
+		stats:        memory.NewStatsRepository(store),
+		event:        memory.NewEventRepository(store),
+		webhook:      memory.NewWebhookRepository(store),
+		domainEvent:  memory.NewDomainEventRepository(store),
+	}
+}
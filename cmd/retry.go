@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// startupAttemptTimeout bounds a single connection attempt, so a dependency
+// that accepts connections but never answers doesn't stall startup
+const startupAttemptTimeout = 10 * time.Second
+
+// retryStartup calls connect until it succeeds or cfg.RetryAttempts are
+// used up, doubling the delay between attempts. Dependencies started by
+// docker-compose alongside the server are often not ready yet.
+func retryStartup(ctx context.Context, cfg config.StartupConfig, name string, connect func(context.Context) error) error {
+	delay := cfg.RetryInitialDelay
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, startupAttemptTimeout)
+		err := connect(attemptCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				log.Info().Str("dependency", name).Int("attempt", attempt).Msg("Dependency is ready")
+			}
+			return nil
+		}
+
+		if attempt >= cfg.RetryAttempts {
+			return fmt.Errorf("%s is not ready after %d attempts: %w", name, attempt, err)
+		}
+
+		log.Warn().
+			Err(err).
+			Str("dependency", name).
+			Int("attempt", attempt).
+			Int("max_attempts", cfg.RetryAttempts).
+			Dur("retry_in", delay).
+			Msg("Dependency is not ready, retrying")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, cfg.RetryMaxDelay)
+	}
+}
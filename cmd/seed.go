@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/repository"
+	"sync-photo-backend/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// runSeed handles "seed": creates two paired users with sample photos
+// for local development and prints their tokens
+func runSeed(configPath string, args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	photos := fs.Int("photos", 6, "number of sample photos to create")
+	noUpload := fs.Bool("no-upload", false, "only create photo records, don't upload images to storage")
+	appID := fs.String("app", config.DefaultAppID, "app to create the users in")
+	fs.Parse(args)
+
+	cfg := loadConfig(configPath)
+	ctx := context.Background()
+
+	db := connectDB(ctx, cfg)
+	defer db.Close()
+
+	syncApps(ctx, db, cfg)
+
+	userRepo := repository.NewUserRepository(db)
+	pairRepo := repository.NewPairRepository(db)
+	photoRepo := repository.NewPhotoRepository(db)
+
+	eventLog := services.NewEventLog(repository.NewDomainEventRepository(db), pairRepo)
+	userService := services.NewUserService(userRepo, cfg.JWT.Secret).
+		WithApps(cfg.Apps).
+		WithSessions(repository.NewRefreshTokenRepository(db), cfg.JWT)
+	pairService := services.NewPairService(pairRepo, userRepo).WithEventLog(eventLog)
+
+	userA, err := userService.CreateUser(ctx, *appID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create user")
+	}
+	userB, err := userService.CreateUser(ctx, *appID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create user")
+	}
+
+	pair, err := pairService.CreatePair(ctx, userA.ID, userB.Code)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create pair")
+	}
+
+	var photoService *services.PhotoService
+	if !*noUpload {
+		store, err := newObjectStore(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Str("provider", cfg.Storage.Provider).Msg("Failed to create object store")
+		}
+		photoService = newPhotoService(cfg, photoRepo, pairRepo, store).WithEventLog(eventLog)
+	}
+
+	// Фото создаются парами, как при синхронной съемке
+	for i := 0; i < *photos; i++ {
+		userID := userA.ID
+		if i%2 == 1 {
+			userID = userB.ID
+		}
+		takenAt := time.Now().UTC().Add(-time.Duration(*photos-i/2*2) * time.Hour)
+
+		if photoService != nil {
+			if _, err := photoService.StorePhoto(ctx, pair, userID, sampleImage(i), "image/jpeg", takenAt); err != nil {
+				log.Fatal().Err(err).Msg("Failed to store sample photo")
+			}
+			continue
+		}
+
+		photo := &models.Photo{
+			ID:        uuid.New().String(),
+			PairID:    pair.ID,
+			UserID:    userID,
+			S3Key:     fmt.Sprintf("%s%s/sample-%d.jpg", cfg.Apps[*appID].S3Prefix, pair.ID, i),
+			TakenAt:   takenAt,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := photoRepo.Create(ctx, photo); err != nil {
+			log.Fatal().Err(err).Msg("Failed to create sample photo")
+		}
+	}
+
+	fmt.Printf("Pair:    %s\n\n", pair.ID)
+	for _, user := range []*models.User{userA, userB} {
+		fmt.Printf("User:    %s\nCode:    %s\nToken:   %s\nRefresh: %s\n\n", user.ID, user.Code, user.Token, user.RefreshToken)
+	}
+	fmt.Printf("Photos:  %d\n", *photos)
+}
+
+// sampleImage renders a small solid-color JPEG so each seeded photo is distinguishable
+func sampleImage(i int) []byte {
+	palette := []color.RGBA{
+		{R: 0xF2, G: 0x8B, B: 0x82, A: 0xFF},
+		{R: 0x8A, G: 0xB4, B: 0xF8, A: 0xFF},
+		{R: 0x81, G: 0xC9, B: 0x95, A: 0xFF},
+		{R: 0xFD, G: 0xD6, B: 0x63, A: 0xFF},
+	}
+	c := palette[i%len(palette)]
+
+	img := image.NewRGBA(image.Rect(0, 0, 480, 640))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80})
+	return buf.Bytes()
+}
@@ -0,0 +1,629 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sync-photo-backend/api"
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/graph"
+	"sync-photo-backend/internal/grpcapi"
+	"sync-photo-backend/internal/handlers"
+	v2 "sync-photo-backend/internal/handlers/v2"
+	"sync-photo-backend/internal/hubbackend"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/ratelimit"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/tracing"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+// devStoragePrefix is where photos are uploaded and served in dev mode
+const devStoragePrefix = "/dev/storage"
+
+// runServe starts the HTTP and WebSocket server. In dev mode data is kept
+// in memory and photos on disk, so no external services are needed.
+func runServe(configPath string, dev bool) {
+	var cfg *config.Config
+	var reloader *config.Reloader
+	if dev {
+		cfg = loadDevConfig(configPath)
+		reloader = config.NewDevReloader(configPath)
+	} else {
+		cfg = loadConfig(configPath)
+		reloader = config.NewReloader(configPath)
+	}
+
+	// Runtime-reloadable settings
+	reloader.OnReload(func(c *config.Config) {
+		setLogLevel(c.Log.Level)
+		log.Info().Str("log_level", c.Log.Level).Msg("Log level reloaded")
+	})
+
+	// Setup tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup tracing")
+	}
+
+	// Setup error reporting
+	flushErrors, err := errreport.Setup(cfg.ErrorReporting)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup error reporting")
+	}
+
+	// Background workers stop when appCtx is cancelled on shutdown
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// Connect to database
+	var db, replica *pgxpool.Pool
+	if !dev {
+		db = connectDB(context.Background(), cfg)
+		defer db.Close()
+		if err := metrics.RegisterDBPool("primary", db); err != nil {
+			log.Fatal().Err(err).Msg("Failed to register database pool metrics")
+		}
+		replica = connectReplica(context.Background(), cfg)
+		if replica != nil {
+			defer replica.Close()
+			if err := metrics.RegisterDBPool("replica", replica); err != nil {
+				log.Fatal().Err(err).Msg("Failed to register database pool metrics")
+			}
+		}
+	}
+
+	// Connect to Redis (optional)
+	redisClient := connectRedis(context.Background(), cfg)
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
+
+	// Rate limiting; counters are shared through Redis when it is configured
+	var ipLimiter, userLimiter ratelimit.Limiter
+	if redisClient != nil {
+		ipLimiter = ratelimit.NewRedisLimiter(redisClient, "ratelimit", cfg.RateLimit.PerIP, cfg.RateLimit.Window)
+		userLimiter = ratelimit.NewRedisLimiter(redisClient, "ratelimit:user", cfg.RateLimit.PerUser, cfg.RateLimit.Window)
+	} else {
+		ipLimiter = ratelimit.NewMemoryLimiter(cfg.RateLimit.PerIP, cfg.RateLimit.Window)
+		userLimiter = ratelimit.NewMemoryLimiter(cfg.RateLimit.PerUser, cfg.RateLimit.Window)
+	}
+	reloader.OnReload(func(c *config.Config) {
+		ipLimiter.SetLimit(c.RateLimit.PerIP, c.RateLimit.Window)
+		userLimiter.SetLimit(c.RateLimit.PerUser, c.RateLimit.Window)
+		log.Info().
+			Int("per_ip", c.RateLimit.PerIP).
+			Int("per_user", c.RateLimit.PerUser).
+			Dur("window", c.RateLimit.Window).
+			Msg("Rate limits reloaded")
+	})
+
+	// Initialize repositories
+	var repos *repositories
+	if dev {
+		repos = newMemoryRepositories()
+		log.Warn().Msg("Dev mode: data is kept in memory and lost on restart")
+	} else {
+		// White-label apps must exist before their users are created
+		syncApps(context.Background(), db, cfg)
+		repos = newPostgresRepositories(cfg, db, replica, redisClient)
+	}
+
+	// Initialize services
+	eventLog := services.NewEventLog(repos.domainEvent, repos.pair)
+	userService := services.NewUserService(repos.user, cfg.JWT.Secret).
+		WithApps(cfg.Apps).
+		WithSessions(repos.refreshToken, cfg.JWT)
+	pairService := services.NewPairService(repos.pair, repos.user).WithEventLog(eventLog)
+	var photoStore services.ObjectStore
+	var devStorageHandler *handlers.DevStorageHandler
+	if dev {
+		fileStore, err := services.NewFileObjectStore(cfg.Dev.StorageDir, cfg.Dev.PublicURL+devStoragePrefix)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create dev photo storage")
+		}
+		photoStore = fileStore
+		devStorageHandler = handlers.NewDevStorageHandler(fileStore, devStoragePrefix)
+		log.Warn().Str("dir", cfg.Dev.StorageDir).Msg("Dev mode: photos are stored on the local filesystem")
+	} else {
+		photoStore, err = newObjectStore(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Str("provider", cfg.Storage.Provider).Msg("Failed to create object store")
+		}
+	}
+	photoService := newPhotoService(cfg, repos.photo, repos.pair, photoStore).WithEventLog(eventLog)
+	// Без S3 сервер работает, но загрузки отвечают 503, пока хранилище
+	// не станет доступно
+	if err := retryStartup(context.Background(), cfg.Startup, "s3", photoService.ProbeStorage); err != nil {
+		log.Error().Err(err).Msg("S3 bucket is unreachable, uploads will fail until it recovers")
+	}
+	wsHub := services.NewWSHub(pairService).
+		WithCountdown(cfg.Capture.Countdown).
+		WithHeartbeat(cfg.WebSocket.PingInterval, cfg.WebSocket.MaxMissedPongs).
+		WithLastSeen(repos.user).
+		WithEventReplay(repos.userEvent, cfg.WebSocket.EventRetention)
+	syncSessionService := services.NewSyncSessionService(repos.syncSession, repos.pair, photoService, wsHub)
+	photoService.WithSyncSessions(syncSessionService)
+	reactionService := services.NewReactionService(repos.reaction, repos.photo, repos.pair, wsHub)
+	photoService.WithReactions(reactionService)
+	pairSettingsService := services.NewPairSettingsService(repos.pairSettings, repos.pair, repos.user, cfg.Capture.Countdown)
+	promptService := services.NewPromptService(repos.prompt, repos.pair, pairSettingsService, wsHub, cfg.Prompts)
+	photoService.WithPrompts(promptService)
+	if cfg.Cluster.Enabled {
+		// Replicas share presence and route WebSocket messages through Redis,
+		// so no sticky sessions are needed behind the load balancer
+		wsHub.WithCluster(hubbackend.NewRedisBackend(redisClient, cfg.Cluster.InstanceID, cfg.Cluster.PresenceTTL))
+		log.Info().Str("instance_id", cfg.Cluster.InstanceID).Msg("Cluster mode enabled")
+	}
+	if cfg.Debug.Faults.Enabled {
+		wsHub.WithFaults(cfg.Debug.Faults.WSDropRate)
+		log.Warn().
+			Dur("latency", cfg.Debug.Faults.Latency).
+			Float64("latency_rate", cfg.Debug.Faults.LatencyRate).
+			Float64("error_rate", cfg.Debug.Faults.ErrorRate).
+			Float64("ws_drop_rate", cfg.Debug.Faults.WSDropRate).
+			Msg("Fault injection enabled, do not use in production")
+	}
+	idempotencyService := services.NewIdempotencyService(repos.idempotency)
+
+	eventService := services.NewEventService(repos.event, cfg.Events)
+
+	flagService := services.NewFlagService(cfg.Flags)
+	reloader.OnReload(func(c *config.Config) {
+		flagService.Update(c.Flags)
+		log.Info().Int("flags", len(c.Flags)).Msg("Feature flags reloaded")
+	})
+
+	maintenanceService := services.NewMaintenanceService(cfg.Maintenance)
+	if redisClient != nil {
+		// Переключение через admin API должно дойти до всех реплик
+		maintenanceService.WithRedis(redisClient)
+	}
+	maintenanceService.OnEnable(func(services.MaintenanceStatus) {
+		wsHub.CloseAll(services.WSCloseMaintenance, "maintenance")
+	})
+	reloader.OnReload(func(c *config.Config) {
+		maintenanceService.Update(c.Maintenance)
+	})
+
+	var pushService *services.PushService
+	// Без ключа APNs сервер работает, но push-уведомления только пишутся в лог
+	if dev || cfg.APNs.KeyPath == "" {
+		pushService, err = services.NewLogPushService(cfg.Push)
+	} else {
+		pushService, err = services.NewPushService(cfg.APNs, cfg.Push)
+		if err == nil {
+			_, err = pushService.WithApps(cfg.APNs, cfg.Apps)
+		}
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create push service")
+	}
+	pushService.WithPairSettings(pairSettingsService)
+	pushService.Start()
+	// trigger_photo доходит до оффлайн-партнера push-уведомлением
+	wsHub.WithPushFallback(repos.user, pushService)
+
+	jobRunner := services.NewJobRunner(repos.job, cfg.Jobs)
+	webhookService := services.NewWebhookService(repos.webhook, jobRunner, cfg.Webhooks, cfg.Jobs).WithApps(cfg.Apps)
+	eventLog.Subscribe(services.WebhookConsumer, webhookService.Publish)
+	compositor := services.NewCompositorService(repos.composite, repos.photo, repos.pair, repos.pairSettings, photoService, jobRunner, cfg.Composites)
+	eventLog.Subscribe(services.CompositorConsumer, compositor.Enqueue)
+	statsService := services.NewStatsService(repos.stats, photoService, wsHub, jobRunner, cfg.Cluster.InstanceID)
+	userService.WithAccountDeletion(repos.uow, jobRunner, pairService, wsHub, photoService, compositor, cfg.Accounts)
+	syncSessionService.WithCaptureDeadline(jobRunner, cfg.Capture.UploadWindow)
+	jobRunner.Start()
+	if err := statsService.Schedule(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to schedule stats rollup")
+	}
+
+	outboxRelay := services.NewOutboxRelay(repos.outbox, repos.user, wsHub, pushService, cfg.Outbox)
+	outboxRelay.Start()
+
+	// Initialize handlers
+	userHandler := handlers.NewUserHandler(userService, pushService)
+	authHandler := handlers.NewAuthHandler(userService)
+	pairHandler := handlers.NewPairHandler(pairService)
+	photoHandler := handlers.NewPhotoHandler(photoService)
+	if cfg.Deprecations.DisablePhotoOffset {
+		photoHandler.WithoutOffset()
+	}
+	compositeHandler := handlers.NewCompositeHandler(compositor)
+	syncSessionHandler := handlers.NewSyncSessionHandler(syncSessionService)
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+	promptHandler := handlers.NewPromptHandler(promptService)
+	pairSettingsHandler := handlers.NewPairSettingsHandler(pairSettingsService)
+	wsHandler := handlers.NewWebSocketHandler(wsHub, userService, pairService, pairSettingsService, photoService, syncSessionService, pushService, flagService, statsService, maintenanceService).
+		WithAuthTimeout(cfg.WebSocket.AuthTimeout)
+	if cfg.Deprecations.DisableWSQueryToken {
+		wsHandler.WithoutQueryToken()
+	}
+	flagHandler := handlers.NewFlagHandler(flagService, pairService)
+	eventHandler := handlers.NewEventHandler(eventService)
+	adminHandler := handlers.NewAdminHandler(pushService, jobRunner, statsService, reloader, maintenanceService, userService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	docsHandler := handlers.NewDocsHandler(api.OpenAPI)
+	photoHandlerV2 := v2.NewPhotoHandler(photoService)
+	momentHandler := v2.NewMomentHandler(photoService)
+	activityHandler := v2.NewActivityHandler(eventLog)
+	userHandlerV2 := v2.NewUserHandler(userService)
+	pairHandlerV2 := v2.NewPairHandler(pairService)
+
+	healthHandler := handlers.NewHealthHandler()
+	if db != nil {
+		healthHandler.AddCheck("database", db.Ping)
+	}
+	if replica != nil {
+		healthHandler.AddCheck("database_replica", replica.Ping)
+	}
+	healthHandler.AddCheck("s3", photoService.CheckStorage)
+	if redisClient != nil {
+		healthHandler.AddCheck("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+
+	// Validate проверил список, ошибки здесь быть не может
+	trustedProxies, err := cfg.Server.ParseTrustedProxies()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid server.trusted_proxies")
+	}
+
+	// Setup router
+	r := chi.NewRouter()
+
+	// Middleware
+	r.Use(chiMiddleware.RequestID)
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Locale)
+	r.Use(middleware.RealIP(trustedProxies))
+	r.Use(middleware.AccessLogger)
+	r.Use(middleware.Metrics)
+	r.Use(errreport.Middleware)
+	r.Use(middleware.Recoverer)
+	r.Use(tracing.Middleware)
+	r.Use(corsMiddleware)
+	r.NotFound(middleware.NotFound)
+	r.MethodNotAllowed(middleware.MethodNotAllowed(r))
+
+	// Routes
+	// JSON endpoints only need small bodies; routes accepting file
+	// uploads should get their own, larger limit
+	jsonBodyLimit := middleware.MaxBodySize(cfg.Server.MaxJSONBodyBytes)
+	idempotency := middleware.Idempotency(idempotencyService)
+	ipRateLimit := func(next http.Handler) http.Handler { return next }
+	uploadRateLimit := ipRateLimit
+	if cfg.RateLimit.Enabled {
+		ipRateLimit = middleware.RateLimitByIP(ipLimiter)
+		uploadRateLimit = middleware.RateLimitByUser(userLimiter, "upload")
+		wsHandler.WithTriggerLimit(userLimiter)
+	}
+
+	// Both API versions share one concurrency budget
+	concurrencyLimit := middleware.ConcurrencyLimit(cfg.RateLimit.MaxConcurrent)
+	requestTimeout := middleware.Timeout(cfg.Server.RequestTimeout)
+	// Admin, health, docs and metrics routes stay available during maintenance
+	maintenance := middleware.Maintenance(maintenanceService)
+	faults := middleware.Faults(cfg.Debug.Faults)
+
+	// The album export streams for longer than the request timeout allows
+	// and has its own concurrency budget
+	r.With(
+		maintenance,
+		faults,
+		middleware.ConcurrencyLimit(cfg.Exports.MaxConcurrent),
+		middleware.AuthMiddleware(userService),
+		middleware.Activity(statsService),
+	).Get("/api/v1/photos/export", photoHandler.ExportPhotos)
+
+	// /api/v1 is frozen: breaking changes go to /api/v2, which shares the
+	// services and middleware but has its own handlers and DTOs
+	r.Route("/api/v1", func(r chi.Router) {
+		// Public routes
+		r.Use(concurrencyLimit)
+		r.Use(requestTimeout)
+
+		r.With(maintenance, faults, ipRateLimit, jsonBodyLimit).Post("/users", userHandler.CreateUser)
+		// Access-токен мог истечь, refresh-токен в теле сам подтверждает сессию
+		r.With(maintenance, faults, ipRateLimit, jsonBodyLimit).Post("/auth/refresh", authHandler.Refresh)
+		r.With(maintenance, faults, ipRateLimit, jsonBodyLimit).Post("/auth/logout", authHandler.Logout)
+		// Удаление отзывает сессии, восстановить аккаунт можно только токеном из тела
+		r.With(maintenance, faults, ipRateLimit, jsonBodyLimit).Post("/auth/restore", authHandler.Restore)
+		r.Get("/openapi.json", docsHandler.GetOpenAPI)
+		if cfg.Docs.SwaggerUI {
+			r.Get("/docs", docsHandler.SwaggerUI)
+		}
+
+		// Protected routes
+		r.Group(func(r chi.Router) {
+			r.Use(maintenance)
+			r.Use(faults)
+			r.Use(jsonBodyLimit)
+			r.Use(middleware.AuthMiddleware(userService))
+			r.Use(middleware.Activity(statsService))
+			r.Put("/users/push-token", userHandler.UpdatePushToken)
+			r.Post("/users/push-token", userHandler.UpdatePushToken)
+			r.Put("/users/quiet-hours", userHandler.UpdateQuietHours)
+			r.Put("/users/trigger-alerts", userHandler.UpdateTriggerAlerts)
+			r.Put("/users/locale", userHandler.UpdateLocale)
+			r.Post("/users/me/push-test", userHandler.SendTestPush)
+			r.Delete("/users/me", userHandler.DeleteMe)
+			r.Get("/flags", flagHandler.GetFlags)
+			r.Post("/events", eventHandler.IngestEvents)
+			r.With(idempotency).Post("/pairs", pairHandler.CreatePair)
+			r.Get("/pairs/history", pairHandler.GetPairHistory)
+			r.Get("/pairs/me/streak", promptHandler.GetStreak)
+			r.Get("/pairs/me/prompt", promptHandler.GetPromptSettings)
+			r.Put("/pairs/me/prompt", promptHandler.UpdatePromptSettings)
+			r.Get("/pairs/me/settings", pairSettingsHandler.GetSettings)
+			r.Patch("/pairs/me/settings", pairSettingsHandler.UpdateSettings)
+			r.Delete("/pairs/{pair_id}", pairHandler.DeletePair)
+			r.Get("/photos", photoHandler.GetPhotos)
+			r.Get("/composites", compositeHandler.GetComposites)
+			r.Get("/sessions", syncSessionHandler.GetSessions)
+			r.Get("/sessions/{session_id}", syncSessionHandler.GetSession)
+			r.With(uploadRateLimit, idempotency).Post("/photos/upload", photoHandler.UploadPhoto)
+			r.Post("/photos/{photo_id}/complete", photoHandler.CompleteUpload)
+			r.Post("/photos/{photo_id}/reactions", reactionHandler.AddReaction)
+			r.With(idempotency).Post("/photos/{photo_id}/comments", reactionHandler.AddComment)
+			r.Get("/photos/{photo_id}/comments", reactionHandler.GetComments)
+		})
+
+		// Admin routes
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(jsonBodyLimit)
+			r.Use(middleware.AdminMiddleware(cfg.Admin.Token, userService))
+			r.Get("/push-stats", adminHandler.GetPushStats)
+			r.Get("/jobs", adminHandler.ListJobs)
+			r.Get("/stats", adminHandler.GetStats)
+			r.Post("/reload", adminHandler.ReloadConfig)
+			r.Get("/maintenance", adminHandler.GetMaintenance)
+			r.Put("/maintenance", adminHandler.SetMaintenance)
+			r.Post("/users/{user_id}/restore", adminHandler.RestoreUser)
+			r.Get("/webhooks", webhookHandler.ListWebhooks)
+			r.Post("/webhooks", webhookHandler.CreateWebhook)
+			r.Put("/webhooks/{webhook_id}", webhookHandler.UpdateWebhook)
+			r.Delete("/webhooks/{webhook_id}", webhookHandler.DeleteWebhook)
+			r.Get("/webhooks/{webhook_id}/deliveries", webhookHandler.ListDeliveries)
+		})
+	})
+
+	if cfg.GraphQL.Enabled {
+		graphqlHandler := graph.NewHandler(userService, pairService, photoService, reactionService)
+		r.With(
+			maintenance,
+			faults,
+			concurrencyLimit,
+			requestTimeout,
+			jsonBodyLimit,
+			middleware.AuthMiddleware(userService),
+			middleware.Activity(statsService),
+		).Handle("/graphql", graphqlHandler)
+		if cfg.GraphQL.Playground {
+			r.Get("/graphql/playground", playground.Handler("Two Pic", "/graphql"))
+		}
+	}
+
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Use(maintenance)
+		r.Use(faults)
+		r.Use(concurrencyLimit)
+		r.Use(requestTimeout)
+		r.With(ipRateLimit, jsonBodyLimit).Post("/users", userHandlerV2.CreateUser)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(userService))
+			r.Use(middleware.Activity(statsService))
+			r.Get("/users/me", userHandlerV2.GetMe)
+			r.With(jsonBodyLimit).Patch("/users/me", userHandlerV2.UpdateMe)
+			r.Delete("/users/me", userHandlerV2.DeleteMe)
+			r.With(jsonBodyLimit, idempotency).Post("/pairs", pairHandlerV2.CreatePair)
+			r.Get("/pairs/{pair_id}", pairHandlerV2.GetPair)
+			r.Delete("/pairs/{pair_id}", pairHandlerV2.DeletePair)
+			r.Get("/photos", photoHandlerV2.GetPhotos)
+			r.Get("/moments", momentHandler.GetMoments)
+			r.Get("/activity", activityHandler.GetActivity)
+		})
+	})
+
+	if devStorageHandler != nil {
+		r.Put(devStoragePrefix+"/*", devStorageHandler.Upload)
+		r.Get(devStoragePrefix+"/*", devStorageHandler.Download)
+	}
+
+	// Health routes
+	r.Get("/healthz", healthHandler.Liveness)
+	r.Get("/readyz", healthHandler.Readiness)
+
+	// Profiling routes
+	if cfg.Debug.PprofAdmin {
+		r.With(middleware.AdminMiddleware(cfg.Admin.Token, userService)).Mount("/debug", chiMiddleware.Profiler())
+	}
+
+	// Metrics route
+	r.Handle("/metrics", metrics.Handler())
+
+	// WebSocket route
+	r.With(ipRateLimit, faults).Get("/ws", wsHandler.HandleWebSocket)
+
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	srv.SetKeepAlivesEnabled(!cfg.Server.DisableKeepAlives)
+
+	// Start server in goroutine
+	go func() {
+		log.Info().
+			Str("host", cfg.Server.Host).
+			Int("port", cfg.Server.Port).
+			Bool("tls", cfg.Server.TLS.Enabled()).
+			Msg("Starting server")
+		if err := listenAndServe(srv, cfg.Server.TLS); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("Server failed to start")
+		}
+	}()
+
+	// Start background workers
+	go idempotencyService.RunCleanup(appCtx, time.Hour)
+	go userService.RunSessionCleanup(appCtx, time.Hour)
+	go wsHub.RunCluster(appCtx)
+	go wsHub.RunEventCleanup(appCtx, time.Hour)
+	go statsService.Run(appCtx)
+	go maintenanceService.Run(appCtx)
+	go eventService.RunCleanup(appCtx, time.Hour)
+	go webhookService.RunCleanup(appCtx)
+	go eventLog.Run(appCtx)
+	if cfg.Uploads.ReapInterval > 0 {
+		go photoService.RunReaper(appCtx, cfg.Uploads.ReapInterval, cfg.Uploads.PendingTTL)
+	}
+	if cfg.Prompts.CheckInterval > 0 {
+		go promptService.Run(appCtx)
+	}
+
+	// Start internal profiling server
+	if cfg.Debug.PprofAddr != "" {
+		go startPprofServer(cfg.Debug.PprofAddr)
+	}
+
+	// Internal gRPC API
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			log.Fatal().Err(err).Str("addr", cfg.GRPC.Addr).Msg("Failed to listen for gRPC")
+		}
+		grpcServer = grpcapi.NewServer(userService, pairService, photoService, cfg.Admin.Token)
+		go func() {
+			log.Info().Str("addr", cfg.GRPC.Addr).Msg("Starting gRPC server")
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatal().Err(err).Msg("gRPC server failed")
+			}
+		}()
+	}
+
+	// Reload config on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Info().Msg("SIGHUP received, reloading configuration")
+			if _, err := reloader.Reload(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload configuration")
+			}
+		}
+	}()
+
+	// Wait for interrupt signal for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Info().Msg("Shutting down server...")
+	stopApp()
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	// Shutdown HTTP server; hijacked WebSocket connections are left to the hub
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Server forced to shutdown")
+	}
+
+	// Listener is closed, so clients told to reconnect reach other replicas
+	if err := wsHub.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("WebSocket connections were not drained in time")
+	}
+
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+
+	// Stop relaying notifications before the push queue is drained
+	if err := outboxRelay.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Outbox relay did not stop in time")
+	}
+
+	// Let running background jobs finish
+	if err := jobRunner.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Background jobs did not finish in time")
+	}
+
+	// Drain queued push notifications
+	if err := pushService.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Push queue was not fully drained")
+	}
+
+	// Flush pending spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
+	flushErrors(2 * time.Second)
+
+	log.Info().Msg("Server exited")
+}
+
+// stopGRPC waits for in-flight gRPC calls, cancelling them when ctx expires
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Error().Msg("gRPC server forced to stop")
+		srv.Stop()
+	}
+}
+
+// startPprofServer serves pprof on an internal address, separate from the public API
+func startPprofServer(addr string) {
+	r := chi.NewRouter()
+	r.Mount("/debug", chiMiddleware.Profiler())
+
+	log.Info().Str("addr", addr).Msg("Starting pprof server")
+	if err := http.ListenAndServe(addr, r); err != nil {
+		log.Error().Err(err).Msg("pprof server failed")
+	}
+}
+
+// corsMiddleware handles CORS
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept-Language, Idempotency-Key, If-Match, If-Unmodified-Since, X-Request-ID, X-App-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Content-Language, Location, Allow, ETag, Last-Modified, Link, Deprecation, Idempotent-Replayed, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
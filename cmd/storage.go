@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/services"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// newObjectStore creates the photo store of storage.provider. All of them
+// are reached through the S3 API: AWS S3, S3-compatible endpoints like
+// Beget or MinIO, and Google Cloud Storage through its XML API with HMAC
+// keys as aws.access_key and aws.secret_key.
+func newObjectStore(cfg *config.Config) (*services.S3ObjectStore, error) {
+	options := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(cfg.AWS.Region)}
+	// Без ключей в конфиге credentials берутся из окружения, ~/.aws или IAM-роли
+	if cfg.AWS.AccessKey != "" {
+		options = append(options, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AWS.AccessKey, cfg.AWS.SecretKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	endpoint := services.S3Endpoint{URL: cfg.AWS.EndpointURL(), PathStyle: cfg.AWS.UsePathStyle()}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint.URL != "" {
+			o.BaseEndpoint = aws.String(endpoint.URL)
+		}
+		o.UsePathStyle = endpoint.PathStyle
+		o.EndpointOptions.DisableHTTPS = cfg.AWS.DisableSSL
+		// Повторы делает PhotoService, чтобы их видел circuit breaker
+		o.Retryer = aws.NopRetryer{}
+		if cfg.Storage.Provider != config.StorageAWS {
+			// GCS и часть совместимых хранилищ отклоняют контрольные суммы
+			// CRC32, которые SDK добавляет по умолчанию
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
+
+	if endpoint.URL == "" {
+		scheme := "https"
+		if cfg.AWS.DisableSSL {
+			scheme = "http"
+		}
+		endpoint.URL = fmt.Sprintf("%s://s3.%s.amazonaws.com", scheme, cfg.AWS.Region)
+	}
+	return services.NewS3ObjectStore(client, cfg.AWS.S3Bucket, endpoint).WithPublicURL(cfg.AWS.PublicURL), nil
+}
+
+// newPhotoService creates the photo service with the storage guard settings from the config
+func newPhotoService(cfg *config.Config, photoRepo services.PhotoRepository, pairRepo services.PairRepository, store services.ObjectStore) *services.PhotoService {
+	return services.NewPhotoService(
+		photoRepo,
+		pairRepo,
+		store,
+		cfg.AWS.RequestTimeout,
+		cfg.AWS.MaxAttempts,
+		cfg.AWS.BreakerThreshold,
+		cfg.AWS.BreakerCooldown,
+	).WithApps(cfg.Apps).
+		WithViewURLs(cfg.AWS.ViewURLTTL).
+		WithMaxUploadSize(int64(cfg.Uploads.MaxSizeMB) << 20).
+		WithExportConcurrency(cfg.Exports.Concurrency)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/config"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// listenAndServe starts srv with the TLS mode selected in cfg
+func listenAndServe(srv *http.Server, cfg config.TLSConfig) error {
+	switch {
+	case cfg.Autocert.Enabled:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Hosts...),
+			Cache:      autocert.DirCache(cfg.Autocert.CacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		go startACMEServer(cfg.Autocert.HTTPAddr, manager)
+		return srv.ListenAndServeTLS("", "")
+	case cfg.CertFile != "":
+		return srv.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+	default:
+		return srv.ListenAndServe()
+	}
+}
+
+// startACMEServer answers Let's Encrypt HTTP-01 challenges and redirects
+// other plain HTTP requests to HTTPS
+func startACMEServer(addr string, manager *autocert.Manager) {
+	log.Info().Str("addr", addr).Msg("Starting ACME challenge server")
+	if err := http.ListenAndServe(addr, manager.HTTPHandler(nil)); err != nil {
+		log.Error().Err(err).Msg("ACME challenge server failed")
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/services"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// Operations measured by ws-bench, in report order
+const (
+	opHubCall  = "hub_call"
+	opDelivery = "delivery"
+)
+
+var benchOps = []string{opHubCall, opDelivery}
+
+// benchOptions holds the ws-bench flags
+type benchOptions struct {
+	conns    int
+	messages int
+	senders  int
+	op       string
+	timeout  time.Duration
+}
+
+// runWSBench handles "ws-bench": connects simulated clients to an in-process
+// hub over loopback and measures SendToUser or TriggerPhoto throughput and
+// latency. Run it before and after changes to the hub and compare.
+func runWSBench(args []string) {
+	fs := flag.NewFlagSet("ws-bench", flag.ExitOnError)
+	opts := benchOptions{}
+	fs.IntVar(&opts.conns, "conns", 1000, "number of simulated connections, e.g. 1000 or 10000")
+	fs.IntVar(&opts.messages, "messages", 20000, "number of hub calls")
+	fs.IntVar(&opts.senders, "senders", 64, "goroutines calling the hub concurrently")
+	fs.StringVar(&opts.op, "op", "send", "hub call to measure: send (SendToUser) or trigger (TriggerPhoto)")
+	fs.DurationVar(&opts.timeout, "timeout", time.Minute, "time to wait for all messages to be delivered")
+	fs.Parse(args)
+
+	// Хаб пишет в лог каждое подключение, на тысячах соединений это искажает замер
+	setupLogger(config.LogConfig{Level: "error"})
+
+	if opts.messages <= 0 || opts.senders <= 0 || opts.conns < 2 {
+		log.Fatal().Msg("-messages and -senders must be positive, -conns at least 2")
+	}
+	if opts.op != "send" && opts.op != "trigger" {
+		log.Fatal().Str("op", opts.op).Msg("-op must be send or trigger")
+	}
+
+	repos := newMemoryRepositories()
+	hub := services.NewWSHub(services.NewPairService(repos.pair, repos.user))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to listen")
+	}
+	server := &http.Server{Handler: benchHubHandler(hub)}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	stats := newLoadStats(benchOps)
+	// В trigger каждое сообщение получают оба пользователя пары
+	expected := int64(opts.messages)
+	if opts.op == "trigger" {
+		expected *= 2
+	}
+	var delivered atomic.Int64
+	done := make(chan struct{})
+
+	clients := make([]*websocket.Conn, 0, opts.conns)
+	for i := 0; i < opts.conns; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s/?user=%s", listener.Addr(), benchUserID(i)), nil)
+		if err != nil {
+			log.Fatal().Err(err).Int("connected", i).Msg("Failed to connect; raise the open files limit (ulimit -n) for large -conns")
+		}
+		clients = append(clients, conn)
+		go readBenchMessages(conn, stats, &delivered, expected, done)
+	}
+	defer func() {
+		for _, conn := range clients {
+			conn.Close()
+		}
+	}()
+
+	// Register вызывается в обработчике на сервере, дождемся всех соединений
+	for i := 0; i < opts.conns; i++ {
+		for !hub.IsOnline(benchUserID(i)) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	fmt.Printf("%d connections, %d %s calls from %d senders\n", opts.conns, opts.messages, opts.op, opts.senders)
+
+	start := time.Now()
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range opts.senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next.Add(1) <= int64(opts.messages) {
+				stats.measure(opHubCall, func() error {
+					return callHub(hub, opts)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	sent := time.Since(start)
+
+	select {
+	case <-done:
+	case <-time.After(opts.timeout):
+		fmt.Printf("timed out waiting for deliveries: %d of %d delivered\n", delivered.Load(), expected)
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("\ncalls: %s, %.0f calls/s\n", sent.Round(time.Millisecond), float64(opts.messages)/sent.Seconds())
+	fmt.Printf("deliveries: %d in %s, %.0f messages/s\n\n", delivered.Load(), elapsed.Round(time.Millisecond), float64(delivered.Load())/elapsed.Seconds())
+	stats.print(os.Stdout)
+}
+
+// callHub makes one hub call to a random pair; the message timestamp
+// carries the send time in nanoseconds so receivers can measure the
+// delivery latency
+func callHub(hub *services.WSHub, opts benchOptions) error {
+	pair := rand.IntN(opts.conns/2) * 2
+	if opts.op == "trigger" {
+		return hub.TriggerPhoto(benchUserID(pair), benchUserID(pair+1), "", time.Now().UnixNano(), time.Time{})
+	}
+	return hub.SendToUser(benchUserID(pair+rand.IntN(2)), services.WSMessage{
+		Type:      "bench",
+		Timestamp: time.Now().UnixNano(),
+	})
+}
+
+// benchHubHandler registers every upgraded connection in the hub under the
+// user ID from the query, like the WebSocket handler does after auth
+func benchHubHandler(hub *services.WSHub) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client, err := hub.Register(r.URL.Query().Get("user"), conn)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		defer hub.Unregister(client)
+		client.ReadPump(func([]byte) {})
+	})
+}
+
+// readBenchMessages records the delivery latency of every message the
+// client receives and closes done once all expected messages arrived
+func readBenchMessages(conn *websocket.Conn, stats *loadStats, delivered *atomic.Int64, expected int64, done chan struct{}) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg services.WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Timestamp == 0 {
+			continue
+		}
+		stats.record(opDelivery, time.Since(time.Unix(0, msg.Timestamp)))
+		if delivered.Add(1) == expected {
+			close(done)
+		}
+	}
+}
+
+func benchUserID(i int) string {
+	return fmt.Sprintf("bench-%d", i)
+}
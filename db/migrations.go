@@ -0,0 +1,9 @@
+// Package db embeds the SQL schema migrations so they ship inside the binary
+package db
+
+import "embed"
+
+// Migrations holds the *.up.sql / *.down.sql files from db/migrations
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN quiet_hours_start VARCHAR(5),
+    ADD COLUMN quiet_hours_end VARCHAR(5),
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN always_allow_triggers BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN time_sensitive_triggers BOOLEAN NOT NULL DEFAULT FALSE;
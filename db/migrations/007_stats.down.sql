@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_pairs_created_at;
+DROP TABLE IF EXISTS daily_stats;
+DROP TABLE IF EXISTS ws_connection_peaks;
+DROP TABLE IF EXISTS user_activity;
@@ -0,0 +1,31 @@
+-- Дни, в которые пользователь был активен (REST-запрос или WebSocket-подключение)
+CREATE TABLE user_activity (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    PRIMARY KEY (day, user_id)
+);
+
+-- Пик одновременных WebSocket-соединений каждого экземпляра за день
+CREATE TABLE ws_connection_peaks (
+    day DATE NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    peak INT NOT NULL,
+    PRIMARY KEY (day, instance_id)
+);
+
+-- Ночная сводка для GET /api/v1/admin/stats
+CREATE TABLE daily_stats (
+    day DATE PRIMARY KEY,
+    daily_active_users INT NOT NULL,
+    weekly_active_users INT NOT NULL,
+    new_users INT NOT NULL,
+    new_pairs INT NOT NULL,
+    moments_completed INT NOT NULL,
+    storage_objects BIGINT NOT NULL,
+    storage_bytes BIGINT NOT NULL,
+    ws_peak_connections INT NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pairs_created_at ON pairs(created_at);
+CREATE INDEX idx_users_created_at ON users(created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE client_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    properties JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_client_events_name_occurred_at ON client_events(name, occurred_at);
+CREATE INDEX idx_client_events_received_at ON client_events(received_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_photos_pair_taken_at;
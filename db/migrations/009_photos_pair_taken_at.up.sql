@@ -0,0 +1,2 @@
+-- Keyset pagination of a pair's photos in /api/v2
+CREATE INDEX idx_photos_pair_taken_at ON photos(pair_id, taken_at DESC, id DESC);
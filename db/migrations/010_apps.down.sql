@@ -0,0 +1,3 @@
+ALTER TABLE pairs DROP COLUMN IF EXISTS app_id;
+ALTER TABLE users DROP COLUMN IF EXISTS app_id;
+DROP TABLE IF EXISTS apps;
@@ -0,0 +1,12 @@
+-- White-label builds of the app share one deployment; every user and pair
+-- belongs to exactly one app
+CREATE TABLE IF NOT EXISTS apps (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO apps (id, name) VALUES ('default', 'TwoPic') ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN app_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES apps(id);
+ALTER TABLE pairs ADD COLUMN app_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES apps(id);
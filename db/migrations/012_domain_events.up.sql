@@ -0,0 +1,84 @@
+-- Журнал доменных событий: только добавление, порядок задает seq
+CREATE TABLE domain_events (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    type VARCHAR(64) NOT NULL,
+    app_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    user_id UUID,
+    pair_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Лента активности пары и дневные сводки по типам событий
+CREATE INDEX idx_domain_events_pair_seq ON domain_events(pair_id, seq DESC) WHERE pair_id IS NOT NULL;
+CREATE INDEX idx_domain_events_type_occurred_at ON domain_events(type, occurred_at);
+
+CREATE FUNCTION domain_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'domain_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER domain_events_append_only
+    BEFORE UPDATE OR DELETE ON domain_events
+    FOR EACH ROW EXECUTE FUNCTION domain_events_append_only();
+
+-- Позиция каждого потребителя журнала (рассылка вебхуков и т.п.)
+CREATE TABLE domain_event_consumers (
+    name VARCHAR(64) PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- История до появления журнала, в порядке времени, чтобы лента пар и
+-- сводки за прошлые дни считались так же, как для новых событий
+INSERT INTO domain_events (id, type, app_id, user_id, pair_id, payload, occurred_at)
+SELECT uuid_generate_v4(), type, app_id, user_id, pair_id, payload, occurred_at
+FROM (
+    SELECT 'user_created' AS type, app_id, id AS user_id, NULL::uuid AS pair_id,
+        jsonb_build_object('user_id', id, 'app_id', app_id) AS payload,
+        created_at AS occurred_at
+    FROM users
+
+    UNION ALL
+
+    SELECT 'pair_created', app_id, user_a_id, id,
+        jsonb_build_object('pair_id', id, 'user_a_id', user_a_id, 'user_b_id', user_b_id, 'initiator_id', user_a_id),
+        created_at
+    FROM pairs
+
+    UNION ALL
+
+    SELECT 'photo_confirmed', pairs.app_id, photos.user_id, photos.pair_id,
+        jsonb_build_object(
+            'photo_id', photos.id, 'pair_id', photos.pair_id, 'user_id', photos.user_id,
+            's3_url', photos.s3_url,
+            'taken_at', to_char(photos.taken_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+        ),
+        photos.created_at
+    FROM photos
+    JOIN pairs ON pairs.id = photos.pair_id
+
+    UNION ALL
+
+    -- Тот же критерий момента, что и в прежнем подсчете сводок
+    SELECT 'moment_completed', pairs.app_id, m.user_id, m.pair_id,
+        jsonb_build_object('pair_id', m.pair_id, 'photo_ids', jsonb_build_array(m.prev_photo_id, m.photo_id)),
+        m.created_at
+    FROM (
+        SELECT
+            id AS photo_id,
+            pair_id,
+            user_id,
+            created_at,
+            LAG(id) OVER w AS prev_photo_id,
+            LAG(user_id) OVER w AS prev_user_id,
+            taken_at - LAG(taken_at) OVER w AS gap
+        FROM photos
+        WINDOW w AS (PARTITION BY pair_id, taken_at::date ORDER BY taken_at)
+    ) m
+    JOIN pairs ON pairs.id = m.pair_id
+    WHERE m.prev_user_id <> m.user_id AND m.gap <= INTERVAL '2 minutes'
+) history
+ORDER BY occurred_at;
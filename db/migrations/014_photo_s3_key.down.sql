@@ -0,0 +1,6 @@
+-- endpoint и bucket не хранятся в базе, поэтому в s3_url попадает ключ;
+-- клиенты старых версий получат относительный URL
+ALTER TABLE photos ADD COLUMN s3_url VARCHAR(500);
+UPDATE photos SET s3_url = s3_key;
+ALTER TABLE photos ALTER COLUMN s3_url SET NOT NULL;
+ALTER TABLE photos DROP COLUMN s3_key;
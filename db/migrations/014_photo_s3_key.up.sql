@@ -0,0 +1,14 @@
+-- Фото хранят только ключ объекта, URL строит сервер: клиент больше не
+-- может подставить партнеру чужой адрес
+ALTER TABLE photos ADD COLUMN s3_key VARCHAR(500);
+
+-- URL были path-style (https://endpoint/bucket/key); если URL не указывает
+-- на объект фото, берется ключ основного приложения
+UPDATE photos SET s3_key = CASE
+    WHEN s3_url LIKE '%/' || pair_id || '/' || id || '.jpg'
+        THEN regexp_replace(s3_url, '^https?://[^/]+/[^/]+/', '')
+    ELSE pair_id || '/' || id || '.jpg'
+END;
+
+ALTER TABLE photos ALTER COLUMN s3_key SET NOT NULL;
+ALTER TABLE photos DROP COLUMN s3_url;
@@ -0,0 +1,8 @@
+ALTER TABLE photos
+    DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE pairs
+    DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at;
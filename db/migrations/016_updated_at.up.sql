@@ -0,0 +1,9 @@
+-- updated_at поддерживают репозитории; по нему клиенты проверяют, что
+-- запись не изменилась с другого устройства
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE pairs ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE photos ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+UPDATE users SET updated_at = created_at;
+UPDATE pairs SET updated_at = created_at;
+UPDATE photos SET updated_at = created_at;
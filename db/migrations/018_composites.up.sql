@@ -0,0 +1,14 @@
+-- Склейки фото обоих партнеров одного момента
+CREATE TABLE composites (
+    id UUID PRIMARY KEY,
+    pair_id UUID NOT NULL REFERENCES pairs(id) ON DELETE CASCADE,
+    s3_key VARCHAR(500) NOT NULL,
+    -- Фото в порядке на склейке: user_a слева или сверху
+    photo_ids UUID[] NOT NULL,
+    layout VARCHAR(16) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_composites_pair_created ON composites(pair_id, created_at DESC);
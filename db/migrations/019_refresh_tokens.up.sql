@@ -0,0 +1,15 @@
+-- Refresh-токены сессий; хранится только SHA-256 хеш токена
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY,
+    -- Сессия не меняется при ротации; ее ID записан в access-токены как claim sid
+    session_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash BYTEA NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    -- Задается при ротации и выходе
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens(session_id);
+CREATE INDEX idx_refresh_tokens_expires ON refresh_tokens(expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE photos DROP COLUMN IF EXISTS session_id;
+DROP TABLE IF EXISTS sync_sessions;
@@ -0,0 +1,18 @@
+-- Сессии синхронной съемки: создаются по trigger_photo, фото обоих партнеров ссылаются на сессию
+CREATE TABLE sync_sessions (
+    id UUID PRIMARY KEY,
+    pair_id UUID NOT NULL REFERENCES pairs(id) ON DELETE CASCADE,
+    initiator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    triggered_at TIMESTAMPTZ NOT NULL,
+    -- Последнее подтвержденное фото каждой стороны до завершения сессии
+    initiator_photo_id UUID REFERENCES photos(id) ON DELETE SET NULL,
+    partner_photo_id UUID REFERENCES photos(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sync_sessions_pair_created ON sync_sessions(pair_id, created_at DESC);
+
+ALTER TABLE photos ADD COLUMN session_id UUID REFERENCES sync_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_photos_session ON photos(session_id) WHERE session_id IS NOT NULL;
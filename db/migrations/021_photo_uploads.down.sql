@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_photos_pending;
+ALTER TABLE photos
+    DROP COLUMN IF EXISTS content_type,
+    DROP COLUMN IF EXISTS size_bytes,
+    DROP COLUMN IF EXISTS uploaded_at;
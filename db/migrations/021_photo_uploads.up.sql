@@ -0,0 +1,10 @@
+-- Фото становится доступным только после проверки объекта в хранилище
+ALTER TABLE photos
+    ADD COLUMN uploaded_at TIMESTAMPTZ,
+    ADD COLUMN size_bytes BIGINT,
+    ADD COLUMN content_type TEXT;
+
+-- Фото до этой миграции уже подтверждены клиентами
+UPDATE photos SET uploaded_at = created_at;
+
+CREATE INDEX idx_photos_pending ON photos(created_at) WHERE uploaded_at IS NULL;
@@ -0,0 +1,3 @@
+-- Без deleted_at удаленные пары стали бы снова активными
+DELETE FROM pairs WHERE deleted_at IS NOT NULL;
+ALTER TABLE pairs DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,3 @@
+-- Удаленная пара остается в истории вместе с фото; повторная пара с тем же
+-- партнером восстанавливает эту строку, поэтому unique_pair не меняется
+ALTER TABLE pairs ADD COLUMN deleted_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+ALTER TABLE photos
+    DROP COLUMN IF EXISTS device_taken_at,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width;
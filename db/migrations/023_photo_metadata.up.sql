@@ -0,0 +1,6 @@
+-- Размеры с учетом EXIF-поворота и время съемки по EXIF, чтобы клиенты
+-- раскладывали галерею без скачивания фото
+ALTER TABLE photos
+    ADD COLUMN width INT,
+    ADD COLUMN height INT,
+    ADD COLUMN device_taken_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+ALTER TABLE sync_sessions
+    DROP COLUMN IF EXISTS missed_at,
+    DROP COLUMN IF EXISTS deadline,
+    DROP COLUMN IF EXISTS capture_at;
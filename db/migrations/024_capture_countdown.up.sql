@@ -0,0 +1,6 @@
+-- Время снимка по обратному отсчету и срок загрузки обеих половин; сессия,
+-- не завершенная к сроку, помечается пропущенной
+ALTER TABLE sync_sessions
+    ADD COLUMN capture_at TIMESTAMPTZ,
+    ADD COLUMN deadline TIMESTAMPTZ,
+    ADD COLUMN missed_at TIMESTAMPTZ;
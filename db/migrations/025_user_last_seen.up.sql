@@ -0,0 +1,3 @@
+-- Когда пользователь последний раз был на связи по WebSocket; обновляется
+-- без updated_at, это не изменение профиля
+ALTER TABLE users ADD COLUMN last_seen_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS photo_comments;
+DROP TABLE IF EXISTS photo_reactions;
@@ -0,0 +1,20 @@
+-- Реакции партнеров на фото: один эмодзи от пользователя ставится один раз
+CREATE TABLE photo_reactions (
+    id UUID PRIMARY KEY,
+    photo_id UUID NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (photo_id, user_id, emoji)
+);
+
+-- Комментарии к фото
+CREATE TABLE photo_comments (
+    id UUID PRIMARY KEY,
+    photo_id UUID NOT NULL REFERENCES photos(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_photo_comments_photo_created ON photo_comments(photo_id, created_at);
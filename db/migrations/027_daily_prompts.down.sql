@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS daily_prompts;
+DROP TABLE IF EXISTS prompt_settings;
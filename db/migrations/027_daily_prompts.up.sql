@@ -0,0 +1,27 @@
+-- Настройки ежедневного задания пары; без строки действуют значения по умолчанию
+CREATE TABLE prompt_settings (
+    pair_id UUID PRIMARY KEY REFERENCES pairs(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    -- Местное время "HH:MM"; NULL - prompts.time
+    local_time VARCHAR(5),
+    -- NULL - часовой пояс user_a
+    timezone VARCHAR(64),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Отправленные задания: одно в местный день пары
+CREATE TABLE daily_prompts (
+    id UUID PRIMARY KEY,
+    pair_id UUID NOT NULL REFERENCES pairs(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    prompt TEXT NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    user_a_completed_at TIMESTAMPTZ,
+    user_b_completed_at TIMESTAMPTZ,
+    -- Выполнено обоими партнерами
+    completed_at TIMESTAMPTZ,
+    UNIQUE (pair_id, day)
+);
+
+CREATE INDEX idx_daily_prompts_pair_sent ON daily_prompts(pair_id, sent_at DESC);
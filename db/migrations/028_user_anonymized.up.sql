@@ -0,0 +1,3 @@
+-- При accounts.shared_content = anonymize аккаунт не удаляется, а обезличивается:
+-- строка остается ради фото пары у партнера и больше не восстанавливается
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMPTZ;
@@ -0,0 +1,15 @@
+-- События WebSocket, которые пользователь должен получить, даже если
+-- переподключится: хранятся до подтверждения клиентом и повторяются
+-- при подключении
+CREATE TABLE user_events (
+    seq BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    -- Сообщение WebSocket целиком
+    message JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    acked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_user_events_pending ON user_events(user_id, seq) WHERE acked_at IS NULL;
+CREATE INDEX idx_user_events_created_at ON user_events(created_at);
@@ -0,0 +1,7 @@
+ALTER TABLE prompt_settings ADD COLUMN timezone VARCHAR(64);
+
+INSERT INTO prompt_settings (pair_id, timezone, updated_at)
+SELECT pair_id, timezone, updated_at FROM pair_settings WHERE timezone IS NOT NULL
+ON CONFLICT (pair_id) DO UPDATE SET timezone = EXCLUDED.timezone;
+
+DROP TABLE IF EXISTS pair_settings;
@@ -0,0 +1,20 @@
+-- Общие настройки пары; без строки действуют значения по умолчанию
+CREATE TABLE pair_settings (
+    pair_id UUID PRIMARY KEY REFERENCES pairs(id) ON DELETE CASCADE,
+    -- Местное время "HH:MM" в timezone, когда push-уведомления пары
+    -- не отправляются; NULL - без тишины
+    mute_start VARCHAR(5),
+    mute_end VARCHAR(5),
+    -- NULL - capture.countdown
+    countdown_ms INTEGER,
+    -- Часовой пояс пары, в том числе ежедневного задания; NULL - пояс user_a
+    timezone VARCHAR(64),
+    auto_composite BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Часовой пояс задания становится часовым поясом пары
+INSERT INTO pair_settings (pair_id, timezone, updated_at)
+SELECT pair_id, timezone, updated_at FROM prompt_settings WHERE timezone IS NOT NULL;
+
+ALTER TABLE prompt_settings DROP COLUMN timezone;
@@ -0,0 +1,3 @@
+-- Заголовки ответа из белого списка (Location, Content-Type, ETag),
+-- которые повторяются вместе с телом
+ALTER TABLE idempotency_keys ADD COLUMN response_headers JSONB;
@@ -0,0 +1,287 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"sync-photo-backend/internal/handlers"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// messageTimeout bounds the wait for a WebSocket message
+const messageTimeout = 10 * time.Second
+
+// call sends a JSON request to the server and decodes the response into
+// out, failing the test unless the response has the status want
+func call(t *testing.T, method, path, token string, body, out interface{}, want int) {
+	t.Helper()
+
+	var payload io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, baseURL+path, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d; body %s", method, path, resp.StatusCode, want, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+}
+
+// createUser creates a user through the API
+func createUser(t *testing.T) handlers.CreateUserResponse {
+	t.Helper()
+
+	var user handlers.CreateUserResponse
+	call(t, http.MethodPost, "/api/v1/users", "", nil, &user, http.StatusOK)
+	return user
+}
+
+// wsClient is a v1 WebSocket connection of a user; messages it reads are
+// queued in messages, which is closed with the connection
+type wsClient struct {
+	conn     *websocket.Conn
+	messages chan services.WSMessage
+}
+
+// dial connects as the user with the token in the subprotocol and waits
+// for pair_status, sent once the hub registered the connection
+func dial(t *testing.T, token string) *wsClient {
+	t.Helper()
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: messageTimeout,
+		Subprotocols:     []string{services.WSSubprotocolV1, services.WSTokenSubprotocolPrefix + token},
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(baseURL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial /ws: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if conn.Subprotocol() != services.WSSubprotocolV1 {
+		t.Fatalf("subprotocol = %q, want %s", conn.Subprotocol(), services.WSSubprotocolV1)
+	}
+
+	c := &wsClient{conn: conn, messages: make(chan services.WSMessage, 64)}
+	go func() {
+		defer close(c.messages)
+		for {
+			var msg services.WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	c.await(t, "pair_status")
+	return c
+}
+
+// send writes a message to the server
+func (c *wsClient) send(t *testing.T, msg services.WSMessage) {
+	t.Helper()
+
+	if err := c.conn.WriteJSON(msg); err != nil {
+		t.Fatalf("send %s: %v", msg.Type, err)
+	}
+}
+
+// await returns the next message of the type, skipping others; an error
+// message fails the test
+func (c *wsClient) await(t *testing.T, messageType string) services.WSMessage {
+	t.Helper()
+
+	timeout := time.After(messageTimeout)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				t.Fatalf("connection closed before %s", messageType)
+			}
+			if msg.Type == messageType {
+				return msg
+			}
+			if msg.Type == "error" {
+				t.Fatalf("error while awaiting %s: %s", messageType, msg.Message)
+			}
+		case <-timeout:
+			t.Fatalf("%s not received within %s", messageType, messageTimeout)
+		}
+	}
+}
+
+// testImage encodes a small JPEG of the color
+func testImage(t *testing.T, c color.RGBA) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := range 48 {
+		for x := range 64 {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// upload takes the user's photo of the session: it gets a pre-signed URL,
+// puts the image to MinIO and confirms the upload
+func upload(t *testing.T, token, sessionID string, data []byte) *models.Photo {
+	t.Helper()
+
+	var presigned services.UploadResponse
+	call(t, http.MethodPost, "/api/v1/photos/upload", token, services.UploadRequest{
+		Filename:    "e2e.jpg",
+		ContentType: "image/jpeg",
+		SizeBytes:   int64(len(data)),
+		SessionID:   sessionID,
+	}, &presigned, http.StatusOK)
+
+	req, err := http.NewRequest(http.MethodPut, presigned.UploadURL, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT upload URL: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT upload URL: status %d; body %s", resp.StatusCode, body)
+	}
+
+	var photo models.Photo
+	call(t, http.MethodPost, "/api/v1/photos/"+url.PathEscape(presigned.PhotoID)+"/complete", token, nil, &photo, http.StatusOK)
+	if photo.UploadedAt == nil {
+		t.Errorf("photo %s: uploaded_at not set after complete", photo.ID)
+	}
+	return &photo
+}
+
+// TestPhotoFlow pairs two users, triggers a photo over WebSocket, uploads
+// both halves of the moment and checks that each partner's gallery serves
+// them from the bucket
+func TestPhotoFlow(t *testing.T) {
+	userA := createUser(t)
+	userB := createUser(t)
+
+	var pair models.Pair
+	call(t, http.MethodPost, "/api/v1/pairs", userA.Token, map[string]string{"partner_code": userB.Code}, &pair, http.StatusOK)
+	if pair.ID == "" {
+		t.Fatal("pair created without id")
+	}
+
+	clientA := dial(t, userA.Token)
+	clientB := dial(t, userB.Token)
+
+	clientA.send(t, services.WSMessage{Type: "trigger_photo"})
+	takePhotoA := clientA.await(t, "take_photo")
+	takePhotoB := clientB.await(t, "take_photo")
+	sessionID := takePhotoA.SessionID
+	if sessionID == "" || takePhotoB.SessionID != sessionID {
+		t.Fatalf("take_photo session_id = %q and %q, want the same non-empty ID", sessionID, takePhotoB.SessionID)
+	}
+	if takePhotoB.InitiatorID != userA.ID {
+		t.Errorf("take_photo initiator_id = %q, want %q", takePhotoB.InitiatorID, userA.ID)
+	}
+
+	images := map[string][]byte{
+		userA.ID: testImage(t, color.RGBA{R: 0xF2, G: 0x8B, B: 0x82, A: 0xFF}),
+		userB.ID: testImage(t, color.RGBA{R: 0x8A, G: 0xB4, B: 0xF8, A: 0xFF}),
+	}
+	photoA := upload(t, userA.Token, sessionID, images[userA.ID])
+	photoB := upload(t, userB.Token, sessionID, images[userB.ID])
+
+	// Момент собран, когда загружены обе половины
+	for _, client := range []*wsClient{clientA, clientB} {
+		if msg := client.await(t, "session_complete"); msg.SessionID != sessionID {
+			t.Errorf("session_complete session_id = %q, want %q", msg.SessionID, sessionID)
+		}
+	}
+
+	for _, user := range []handlers.CreateUserResponse{userA, userB} {
+		var gallery handlers.PhotoListResponse
+		call(t, http.MethodGet, "/api/v1/photos?cursor=", user.Token, nil, &gallery, http.StatusOK)
+
+		var ids []string
+		for _, photo := range gallery.Photos {
+			ids = append(ids, photo.ID)
+			if photo.SessionID == nil || *photo.SessionID != sessionID {
+				t.Errorf("photo %s: session_id = %v, want %s", photo.ID, photo.SessionID, sessionID)
+			}
+			assertStored(t, photo.S3URL, images[photo.UserID])
+		}
+		slices.Sort(ids)
+		want := []string{photoA.ID, photoB.ID}
+		slices.Sort(want)
+		if !slices.Equal(ids, want) {
+			t.Errorf("gallery of %s = %v, want %v", user.ID, ids, want)
+		}
+	}
+}
+
+// assertStored fails the test unless the photo URL serves the uploaded
+// image
+func assertStored(t *testing.T, photoURL string, want []byte) {
+	t.Helper()
+
+	resp, err := http.Get(photoURL)
+	if err != nil {
+		t.Fatalf("GET photo: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("GET photo: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET photo: status %d; body %s", resp.StatusCode, data)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("GET photo: got %d bytes, not the %d uploaded", len(data), len(want))
+	}
+}
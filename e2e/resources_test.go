@@ -0,0 +1,138 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+
+	"sync-photo-backend/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// runSuffix returns a random suffix naming the database and the bucket of
+// one run
+func runSuffix() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// createDatabase creates the database name on the server of adminURL and
+// returns its URL and a function dropping it
+func createDatabase(ctx context.Context, adminURL, name string) (string, func(), error) {
+	u, err := url.Parse(adminURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse DATABASE_URL: %w", err)
+	}
+
+	conn, err := pgx.Connect(ctx, adminURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("connect to PostgreSQL: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	ident := pgx.Identifier{name}.Sanitize()
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+ident); err != nil {
+		return "", nil, fmt.Errorf("create database %s: %w", name, err)
+	}
+
+	drop := func() {
+		ctx := context.Background()
+		conn, err := pgx.Connect(ctx, adminURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: drop database %s: %v\n", name, err)
+			return
+		}
+		defer conn.Close(ctx)
+		// FORCE закрывает соединения, которые сервер мог не успеть закрыть
+		if _, err := conn.Exec(ctx, "DROP DATABASE IF EXISTS "+ident+" WITH (FORCE)"); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: drop database %s: %v\n", name, err)
+		}
+	}
+
+	u.Path = "/" + name
+	return u.String(), drop, nil
+}
+
+// newS3Client returns a client of the MinIO the server uses, configured
+// the way cmd/storage.go configures it
+func newS3Client(ctx context.Context) (*s3.Client, error) {
+	endpoint := config.AWSConfig{
+		Endpoint:   os.Getenv("AWS_ENDPOINT"),
+		DisableSSL: os.Getenv("AWS_DISABLE_SSL") == "true",
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(region),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			os.Getenv("AWS_ACCESS_KEY"), os.Getenv("AWS_SECRET_KEY"), "")),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint.EndpointURL())
+		o.UsePathStyle = true
+	}), nil
+}
+
+// createBucket creates the bucket and returns a function deleting it with
+// everything uploaded during the run
+func createBucket(ctx context.Context, client *s3.Client, bucket string) (func(), error) {
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return nil, fmt.Errorf("create bucket %s: %w", bucket, err)
+	}
+
+	return func() {
+		ctx := context.Background()
+		if err := emptyBucket(ctx, client, bucket); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: empty bucket %s: %v\n", bucket, err)
+			return
+		}
+		if _, err := client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(bucket)}); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: delete bucket %s: %v\n", bucket, err)
+		}
+	}, nil
+}
+
+// emptyBucket deletes all objects of the bucket
+func emptyBucket(ctx context.Context, client *s3.Client, bucket string) error {
+	pages := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+		objects := make([]types.ObjectIdentifier, len(page.Contents))
+		for i, object := range page.Contents {
+			objects[i] = types.ObjectIdentifier{Key: object.Key}
+		}
+		if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores serialized values with a TTL
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// entry is a single cached value
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process cache. Invalidation is not shared between
+// replicas; use RedisCache for multi-instance deployments.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+	lastGC  time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]entry),
+		lastGC:  time.Now(),
+	}
+}
+
+// Get implements Cache
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+// Set implements Cache
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.gc(now, ttl)
+	c.entries[key] = entry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Delete implements Cache
+func (c *MemoryCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// gc drops expired entries at most once per ttl; must be called with c.mu held
+func (c *MemoryCache) gc(now time.Time, ttl time.Duration) {
+	if now.Sub(c.lastGC) < ttl {
+		return
+	}
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.lastGC = now
+}
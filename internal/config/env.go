@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix starts the generic override of every field, named after its
+// YAML path, e.g. SYNCPHOTO_AWS_VIEW_URL_TTL for aws.view_url_ttl
+const envPrefix = "SYNCPHOTO_"
+
+// applyEnv overrides values loaded from YAML with environment variables.
+// Secrets (passwords, keys, tokens) should be passed this way in containers
+// instead of being written to config.yaml. SYNCPHOTO_* variables take
+// precedence over the short names below.
+func (c *Config) applyEnv() error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok {
+			*dst = v
+		}
+	}
+	num := func(name string, dst *int) {
+		if v, ok := os.LookupEnv(name); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be an integer: %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	flag := func(name string, dst *bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a boolean: %q", name, v))
+				return
+			}
+			*dst = b
+		}
+	}
+
+	str("SERVER_HOST", &c.Server.Host)
+	num("SERVER_PORT", &c.Server.Port)
+	str("TLS_CERT_FILE", &c.Server.TLS.CertFile)
+	str("TLS_KEY_FILE", &c.Server.TLS.KeyFile)
+	flag("TLS_AUTOCERT_ENABLED", &c.Server.TLS.Autocert.Enabled)
+
+	str("DATABASE_URL", &c.Database.URL)
+	str("DATABASE_HOST", &c.Database.Host)
+	num("DATABASE_PORT", &c.Database.Port)
+	str("DATABASE_USER", &c.Database.User)
+	str("DATABASE_PASSWORD", &c.Database.Password)
+	str("DATABASE_DBNAME", &c.Database.DBName)
+	str("DATABASE_SSLMODE", &c.Database.SSLMode)
+	str("DATABASE_REPLICA_DSN", &c.Database.ReplicaDSN)
+
+	str("AWS_REGION", &c.AWS.Region)
+	str("AWS_S3_BUCKET", &c.AWS.S3Bucket)
+	str("AWS_ACCESS_KEY", &c.AWS.AccessKey)
+	str("AWS_SECRET_KEY", &c.AWS.SecretKey)
+	str("AWS_ENDPOINT", &c.AWS.Endpoint)
+	flag("AWS_DISABLE_SSL", &c.AWS.DisableSSL)
+	if _, ok := os.LookupEnv("AWS_PATH_STYLE"); ok {
+		c.AWS.PathStyle = new(bool)
+		flag("AWS_PATH_STYLE", c.AWS.PathStyle)
+	}
+	str("STORAGE_PROVIDER", &c.Storage.Provider)
+
+	str("JWT_SECRET", &c.JWT.Secret)
+	str("LOG_LEVEL", &c.Log.Level)
+	str("LOG_FORMAT", &c.Log.Format)
+
+	str("APNS_KEY_PATH", &c.APNs.KeyPath)
+	str("APNS_KEY_ID", &c.APNs.KeyID)
+	str("APNS_TEAM_ID", &c.APNs.TeamID)
+	str("APNS_BUNDLE_ID", &c.APNs.BundleID)
+	flag("APNS_PRODUCTION", &c.APNs.Production)
+
+	str("ERROR_REPORTING_DSN", &c.ErrorReporting.DSN)
+	str("ERROR_REPORTING_ENVIRONMENT", &c.ErrorReporting.Environment)
+
+	num("STARTUP_RETRY_ATTEMPTS", &c.Startup.RetryAttempts)
+
+	flag("MAINTENANCE_ENABLED", &c.Maintenance.Enabled)
+
+	str("ADMIN_TOKEN", &c.Admin.Token)
+
+	str("REDIS_ADDR", &c.Redis.Addr)
+	str("REDIS_PASSWORD", &c.Redis.Password)
+	num("REDIS_DB", &c.Redis.DB)
+
+	str("EVENTS_SINK_URL", &c.Events.SinkURL)
+
+	flag("CLUSTER_ENABLED", &c.Cluster.Enabled)
+	str("CLUSTER_INSTANCE_ID", &c.Cluster.InstanceID)
+
+	flag("DOCS_SWAGGER_UI", &c.Docs.SwaggerUI)
+
+	flag("GRPC_ENABLED", &c.GRPC.Enabled)
+	str("GRPC_ADDR", &c.GRPC.Addr)
+
+	flag("GRAPHQL_ENABLED", &c.GraphQL.Enabled)
+
+	errs = append(errs, c.applyPrefixedEnv()...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment overrides:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyPrefixedEnv sets fields from their SYNCPHOTO_* variables. Lists are
+// comma-separated; maps such as apps and flags can only be set in the file.
+// Variables matching no field are reported, so a typo doesn't leave the
+// value from the file in place unnoticed.
+func (c *Config) applyPrefixedEnv() []error {
+	fields := map[string]reflect.Value{}
+	collectEnvFields(reflect.ValueOf(c).Elem(), envPrefix, fields)
+
+	var errs []error
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s doesn't match any config field", name))
+			continue
+		}
+		if err := setEnvField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s %w: %q", name, err, value))
+		}
+	}
+	return errs
+}
+
+// collectEnvFields maps the variable name of every settable field of v to
+// the field, descending into nested sections
+func collectEnvFields(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		name = prefix + strings.ToUpper(name)
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			collectEnvFields(field, name+"_", fields)
+		case reflect.Map:
+		default:
+			fields[name] = field
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setEnvField parses value into the field according to its type
+func setEnvField(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setEnvField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if field.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return errors.New("must be a duration such as 30s or 1h")
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.New("can't be set from the environment")
+		}
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return errors.New("can't be set from the environment")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import "sync"
+
+// Reloader re-reads the configuration at runtime and passes it to
+// subscribers. Only values that are safe to change without a restart
+// (log level, limits, flags) should be applied by subscribers.
+type Reloader struct {
+	path  string
+	load  func(string) (*Config, error)
+	mu    sync.Mutex
+	hooks []func(*Config)
+}
+
+// NewReloader creates a reloader for the config file at path
+func NewReloader(path string) *Reloader {
+	return &Reloader{path: path, load: Load}
+}
+
+// NewDevReloader creates a reloader validating the config file for dev mode
+func NewDevReloader(path string) *Reloader {
+	return &Reloader{path: path, load: LoadDev}
+}
+
+// OnReload registers a function called with the new config after each successful reload
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload loads and validates the config, then notifies subscribers.
+// On error the running configuration is left untouched.
+func (r *Reloader) Reload() (*Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hook := range r.hooks {
+		hook(cfg)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,88 @@
+// Package domain holds the errors the services return for expected failures.
+// Handlers of every transport match them with errors.Is; the messages are
+// part of the v1 API and must not change.
+package domain
+
+import "errors"
+
+var (
+	// ErrUserNotFound is returned when a user doesn't exist
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserDeleted is returned for accounts deleted but not purged yet
+	ErrUserDeleted = errors.New("user is deleted")
+
+	// ErrInvalidID is returned for IDs that aren't UUIDs
+	ErrInvalidID = errors.New("invalid id")
+
+	// ErrPairNotFound is returned when a pair doesn't exist
+	ErrPairNotFound = errors.New("pair not found")
+
+	// ErrPhotoNotFound is returned when a photo doesn't exist
+	ErrPhotoNotFound = errors.New("photo not found")
+
+	// ErrPartnerNotFound is returned when no user of the app has the partner code
+	ErrPartnerNotFound = errors.New("partner not found")
+
+	// ErrInvalidPartnerCode is returned for partner codes of the wrong length
+	ErrInvalidPartnerCode = errors.New("partner code must be 6 characters")
+
+	// ErrSelfPair is returned when a user enters their own code
+	ErrSelfPair = errors.New("cannot create pair with yourself")
+
+	// ErrAlreadyPaired is returned when the user is already in a pair
+	ErrAlreadyPaired = errors.New("user is already in a pair")
+
+	// ErrPartnerAlreadyPaired is returned when the partner is already in a pair
+	ErrPartnerAlreadyPaired = errors.New("partner is already in a pair")
+
+	// ErrSyncSessionNotFound is returned when a sync session doesn't exist
+	// or belongs to another pair
+	ErrSyncSessionNotFound = errors.New("sync session not found")
+
+	// ErrUnsupportedPhotoType is returned when a photo is uploaded as a
+	// content type other than JPEG, PNG, HEIC or WebP
+	ErrUnsupportedPhotoType = errors.New("unsupported photo type")
+
+	// ErrPhotoTooLarge is returned when a photo is larger than uploads may be
+	ErrPhotoTooLarge = errors.New("photo is too large")
+
+	// ErrSyncSessionMissed is returned when uploading for a sync session
+	// whose photos weren't uploaded before its deadline
+	ErrSyncSessionMissed = errors.New("sync session was missed")
+
+	// ErrNotInPair is returned when the user has no pair yet
+	ErrNotInPair = errors.New("user is not in a pair")
+
+	// ErrNotPairMember is returned when the user acts on someone else's pair
+	ErrNotPairMember = errors.New("user is not a member of this pair")
+
+	// ErrNotPhotoOwner is returned when a user confirms someone else's photo
+	ErrNotPhotoOwner = errors.New("photo belongs to another user")
+
+	// ErrPhotoNotUploaded is returned when a photo is completed before its
+	// object is in storage
+	ErrPhotoNotUploaded = errors.New("photo has not been uploaded")
+
+	// ErrInvalidPhotoUpload is returned when the uploaded object is empty or
+	// not the image declared for the upload
+	ErrInvalidPhotoUpload = errors.New("uploaded file is not a valid photo")
+
+	// ErrPreconditionFailed is returned when a conditional update finds the
+	// record changed since the client read it
+	ErrPreconditionFailed = errors.New("record was modified since it was read")
+
+	// ErrWebhookNotFound is returned when a webhook doesn't exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrWebhookDeliveryNotFound is returned when a delivery doesn't exist,
+	// e.g. because its webhook was deleted
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrInvalidRefreshToken is returned for refresh tokens that are unknown,
+	// expired or revoked
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+
+	// ErrPartnerOffline is returned when a trigger can't reach the partner
+	ErrPartnerOffline = errors.New("partner is offline")
+)
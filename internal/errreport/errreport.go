@@ -0,0 +1,140 @@
+// Package errreport sends unexpected errors and panics to Sentry or a
+// Sentry-compatible service (GlitchTip, Bugsink). Without a DSN every
+// helper is a no-op.
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/redact"
+
+	"github.com/getsentry/sentry-go"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+// Setup initialises the Sentry client. The returned function flushes
+// buffered events and must be called on shutdown.
+func Setup(cfg config.ErrorReportingConfig) (func(time.Duration), error) {
+	if cfg.DSN == "" {
+		return func(time.Duration) {}, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+		BeforeSend:       redactEvent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize error reporting: %w", err)
+	}
+
+	return func(timeout time.Duration) { sentry.Flush(timeout) }, nil
+}
+
+// redactEvent removes credentials from an event before it leaves the
+// process: the WebSocket token is in the query string, and error messages
+// may quote pre-signed URLs
+func redactEvent(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if event.Request != nil {
+		event.Request.URL = redact.String(event.Request.URL)
+		event.Request.QueryString = strings.TrimPrefix(redact.String("?"+event.Request.QueryString), "?")
+	}
+	event.Message = redact.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = redact.String(event.Exception[i].Value)
+	}
+	return event
+}
+
+// Middleware gives every request its own scope, so tags set by SetUser and
+// SetPair don't leak between requests. It must run before
+// middleware.Recoverer, which reports panics with this scope.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetRequest(r)
+		if requestID := chiMiddleware.GetReqID(r.Context()); requestID != "" {
+			hub.Scope().SetTag("request_id", requestID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(sentry.SetHubOnContext(r.Context(), hub)))
+	})
+}
+
+// SetUser tags errors reported during the request with the user's ID
+func SetUser(ctx context.Context, userID string) {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetUser(sentry.User{ID: userID})
+		hub.Scope().SetTag("user_id", userID)
+	}
+}
+
+// SetPair tags errors reported during the request with the pair's ID
+func SetPair(ctx context.Context, pairID string) {
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetTag("pair_id", pairID)
+	}
+}
+
+// Capture reports err with the tags of the request in ctx. tags are extra
+// key-value pairs, e.g. "job_kind", job.Kind.
+func Capture(ctx context.Context, err error, tags ...string) {
+	if err == nil {
+		return
+	}
+	withTags(ctx, tags).CaptureException(err)
+}
+
+// Recover reports a panic in a background goroutine and stops it from
+// crashing the process. It must be deferred directly:
+//
+//	defer errreport.Recover(ctx, "ws_hub.notify_partner_status", "user_id", userID)
+func Recover(ctx context.Context, where string, tags ...string) {
+	if p := recover(); p != nil {
+		CapturePanic(ctx, p, where, tags...)
+	}
+}
+
+// CapturePanic logs and reports an already recovered panic value. tags are
+// key-value pairs added to the event.
+func CapturePanic(ctx context.Context, p interface{}, where string, tags ...string) {
+	log.Ctx(ctx).Error().
+		Interface("panic", p).
+		Str("where", where).
+		Strs("tags", tags).
+		Str("stack", string(debug.Stack())).
+		Msg("Recovered from panic")
+
+	withTags(ctx, append([]string{"where", where}, tags...)).RecoverWithContext(ctx, p)
+}
+
+// withTags returns a clone of the hub in ctx carrying tags, so they don't
+// leak into events of other requests or goroutines
+func withTags(ctx context.Context, tags []string) *sentry.Hub {
+	h := hub(ctx)
+	if len(tags) == 0 {
+		return h
+	}
+	h = h.Clone()
+	for i := 0; i+1 < len(tags); i += 2 {
+		h.Scope().SetTag(tags[i], tags[i+1])
+	}
+	return h
+}
+
+// hub returns the request's hub, or the global one outside requests
+func hub(ctx context.Context) *sentry.Hub {
+	if h := sentry.GetHubFromContext(ctx); h != nil {
+		return h
+	}
+	return sentry.CurrentHub()
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/services"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/rs/zerolog/log"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// maxComplexity bounds how many fields one query may resolve, so nested
+// pages of moments can't be multiplied into an expensive query
+const maxComplexity = 500
+
+// NewHandler creates the GraphQL HTTP handler. Requests must pass
+// AuthMiddleware first: resolvers read the user from the context.
+func NewHandler(userService *services.UserService, pairService *services.PairService, photoService *services.PhotoService, reactionService *services.ReactionService) *handler.Server {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{
+		userService:     userService,
+		pairService:     pairService,
+		photoService:    photoService,
+		reactionService: reactionService,
+	}}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(func(ctx context.Context, p interface{}) error {
+		errreport.CapturePanic(ctx, p, "graphql.resolver")
+		return errors.New("internal error")
+	})
+	return srv
+}
+
+// presentError keeps validation errors and hides internal ones, like the
+// REST handlers do
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var resolverErr *gqlerror.Error
+	if errors.As(err, &resolverErr) && resolverErr.Err == nil {
+		return gqlErr
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidCursor):
+		gqlErr.Message = services.ErrInvalidCursor.Error()
+		gqlErr.Extensions = map[string]interface{}{"code": "invalid_cursor"}
+	case errors.Is(err, domain.ErrNotInPair):
+		gqlErr.Message = domain.ErrNotInPair.Error()
+		gqlErr.Extensions = map[string]interface{}{"code": "not_in_pair"}
+	default:
+		log.Ctx(ctx).Error().Err(err).Str("path", gqlErr.Path.String()).Msg("GraphQL resolver failed")
+		errreport.Capture(ctx, err)
+		gqlErr.Message = "internal error"
+		gqlErr.Extensions = map[string]interface{}{"code": "internal"}
+	}
+	return gqlErr
+}
@@ -0,0 +1,26 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"sync-photo-backend/internal/models"
+)
+
+type MomentConnection struct {
+	Nodes    []*models.Moment `json:"nodes"`
+	PageInfo *PageInfo        `json:"pageInfo"`
+}
+
+type PageInfo struct {
+	// Pass as after to get the next page; null on the last page
+	EndCursor   *string `json:"endCursor,omitempty"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
+type PhotoConnection struct {
+	Nodes    []*models.Photo `json:"nodes"`
+	PageInfo *PageInfo       `json:"pageInfo"`
+}
+
+type Query struct {
+}
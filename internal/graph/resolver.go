@@ -0,0 +1,55 @@
+// Package graph serves the gallery over GraphQL. The schema lives in
+// schema.graphqls; generated.go and model/ are produced by gqlgen.
+package graph
+
+//go:generate go tool gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"errors"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/graph/model"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+)
+
+// Resolver holds the services shared by all resolvers
+type Resolver struct {
+	userService     *services.UserService
+	pairService     *services.PairService
+	photoService    *services.PhotoService
+	reactionService *services.ReactionService
+}
+
+// userPair returns the user's pair, or nil if the user is not paired
+func (r *Resolver) userPair(ctx context.Context, userID string) (*models.Pair, error) {
+	pair, err := r.pairService.GetPairByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotInPair) {
+		return nil, nil
+	}
+	return pair, err
+}
+
+func parseAfter(after *string) (services.PhotoCursor, error) {
+	if after == nil {
+		return services.PhotoCursor{}, nil
+	}
+	return services.ParsePhotoCursor(*after)
+}
+
+func pageInfo(next *services.PhotoCursor) *model.PageInfo {
+	if next == nil {
+		return &model.PageInfo{}
+	}
+	cursor := next.String()
+	return &model.PageInfo{EndCursor: &cursor, HasNextPage: true}
+}
+
+// intValue returns 0, the service default, for an omitted argument
+func intValue(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,85 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"sync-photo-backend/internal/graph/model"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+)
+
+// Photos is the resolver for the photos field.
+func (r *pairResolver) Photos(ctx context.Context, obj *models.Pair, first *int, after *string) (*model.PhotoConnection, error) {
+	cursor, err := parseAfter(after)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := middleware.GetUserID(ctx)
+	photos, next, err := r.photoService.ListPhotos(ctx, userID, cursor, intValue(first))
+	if err != nil {
+		return nil, err
+	}
+	return &model.PhotoConnection{Nodes: photos, PageInfo: pageInfo(next)}, nil
+}
+
+// Moments is the resolver for the moments field.
+func (r *pairResolver) Moments(ctx context.Context, obj *models.Pair, first *int, after *string) (*model.MomentConnection, error) {
+	cursor, err := parseAfter(after)
+	if err != nil {
+		return nil, err
+	}
+
+	userID := middleware.GetUserID(ctx)
+	moments, next, err := r.photoService.ListMoments(ctx, userID, cursor, intValue(first))
+	if err != nil {
+		return nil, err
+	}
+	return &model.MomentConnection{Nodes: moments, PageInfo: pageInfo(next)}, nil
+}
+
+// Reactions is the resolver for the reactions field.
+func (r *photoResolver) Reactions(ctx context.Context, obj *models.Photo) ([]*models.Reaction, error) {
+	return r.reactionService.ListReactions(ctx, middleware.GetUserID(ctx), obj.ID)
+}
+
+// Comments is the resolver for the comments field.
+func (r *photoResolver) Comments(ctx context.Context, obj *models.Photo, first *int, offset *int) ([]*models.Comment, error) {
+	comments, _, err := r.reactionService.ListComments(ctx, middleware.GetUserID(ctx), obj.ID, intValue(first), intValue(offset))
+	return comments, err
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+	return r.userService.GetUser(ctx, middleware.GetUserID(ctx))
+}
+
+// Pair is the resolver for the pair field.
+func (r *queryResolver) Pair(ctx context.Context) (*models.Pair, error) {
+	return r.userPair(ctx, middleware.GetUserID(ctx))
+}
+
+// Pair is the resolver for the pair field.
+func (r *userResolver) Pair(ctx context.Context, obj *models.User) (*models.Pair, error) {
+	return r.userPair(ctx, obj.ID)
+}
+
+// Pair returns PairResolver implementation.
+func (r *Resolver) Pair() PairResolver { return &pairResolver{r} }
+
+// Photo returns PhotoResolver implementation.
+func (r *Resolver) Photo() PhotoResolver { return &photoResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type pairResolver struct{ *Resolver }
+type photoResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
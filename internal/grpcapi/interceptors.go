@@ -0,0 +1,97 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type callerKey struct{}
+
+// authenticator applies the /api/v1/admin token rules to gRPC calls
+type authenticator struct {
+	token       string
+	userService *services.UserService
+}
+
+// authenticate returns ctx carrying the caller, or an Unauthenticated error
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+
+	provided, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || provided == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+
+	caller, err := middleware.AuthenticateAdmin(provided, a.token, a.userService)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	return context.WithValue(ctx, callerKey{}, caller), nil
+}
+
+func (a *authenticator) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// unaryLogger logs every call like the HTTP access log. It runs before
+// authentication so rejected calls are logged too.
+func unaryLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	logger := log.With().Str("method", info.FullMethod).Logger()
+	ctx = logger.WithContext(ctx)
+
+	resp, err := handler(ctx, req)
+
+	event := logger.Info()
+	if code := status.Code(err); code == codes.Internal || code == codes.Unknown {
+		event = logger.Error().Err(err)
+		errreport.Capture(ctx, err)
+	}
+	event.
+		Str("code", status.Code(err).String()).
+		Dur("duration", time.Since(start)).
+		Msg("gRPC call")
+	return resp, err
+}
+
+// callerFrom returns the authenticated caller: "admin" or the admin JWT subject
+func callerFrom(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
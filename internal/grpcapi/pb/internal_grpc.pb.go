@@ -0,0 +1,449 @@
+// Internal gRPC API for trusted components: media workers, moderation,
+// admin tooling. Callers authenticate with the admin token or an admin JWT
+// from "create-admin-token" in the "authorization: Bearer <token>" metadata.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: twopic/v1/internal.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	UserService_GetUser_FullMethodName = "/twopic.v1.UserService/GetUser"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(User)
+	err := c.cc.Invoke(ctx, UserService_GetUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility.
+type UserServiceServer interface {
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUserServiceServer struct{}
+
+func (UnimplementedUserServiceServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUserServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "twopic.v1.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _UserService_GetUser_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "twopic/v1/internal.proto",
+}
+
+const (
+	PairService_GetPair_FullMethodName       = "/twopic.v1.PairService/GetPair"
+	PairService_GetPairByUser_FullMethodName = "/twopic.v1.PairService/GetPairByUser"
+	PairService_DeletePair_FullMethodName    = "/twopic.v1.PairService/DeletePair"
+)
+
+// PairServiceClient is the client API for PairService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PairServiceClient interface {
+	GetPair(ctx context.Context, in *GetPairRequest, opts ...grpc.CallOption) (*Pair, error)
+	GetPairByUser(ctx context.Context, in *GetPairByUserRequest, opts ...grpc.CallOption) (*Pair, error)
+	// DeletePair deletes the pair and its photos on behalf of one of its members
+	DeletePair(ctx context.Context, in *DeletePairRequest, opts ...grpc.CallOption) (*DeletePairResponse, error)
+}
+
+type pairServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPairServiceClient(cc grpc.ClientConnInterface) PairServiceClient {
+	return &pairServiceClient{cc}
+}
+
+func (c *pairServiceClient) GetPair(ctx context.Context, in *GetPairRequest, opts ...grpc.CallOption) (*Pair, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pair)
+	err := c.cc.Invoke(ctx, PairService_GetPair_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pairServiceClient) GetPairByUser(ctx context.Context, in *GetPairByUserRequest, opts ...grpc.CallOption) (*Pair, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pair)
+	err := c.cc.Invoke(ctx, PairService_GetPairByUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pairServiceClient) DeletePair(ctx context.Context, in *DeletePairRequest, opts ...grpc.CallOption) (*DeletePairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePairResponse)
+	err := c.cc.Invoke(ctx, PairService_DeletePair_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PairServiceServer is the server API for PairService service.
+// All implementations must embed UnimplementedPairServiceServer
+// for forward compatibility.
+type PairServiceServer interface {
+	GetPair(context.Context, *GetPairRequest) (*Pair, error)
+	GetPairByUser(context.Context, *GetPairByUserRequest) (*Pair, error)
+	// DeletePair deletes the pair and its photos on behalf of one of its members
+	DeletePair(context.Context, *DeletePairRequest) (*DeletePairResponse, error)
+	mustEmbedUnimplementedPairServiceServer()
+}
+
+// UnimplementedPairServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPairServiceServer struct{}
+
+func (UnimplementedPairServiceServer) GetPair(context.Context, *GetPairRequest) (*Pair, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPair not implemented")
+}
+func (UnimplementedPairServiceServer) GetPairByUser(context.Context, *GetPairByUserRequest) (*Pair, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPairByUser not implemented")
+}
+func (UnimplementedPairServiceServer) DeletePair(context.Context, *DeletePairRequest) (*DeletePairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePair not implemented")
+}
+func (UnimplementedPairServiceServer) mustEmbedUnimplementedPairServiceServer() {}
+func (UnimplementedPairServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafePairServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PairServiceServer will
+// result in compilation errors.
+type UnsafePairServiceServer interface {
+	mustEmbedUnimplementedPairServiceServer()
+}
+
+func RegisterPairServiceServer(s grpc.ServiceRegistrar, srv PairServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPairServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PairService_ServiceDesc, srv)
+}
+
+func _PairService_GetPair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PairServiceServer).GetPair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PairService_GetPair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PairServiceServer).GetPair(ctx, req.(*GetPairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PairService_GetPairByUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPairByUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PairServiceServer).GetPairByUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PairService_GetPairByUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PairServiceServer).GetPairByUser(ctx, req.(*GetPairByUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PairService_DeletePair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PairServiceServer).DeletePair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PairService_DeletePair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PairServiceServer).DeletePair(ctx, req.(*DeletePairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PairService_ServiceDesc is the grpc.ServiceDesc for PairService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PairService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "twopic.v1.PairService",
+	HandlerType: (*PairServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPair",
+			Handler:    _PairService_GetPair_Handler,
+		},
+		{
+			MethodName: "GetPairByUser",
+			Handler:    _PairService_GetPairByUser_Handler,
+		},
+		{
+			MethodName: "DeletePair",
+			Handler:    _PairService_DeletePair_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "twopic/v1/internal.proto",
+}
+
+const (
+	PhotoService_ListPhotos_FullMethodName  = "/twopic.v1.PhotoService/ListPhotos"
+	PhotoService_ListMoments_FullMethodName = "/twopic.v1.PhotoService/ListMoments"
+)
+
+// PhotoServiceClient is the client API for PhotoService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PhotoServiceClient interface {
+	// ListPhotos pages through the photos of the user's pair, newest first
+	ListPhotos(ctx context.Context, in *ListPhotosRequest, opts ...grpc.CallOption) (*ListPhotosResponse, error)
+	// ListMoments pages through the moments of the user's pair, newest first
+	ListMoments(ctx context.Context, in *ListMomentsRequest, opts ...grpc.CallOption) (*ListMomentsResponse, error)
+}
+
+type photoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPhotoServiceClient(cc grpc.ClientConnInterface) PhotoServiceClient {
+	return &photoServiceClient{cc}
+}
+
+func (c *photoServiceClient) ListPhotos(ctx context.Context, in *ListPhotosRequest, opts ...grpc.CallOption) (*ListPhotosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPhotosResponse)
+	err := c.cc.Invoke(ctx, PhotoService_ListPhotos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *photoServiceClient) ListMoments(ctx context.Context, in *ListMomentsRequest, opts ...grpc.CallOption) (*ListMomentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMomentsResponse)
+	err := c.cc.Invoke(ctx, PhotoService_ListMoments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PhotoServiceServer is the server API for PhotoService service.
+// All implementations must embed UnimplementedPhotoServiceServer
+// for forward compatibility.
+type PhotoServiceServer interface {
+	// ListPhotos pages through the photos of the user's pair, newest first
+	ListPhotos(context.Context, *ListPhotosRequest) (*ListPhotosResponse, error)
+	// ListMoments pages through the moments of the user's pair, newest first
+	ListMoments(context.Context, *ListMomentsRequest) (*ListMomentsResponse, error)
+	mustEmbedUnimplementedPhotoServiceServer()
+}
+
+// UnimplementedPhotoServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPhotoServiceServer struct{}
+
+func (UnimplementedPhotoServiceServer) ListPhotos(context.Context, *ListPhotosRequest) (*ListPhotosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPhotos not implemented")
+}
+func (UnimplementedPhotoServiceServer) ListMoments(context.Context, *ListMomentsRequest) (*ListMomentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMoments not implemented")
+}
+func (UnimplementedPhotoServiceServer) mustEmbedUnimplementedPhotoServiceServer() {}
+func (UnimplementedPhotoServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafePhotoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PhotoServiceServer will
+// result in compilation errors.
+type UnsafePhotoServiceServer interface {
+	mustEmbedUnimplementedPhotoServiceServer()
+}
+
+func RegisterPhotoServiceServer(s grpc.ServiceRegistrar, srv PhotoServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPhotoServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PhotoService_ServiceDesc, srv)
+}
+
+func _PhotoService_ListPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhotoServiceServer).ListPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PhotoService_ListPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhotoServiceServer).ListPhotos(ctx, req.(*ListPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PhotoService_ListMoments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMomentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PhotoServiceServer).ListMoments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PhotoService_ListMoments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PhotoServiceServer).ListMoments(ctx, req.(*ListMomentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PhotoService_ServiceDesc is the grpc.ServiceDesc for PhotoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PhotoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "twopic.v1.PhotoService",
+	HandlerType: (*PhotoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPhotos",
+			Handler:    _PhotoService_ListPhotos_Handler,
+		},
+		{
+			MethodName: "ListMoments",
+			Handler:    _PhotoService_ListMoments_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "twopic/v1/internal.proto",
+}
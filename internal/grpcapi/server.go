@@ -0,0 +1,32 @@
+// Package grpcapi serves the user, pair and photo services over gRPC for
+// internal components. Messages are defined in proto/twopic/v1/internal.proto.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=sync-photo-backend --go-grpc_out=../.. --go-grpc_opt=module=sync-photo-backend twopic/v1/internal.proto
+
+import (
+	"sync-photo-backend/internal/grpcapi/pb"
+	"sync-photo-backend/internal/services"
+
+	"google.golang.org/grpc"
+)
+
+// NewServer creates a gRPC server with all services registered. Every call
+// must carry the static admin token or an admin JWT.
+func NewServer(
+	userService *services.UserService,
+	pairService *services.PairService,
+	photoService *services.PhotoService,
+	adminToken string,
+) *grpc.Server {
+	auth := &authenticator{token: adminToken, userService: userService}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryLogger, auth.unary),
+		grpc.ChainStreamInterceptor(auth.stream),
+	)
+
+	pb.RegisterUserServiceServer(srv, &userServer{userService: userService})
+	pb.RegisterPairServiceServer(srv, &pairServer{pairService: pairService})
+	pb.RegisterPhotoServiceServer(srv, &photoServer{photoService: photoService})
+	return srv
+}
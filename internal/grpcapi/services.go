@@ -0,0 +1,201 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/grpcapi/pb"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	userService *services.UserService
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if err := checkID("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	user, err := s.userService.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+type pairServer struct {
+	pb.UnimplementedPairServiceServer
+	pairService *services.PairService
+}
+
+func (s *pairServer) GetPair(ctx context.Context, req *pb.GetPairRequest) (*pb.Pair, error) {
+	if err := checkID("pair_id", req.GetPairId()); err != nil {
+		return nil, err
+	}
+	pair, err := s.pairService.GetPairByID(ctx, req.GetPairId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPair(pair), nil
+}
+
+func (s *pairServer) GetPairByUser(ctx context.Context, req *pb.GetPairByUserRequest) (*pb.Pair, error) {
+	if err := checkID("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	pair, err := s.pairService.GetPairByUserID(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPair(pair), nil
+}
+
+func (s *pairServer) DeletePair(ctx context.Context, req *pb.DeletePairRequest) (*pb.DeletePairResponse, error) {
+	if err := checkID("pair_id", req.GetPairId()); err != nil {
+		return nil, err
+	}
+	if err := checkID("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	if err := s.pairService.DeletePair(ctx, req.GetPairId(), req.GetUserId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	log.Ctx(ctx).Info().
+		Str("caller", callerFrom(ctx)).
+		Str("pair_id", req.GetPairId()).
+		Str("user_id", req.GetUserId()).
+		Msg("Pair deleted over gRPC")
+	return &pb.DeletePairResponse{}, nil
+}
+
+type photoServer struct {
+	pb.UnimplementedPhotoServiceServer
+	photoService *services.PhotoService
+}
+
+func (s *photoServer) ListPhotos(ctx context.Context, req *pb.ListPhotosRequest) (*pb.ListPhotosResponse, error) {
+	if err := checkID("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	cursor, err := services.ParsePhotoCursor(req.GetCursor())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	photos, next, err := s.photoService.ListPhotos(ctx, req.GetUserId(), cursor, int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListPhotosResponse{
+		Photos:     toPhotos(photos),
+		NextCursor: cursorString(next),
+	}, nil
+}
+
+func (s *photoServer) ListMoments(ctx context.Context, req *pb.ListMomentsRequest) (*pb.ListMomentsResponse, error) {
+	if err := checkID("user_id", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	cursor, err := services.ParsePhotoCursor(req.GetCursor())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	moments, next, err := s.photoService.ListMoments(ctx, req.GetUserId(), cursor, int(req.GetLimit()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	result := make([]*pb.Moment, 0, len(moments))
+	for _, moment := range moments {
+		result = append(result, &pb.Moment{
+			Id:        moment.ID,
+			Photos:    toPhotos(moment.Photos),
+			StartedAt: timestamppb.New(moment.StartedAt),
+			EndedAt:   timestamppb.New(moment.EndedAt),
+			Complete:  moment.Complete,
+		})
+	}
+	return &pb.ListMomentsResponse{
+		Moments:    result,
+		NextCursor: cursorString(next),
+	}, nil
+}
+
+// checkID rejects empty and malformed IDs with InvalidArgument
+func checkID(field, id string) error {
+	if id == "" {
+		return status.Error(codes.InvalidArgument, field+" is required")
+	}
+	if err := validation.CheckID(field, id); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// toStatus maps service errors to gRPC status codes
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCursor), errors.Is(err, domain.ErrInvalidID):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrNotInPair), errors.Is(err, domain.ErrPairNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrNotPairMember):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func cursorString(c *services.PhotoCursor) string {
+	if c == nil {
+		return ""
+	}
+	return c.String()
+}
+
+func toUser(user *models.User) *pb.User {
+	return &pb.User{
+		Id:           user.ID,
+		Code:         user.Code,
+		Timezone:     user.Timezone,
+		HasPushToken: user.PushToken != nil && *user.PushToken != "",
+		CreatedAt:    timestamppb.New(user.CreatedAt),
+	}
+}
+
+func toPair(pair *models.Pair) *pb.Pair {
+	return &pb.Pair{
+		Id:        pair.ID,
+		UserAId:   pair.UserAID,
+		UserBId:   pair.UserBID,
+		CreatedAt: timestamppb.New(pair.CreatedAt),
+	}
+}
+
+func toPhotos(photos []*models.Photo) []*pb.Photo {
+	result := make([]*pb.Photo, 0, len(photos))
+	for _, photo := range photos {
+		result = append(result, &pb.Photo{
+			Id:        photo.ID,
+			PairId:    photo.PairID,
+			UserId:    photo.UserID,
+			Url:       photo.S3URL,
+			TakenAt:   timestamppb.New(photo.TakenAt),
+			CreatedAt: timestamppb.New(photo.CreatedAt),
+		})
+	}
+	return result
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// AdminHandler handles admin HTTP requests
+type AdminHandler struct {
+	pushService  *services.PushService
+	jobRunner    *services.JobRunner
+	statsService *services.StatsService
+	reloader     *config.Reloader
+	maintenance  *services.MaintenanceService
+	userService  *services.UserService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	pushService *services.PushService,
+	jobRunner *services.JobRunner,
+	statsService *services.StatsService,
+	reloader *config.Reloader,
+	maintenance *services.MaintenanceService,
+	userService *services.UserService,
+) *AdminHandler {
+	return &AdminHandler{
+		pushService:  pushService,
+		jobRunner:    jobRunner,
+		statsService: statsService,
+		reloader:     reloader,
+		maintenance:  maintenance,
+		userService:  userService,
+	}
+}
+
+// PushStatsResponse represents push delivery counters since startup
+type PushStatsResponse struct {
+	Stats []services.PushStat `json:"stats"`
+}
+
+// JobListResponse represents recent background jobs
+type JobListResponse struct {
+	Jobs []*models.Job `json:"jobs"`
+}
+
+// StatsResponse represents daily service statistics, newest first
+type StatsResponse struct {
+	Days []*models.DailyStats `json:"days"`
+}
+
+// ReloadResponse is returned after a successful configuration reload
+type ReloadResponse struct {
+	Status   string `json:"status"`
+	LogLevel string `json:"log_level"`
+}
+
+// SetMaintenanceRequest turns the maintenance override on or off
+type SetMaintenanceRequest struct {
+	Enabled bool `json:"enabled"`
+	// Message replaces the configured one while the override is on
+	Message string `json:"message,omitempty"`
+}
+
+// MaintenanceStatusResponse represents the effective maintenance state
+type MaintenanceStatusResponse struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after"` // seconds
+}
+
+// GetPushStats handles GET /api/v1/admin/push-stats
+func (h *AdminHandler) GetPushStats(w http.ResponseWriter, r *http.Request) {
+	response := PushStatsResponse{
+		Stats: h.pushService.Stats(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListJobs handles GET /api/v1/admin/jobs?status=failed&limit=50
+func (h *AdminHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.JobStatusPending, models.JobStatusRunning, models.JobStatusDone, models.JobStatusFailed:
+	default:
+		respondError(w, "status must be one of pending, running, done, failed", http.StatusBadRequest)
+		return
+	}
+
+	limit := pagination.Limit(r.URL.Query(), services.AdminListLimits)
+
+	jobs, err := h.jobRunner.List(ctx, status, limit)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to list jobs")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(JobListResponse{
+		Jobs: jobs,
+	})
+}
+
+// GetStats handles GET /api/v1/admin/stats?days=30
+func (h *AdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		if parsedDays, err := strconv.Atoi(daysStr); err == nil && parsedDays > 0 && parsedDays <= 366 {
+			days = parsedDays
+		}
+	}
+
+	stats, err := h.statsService.List(ctx, days)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to list stats")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to list stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatsResponse{
+		Days: stats,
+	})
+}
+
+// ReloadConfig handles POST /api/v1/admin/reload
+func (h *AdminHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.reloader.Reload()
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to reload configuration")
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Ctx(r.Context()).Info().Msg("Configuration reloaded via admin endpoint")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ReloadResponse{
+		Status:   "ok",
+		LogLevel: cfg.Log.Level,
+	})
+}
+
+// GetMaintenance handles GET /api/v1/admin/maintenance
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	respondMaintenance(w, h.maintenance.Status())
+}
+
+// SetMaintenance handles PUT /api/v1/admin/maintenance
+func (h *AdminHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req SetMaintenanceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	status, err := h.maintenance.Set(ctx, req.Enabled, req.Message)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to set maintenance mode")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to set maintenance mode", http.StatusInternalServerError)
+		return
+	}
+
+	log.Ctx(ctx).Warn().Bool("enabled", req.Enabled).Msg("Maintenance override set via admin endpoint")
+	respondMaintenance(w, status)
+}
+
+// RestoreUser handles POST /api/v1/admin/users/{user_id}/restore. It
+// reverses an accidental deletion within the recovery window; users that
+// aren't deleted or were already purged are answered with 404.
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := chi.URLParam(r, "user_id")
+	if err := validation.CheckID("user_id", userID); err != nil {
+		respondServiceError(w, r, err, "Failed to restore user")
+		return
+	}
+
+	if err := h.userService.RestoreUser(ctx, userID); err != nil {
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to restore user")
+		}
+		respondServiceError(w, r, err, "Failed to restore user")
+		return
+	}
+
+	log.Ctx(ctx).Warn().Str("user_id", userID).Msg("Deleted user restored via admin endpoint")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+func respondMaintenance(w http.ResponseWriter, status services.MaintenanceStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MaintenanceStatusResponse{
+		Enabled:    status.Enabled,
+		Message:    status.Message,
+		RetryAfter: int(status.RetryAfter.Seconds()),
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AuthHandler handles session requests
+type AuthHandler struct {
+	userService *services.UserService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(userService *services.UserService) *AuthHandler {
+	return &AuthHandler{userService: userService}
+}
+
+// RefreshTokenRequest represents the request body for refreshing and ending a session
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=256"`
+}
+
+// Refresh handles POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.userService.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to refresh session")
+		respondServiceError(w, r, err, "Failed to refresh session")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout handles POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.Logout(ctx, req.RefreshToken); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to end session")
+		respondServiceError(w, r, err, "Failed to end session")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+// Restore handles POST /api/v1/auth/restore. It undoes the deletion of the
+// account within accounts.recovery_window with a refresh token the deletion
+// revoked and returns the tokens of a new session.
+func (h *AuthHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	tokens, err := h.userService.RestoreAccount(ctx, req.RefreshToken)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Failed to restore account")
+		respondServiceError(w, r, err, "Failed to restore account")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
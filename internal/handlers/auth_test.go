@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/repository/memory"
+	"sync-photo-backend/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// newAuthRouter routes user creation, account deletion and the session
+// endpoints to services backed by the memory repositories, with deleted
+// accounts kept for recoveryWindow
+func newAuthRouter(t *testing.T, recoveryWindow time.Duration) http.Handler {
+	t.Helper()
+
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	pairService := services.NewPairService(memory.NewPairRepository(store), userRepo)
+	jobRunner := services.NewJobRunner(memory.NewJobRepository(store), config.JobsConfig{})
+	userService := services.NewUserService(userRepo, "test-secret-of-at-least-32-characters").
+		WithSessions(memory.NewRefreshTokenRepository(store), config.JWTConfig{AccessTTL: time.Hour, RefreshTTL: 24 * time.Hour})
+	userService.WithAccountDeletion(memory.NewUnitOfWork(), jobRunner, pairService, services.NewWSHub(pairService), nil, nil,
+		config.AccountsConfig{RecoveryWindow: recoveryWindow})
+
+	userHandler := NewUserHandler(userService, nil)
+	authHandler := NewAuthHandler(userService)
+
+	r := chi.NewRouter()
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Post("/users", userHandler.CreateUser)
+		r.Post("/auth/refresh", authHandler.Refresh)
+		r.Post("/auth/restore", authHandler.Restore)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(userService))
+			r.Delete("/users/me", userHandler.DeleteMe)
+		})
+	})
+	return r
+}
+
+// send sends a request to the router and returns the recorded response
+func send(t *testing.T, router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// sessionRequest sends the refresh token to a session endpoint and returns
+// the recorded response and the tokens of a successful one
+func sessionRequest(t *testing.T, router http.Handler, path, refreshToken string) (*httptest.ResponseRecorder, services.Tokens) {
+	t.Helper()
+
+	rec := send(t, router, http.MethodPost, path, "", `{"refresh_token": "`+refreshToken+`"}`)
+	var tokens services.Tokens
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, tokens
+}
+
+// createDeletedUser creates a user, refreshes their session once and
+// deletes the account. It returns the refresh token rotated before the
+// deletion and the one the deletion revoked.
+func createDeletedUser(t *testing.T, router http.Handler) (rotated, revoked string) {
+	t.Helper()
+
+	rec := send(t, router, http.MethodPost, "/api/v1/users", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create user: status %d: %s", rec.Code, rec.Body)
+	}
+	var created CreateUserResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, tokens := sessionRequest(t, router, "/api/v1/auth/refresh", created.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := send(t, router, http.MethodDelete, "/api/v1/users/me", tokens.Token, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete user: status %d: %s", rec.Code, rec.Body)
+	}
+	return created.RefreshToken, tokens.RefreshToken
+}
+
+func TestRestoreAccount(t *testing.T) {
+	router := newAuthRouter(t, time.Hour)
+	rotated, revoked := createDeletedUser(t, router)
+
+	if rec, _ := sessionRequest(t, router, "/api/v1/auth/refresh", revoked); rec.Code != http.StatusUnauthorized {
+		t.Errorf("refresh of a deleted account: status %d, want 401", rec.Code)
+	}
+	if rec, _ := sessionRequest(t, router, "/api/v1/auth/restore", rotated); rec.Code != http.StatusUnauthorized {
+		t.Errorf("restore with a token rotated before the deletion: status %d, want 401", rec.Code)
+	}
+
+	rec, tokens := sessionRequest(t, router, "/api/v1/auth/restore", revoked)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: status %d: %s", rec.Code, rec.Body)
+	}
+	if tokens.Token == "" || tokens.RefreshToken == "" {
+		t.Fatalf("restore returned no session: %+v", tokens)
+	}
+
+	// Восстановленный аккаунт работает в новой сессии, а старая не возобновилась
+	if rec, _ := sessionRequest(t, router, "/api/v1/auth/refresh", tokens.RefreshToken); rec.Code != http.StatusOK {
+		t.Errorf("refresh of the restored session: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec, _ := sessionRequest(t, router, "/api/v1/auth/restore", revoked); rec.Code != http.StatusUnauthorized {
+		t.Errorf("second restore: status %d, want 401", rec.Code)
+	}
+}
+
+func TestRestoreAccountAfterWindow(t *testing.T) {
+	router := newAuthRouter(t, time.Nanosecond)
+	_, revoked := createDeletedUser(t, router)
+
+	if rec, _ := sessionRequest(t, router, "/api/v1/auth/restore", revoked); rec.Code != http.StatusUnauthorized {
+		t.Errorf("restore after the recovery window: status %d, want 401", rec.Code)
+	}
+}
@@ -2,17 +2,90 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/validation"
 )
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string                  `json:"error"`
+	Code      string                  `json:"code,omitempty"`
+	Message   string                  `json:"message,omitempty"` // localized text for code
+	Fields    []validation.FieldError `json:"fields,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// StatusResponse is returned by endpoints that only report success
+type StatusResponse struct {
+	Status string `json:"status"`
 }
 
-// respondError sends an error response
+// respondError sends an error response. The request ID is taken from the
+// response header set by the RequestID middleware so users can quote it.
 func respondError(w http.ResponseWriter, message string, statusCode int) {
+	respondErrorCode(w, "", message, statusCode)
+}
+
+// respondErrorCode sends an error response with a machine-readable code
+func respondErrorCode(w http.ResponseWriter, code, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Message:   middleware.Message(w, code),
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	})
+}
+
+// respondServiceError maps a service error to its status and code, see
+// middleware.RespondServiceError. Unexpected errors are reported and answered
+// with message; callers log the failure with their own fields.
+func respondServiceError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if !middleware.RespondServiceError(w, err, message) {
+		errreport.Capture(r.Context(), err)
+	}
+}
+
+// respondJSON sends v as a JSON response
+func respondJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodeJSON decodes the request body into v and validates it against its
+// `validate` tags. It responds with an error if the body is malformed,
+// invalid or exceeds the route's size limit.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	if fieldErrs := validation.Struct(v); fieldErrs != nil {
+		respondValidationError(w, fieldErrs)
+		return false
+	}
+	return true
+}
+
+// respondValidationError sends the field errors of an invalid request. The
+// first one doubles as the error message, as v1 responded before.
+func respondValidationError(w http.ResponseWriter, fieldErrs []validation.FieldError) {
+	respondJSON(w, http.StatusBadRequest, ErrorResponse{
+		Error:     fieldErrs[0].Message,
+		Code:      middleware.ErrCodeInvalidRequest,
+		Message:   middleware.Message(w, middleware.ErrCodeInvalidRequest),
+		Fields:    fieldErrs,
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	})
 }
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CompositeHandler handles requests for stitched moment photos
+type CompositeHandler struct {
+	compositor *services.CompositorService
+}
+
+// NewCompositeHandler creates a new composite handler
+func NewCompositeHandler(compositor *services.CompositorService) *CompositeHandler {
+	return &CompositeHandler{compositor: compositor}
+}
+
+// CompositeListResponse represents a page of the pair's composites
+type CompositeListResponse struct {
+	Composites []*models.Composite `json:"composites"`
+	Total      int                 `json:"total"`
+}
+
+// GetComposites handles GET /api/v1/composites
+func (h *CompositeHandler) GetComposites(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	limit, offset := pagination.Offset(r.URL.Query(), services.CompositeLimits)
+
+	composites, total, err := h.compositor.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get composites")
+		respondServiceError(w, r, err, "Failed to get composites")
+		return
+	}
+
+	pagination.OffsetLinks(w, r, limit, offset, total)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CompositeListResponse{Composites: composites, Total: total})
+}
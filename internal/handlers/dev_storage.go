@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DevStorageHandler stands in for the S3 bucket in dev mode: clients PUT
+// photos to the upload URLs and GET them back from the photo URLs
+type DevStorageHandler struct {
+	store  *services.FileObjectStore
+	prefix string
+	files  http.Handler
+}
+
+// NewDevStorageHandler creates a handler for objects of store served under prefix
+func NewDevStorageHandler(store *services.FileObjectStore, prefix string) *DevStorageHandler {
+	return &DevStorageHandler{
+		store:  store,
+		prefix: prefix,
+		files:  http.StripPrefix(prefix, http.FileServer(http.Dir(store.Dir()))),
+	}
+}
+
+// Upload handles PUT {prefix}/*
+func (h *DevStorageHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, h.prefix+"/")
+	if err := h.store.Write(key, r.Body); err != nil {
+		if errors.Is(err, services.ErrInvalidObjectKey) {
+			respondError(w, "Invalid object key", http.StatusBadRequest)
+			return
+		}
+		log.Ctx(r.Context()).Error().Err(err).Str("key", key).Msg("Failed to store uploaded object")
+		respondError(w, "Failed to store object", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Download handles GET {prefix}/*
+func (h *DevStorageHandler) Download(w http.ResponseWriter, r *http.Request) {
+	h.files.ServeHTTP(w, r)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EventHandler handles client analytics event ingestion
+type EventHandler struct {
+	eventService *services.EventService
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(eventService *services.EventService) *EventHandler {
+	return &EventHandler{
+		eventService: eventService,
+	}
+}
+
+// IngestEventsRequest represents a batch of client analytics events
+type IngestEventsRequest struct {
+	Events []services.EventInput `json:"events"`
+}
+
+// IngestEventsResponse reports how many events were accepted
+type IngestEventsResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// IngestEvents handles POST /api/v1/events
+func (h *EventHandler) IngestEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req IngestEventsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	accepted, err := h.eventService.Ingest(ctx, userID, req.Events)
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidEvent) {
+			log.Ctx(ctx).Error().Err(err).Int("events", len(req.Events)).Msg("Failed to store client events")
+		}
+		respondServiceError(w, r, err, "Failed to store events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(IngestEventsResponse{
+		Accepted: accepted,
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+)
+
+// FlagHandler handles feature flag HTTP requests
+type FlagHandler struct {
+	flagService *services.FlagService
+	pairService *services.PairService
+}
+
+// NewFlagHandler creates a new flag handler
+func NewFlagHandler(flagService *services.FlagService, pairService *services.PairService) *FlagHandler {
+	return &FlagHandler{
+		flagService: flagService,
+		pairService: pairService,
+	}
+}
+
+// FlagsResponse maps feature flag names to their value for the user
+type FlagsResponse struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// GetFlags handles GET /api/v1/flags
+func (h *FlagHandler) GetFlags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	// Пользователь без пары получает флаги по собственному ID
+	var pairID string
+	if pair, err := h.pairService.GetPairByUserID(ctx, userID); err == nil && pair != nil {
+		pairID = pair.ID
+	}
+
+	response := FlagsResponse{
+		Flags: h.flagService.Evaluate(userID, pairID),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const readinessTimeout = 3 * time.Second
+
+// HealthCheck checks a single dependency
+type HealthCheck func(ctx context.Context) error
+
+// DependencyStatus represents the readiness of a single dependency
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessResponse represents the readiness of the instance and its dependencies
+type ReadinessResponse struct {
+	Status       string                      `json:"status"` // ok или unavailable
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	checks map[string]HealthCheck
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{
+		checks: make(map[string]HealthCheck),
+	}
+}
+
+// AddCheck registers a dependency that must be healthy for the instance to be ready
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.checks[name] = check
+}
+
+// Liveness handles GET /healthz
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+// Readiness handles GET /readyz
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	type result struct {
+		name   string
+		status DependencyStatus
+	}
+
+	// Проверяем зависимости параллельно, чтобы медленная не задерживала остальные
+	results := make(chan result, len(h.checks))
+	for name, check := range h.checks {
+		go func(name string, check HealthCheck) {
+			start := time.Now()
+			err := check(ctx)
+			status := DependencyStatus{
+				Status:    "ok",
+				LatencyMS: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				status.Status = "fail"
+				status.Error = err.Error()
+			}
+			results <- result{name: name, status: status}
+		}(name, check)
+	}
+
+	ready := true
+	dependencies := make(map[string]DependencyStatus, len(h.checks))
+	for range h.checks {
+		res := <-results
+		dependencies[res.name] = res.status
+		if res.status.Status != "ok" {
+			ready = false
+			log.Ctx(r.Context()).Warn().
+				Str("dependency", res.name).
+				Str("error", res.status.Error).
+				Msg("Readiness check failed")
+		}
+	}
+
+	statusCode := http.StatusOK
+	status := "ok"
+	if !ready {
+		statusCode = http.StatusServiceUnavailable
+		status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ReadinessResponse{
+		Status:       status,
+		Dependencies: dependencies,
+	})
+}
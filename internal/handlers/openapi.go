@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"net/http"
+
+	v2 "sync-photo-backend/internal/handlers/v2"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/openapi"
+	"sync-photo-backend/internal/services"
+)
+
+// APIVersion is the version reported in the OpenAPI document
+const APIVersion = "1.0.0"
+
+var idempotencyKeyHeader = openapi.Parameter{
+	Name:        "Idempotency-Key",
+	Description: "Repeating a request with the same key returns the stored response",
+}
+
+var appIDHeader = openapi.Parameter{
+	Name:        middleware.AppIDHeader,
+	Description: "White-label app the user belongs to; defaults to the main app",
+}
+
+// Routes annotates the REST endpoints for the OpenAPI document. Keep it in
+// sync with the router in cmd/serve.go and regenerate api/openapi.json with
+// go generate ./api
+var Routes = []openapi.Route{
+	{
+		Method: http.MethodPost, Path: "/api/v1/users", ID: "createUser", Tag: "users",
+		Summary:   "Create a user and return its token and pairing code",
+		Headers:   []openapi.Parameter{appIDHeader},
+		Responses: responses(http.StatusOK, CreateUserResponse{}, http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/refresh", ID: "refreshSession", Tag: "users",
+		Summary:   "Exchange a refresh token for a new token and the next refresh token; the presented one is revoked",
+		Request:   RefreshTokenRequest{},
+		Responses: responses(http.StatusOK, services.Tokens{}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/logout", ID: "logout", Tag: "users",
+		Summary:   "End the session of the refresh token; its tokens stop working right away",
+		Request:   RefreshTokenRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/auth/restore", ID: "restoreAccount", Tag: "users",
+		Summary:   "Restore the deleted account within accounts.recovery_window with a refresh token the deletion revoked and start a new session",
+		Request:   RefreshTokenRequest{},
+		Responses: responses(http.StatusOK, services.Tokens{}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/users/push-token", ID: "updatePushToken", Tag: "users",
+		Summary: "Set the APNs device token", Auth: openapi.AuthUser,
+		Request:   UpdatePushTokenRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/users/push-token", ID: "registerPushToken", Tag: "users",
+		Summary: "Register or rotate the APNs device token; same as PUT", Auth: openapi.AuthUser,
+		Request:   UpdatePushTokenRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/users/quiet-hours", ID: "updateQuietHours", Tag: "users",
+		Summary: "Set quiet hours; null start and end disable them", Auth: openapi.AuthUser,
+		Request:   UpdateQuietHoursRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/users/trigger-alerts", ID: "updateTriggerAlerts", Tag: "users",
+		Summary: "Opt in to time-sensitive trigger pushes", Auth: openapi.AuthUser,
+		Request:   UpdateTriggerAlertsRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/users/locale", ID: "updateLocale", Tag: "users",
+		Summary: "Set the language of messages for clients without Accept-Language; empty resets it", Auth: openapi.AuthUser,
+		Request:   UpdateLocaleRequest{},
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/users/me/push-test", ID: "sendTestPush", Tag: "users",
+		Summary: "Send a test push to the user's device", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, StatusResponse{},
+			http.StatusBadRequest, http.StatusBadGateway, http.StatusServiceUnavailable),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/users/me", ID: "deleteMe", Tag: "users",
+		Summary: "Delete the account and close its WebSocket; it can be restored within accounts.recovery_window", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusNoContent, nil, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/flags", ID: "getFlags", Tag: "users",
+		Summary: "Evaluate feature flags for the user", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, FlagsResponse{}),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/events", ID: "ingestEvents", Tag: "events",
+		Summary: "Submit a batch of client analytics events", Auth: openapi.AuthUser,
+		Request:   IngestEventsRequest{},
+		Responses: responses(http.StatusAccepted, IngestEventsResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/pairs", ID: "createPair", Tag: "pairs",
+		Summary: "Pair with the partner by their code; a previous pair with the same partner is restored with its photos", Auth: openapi.AuthUser,
+		Headers: []openapi.Parameter{idempotencyKeyHeader},
+		Request: CreatePairRequest{},
+		Responses: responses(http.StatusOK, models.Pair{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/pairs/{pair_id}", ID: "deletePair", Tag: "pairs",
+		Summary: "Delete the pair; it stays in the history and its photos are kept", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusNoContent, nil,
+			http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/pairs/history", ID: "getPairHistory", Tag: "pairs",
+		Summary: "List the user's current and deleted pairs, newest first", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, PairHistoryResponse{}, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/pairs/me/streak", ID: "getStreak", Tag: "pairs",
+		Summary: "Get the pair's daily prompt streak and its latest prompt", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, services.Streak{}, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/pairs/me/prompt", ID: "getPromptSettings", Tag: "pairs",
+		Summary: "Get when the pair gets its daily prompt", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, services.PromptSchedule{}, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/pairs/me/prompt", ID: "updatePromptSettings", Tag: "pairs",
+		Summary: "Change when the pair gets its daily prompt; empty time restores the default, timezone changes the timezone of the pair and is kept if empty", Auth: openapi.AuthUser,
+		Request:   services.PromptSettingsRequest{},
+		Responses: responses(http.StatusOK, services.PromptSchedule{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/pairs/me/settings", ID: "getPairSettings", Tag: "pairs",
+		Summary: "Get the settings both partners share: mute hours, countdown, timezone and auto composites", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, services.PairOptions{}, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v1/pairs/me/settings", ID: "updatePairSettings", Tag: "pairs",
+		Summary: "Change the settings both partners share; omitted fields are kept, empty mute hours turn muting off, zero countdown_ms and empty timezone restore the defaults", Auth: openapi.AuthUser,
+		Request:   services.PairSettingsRequest{},
+		Responses: responses(http.StatusOK, services.PairOptions{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/photos", ID: "getPhotos", Tag: "photos",
+		Summary: "List the pair's photos, newest first; Link points to the next page. Pass cursor, empty for the first page, to page by cursor; offset is deprecated", Auth: openapi.AuthUser,
+		Query: []openapi.Parameter{
+			{Name: "cursor", Description: "next_cursor of the previous page; empty for the first page"},
+			{Name: "limit", Description: "Page size, 50 by default, at most 100", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", Description: "Can't be combined with cursor; rejected if deprecations.disable_photo_offset is set", Deprecated: true, Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, PhotoListResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/photos/export", ID: "exportPhotos", Tag: "photos",
+		Summary: "Download the pair's uploaded photos as a ZIP archive (application/zip) with manifest.json; a failure mid-stream aborts the connection", Auth: openapi.AuthUser,
+		Query: []openapi.Parameter{
+			{Name: "from", Description: "Only photos taken at or after this RFC 3339 time or YYYY-MM-DD date"},
+			{Name: "to", Description: "Only photos taken before this RFC 3339 time, or on or before this YYYY-MM-DD date"},
+		},
+		Responses: responses(http.StatusOK, nil, http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusTooManyRequests, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/composites", ID: "getComposites", Tag: "photos",
+		Summary: "List the pair's moments stitched into one image, newest first; Link points to the next and previous pages", Auth: openapi.AuthUser,
+		Query: []openapi.Parameter{
+			{Name: "limit", Description: "Page size, 20 by default, at most 50", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, CompositeListResponse{}, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/sessions", ID: "getSyncSessions", Tag: "photos",
+		Summary: "List the pair's sync sessions with their photos, newest first; Link points to the next and previous pages", Auth: openapi.AuthUser,
+		Query: []openapi.Parameter{
+			{Name: "limit", Description: "Page size, 20 by default, at most 50", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, SyncSessionListResponse{}, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/sessions/{session_id}", ID: "getSyncSession", Tag: "photos",
+		Summary: "Get a sync session of the pair with the photos of both partners", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, models.SyncSession{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/photos/upload", ID: "uploadPhoto", Tag: "photos",
+		Summary: "Get a pre-signed S3 URL for uploading a photo", Auth: openapi.AuthUser,
+		Headers: []openapi.Parameter{idempotencyKeyHeader},
+		Request: services.UploadRequest{},
+		Responses: responses(http.StatusOK, services.UploadResponse{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType,
+			http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/photos/{photo_id}/complete", ID: "completePhotoUpload", Tag: "photos",
+		Summary: "Verify the uploaded object in storage and make the photo available to the partner", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, models.Photo{},
+			http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+			http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusServiceUnavailable),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/photos/{photo_id}/reactions", ID: "addPhotoReaction", Tag: "photos",
+		Summary: "React to a photo of the pair with an emoji; the partner gets photo_reaction. Repeating a reaction answers 204", Auth: openapi.AuthUser,
+		Request: services.ReactionRequest{},
+		Responses: withResponse(responses(http.StatusCreated, models.Reaction{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+			http.StatusNoContent, nil),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/photos/{photo_id}/comments", ID: "addPhotoComment", Tag: "photos",
+		Summary: "Comment on a photo of the pair; the partner gets photo_comment", Auth: openapi.AuthUser,
+		Headers: []openapi.Parameter{idempotencyKeyHeader},
+		Request: services.CommentRequest{},
+		Responses: responses(http.StatusCreated, models.Comment{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/photos/{photo_id}/comments", ID: "getPhotoComments", Tag: "photos",
+		Summary: "List the comments on a photo of the pair, oldest first; Link points to the next and previous pages", Auth: openapi.AuthUser,
+		Query: []openapi.Parameter{
+			{Name: "limit", Description: "Page size, 50 by default, at most 100", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "offset", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, CommentListResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/push-stats", ID: "getPushStats", Tag: "admin",
+		Summary: "Push delivery counters since startup", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusOK, PushStatsResponse{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/jobs", ID: "listJobs", Tag: "admin",
+		Summary: "List recent background jobs", Auth: openapi.AuthAdmin,
+		Query: []openapi.Parameter{
+			{Name: "status", Description: "pending, running, done or failed"},
+			{Name: "limit", Description: "50 by default, at most 500", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, JobListResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/stats", ID: "getStats", Tag: "admin",
+		Summary: "Daily usage statistics, newest first", Auth: openapi.AuthAdmin,
+		Query: []openapi.Parameter{
+			{Name: "days", Description: "Number of days, 30 by default", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, StatsResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/reload", ID: "reloadConfig", Tag: "admin",
+		Summary: "Reload runtime settings from the config file", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusOK, ReloadResponse{}, http.StatusBadRequest),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/maintenance", ID: "getMaintenance", Tag: "admin",
+		Summary: "Effective maintenance mode state", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusOK, MaintenanceStatusResponse{}),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/maintenance", ID: "setMaintenance", Tag: "admin",
+		Summary: "Turn the maintenance override on or off for all replicas", Auth: openapi.AuthAdmin,
+		Request:   SetMaintenanceRequest{},
+		Responses: responses(http.StatusOK, MaintenanceStatusResponse{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/users/{user_id}/restore", ID: "restoreUser", Tag: "admin",
+		Summary: "Restore a deleted account before it is purged", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusOK, StatusResponse{}, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/webhooks", ID: "listWebhooks", Tag: "admin",
+		Summary: "List outgoing webhooks", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusOK, WebhookListResponse{}, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/admin/webhooks", ID: "createWebhook", Tag: "admin",
+		Summary: "Create a webhook; the response is the only place its signing secret is shown", Auth: openapi.AuthAdmin,
+		Request:   WebhookRequest{},
+		Responses: responses(http.StatusCreated, models.Webhook{}, http.StatusBadRequest, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/admin/webhooks/{webhook_id}", ID: "updateWebhook", Tag: "admin",
+		Summary: "Replace a webhook's URL, events, app and enabled flag", Auth: openapi.AuthAdmin,
+		Request: WebhookRequest{},
+		Responses: responses(http.StatusOK, models.Webhook{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/admin/webhooks/{webhook_id}", ID: "deleteWebhook", Tag: "admin",
+		Summary: "Delete a webhook and its delivery log", Auth: openapi.AuthAdmin,
+		Responses: responses(http.StatusNoContent, nil, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/admin/webhooks/{webhook_id}/deliveries", ID: "listWebhookDeliveries", Tag: "admin",
+		Summary: "Delivery log of a webhook, newest first", Auth: openapi.AuthAdmin,
+		Query: []openapi.Parameter{
+			{Name: "status", Description: "pending, delivered or failed"},
+			{Name: "limit", Description: "50 by default, at most 500", Schema: &openapi.Schema{Type: "integer"}},
+		},
+		Responses: responses(http.StatusOK, WebhookDeliveryListResponse{},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+	},
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "liveness", Tag: "health",
+		Summary:   "Liveness probe",
+		Responses: map[int]interface{}{http.StatusOK: StatusResponse{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "readiness", Tag: "health",
+		Summary: "Readiness probe checking dependencies",
+		Responses: map[int]interface{}{
+			http.StatusOK:                 ReadinessResponse{},
+			http.StatusServiceUnavailable: ReadinessResponse{},
+		},
+	},
+}
+
+// maintenanceExempt lists tags of routes served during maintenance mode
+var maintenanceExempt = map[string]bool{"admin": true, "health": true}
+
+// OpenAPIDocument builds the OpenAPI document of the REST API
+func OpenAPIDocument() *openapi.Document {
+	all := append(append([]openapi.Route{}, Routes...), v2.Routes...)
+	routes := make([]openapi.Route, len(all))
+	for i, route := range all {
+		extra := map[int]interface{}{}
+		// Все защищённые маршруты отвечают 401 без валидного токена
+		if route.Auth != openapi.AuthNone {
+			extra[http.StatusUnauthorized] = ErrorResponse{}
+		}
+		// Удаленные аккаунты получают 403 account_deleted
+		if route.Auth == openapi.AuthUser {
+			extra[http.StatusForbidden] = ErrorResponse{}
+		}
+		if !maintenanceExempt[route.Tag] {
+			extra[http.StatusServiceUnavailable] = middleware.MaintenanceResponse{}
+		}
+		// Описанные в маршруте ответы важнее общих
+		for status, body := range route.Responses {
+			extra[status] = body
+		}
+		route.Responses = extra
+		routes[i] = route
+	}
+	return openapi.Build("Two Pic API", APIVersion, routes)
+}
+
+// responses maps the success status to its body and the listed error
+// statuses to ErrorResponse
+func responses(status int, body interface{}, errorStatuses ...int) map[int]interface{} {
+	result := map[int]interface{}{status: body}
+	for _, s := range errorStatuses {
+		result[s] = ErrorResponse{}
+	}
+	return result
+}
+
+// withResponse adds another successful response to result
+func withResponse(result map[int]interface{}, status int, body interface{}) map[int]interface{} {
+	result[status] = body
+	return result
+}
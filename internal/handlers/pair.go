@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"sync-photo-backend/internal/domain"
 	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
 	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
@@ -14,24 +17,18 @@ import (
 // PairHandler handles pair-related HTTP requests
 type PairHandler struct {
 	pairService *services.PairService
-	userService *services.UserService
-	pushService *services.PushService
-	wsHub       *services.WSHub
 }
 
 // NewPairHandler creates a new pair handler
-func NewPairHandler(pairService *services.PairService, userService *services.UserService, pushService *services.PushService, wsHub *services.WSHub) *PairHandler {
+func NewPairHandler(pairService *services.PairService) *PairHandler {
 	return &PairHandler{
 		pairService: pairService,
-		userService: userService,
-		pushService: pushService,
-		wsHub:       wsHub,
 	}
 }
 
 // CreatePairRequest represents the request body for creating a pair
 type CreatePairRequest struct {
-	PartnerCode string `json:"partner_code"`
+	PartnerCode string `json:"partner_code" validate:"required,len=6"`
 }
 
 // CreatePair handles POST /api/v1/pairs
@@ -40,77 +37,29 @@ func (h *PairHandler) CreatePair(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(ctx)
 
 	var req CreatePairRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate partner code
-	if req.PartnerCode == "" {
-		respondError(w, "partner_code is required", http.StatusBadRequest)
-		return
-	}
-
-	if len(req.PartnerCode) != 6 {
-		respondError(w, "partner_code must be 6 characters", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	pair, err := h.pairService.CreatePair(ctx, userID, req.PartnerCode)
 	if err != nil {
-		log.Error().
+		log.Ctx(ctx).Error().
 			Err(err).
 			Str("user_id", userID).
 			Str("partner_code", req.PartnerCode).
 			Msg("Failed to create pair")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "partner not found" {
-			statusCode = http.StatusNotFound
-		} else if err.Error() == "cannot create pair with yourself" ||
-			err.Error() == "user is already in a pair" ||
-			err.Error() == "partner is already in a pair" {
-			statusCode = http.StatusConflict
-		}
-
-		respondError(w, err.Error(), statusCode)
+		respondServiceError(w, r, err, "Failed to create pair")
 		return
 	}
 
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("user_id", userID).
 		Str("partner_code", req.PartnerCode).
 		Str("pair_id", pair.ID).
 		Msg("Pair created")
 
-	// Определить ID партнера
-	partnerID := pair.UserBID
-	if pair.UserBID == userID {
-		partnerID = pair.UserAID
-	}
-
-	// Отправить уведомление обоим пользователям через WebSocket (если они онлайн)
-	// Уведомление инициатору создания пары
-	if h.wsHub.IsOnline(userID) {
-		if err := h.wsHub.NotifyPairCreated(userID, pair.ID, pair.UserAID, pair.UserBID, pair.CreatedAt); err != nil {
-			log.Error().
-				Err(err).
-				Str("user_id", userID).
-				Msg("Failed to notify user about pair creation")
-			// Не возвращаем ошибку, так как пара уже создана
-		}
-	}
-
-	// Уведомление партнеру
-	if h.wsHub.IsOnline(partnerID) {
-		if err := h.wsHub.NotifyPairCreated(partnerID, pair.ID, pair.UserAID, pair.UserBID, pair.CreatedAt); err != nil {
-			log.Error().
-				Err(err).
-				Str("partner_id", partnerID).
-				Msg("Failed to notify partner about pair creation")
-			// Не возвращаем ошибку, так как пара уже создана
-		}
-	}
+	// Уведомления обоим пользователям (WebSocket или push) отправляет outbox relay
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -123,97 +72,75 @@ func (h *PairHandler) DeletePair(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(ctx)
 	pairID := chi.URLParam(r, "pair_id")
 
-	if pairID == "" {
-		respondError(w, "pair_id is required", http.StatusBadRequest)
+	if err := validation.CheckID("pair_id", pairID); err != nil {
+		respondServiceError(w, r, err, "Failed to delete pair")
 		return
 	}
 
-	// Получить информацию о паре для определения партнера перед удалением
+	// Получить информацию о паре для проверки членства
 	pair, err := h.pairService.GetPairByID(ctx, pairID)
 	if err != nil {
-		log.Error().
+		log.Ctx(ctx).Error().
 			Err(err).
 			Str("user_id", userID).
 			Str("pair_id", pairID).
 			Msg("Failed to get pair")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "pair not found" {
-			statusCode = http.StatusNotFound
-		}
-
-		respondError(w, err.Error(), statusCode)
+		respondServiceError(w, r, err, "Failed to get pair")
 		return
 	}
 
 	// Проверить, что пользователь является членом пары
 	if pair.UserAID != userID && pair.UserBID != userID {
-		respondError(w, "user is not a member of this pair", http.StatusForbidden)
+		respondServiceError(w, r, domain.ErrNotPairMember, "Failed to delete pair")
 		return
 	}
 
-	// Определить ID партнера
-	partnerID := pair.UserAID
-	if pair.UserAID == userID {
-		partnerID = pair.UserBID
-	}
-
 	// Удалить пару
 	err = h.pairService.DeletePair(ctx, pairID, userID)
 	if err != nil {
-		log.Error().
+		log.Ctx(ctx).Error().
 			Err(err).
 			Str("user_id", userID).
 			Str("pair_id", pairID).
 			Msg("Failed to delete pair")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "pair not found" {
-			statusCode = http.StatusNotFound
-		} else if err.Error() == "user is not a member of this pair" {
-			statusCode = http.StatusForbidden
-		}
-
-		respondError(w, err.Error(), statusCode)
+		respondServiceError(w, r, err, "Failed to delete pair")
 		return
 	}
 
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("user_id", userID).
 		Str("pair_id", pairID).
 		Msg("Pair deleted")
 
-	// Отправить уведомление обоим пользователям через WebSocket (если они онлайн)
-	// Уведомление инициатору удаления пары
-	if h.wsHub.IsOnline(userID) {
-		if err := h.wsHub.NotifyPairDeleted(userID); err != nil {
-			log.Error().
-				Err(err).
-				Str("user_id", userID).
-				Msg("Failed to notify user about pair deletion")
-			// Не возвращаем ошибку, так как пара уже удалена
-		}
-	}
+	// Уведомления обоим пользователям (WebSocket или push) отправляет outbox relay
 
-	// Уведомление партнеру
-	if h.wsHub.IsOnline(partnerID) {
-		if err := h.wsHub.NotifyPairDeleted(partnerID); err != nil {
-			log.Error().
-				Err(err).
-				Str("partner_id", partnerID).
-				Msg("Failed to notify partner about pair deletion")
-		}
-	} else {
-		pushToken, err := h.userService.GetPushToken(ctx, partnerID)
-		if err == nil && pushToken != nil && *pushToken != "" {
-			if err := h.pushService.SendPairDeletedNotification(*pushToken); err != nil {
-				log.Error().
-					Err(err).
-					Str("partner_id", partnerID).
-					Msg("Failed to send pair_deleted push notification")
-			}
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PairHistoryResponse lists the user's current and deleted pairs
+type PairHistoryResponse struct {
+	Pairs []*models.Pair `json:"pairs"`
+}
+
+// GetPairHistory handles GET /api/v1/pairs/history
+func (h *PairHandler) GetPairHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	pairs, err := h.pairService.GetPairHistory(ctx, userID)
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to get pair history")
+
+		respondServiceError(w, r, err, "Failed to get pair history")
+		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PairHistoryResponse{Pairs: pairs})
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PairSettingsHandler handles the settings both partners of a pair share
+type PairSettingsHandler struct {
+	pairSettings *services.PairSettingsService
+}
+
+// NewPairSettingsHandler creates a new pair settings handler
+func NewPairSettingsHandler(pairSettings *services.PairSettingsService) *PairSettingsHandler {
+	return &PairSettingsHandler{pairSettings: pairSettings}
+}
+
+// GetSettings handles GET /api/v1/pairs/me/settings
+func (h *PairSettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	options, err := h.pairSettings.Options(ctx, userID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get pair settings")
+		respondServiceError(w, r, err, "Failed to get pair settings")
+		return
+	}
+	respondJSON(w, http.StatusOK, options)
+}
+
+// UpdateSettings handles PATCH /api/v1/pairs/me/settings
+func (h *PairSettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req services.PairSettingsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	options, err := h.pairSettings.Update(ctx, userID, req)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update pair settings")
+		respondServiceError(w, r, err, "Failed to update pair settings")
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Int64("countdown_ms", options.CountdownMS).
+		Str("timezone", options.Timezone).
+		Bool("auto_composite", options.AutoComposite).
+		Bool("muted", options.MuteStart != nil).
+		Msg("Pair settings updated")
+	respondJSON(w, http.StatusOK, options)
+}
@@ -3,17 +3,22 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
 	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 )
 
 // PhotoHandler handles photo-related HTTP requests
 type PhotoHandler struct {
 	photoService *services.PhotoService
+	// offsetDisabled is set by WithoutOffset
+	offsetDisabled bool
 }
 
 // NewPhotoHandler creates a new photo handler
@@ -23,46 +28,99 @@ func NewPhotoHandler(photoService *services.PhotoService) *PhotoHandler {
 	}
 }
 
-// GetPhotos handles GET /api/v1/photos
+// WithoutOffset makes GET /api/v1/photos page by cursor only, as
+// configured in deprecations.disable_photo_offset
+func (h *PhotoHandler) WithoutOffset() *PhotoHandler {
+	h.offsetDisabled = true
+	return h
+}
+
+// PhotoListResponse represents a page of the pair's photos
+type PhotoListResponse struct {
+	Photos []*models.Photo `json:"photos"`
+	// Total is only counted in the deprecated offset mode
+	Total *int `json:"total,omitempty"`
+	// NextCursor is passed as ?cursor= to get the next page; absent on the
+	// last page and in offset mode
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// GetPhotos handles GET /api/v1/photos. With ?cursor=, empty for the first
+// page, it pages by cursor; otherwise by ?offset=, which is deprecated:
+// OFFSET reads every skipped row, so deep pages get slow.
 func (h *PhotoHandler) GetPhotos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Has("cursor") || h.offsetDisabled {
+		if query.Has("offset") {
+			message := "offset can't be used with cursor"
+			if h.offsetDisabled {
+				message = "offset pagination is disabled, use cursor"
+			}
+			respondErrorCode(w, middleware.ErrCodeInvalidRequest, message, http.StatusBadRequest)
+			return
+		}
+		h.getPhotosByCursor(w, r)
+		return
+	}
+
 	ctx := r.Context()
 	userID := middleware.GetUserID(ctx)
 
-	// Parse query parameters
-	limit := 50
-	offset := 0
+	limit, offset := pagination.Offset(query, services.PhotoPageLimits)
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
-			limit = parsedLimit
-		}
+	photos, total, err := h.photoService.GetPhotosByPair(ctx, userID, limit, offset)
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to get photos")
+
+		respondServiceError(w, r, err, "Failed to get photos")
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil {
-			offset = parsedOffset
-		}
+	response := PhotoListResponse{
+		Photos: photos,
+		Total:  &total,
 	}
 
-	photos, total, err := h.photoService.GetPhotosByPair(ctx, userID, limit, offset)
+	pagination.OffsetLinks(w, r, limit, offset, total)
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// getPhotosByCursor answers GET /api/v1/photos?cursor= with the page of
+// the photo feed after the cursor
+func (h *PhotoHandler) getPhotosByCursor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	query := r.URL.Query()
+
+	cursor, err := services.ParsePhotoCursor(query.Get("cursor"))
+	if err != nil {
+		respondServiceError(w, r, err, "Failed to get photos")
+		return
+	}
+	limit := pagination.Limit(query, services.FeedLimits)
+
+	photos, next, err := h.photoService.ListPhotos(ctx, userID, cursor, limit)
 	if err != nil {
-		log.Error().
+		log.Ctx(ctx).Error().
 			Err(err).
 			Str("user_id", userID).
 			Msg("Failed to get photos")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "user is not in a pair" {
-			statusCode = http.StatusNotFound
-		}
-
-		respondError(w, err.Error(), statusCode)
+		respondServiceError(w, r, err, "Failed to get photos")
 		return
 	}
 
-	response := map[string]interface{}{
-		"photos": photos,
-		"total":  total,
+	response := PhotoListResponse{Photos: photos}
+	if next != nil {
+		token := next.String()
+		response.NextCursor = &token
+		pagination.CursorLinks(w, r, token)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -76,14 +134,7 @@ func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(ctx)
 
 	var req services.UploadRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	// Validate request
-	if req.Filename == "" {
-		respondError(w, "filename is required", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -91,24 +142,19 @@ func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 		req.ContentType = "image/jpeg" // Default
 	}
 
-	response, err := h.photoService.GetPreSignedURL(ctx, userID, req.Filename, req.ContentType)
+	response, err := h.photoService.GetPreSignedURL(ctx, userID, req.Filename, req.ContentType, req.SizeBytes, req.SessionID)
 	if err != nil {
-		log.Error().
+		log.Ctx(ctx).Error().
 			Err(err).
 			Str("user_id", userID).
 			Str("filename", req.Filename).
 			Msg("Failed to generate pre-signed URL")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "user is not in a pair" {
-			statusCode = http.StatusNotFound
-		}
-
-		respondError(w, err.Error(), statusCode)
+		respondServiceError(w, r, err, "Failed to generate upload URL")
 		return
 	}
 
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("user_id", userID).
 		Str("photo_id", response.PhotoID).
 		Str("filename", req.Filename).
@@ -118,3 +164,37 @@ func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// CompleteUpload handles POST /api/v1/photos/{photo_id}/complete
+func (h *PhotoHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	photoID := chi.URLParam(r, "photo_id")
+
+	if err := validation.CheckID("photo_id", photoID); err != nil {
+		respondServiceError(w, r, err, "Failed to complete upload")
+		return
+	}
+
+	photo, err := h.photoService.CompleteUpload(ctx, userID, photoID)
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("user_id", userID).
+			Str("photo_id", photoID).
+			Msg("Failed to complete upload")
+
+		respondServiceError(w, r, err, "Failed to complete upload")
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Str("photo_id", photoID).
+		Int64("size_bytes", photo.SizeBytes).
+		Msg("Photo uploaded")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(photo)
+}
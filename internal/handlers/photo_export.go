@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/rs/zerolog/log"
+)
+
+// exportWriteTimeout bounds each write of an export to the client, in place
+// of server.write_timeout, which a whole album would outlast
+const exportWriteTimeout = time.Minute
+
+// ExportPhotos handles GET /api/v1/photos/export. The ZIP archive is
+// streamed as the photos are downloaded, so a failure after the first
+// byte can only abort the connection; the client gets a truncated archive
+// and should retry.
+func (h *PhotoHandler) ExportPhotos(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	filter, fieldErrs := exportFilter(r)
+	if fieldErrs != nil {
+		respondValidationError(w, fieldErrs)
+		return
+	}
+
+	export, err := h.photoService.Export(ctx, userID, filter)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to export photos")
+		respondServiceError(w, r, err, "Failed to export photos")
+		return
+	}
+
+	filename := "two-pic-" + time.Now().UTC().Format(time.DateOnly) + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	started := time.Now()
+	if err := export.WriteZip(ctx, newDeadlineWriter(w, exportWriteTimeout)); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Int("photos", export.Len()).Msg("Photo export aborted")
+		// Обрываем соединение, чтобы клиент не принял архив за целый
+		panic(http.ErrAbortHandler)
+	}
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Int("photos", export.Len()).
+		Dur("duration", time.Since(started)).
+		Msg("Photos exported")
+}
+
+// exportFilter reads ?from= and ?to= of an export: RFC 3339 times, or
+// dates that include the whole UTC day
+func exportFilter(r *http.Request) (services.ExportFilter, []validation.FieldError) {
+	var filter services.ExportFilter
+	var fieldErrs []validation.FieldError
+	for _, bound := range []struct {
+		name  string
+		dst   *time.Time
+		isEnd bool
+	}{
+		{"from", &filter.From, false},
+		{"to", &filter.To, true},
+	} {
+		raw := r.URL.Query().Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			*bound.dst = t.UTC()
+			continue
+		}
+		day, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			fieldErrs = append(fieldErrs, validation.FieldError{
+				Field:   bound.name,
+				Rule:    "datetime",
+				Message: bound.name + " must be an RFC 3339 time or a YYYY-MM-DD date",
+			})
+			continue
+		}
+		if bound.isEnd {
+			// Граница исключающая, дата до включает весь день
+			day = day.AddDate(0, 0, 1)
+		}
+		*bound.dst = day
+	}
+	if fieldErrs == nil && !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		fieldErrs = append(fieldErrs, validation.FieldError{
+			Field:   "to",
+			Rule:    "gtfield",
+			Message: "to must be after from",
+		})
+	}
+	return filter, fieldErrs
+}
+
+// deadlineWriter moves the write deadline of the connection before each
+// write, so a long response isn't cut by the server's write timeout while
+// a stalled client still is
+type deadlineWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func newDeadlineWriter(w http.ResponseWriter, timeout time.Duration) *deadlineWriter {
+	return &deadlineWriter{w: w, rc: http.NewResponseController(w), timeout: timeout}
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	// Обертки без Unwrap не дают сдвинуть срок, остается server.write_timeout
+	_ = d.rc.SetWriteDeadline(time.Now().Add(d.timeout))
+	return d.w.Write(p)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PromptHandler handles the daily photo prompts of the user's pair
+type PromptHandler struct {
+	promptService *services.PromptService
+}
+
+// NewPromptHandler creates a new prompt handler
+func NewPromptHandler(promptService *services.PromptService) *PromptHandler {
+	return &PromptHandler{promptService: promptService}
+}
+
+// GetStreak handles GET /api/v1/pairs/me/streak
+func (h *PromptHandler) GetStreak(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	streak, err := h.promptService.Streak(ctx, userID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get streak")
+		respondServiceError(w, r, err, "Failed to get streak")
+		return
+	}
+	respondJSON(w, http.StatusOK, streak)
+}
+
+// GetPromptSettings handles GET /api/v1/pairs/me/prompt
+func (h *PromptHandler) GetPromptSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	schedule, err := h.promptService.Schedule(ctx, userID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get prompt settings")
+		respondServiceError(w, r, err, "Failed to get prompt settings")
+		return
+	}
+	respondJSON(w, http.StatusOK, schedule)
+}
+
+// UpdatePromptSettings handles PUT /api/v1/pairs/me/prompt
+func (h *PromptHandler) UpdatePromptSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req services.PromptSettingsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	schedule, err := h.promptService.UpdateSchedule(ctx, userID, req)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update prompt settings")
+		respondServiceError(w, r, err, "Failed to update prompt settings")
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Bool("enabled", schedule.Enabled).
+		Str("prompt_time", schedule.Time).
+		Str("timezone", schedule.Timezone).
+		Msg("Prompt settings updated")
+	respondJSON(w, http.StatusOK, schedule)
+}
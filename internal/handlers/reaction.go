@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ReactionHandler handles reactions and comments on photos
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+}
+
+// NewReactionHandler creates a new reaction handler
+func NewReactionHandler(reactionService *services.ReactionService) *ReactionHandler {
+	return &ReactionHandler{reactionService: reactionService}
+}
+
+// CommentListResponse represents a page of a photo's comments
+type CommentListResponse struct {
+	Comments []*models.Comment `json:"comments"`
+	Total    int               `json:"total"`
+}
+
+// AddReaction handles POST /api/v1/photos/{photo_id}/reactions. A new
+// reaction is returned with 201; repeating one answers 204.
+func (h *ReactionHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	photoID := chi.URLParam(r, "photo_id")
+
+	if err := validation.CheckID("photo_id", photoID); err != nil {
+		respondServiceError(w, r, err, "Failed to add reaction")
+		return
+	}
+	var req services.ReactionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	reaction, err := h.reactionService.AddReaction(ctx, userID, photoID, req.Emoji)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Str("photo_id", photoID).Msg("Failed to add reaction")
+		respondServiceError(w, r, err, "Failed to add reaction")
+		return
+	}
+	if reaction == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reaction)
+}
+
+// AddComment handles POST /api/v1/photos/{photo_id}/comments
+func (h *ReactionHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	photoID := chi.URLParam(r, "photo_id")
+
+	if err := validation.CheckID("photo_id", photoID); err != nil {
+		respondServiceError(w, r, err, "Failed to add comment")
+		return
+	}
+	var req services.CommentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	comment, err := h.reactionService.AddComment(ctx, userID, photoID, req.Text)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Str("photo_id", photoID).Msg("Failed to add comment")
+		respondServiceError(w, r, err, "Failed to add comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// GetComments handles GET /api/v1/photos/{photo_id}/comments
+func (h *ReactionHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	photoID := chi.URLParam(r, "photo_id")
+
+	if err := validation.CheckID("photo_id", photoID); err != nil {
+		respondServiceError(w, r, err, "Failed to get comments")
+		return
+	}
+	limit, offset := pagination.Offset(r.URL.Query(), services.CommentLimits)
+
+	comments, total, err := h.reactionService.ListComments(ctx, userID, photoID, limit, offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Str("photo_id", photoID).Msg("Failed to get comments")
+		respondServiceError(w, r, err, "Failed to get comments")
+		return
+	}
+
+	pagination.OffsetLinks(w, r, limit, offset, total)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CommentListResponse{Comments: comments, Total: total})
+}
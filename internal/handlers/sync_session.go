@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// SyncSessionHandler handles requests for sync sessions
+type SyncSessionHandler struct {
+	sessions *services.SyncSessionService
+}
+
+// NewSyncSessionHandler creates a new sync session handler
+func NewSyncSessionHandler(sessions *services.SyncSessionService) *SyncSessionHandler {
+	return &SyncSessionHandler{sessions: sessions}
+}
+
+// SyncSessionListResponse represents a page of the pair's sync sessions
+type SyncSessionListResponse struct {
+	Sessions []*models.SyncSession `json:"sessions"`
+	Total    int                   `json:"total"`
+}
+
+// GetSessions handles GET /api/v1/sessions
+func (h *SyncSessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	limit, offset := pagination.Offset(r.URL.Query(), services.SyncSessionLimits)
+
+	sessions, total, err := h.sessions.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get sync sessions")
+		respondServiceError(w, r, err, "Failed to get sync sessions")
+		return
+	}
+
+	pagination.OffsetLinks(w, r, limit, offset, total)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SyncSessionListResponse{Sessions: sessions, Total: total})
+}
+
+// GetSession handles GET /api/v1/sessions/{session_id}
+func (h *SyncSessionHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	sessionID := chi.URLParam(r, "session_id")
+
+	if err := validation.CheckID("session_id", sessionID); err != nil {
+		respondServiceError(w, r, err, "Failed to get sync session")
+		return
+	}
+
+	session, err := h.sessions.Get(ctx, userID, sessionID)
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("user_id", userID).
+			Str("session_id", sessionID).
+			Msg("Failed to get sync session")
+		respondServiceError(w, r, err, "Failed to get sync session")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(session)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/errreport"
 	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
 	"sync-photo-backend/internal/services"
 
 	"github.com/rs/zerolog/log"
@@ -13,63 +17,239 @@ import (
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
 	userService *services.UserService
+	pushService *services.PushService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, pushService *services.PushService) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		pushService: pushService,
 	}
 }
 
+// UpdatePushTokenRequest represents the request body for updating the push token
+type UpdatePushTokenRequest struct {
+	PushToken string `json:"push_token" validate:"required,max=4096"`
+}
+
+// UpdateQuietHoursRequest represents the request body for updating quiet hours
+type UpdateQuietHoursRequest struct {
+	Start               *string `json:"quiet_hours_start" validate:"omitempty,datetime=15:04"` // null disables quiet hours
+	End                 *string `json:"quiet_hours_end" validate:"omitempty,datetime=15:04"`
+	Timezone            string  `json:"timezone" validate:"omitempty,timezone"` // UTC by default
+	AlwaysAllowTriggers bool    `json:"always_allow_triggers"`
+}
+
+// UpdateTriggerAlertsRequest represents the request body for updating trigger alerts
+type UpdateTriggerAlertsRequest struct {
+	TimeSensitive bool `json:"time_sensitive"`
+}
+
+// UpdateLocaleRequest represents the request body for updating the locale
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale"` // "en" or "ru", "" resets to Accept-Language
+}
+
+// CreateUserResponse is the response to creating a user, the only one with
+// its token
+type CreateUserResponse struct {
+	models.User
+	Token string `json:"token"`
+	// RefreshToken renews the token with POST /api/v1/auth/refresh
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
 // CreateUser handles POST /api/v1/users
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	user, err := h.userService.CreateUser(ctx)
+	appID := r.Header.Get(middleware.AppIDHeader)
+	if appID == "" {
+		appID = config.DefaultAppID
+	}
+
+	user, err := h.userService.CreateUser(ctx, appID)
+	if errors.Is(err, services.ErrUnknownApp) {
+		respondError(w, "Unknown app", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to create user")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to create user")
+		errreport.Capture(ctx, err)
 		respondError(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
-	log.Info().
+	log.Ctx(ctx).Info().
 		Str("user_id", user.ID).
+		Str("app_id", user.AppID).
 		Str("code", user.Code).
 		Msg("User created")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(CreateUserResponse{User: *user, Token: user.Token, RefreshToken: user.RefreshToken})
 }
 
-// UpdatePushToken handles PUT /api/v1/users/push-token
+// UpdatePushToken handles PUT and POST /api/v1/users/push-token. Both
+// register the token and replace a rotated one.
 func (h *UserHandler) UpdatePushToken(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := middleware.GetUserID(ctx)
 
-	var req struct {
-		PushToken string `json:"push_token"`
+	var req UpdatePushTokenRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.UpdatePushToken(ctx, userID, req.PushToken); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update push token")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to update push token", http.StatusInternalServerError)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("Push token updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+// UpdateQuietHours handles PUT /api/v1/users/quiet-hours
+func (h *UserHandler) UpdateQuietHours(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req UpdateQuietHoursRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+
+	if err := services.ValidateQuietHours(req.Start, req.End, req.Timezone); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if req.PushToken == "" {
-		respondError(w, "push_token is required", http.StatusBadRequest)
+	if err := h.userService.UpdateQuietHours(ctx, userID, req.Start, req.End, req.Timezone, req.AlwaysAllowTriggers); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update quiet hours")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to update quiet hours", http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.userService.UpdatePushToken(ctx, userID, req.PushToken); err != nil {
-		log.Error().Err(err).Str("user_id", userID).Msg("Failed to update push token")
-		respondError(w, "Failed to update push token", http.StatusInternalServerError)
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("Quiet hours updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+// UpdateLocale handles PUT /api/v1/users/locale
+func (h *UserHandler) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req UpdateLocaleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.UpdateLocale(ctx, userID, req.Locale); err != nil {
+		if !errors.Is(err, services.ErrUnsupportedLocale) {
+			log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update locale")
+		}
+		respondServiceError(w, r, err, "Failed to update locale")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Str("locale", req.Locale).Msg("Locale updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+}
+
+// DeleteMe handles DELETE /api/v1/users/me. The account can be restored
+// until it is purged after accounts.recovery_window.
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	if err := h.userService.DeleteUser(ctx, userID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to delete user")
+		respondServiceError(w, r, err, "Failed to delete user")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("User deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SendTestPush handles POST /api/v1/users/me/push-test
+func (h *UserHandler) SendTestPush(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	user, err := h.userService.GetUser(ctx, userID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to get user")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.pushService.SendTestNotification(ctx, user); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("user_id", userID).Msg("Test push failed")
+
+		statusCode := http.StatusBadGateway
+		switch {
+		case errors.Is(err, services.ErrNoPushToken):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, services.ErrPushQueueFull), errors.Is(err, services.ErrPushDispatcherStopped):
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		respondError(w, err.Error(), statusCode)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("Test push delivered")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "delivered"})
+}
+
+// UpdateTriggerAlerts handles PUT /api/v1/users/trigger-alerts
+func (h *UserHandler) UpdateTriggerAlerts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req UpdateTriggerAlertsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.UpdateTimeSensitiveTriggers(ctx, userID, req.TimeSensitive); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to update trigger alerts")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to update trigger alerts", http.StatusInternalServerError)
 		return
 	}
 
-	log.Info().Str("user_id", userID).Msg("Push token updated")
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Bool("time_sensitive", req.TimeSensitive).
+		Msg("Trigger alerts updated")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
 }
@@ -0,0 +1,84 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+)
+
+// ActivityEvent is the v2 representation of an entry in the pair's activity feed
+type ActivityEvent struct {
+	ID string `json:"id"`
+	// Type: pair_created, trigger_started, photo_confirmed, moment_completed
+	Type string `json:"type"`
+	// UserID is the pair member who caused the event
+	UserID     *string   `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// Data is the event's payload; its fields depend on Type
+	Data json.RawMessage `json:"data"`
+}
+
+// ActivityPage is one page of the pair's activity feed
+type ActivityPage struct {
+	Items      []ActivityEvent `json:"items"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+// ActivityHandler handles /api/v2 activity requests
+type ActivityHandler struct {
+	eventLog *services.EventLog
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(eventLog *services.EventLog) *ActivityHandler {
+	return &ActivityHandler{
+		eventLog: eventLog,
+	}
+}
+
+// GetActivity handles GET /api/v2/activity
+func (h *ActivityHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	query, ok := readPageQuery(w, r)
+	if !ok {
+		return
+	}
+	cursor, err := services.ParseActivityCursor(query.Cursor)
+	if err != nil {
+		respondError(w, middleware.ErrCodeInvalidCursor, "cursor is invalid or expired", http.StatusBadRequest)
+		return
+	}
+
+	events, next, err := h.eventLog.Activity(ctx, userID, cursor, query.limit())
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	items := make([]ActivityEvent, 0, len(events))
+	for _, event := range events {
+		items = append(items, toActivityEvent(event))
+	}
+	page := ActivityPage{Items: items}
+	if next != nil {
+		token := next.String()
+		page.NextCursor = nextPage(w, r, &token)
+	}
+	respondJSON(w, http.StatusOK, page)
+}
+
+func toActivityEvent(event *models.DomainEvent) ActivityEvent {
+	return ActivityEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		OccurredAt: event.OccurredAt,
+		Data:       event.Payload,
+	}
+}
@@ -0,0 +1,175 @@
+// Package v2 implements the /api/v2 handlers. Handlers share the service
+// layer with /api/v1 and map models to their own DTOs, so v1 responses stay
+// frozen while v2 evolves.
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorResponse is the v2 error body. Unlike v1, code is always set.
+type ErrorResponse struct {
+	Error     string                  `json:"error"`
+	Code      string                  `json:"code"`
+	Message   string                  `json:"message,omitempty"` // localized text for code
+	Fields    []validation.FieldError `json:"fields,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// PageQuery holds the query parameters of paginated requests
+type PageQuery struct {
+	Cursor string `query:"cursor"`
+	Limit  *int   `query:"limit" validate:"omitempty,min=1"` // nil means the service default
+}
+
+// respondJSON sends a JSON response
+func respondJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// respondCreated sends a 201 with the Location of the new resource
+func respondCreated(w http.ResponseWriter, location string, v interface{}) {
+	w.Header().Set("Location", location)
+	respondJSON(w, http.StatusCreated, v)
+}
+
+// respondError sends a typed error response
+func respondError(w http.ResponseWriter, code, message string, statusCode int) {
+	respondJSON(w, statusCode, ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Message:   middleware.Message(w, code),
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	})
+}
+
+// respondServiceError maps a service error to its typed response, see
+// middleware.RespondServiceError. Unknown errors are logged and hidden
+// behind a generic message.
+func respondServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if middleware.RespondServiceError(w, err, "Internal server error") {
+		return
+	}
+	log.Ctx(r.Context()).Error().Err(err).Msg("Request failed")
+	errreport.Capture(r.Context(), err)
+}
+
+// respondValidationError sends the field errors of an invalid request
+func respondValidationError(w http.ResponseWriter, fieldErrs []validation.FieldError) {
+	respondJSON(w, http.StatusBadRequest, ErrorResponse{
+		Error:     fieldErrs[0].Message,
+		Code:      middleware.ErrCodeInvalidRequest,
+		Message:   middleware.Message(w, middleware.ErrCodeInvalidRequest),
+		Fields:    fieldErrs,
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	})
+}
+
+// decodeJSON decodes the request body into v and validates it against its
+// `validate` tags. It responds with an error if the body is malformed,
+// invalid or exceeds the route's size limit.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, middleware.ErrCodeBodyTooLarge, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		respondError(w, middleware.ErrCodeInvalidRequest, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	if fieldErrs := validation.Struct(v); fieldErrs != nil {
+		respondValidationError(w, fieldErrs)
+		return false
+	}
+	return true
+}
+
+// setVersion sends the version of the record in the response as ETag and
+// Last-Modified, for If-Match and If-Unmodified-Since of later updates
+func setVersion(w http.ResponseWriter, updatedAt time.Time) {
+	w.Header().Set("ETag", `"`+services.Version(updatedAt)+`"`)
+	w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+}
+
+// precondition reads If-Match and If-Unmodified-Since. Like RFC 9110 says,
+// If-Unmodified-Since is ignored with If-Match or when it isn't a valid date.
+func precondition(r *http.Request) services.Precondition {
+	var pre services.Precondition
+	if etag := r.Header.Get("If-Match"); etag != "" {
+		if etag != "*" {
+			pre.Version = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+		}
+		return pre
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil {
+		pre.UnmodifiedSince = since
+	}
+	return pre
+}
+
+// readPageQuery reads the query parameters of a paginated request and responds
+// with an error if they are invalid
+func readPageQuery(w http.ResponseWriter, r *http.Request) (PageQuery, bool) {
+	var query PageQuery
+	if fieldErrs := validation.Query(r.URL.Query(), &query); fieldErrs != nil {
+		respondValidationError(w, fieldErrs)
+		return query, false
+	}
+	return query, true
+}
+
+// limit returns the requested page size; 0 means the service default
+func (q PageQuery) limit() int {
+	if q.Limit == nil {
+		return 0
+	}
+	return *q.Limit
+}
+
+// encodeCursor returns the next_cursor value; nil encodes the last page
+func encodeCursor(c *services.PhotoCursor) *string {
+	if c == nil {
+		return nil
+	}
+	token := c.String()
+	return &token
+}
+
+// nextPage links the page at next_cursor in the Link header and returns
+// next_cursor; it must be called before the response is written
+func nextPage(w http.ResponseWriter, r *http.Request, next *string) *string {
+	if next != nil {
+		pagination.CursorLinks(w, r, *next)
+	}
+	return next
+}
+
+// pageParams reads the cursor and limit of a paginated request and responds
+// with an error if either is invalid
+func pageParams(w http.ResponseWriter, r *http.Request) (services.PhotoCursor, int, bool) {
+	query, ok := readPageQuery(w, r)
+	if !ok {
+		return services.PhotoCursor{}, 0, false
+	}
+	cursor, err := services.ParsePhotoCursor(query.Cursor)
+	if err != nil {
+		respondError(w, middleware.ErrCodeInvalidCursor, "cursor is invalid or expired", http.StatusBadRequest)
+		return services.PhotoCursor{}, 0, false
+	}
+	return cursor, query.limit(), true
+}
@@ -0,0 +1,148 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/repository/memory"
+	"sync-photo-backend/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestRouter routes the v2 create endpoints to services backed by the
+// memory repositories, with the middleware they run behind in serve
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	userService := services.NewUserService(userRepo, "test-secret")
+	pairService := services.NewPairService(memory.NewPairRepository(store), userRepo)
+
+	userHandler := NewUserHandler(userService)
+	pairHandler := NewPairHandler(pairService)
+
+	r := chi.NewRouter()
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Post("/users", userHandler.CreateUser)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(userService))
+			r.Post("/pairs", pairHandler.CreatePair)
+		})
+	})
+	return r
+}
+
+// do sends a request to the router and returns the recorded response
+func do(t *testing.T, router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// createUser creates a user through the router
+func createUser(t *testing.T, router http.Handler) CreatedUser {
+	t.Helper()
+
+	rec := do(t, router, http.MethodPost, "/api/v2/users", "", "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v2/users: status %d, body %s", rec.Code, rec.Body)
+	}
+	var user CreatedUser
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		t.Fatalf("POST /api/v2/users: %v", err)
+	}
+	return user
+}
+
+func TestCreateUser(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := do(t, router, http.MethodPost, "/api/v2/users", "", "")
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if got := rec.Header().Get("Location"); got != "/api/v2/users/me" {
+		t.Errorf("Location = %q, want /api/v2/users/me", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var user CreatedUser
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if user.ID == "" || user.Code == "" || user.Token == "" {
+		t.Errorf("body = %s, want id, code and token", rec.Body)
+	}
+}
+
+func TestCreatePair(t *testing.T) {
+	router := newTestRouter(t)
+	userA := createUser(t, router)
+	userB := createUser(t, router)
+
+	rec := do(t, router, http.MethodPost, "/api/v2/pairs", userB.Token, `{"partner_code":"`+userA.Code+`"}`)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var pair Pair
+	if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if want := "/api/v2/pairs/" + pair.ID; rec.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+	if pair.PartnerID != userA.ID {
+		t.Errorf("partner_id = %q, want %q", pair.PartnerID, userA.ID)
+	}
+}
+
+func TestCreatePairErrors(t *testing.T) {
+	router := newTestRouter(t)
+	user := createUser(t, router)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"invalid code", `{"partner_code":"abc"}`, http.StatusBadRequest, middleware.ErrCodeInvalidRequest},
+		{"unknown code", `{"partner_code":"ZZZZZZ"}`, http.StatusNotFound, middleware.ErrCodePartnerNotFound},
+		{"own code", `{"partner_code":"` + user.Code + `"}`, http.StatusConflict, middleware.ErrCodeSelfPair},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(t, router, http.MethodPost, "/api/v2/pairs", user.Token, tt.body)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Header().Get("Location") != "" {
+				t.Errorf("Location = %q on an error", rec.Header().Get("Location"))
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+)
+
+// Moment is the v2 representation of photos taken together by a pair
+type Moment struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// Complete is true when both partners contributed a photo
+	Complete bool    `json:"complete"`
+	Photos   []Photo `json:"photos"`
+}
+
+// MomentPage is one page of the pair's moments
+type MomentPage struct {
+	Items      []Moment `json:"items"`
+	NextCursor *string  `json:"next_cursor"`
+}
+
+// MomentHandler handles /api/v2 moment requests
+type MomentHandler struct {
+	photoService *services.PhotoService
+}
+
+// NewMomentHandler creates a new moment handler
+func NewMomentHandler(photoService *services.PhotoService) *MomentHandler {
+	return &MomentHandler{
+		photoService: photoService,
+	}
+}
+
+// GetMoments handles GET /api/v2/moments
+func (h *MomentHandler) GetMoments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	cursor, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	moments, next, err := h.photoService.ListMoments(ctx, userID, cursor, limit)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	items := make([]Moment, 0, len(moments))
+	for _, moment := range moments {
+		items = append(items, toMoment(moment))
+	}
+	respondJSON(w, http.StatusOK, MomentPage{
+		Items:      items,
+		NextCursor: nextPage(w, r, encodeCursor(next)),
+	})
+}
+
+func toMoment(moment *models.Moment) Moment {
+	return Moment{
+		ID:        moment.ID,
+		StartedAt: moment.StartedAt,
+		EndedAt:   moment.EndedAt,
+		Complete:  moment.Complete,
+		Photos:    toPhotos(moment.Photos),
+	}
+}
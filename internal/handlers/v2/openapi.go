@@ -0,0 +1,98 @@
+package v2
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/openapi"
+)
+
+var pageQuery = []openapi.Parameter{
+	{Name: "cursor", Description: "next_cursor of the previous page; omit for the first page"},
+	{Name: "limit", Description: "Page size; the default and maximum depend on the endpoint", Schema: &openapi.Schema{Type: "integer"}},
+}
+
+var preconditionHeaders = []openapi.Parameter{
+	{Name: "If-Match", Description: "ETag of the read record; 412 if it changed since"},
+	{Name: "If-Unmodified-Since", Description: "Last-Modified of the read record; 412 if it changed since. Ignored with If-Match"},
+}
+
+// Routes annotates the /api/v2 endpoints for the OpenAPI document
+var Routes = []openapi.Route{
+	{
+		Method: http.MethodPost, Path: "/api/v2/users", ID: "createUserV2", Tag: "v2",
+		Summary: "Create a user; Location points to /api/v2/users/me",
+		Responses: map[int]interface{}{
+			http.StatusCreated:             CreatedUser{},
+			http.StatusBadRequest:          ErrorResponse{},
+			http.StatusTooManyRequests:     ErrorResponse{},
+			http.StatusInternalServerError: ErrorResponse{},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v2/users/me", ID: "getMe", Tag: "v2",
+		Summary: "The authenticated user", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, User{}),
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/v2/users/me", ID: "updateMe", Tag: "v2",
+		Summary: "Change the user's settings; absent fields keep their value", Auth: openapi.AuthUser,
+		Headers:   preconditionHeaders,
+		Request:   UpdateUserRequest{},
+		Responses: responses(http.StatusOK, User{}, http.StatusBadRequest, http.StatusPreconditionFailed),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v2/users/me", ID: "deleteMeV2", Tag: "v2",
+		Summary: "Delete the account and close its WebSocket; it can be restored within accounts.recovery_window", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusNoContent, nil),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v2/pairs", ID: "createPairV2", Tag: "v2",
+		Summary: "Pair with the partner by their code; Location points to the new pair", Auth: openapi.AuthUser,
+		Request:   CreatePairRequest{},
+		Responses: responses(http.StatusCreated, Pair{}, http.StatusConflict),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v2/pairs/{pair_id}", ID: "getPair", Tag: "v2",
+		Summary: "A pair the user is a member of", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusOK, Pair{}, http.StatusForbidden),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v2/pairs/{pair_id}", ID: "deletePairV2", Tag: "v2",
+		Summary: "Delete the pair and its photos", Auth: openapi.AuthUser,
+		Responses: responses(http.StatusNoContent, nil, http.StatusForbidden),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v2/photos", ID: "getPhotosV2", Tag: "v2",
+		Summary: "Page through the pair's photos, newest first; 50 by default, at most 100", Auth: openapi.AuthUser,
+		Query:     pageQuery,
+		Responses: responses(http.StatusOK, PhotoPage{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v2/moments", ID: "getMoments", Tag: "v2",
+		Summary: "Page through the pair's moments, newest first; 20 by default, at most 50", Auth: openapi.AuthUser,
+		Query:     pageQuery,
+		Responses: responses(http.StatusOK, MomentPage{}),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v2/activity", ID: "getActivity", Tag: "v2",
+		Summary: "Page through the pair's activity feed, newest first; 50 by default, at most 100", Auth: openapi.AuthUser,
+		Query:     pageQuery,
+		Responses: responses(http.StatusOK, ActivityPage{}),
+	},
+}
+
+// responses adds the typed errors every authenticated v2 route can return
+// and the route's own error statuses
+func responses(status int, body interface{}, errorStatuses ...int) map[int]interface{} {
+	result := map[int]interface{}{
+		status:                         body,
+		http.StatusBadRequest:          ErrorResponse{},
+		http.StatusUnauthorized:        ErrorResponse{},
+		http.StatusNotFound:            ErrorResponse{},
+		http.StatusInternalServerError: ErrorResponse{},
+	}
+	for _, s := range errorStatuses {
+		result[s] = ErrorResponse{}
+	}
+	return result
+}
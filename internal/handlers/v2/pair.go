@@ -0,0 +1,134 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Pair is the v2 representation of a pair, as seen by one of its members
+type Pair struct {
+	ID        string    `json:"id"`
+	PartnerID string    `json:"partner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreatePairRequest is the body of POST /api/v2/pairs
+type CreatePairRequest struct {
+	PartnerCode string `json:"partner_code" validate:"required,len=6"`
+}
+
+// PairHandler handles /api/v2 pair requests
+type PairHandler struct {
+	pairService *services.PairService
+}
+
+// NewPairHandler creates a new v2 pair handler
+func NewPairHandler(pairService *services.PairService) *PairHandler {
+	return &PairHandler{
+		pairService: pairService,
+	}
+}
+
+// CreatePair handles POST /api/v2/pairs
+func (h *PairHandler) CreatePair(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req CreatePairRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	pair, err := h.pairService.CreatePair(ctx, userID, req.PartnerCode)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Str("pair_id", pair.ID).
+		Msg("Pair created")
+
+	respondCreated(w, "/api/v2/pairs/"+pair.ID, toPair(pair, userID))
+}
+
+// GetPair handles GET /api/v2/pairs/{pair_id}
+func (h *PairHandler) GetPair(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	pair, ok := h.memberPair(w, r, userID)
+	if !ok {
+		return
+	}
+
+	setVersion(w, pair.UpdatedAt)
+	respondJSON(w, http.StatusOK, toPair(pair, userID))
+}
+
+// DeletePair handles DELETE /api/v2/pairs/{pair_id}
+func (h *PairHandler) DeletePair(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	pair, ok := h.memberPair(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.pairService.DeletePair(ctx, pair.ID, userID); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Str("pair_id", pair.ID).
+		Msg("Pair deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// memberPair returns the pair of the {pair_id} path parameter and responds
+// with an error unless it exists and the user is a member
+func (h *PairHandler) memberPair(w http.ResponseWriter, r *http.Request, userID string) (*models.Pair, bool) {
+	pairID := chi.URLParam(r, "pair_id")
+	if err := validation.CheckID("pair_id", pairID); err != nil {
+		respondServiceError(w, r, err)
+		return nil, false
+	}
+	pair, err := h.pairService.GetPairByID(r.Context(), pairID)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return nil, false
+	}
+	if pair.UserAID != userID && pair.UserBID != userID {
+		respondServiceError(w, r, domain.ErrNotPairMember)
+		return nil, false
+	}
+	return pair, true
+}
+
+func toPair(pair *models.Pair, userID string) Pair {
+	partnerID := pair.UserAID
+	if partnerID == userID {
+		partnerID = pair.UserBID
+	}
+	return Pair{
+		ID:        pair.ID,
+		PartnerID: partnerID,
+		CreatedAt: pair.CreatedAt,
+		UpdatedAt: pair.UpdatedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+)
+
+// Photo is the v2 representation of a photo
+type Photo struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	URL       string `json:"url"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	// DeviceTakenAt is the EXIF capture time, absent if the file has none
+	DeviceTakenAt *time.Time `json:"device_taken_at,omitempty"`
+	TakenAt       time.Time  `json:"taken_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ReactionCount int        `json:"reaction_count"`
+	CommentCount  int        `json:"comment_count"`
+}
+
+// PhotoPage is one page of the pair's photo feed
+type PhotoPage struct {
+	Items []Photo `json:"items"`
+	// NextCursor is passed as ?cursor= to get the next page; null on the last page
+	NextCursor *string `json:"next_cursor"`
+}
+
+// PhotoHandler handles /api/v2 photo requests
+type PhotoHandler struct {
+	photoService *services.PhotoService
+}
+
+// NewPhotoHandler creates a new v2 photo handler
+func NewPhotoHandler(photoService *services.PhotoService) *PhotoHandler {
+	return &PhotoHandler{
+		photoService: photoService,
+	}
+}
+
+// GetPhotos handles GET /api/v2/photos
+func (h *PhotoHandler) GetPhotos(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	cursor, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	photos, next, err := h.photoService.ListPhotos(ctx, userID, cursor, limit)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, PhotoPage{
+		Items:      toPhotos(photos),
+		NextCursor: nextPage(w, r, encodeCursor(next)),
+	})
+}
+
+func toPhoto(photo *models.Photo) Photo {
+	return Photo{
+		ID:            photo.ID,
+		UserID:        photo.UserID,
+		URL:           photo.S3URL,
+		Width:         photo.Width,
+		Height:        photo.Height,
+		SizeBytes:     photo.SizeBytes,
+		DeviceTakenAt: photo.DeviceTakenAt,
+		TakenAt:       photo.TakenAt,
+		UpdatedAt:     photo.UpdatedAt,
+		ReactionCount: photo.ReactionCount,
+		CommentCount:  photo.CommentCount,
+	}
+}
+
+func toPhotos(photos []*models.Photo) []Photo {
+	result := make([]Photo, 0, len(photos))
+	for _, photo := range photos {
+		result = append(result, toPhoto(photo))
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// User is the v2 representation of a user
+type User struct {
+	ID    string `json:"id"`
+	AppID string `json:"app_id"`
+	// Code is shared with the partner to pair
+	Code                  string     `json:"code"`
+	Locale                *string    `json:"locale"`
+	QuietHours            QuietHours `json:"quiet_hours"`
+	TimeSensitiveTriggers bool       `json:"time_sensitive_triggers"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// QuietHours are the user's quiet hours; null start and end turn them off
+type QuietHours struct {
+	Start               *string `json:"start" validate:"omitempty,datetime=15:04"`
+	End                 *string `json:"end" validate:"omitempty,datetime=15:04"`
+	Timezone            string  `json:"timezone" validate:"omitempty,timezone"` // UTC by default
+	AlwaysAllowTriggers bool    `json:"always_allow_triggers"`
+}
+
+// UpdateUserRequest is the body of PATCH /api/v2/users/me; absent fields
+// keep their value
+type UpdateUserRequest struct {
+	QuietHours            *QuietHours `json:"quiet_hours"`
+	TimeSensitiveTriggers *bool       `json:"time_sensitive_triggers"`
+	Locale                *string     `json:"locale"` // "" resets to Accept-Language
+}
+
+// CreatedUser is the response to creating a user, the only one with its token
+type CreatedUser struct {
+	User
+	Token string `json:"token"`
+	// RefreshToken renews the token with POST /api/v1/auth/refresh
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// UserHandler handles /api/v2 user requests
+type UserHandler struct {
+	userService *services.UserService
+}
+
+// NewUserHandler creates a new v2 user handler
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// CreateUser handles POST /api/v2/users
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	appID := r.Header.Get(middleware.AppIDHeader)
+	if appID == "" {
+		appID = config.DefaultAppID
+	}
+
+	user, err := h.userService.CreateUser(ctx, appID)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("user_id", user.ID).
+		Str("app_id", user.AppID).
+		Msg("User created")
+
+	// Ресурс нового пользователя — /users/me с его токеном
+	respondCreated(w, "/api/v2/users/me", CreatedUser{
+		User:         toUser(user),
+		Token:        user.Token,
+		RefreshToken: user.RefreshToken,
+	})
+}
+
+// GetMe handles GET /api/v2/users/me
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := h.userService.GetUser(ctx, middleware.GetUserID(ctx))
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	setVersion(w, user.UpdatedAt)
+	respondJSON(w, http.StatusOK, toUser(user))
+}
+
+// UpdateMe handles PATCH /api/v2/users/me. With If-Match or
+// If-Unmodified-Since the update is rejected with 412 if the user changed
+// since the client read it.
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	var req UpdateUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var update services.SettingsUpdate
+	if q := req.QuietHours; q != nil {
+		if q.Timezone == "" {
+			q.Timezone = "UTC"
+		}
+		if err := services.ValidateQuietHours(q.Start, q.End, q.Timezone); err != nil {
+			respondError(w, middleware.ErrCodeInvalidRequest, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.QuietHours = &services.QuietHours{
+			Start:               q.Start,
+			End:                 q.End,
+			Timezone:            q.Timezone,
+			AlwaysAllowTriggers: q.AlwaysAllowTriggers,
+		}
+	}
+	update.TimeSensitive = req.TimeSensitiveTriggers
+	update.Locale = req.Locale
+
+	user, err := h.userService.UpdateSettings(ctx, userID, update, precondition(r))
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("User settings updated")
+
+	setVersion(w, user.UpdatedAt)
+	respondJSON(w, http.StatusOK, toUser(user))
+}
+
+// DeleteMe handles DELETE /api/v2/users/me. The account can be restored
+// until it is purged after accounts.recovery_window.
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+
+	if err := h.userService.DeleteUser(ctx, userID); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", userID).Msg("User deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toUser(user *models.User) User {
+	return User{
+		ID:     user.ID,
+		AppID:  user.AppID,
+		Code:   user.Code,
+		Locale: user.Locale,
+		QuietHours: QuietHours{
+			Start:               user.QuietHoursStart,
+			End:                 user.QuietHoursEnd,
+			Timezone:            user.Timezone,
+			AlwaysAllowTriggers: user.AlwaysAllowTriggers,
+		},
+		TimeSensitiveTriggers: user.TimeSensitive,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
+	}
+}
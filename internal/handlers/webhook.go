@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookHandler handles admin requests managing outgoing webhooks
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// WebhookRequest creates or replaces a webhook
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Events: pair_created, pair_deleted, trigger_started, photo_uploaded, moment_completed
+	Events []string `json:"events"`
+	// AppID limits the webhook to one app; omit to receive events of all apps
+	AppID *string `json:"app_id,omitempty"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+func (req WebhookRequest) input() services.WebhookInput {
+	enabled := req.Enabled == nil || *req.Enabled
+	return services.WebhookInput{URL: req.URL, Events: req.Events, AppID: req.AppID, Enabled: enabled}
+}
+
+// WebhookListResponse represents all configured webhooks
+type WebhookListResponse struct {
+	Webhooks []*models.Webhook `json:"webhooks"`
+}
+
+// WebhookDeliveryListResponse represents a webhook's delivery log
+type WebhookDeliveryListResponse struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries"`
+}
+
+// ListWebhooks handles GET /api/v1/admin/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := h.webhookService.List(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to list webhooks")
+		errreport.Capture(ctx, err)
+		respondError(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, WebhookListResponse{Webhooks: webhooks})
+}
+
+// CreateWebhook handles POST /api/v1/admin/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req WebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.Create(ctx, req.input())
+	if err != nil {
+		h.respondWebhookError(w, r, err, "Failed to create webhook")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("webhook_id", webhook.ID).Str("url", webhook.URL).Msg("Webhook created")
+	respondJSON(w, http.StatusCreated, webhook)
+}
+
+// UpdateWebhook handles PUT /api/v1/admin/webhooks/{webhook_id}
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req WebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.Update(ctx, chi.URLParam(r, "webhook_id"), req.input())
+	if err != nil {
+		h.respondWebhookError(w, r, err, "Failed to update webhook")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("webhook_id", webhook.ID).Bool("enabled", webhook.Enabled).Msg("Webhook updated")
+	respondJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /api/v1/admin/webhooks/{webhook_id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := chi.URLParam(r, "webhook_id")
+
+	if err := h.webhookService.Delete(ctx, webhookID); err != nil {
+		h.respondWebhookError(w, r, err, "Failed to delete webhook")
+		return
+	}
+
+	log.Ctx(ctx).Info().Str("webhook_id", webhookID).Msg("Webhook deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/v1/admin/webhooks/{webhook_id}/deliveries?status=failed&limit=50
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+	default:
+		respondError(w, "status must be one of pending, delivered, failed", http.StatusBadRequest)
+		return
+	}
+
+	limit := pagination.Limit(r.URL.Query(), services.AdminListLimits)
+
+	deliveries, err := h.webhookService.Deliveries(ctx, chi.URLParam(r, "webhook_id"), status, limit)
+	if err != nil {
+		h.respondWebhookError(w, r, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, WebhookDeliveryListResponse{Deliveries: deliveries})
+}
+
+// respondWebhookError maps webhook service errors to HTTP statuses
+func (h *WebhookHandler) respondWebhookError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhook):
+		respondError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrWebhookNotFound):
+		respondError(w, "Webhook not found", http.StatusNotFound)
+	default:
+		ctx := r.Context()
+		log.Ctx(ctx).Error().Err(err).Msg(message)
+		errreport.Capture(ctx, err)
+		respondError(w, message, http.StatusInternalServerError)
+	}
+}
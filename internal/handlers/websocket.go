@@ -2,19 +2,73 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
-
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/i18n"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/middleware"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/ratelimit"
 	"sync-photo-backend/internal/services"
+	"sync-photo-backend/internal/validation"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
 
+// wsMessageTimeout bounds the work done for a single incoming WebSocket message
+const wsMessageTimeout = 10 * time.Second
+
+// defaultWSAuthTimeout bounds the wait for the auth message unless
+// WithAuthTimeout was called
+const defaultWSAuthTimeout = 5 * time.Second
+
+// Codes of WebSocket error messages; the message text is localized, see i18n
+const (
+	wsErrInvalidMessage      = "invalid_message"
+	wsErrUnknownMessageType  = "unknown_message_type"
+	wsErrFeatureUnavailable  = "feature_unavailable"
+	wsErrMissingPhotoFields  = "missing_photo_fields"
+	wsErrPhotoUpdateFailed   = "photo_update_failed"
+	wsErrPushFailed          = "push_failed"
+	wsErrPartnerNoPush       = "partner_no_push"
+	wsErrPartnerInQuietHours = "partner_quiet_hours"
+	wsErrPairMuted           = "pair_muted"
+)
+
+// messageFlags lists message types gated by a feature flag
+var messageFlags = map[string]string{
+	"call_partner": services.FlagCallPartner,
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for MVP
 	},
+	// Клиенты без подпротокола работают по v1
+	Subprotocols: []string{services.WSSubprotocolV2, services.WSSubprotocolV1},
+}
+
+// wsReply tracks the answer to a v2 client message with an id: an ack,
+// unless an error was sent for it
+type wsReply struct {
+	id     string
+	failed bool
+}
+
+type wsReplyKey struct{}
+
+// withReply makes errors sent while handling the message answer its id
+func withReply(ctx context.Context, id string) (context.Context, *wsReply) {
+	reply := &wsReply{id: id}
+	return context.WithValue(ctx, wsReplyKey{}, reply), reply
 }
 
 // WebSocketHandler handles WebSocket connections
@@ -22,8 +76,19 @@ type WebSocketHandler struct {
 	hub          *services.WSHub
 	userService  *services.UserService
 	pairService  *services.PairService
+	pairSettings *services.PairSettingsService
 	photoService *services.PhotoService
+	syncSessions *services.SyncSessionService
 	pushService  *services.PushService
+	flagService  *services.FlagService
+	statsService *services.StatsService
+	maintenance  *services.MaintenanceService
+	// triggerLimiter is nil unless WithTriggerLimit was called
+	triggerLimiter ratelimit.Limiter
+	// authTimeout bounds the wait for the auth message, see WithAuthTimeout
+	authTimeout time.Duration
+	// queryTokenDisabled is set by WithoutQueryToken
+	queryTokenDisabled bool
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
@@ -31,51 +96,141 @@ func NewWebSocketHandler(
 	hub *services.WSHub,
 	userService *services.UserService,
 	pairService *services.PairService,
+	pairSettings *services.PairSettingsService,
 	photoService *services.PhotoService,
+	syncSessions *services.SyncSessionService,
 	pushService *services.PushService,
+	flagService *services.FlagService,
+	statsService *services.StatsService,
+	maintenance *services.MaintenanceService,
 ) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:          hub,
 		userService:  userService,
 		pairService:  pairService,
+		pairSettings: pairSettings,
 		photoService: photoService,
+		syncSessions: syncSessions,
 		pushService:  pushService,
+		flagService:  flagService,
+		statsService: statsService,
+		maintenance:  maintenance,
+		authTimeout:  defaultWSAuthTimeout,
 	}
 }
 
-// HandleWebSocket handles WebSocket connections
+// WithTriggerLimit limits trigger_photo messages per user; over the limit
+// the sender gets a rate_limited error
+func (h *WebSocketHandler) WithTriggerLimit(limiter ratelimit.Limiter) *WebSocketHandler {
+	h.triggerLimiter = limiter
+	return h
+}
+
+// WithAuthTimeout sets how long a connection without a token in the
+// handshake has to send its auth message
+func (h *WebSocketHandler) WithAuthTimeout(timeout time.Duration) *WebSocketHandler {
+	h.authTimeout = timeout
+	return h
+}
+
+// WithoutQueryToken rejects tokens in ?token=, which proxies log with the
+// URL, as configured in deprecations.disable_ws_query_token
+func (h *WebSocketHandler) WithoutQueryToken() *WebSocketHandler {
+	h.queryTokenDisabled = true
+	return h
+}
+
+// HandleWebSocket handles WebSocket connections. The access token comes in
+// a Sec-WebSocket-Protocol prefixed with services.WSTokenSubprotocolPrefix,
+// in an auth message sent first, or in ?token= unless WithoutQueryToken was
+// called.
 func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Get token from query parameter
-	token := r.URL.Query().Get("token")
-	if token == "" {
-		respondError(w, "token required", http.StatusUnauthorized)
+	token, source, ok := h.handshakeToken(w, r)
+	if !ok {
 		return
 	}
 
-	// Validate token
-	userID, err := h.userService.ValidateJWT(token)
-	if err != nil {
-		respondError(w, "invalid token", http.StatusUnauthorized)
-		return
+	// С токеном в handshake ошибки авторизации приходят обычным HTTP-ответом
+	var user *models.User
+	if token != "" {
+		var err error
+		user, err = h.authenticate(r.Context(), token)
+		if errors.Is(err, errInvalidWSToken) {
+			respondError(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Failed to authenticate WebSocket connection")
+			respondServiceError(w, r, err, "Failed to authenticate")
+			return
+		}
+	}
+
+	// Long-lived connections must not inherit the server's write timeout;
+	// the hub bounds each message write instead
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Ctx(r.Context()).Warn().Err(err).Msg("Failed to clear WebSocket write deadline")
+	}
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Ctx(r.Context()).Warn().Err(err).Msg("Failed to clear WebSocket read deadline")
 	}
 
 	// Upgrade connection
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to upgrade WebSocket connection")
+		log.Ctx(r.Context()).Error().Err(err).Msg("Failed to upgrade WebSocket connection")
 		return
 	}
 	defer conn.Close()
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	// Браузеры и мобильные клиенты не видят тело ответа на handshake,
+	// поэтому о техработах сообщаем кодом закрытия
+	if h.maintenance.Status().Enabled {
+		writeClose(conn, services.WSCloseMaintenance, "maintenance")
+		return
+	}
+
+	var authID string
+	if user == nil {
+		source = "message"
+		if user, authID, ok = h.authenticateFirstMessage(r.Context(), conn); !ok {
+			return
+		}
+	}
+	metrics.WSAuth.WithLabelValues(source).Inc()
+	userID := user.ID
+	errreport.SetUser(r.Context(), userID)
+
+	// Строки лога соединения несут request_id handshake и user_id
+	logger := log.Ctx(r.Context()).With().Str("user_id", userID).Logger()
+	ctx := logger.WithContext(r.Context())
+
+	// Ошибки в сокет пишутся на языке из Accept-Language handshake или сохраненном пользователем
+	if _, ok := i18n.FromContext(ctx); !ok {
+		if locale, ok := services.SavedLocale(user); ok {
+			ctx = i18n.WithLocale(ctx, locale)
+		}
+	}
 
-	// Register connection
-	if err := h.hub.Register(userID, conn); err != nil {
-		log.Error().Err(err).Str("user_id", userID).Msg("Failed to register WebSocket connection")
+	// Register connection; from here on only the hub writes to it
+	client, err := h.hub.Register(userID, conn)
+	if errors.Is(err, services.ErrHubShutdown) {
+		writeClose(conn, services.WSCloseShutdown, "server_shutdown")
 		return
 	}
-	defer h.hub.Unregister(userID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to register WebSocket connection")
+		return
+	}
+	defer h.hub.Unregister(client)
+	if authID != "" {
+		h.hub.SendToClient(client, services.WSMessage{Type: "ack", ID: authID})
+	}
 
 	// Get user's pair and notify partner
-	ctx := r.Context()
+	h.statsService.RecordActivity(ctx, userID)
 	pair, err := h.pairService.GetPairByUserID(ctx, userID)
 	if err == nil && pair != nil {
 		// Пара существует - отправить pair_status с данными пары
@@ -83,31 +238,37 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		if partnerID == userID {
 			partnerID = pair.UserBID
 		}
-		h.hub.NotifyPartnerStatus(userID, partnerID, true)
-
-		// Проверить, онлайн ли партнер, и отправить статус подключающемуся пользователю
-		if h.hub.IsOnline(partnerID) {
-			log.Debug().
-				Str("user_id", userID).
+		h.hub.NotifyPartnerStatus(userID, partnerID, true, nil)
+
+		// Отправить подключающемуся пользователю статус партнера; оффлайн-партнер
+		// приходит с last_seen_at, если он уже подключался
+		online := h.hub.IsOnline(partnerID)
+		partnerStatusMsg := services.WSMessage{
+			Type:   "partner_status",
+			Online: &online,
+		}
+		if !online {
+			lastSeenAt, err := h.hub.LastSeen(ctx, partnerID)
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("partner_id", partnerID).Msg("Failed to get partner last seen time")
+			}
+			partnerStatusMsg.LastSeenAt = lastSeenAt
+		}
+		if online || partnerStatusMsg.LastSeenAt != nil {
+			log.Ctx(ctx).Debug().
 				Str("partner_id", partnerID).
-				Msg("Partner is online, sending partner_status to connecting user")
+				Bool("online", online).
+				Msg("Sending partner_status to connecting user")
 
-			online := true
-			partnerStatusMsg := services.WSMessage{
-				Type:   "partner_status",
-				Online: &online,
-			}
 			if err := h.hub.SendToUser(userID, partnerStatusMsg); err != nil {
-				log.Error().
+				log.Ctx(ctx).Error().
 					Err(err).
-					Str("user_id", userID).
 					Msg("Failed to send partner status to connecting user")
 			}
 		}
 
 		// Отправить информацию о паре
-		log.Debug().
-			Str("user_id", userID).
+		log.Ctx(ctx).Debug().
 			Str("pair_id", pair.ID).
 			Msg("Sending pair_status to connecting user (has_pair: true)")
 
@@ -119,16 +280,13 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 			},
 		}
 		if err := h.hub.SendToUser(userID, pairStatusMsg); err != nil {
-			log.Error().
+			log.Ctx(ctx).Error().
 				Err(err).
-				Str("user_id", userID).
 				Msg("Failed to send pair_status message")
 		}
 	} else {
 		// Пары нет - отправить pair_status без пары
-		log.Debug().
-			Str("user_id", userID).
-			Msg("Sending pair_status to connecting user (has_pair: false)")
+		log.Ctx(ctx).Debug().Msg("Sending pair_status to connecting user (has_pair: false)")
 
 		pairStatusMsg := services.WSMessage{
 			Type: "pair_status",
@@ -137,41 +295,172 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 			},
 		}
 		if err := h.hub.SendToUser(userID, pairStatusMsg); err != nil {
-			log.Error().
+			log.Ctx(ctx).Error().
 				Err(err).
-				Str("user_id", userID).
 				Msg("Failed to send pair_status message")
 		}
 	}
 
-	log.Info().Str("user_id", userID).Msg("WebSocket connection established")
+	// События, отправленные, пока пользователь был не на связи
+	h.hub.Replay(ctx, client)
+
+	log.Ctx(ctx).Info().Msg("WebSocket connection established")
 
 	// Handle messages
-	for {
-		_, messageBytes, err := conn.ReadMessage()
+	parse := parseWSMessage
+	if client.Protocol() == services.WSProtocolV2 {
+		parse = parseWSMessageV2
+	}
+	err = client.ReadPump(func(messageBytes []byte) {
+		msg, err := parse(messageBytes, time.Now())
+		msgCtx, reply := withReply(ctx, msg.ID)
+		if errors.Is(err, errUnknownMessageType) {
+			metrics.WSMessages.WithLabelValues("received", "unknown").Inc()
+			log.Ctx(ctx).Warn().Err(err).Msg("Unknown WebSocket message type")
+			h.sendError(msgCtx, client, wsErrUnknownMessageType)
+			return
+		}
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Error().Err(err).Str("user_id", userID).Msg("WebSocket error")
-			}
-			break
+			metrics.WSMessages.WithLabelValues("received", "invalid").Inc()
+			log.Ctx(ctx).Warn().Err(err).Msg("Failed to parse WebSocket message")
+			h.sendError(msgCtx, client, wsErrInvalidMessage)
+			return
 		}
+		metrics.WSMessages.WithLabelValues("received", msg.Type).Inc()
 
-		var msg services.WSMessage
-		if err := json.Unmarshal(messageBytes, &msg); err != nil {
-			log.Error().Err(err).Str("user_id", userID).Msg("Failed to parse WebSocket message")
-			h.sendError(conn, "Invalid message format")
-			continue
+		msgCtx, cancel := context.WithTimeout(msgCtx, wsMessageTimeout)
+		err = h.handleMessage(msgCtx, userID, msg)
+		cancel()
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("type", msg.Type).Msg("Failed to handle message")
+			h.sendError(msgCtx, client, middleware.ErrCodeInternal)
+			return
+		}
+		if reply.id != "" && !reply.failed {
+			h.hub.SendToClient(client, services.WSMessage{Type: "ack", ID: reply.id})
 		}
+	})
+	if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+		log.Ctx(ctx).Error().Err(err).Msg("WebSocket error")
+	}
+}
 
-		if err := h.handleMessage(ctx, userID, msg); err != nil {
-			log.Error().Err(err).Str("user_id", userID).Str("type", msg.Type).Msg("Failed to handle message")
-			h.sendError(conn, err.Error())
+// errInvalidWSToken is returned for tokens that don't authenticate anyone
+var errInvalidWSToken = errors.New("invalid token")
+
+// handshakeToken returns the token passed in the handshake and where it
+// came from; "" if the client authenticates with its first message. It
+// responds with 400 to a token subprotocol offered without a version one
+// and with 401 to a token in the query when those are disabled.
+func (h *WebSocketHandler) handshakeToken(w http.ResponseWriter, r *http.Request) (token, source string, ok bool) {
+	var versioned bool
+	for _, protocol := range websocket.Subprotocols(r) {
+		if protocol == services.WSSubprotocolV1 || protocol == services.WSSubprotocolV2 {
+			versioned = true
 		}
+		if t, found := strings.CutPrefix(protocol, services.WSTokenSubprotocolPrefix); found && token == "" {
+			token = t
+		}
+	}
+	if token != "" {
+		if !versioned {
+			respondError(w, "token subprotocol must be offered with "+services.WSSubprotocolV1+" or "+services.WSSubprotocolV2, http.StatusBadRequest)
+			return "", "", false
+		}
+		return token, "subprotocol", true
+	}
+
+	token = r.URL.Query().Get("token")
+	if token == "" {
+		return "", "", true
 	}
+	if h.queryTokenDisabled {
+		respondError(w, "token in the query is disabled, pass it in Sec-WebSocket-Protocol or an auth message", http.StatusUnauthorized)
+		return "", "", false
+	}
+	return token, "query", true
+}
+
+// authenticate returns the active user of the token; errInvalidWSToken
+// if it is invalid, revoked or of a purged user
+func (h *WebSocketHandler) authenticate(ctx context.Context, token string) (*models.User, error) {
+	claims, err := h.userService.ValidateJWT(token)
+	if err != nil {
+		return nil, errInvalidWSToken
+	}
+
+	active, err := h.userService.SessionActive(ctx, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !active {
+		return nil, errInvalidWSToken
+	}
+
+	user, err := h.userService.ActiveUser(ctx, claims.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, errInvalidWSToken
+	}
+	return user, err
+}
+
+// authenticateFirstMessage authenticates a connection that passed no token
+// in the handshake with the auth message it must send within authTimeout.
+// It returns the user and the v2 id of the message to ack; on failure the
+// connection is closed with a close code and ok is false.
+func (h *WebSocketHandler) authenticateFirstMessage(ctx context.Context, conn *websocket.Conn) (user *models.User, id string, ok bool) {
+	conn.SetReadDeadline(time.Now().Add(h.authTimeout))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("WebSocket auth message not received")
+		writeClose(conn, services.WSCloseUnauthorized, "auth_timeout")
+		return nil, "", false
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	protocol := services.WSProtocolV1
+	if conn.Subprotocol() == services.WSSubprotocolV2 {
+		protocol = services.WSProtocolV2
+	}
+	token, id, err := parseWSAuth(protocol, data)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("Invalid WebSocket auth message")
+		writeClose(conn, services.WSCloseUnauthorized, "unauthorized")
+		return nil, "", false
+	}
+
+	user, err = h.authenticate(ctx, token)
+	switch {
+	case err == nil:
+		return user, id, true
+	case errors.Is(err, errInvalidWSToken):
+		writeClose(conn, services.WSCloseUnauthorized, "unauthorized")
+	case errors.Is(err, domain.ErrUserDeleted):
+		writeClose(conn, services.WSCloseAccountDeleted, "account_deleted")
+	default:
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to authenticate WebSocket connection")
+		writeClose(conn, websocket.CloseInternalServerErr, "internal_error")
+	}
+	return nil, "", false
+}
+
+// writeClose sends a close frame on a connection the hub doesn't own
+func writeClose(conn *websocket.Conn, code int, text string) {
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, text),
+		time.Now().Add(time.Second))
 }
 
 // handleMessage processes incoming WebSocket messages
 func (h *WebSocketHandler) handleMessage(ctx context.Context, userID string, msg services.WSMessage) error {
+	if flag, ok := messageFlags[msg.Type]; ok && !h.flagEnabled(ctx, flag, userID) {
+		return h.sendErrorToUser(ctx, userID, wsErrFeatureUnavailable)
+	}
+
+	if services.Relayable(msg.Type) {
+		return h.handleRelay(ctx, userID, msg)
+	}
+
 	switch msg.Type {
 	case "trigger_photo":
 		return h.handleTriggerPhoto(ctx, userID, msg)
@@ -179,17 +468,23 @@ func (h *WebSocketHandler) handleMessage(ctx context.Context, userID string, msg
 		return h.handlePhotoUploaded(ctx, userID, msg)
 	case "call_partner":
 		return h.handleCallPartner(ctx, userID)
+	case "ack":
+		return h.hub.Ack(ctx, userID, msg.Seq)
 	default:
-		return h.sendErrorToUser(userID, "Unknown message type")
+		return h.sendErrorToUser(ctx, userID, wsErrUnknownMessageType)
 	}
 }
 
 // handleTriggerPhoto handles trigger_photo message
 func (h *WebSocketHandler) handleTriggerPhoto(ctx context.Context, userID string, msg services.WSMessage) error {
+	if limited, err := h.triggerLimited(ctx, userID); limited {
+		return err
+	}
+
 	// Get user's pair
 	pair, err := h.pairService.GetPairByUserID(ctx, userID)
 	if err != nil {
-		return h.sendErrorToUser(userID, "You are not in a pair")
+		return h.sendErrorToUser(ctx, userID, middleware.ErrCodeNotInPair)
 	}
 
 	// Get partner ID
@@ -198,29 +493,72 @@ func (h *WebSocketHandler) handleTriggerPhoto(ctx context.Context, userID string
 		partnerID = pair.UserBID
 	}
 
-	// Trigger photo
+	// Без timestamp время ставит хаб
 	timestamp := msg.Timestamp
-	if timestamp == 0 {
-		// Use current timestamp if not provided
-		// We'll handle this in the hub
+
+	// Обратный отсчет, только если партнер на связи: по push он снимет,
+	// когда откроет уведомление
+	var captureAt time.Time
+	if h.hub.IsOnline(partnerID) {
+		// Без настроек пары отсчет по умолчанию
+		settings, err := h.pairSettings.Settings(ctx, pair.ID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("pair_id", pair.ID).Msg("Failed to get pair settings")
+		}
+		captureAt = h.hub.CaptureAt(settings, userID, partnerID)
+	}
+
+	// Фото обоих партнеров загружаются с session_id из take_photo
+	session, err := h.syncSessions.Start(ctx, pair, userID, timestamp, captureAt)
+	if err != nil {
+		return err
 	}
 
-	return h.hub.TriggerPhoto(userID, partnerID, timestamp)
+	// Оффлайн-партнер получает take_photo push-уведомлением; если и оно
+	// не ушло, триггер не пишется в журнал
+	if err := h.hub.TriggerPhoto(userID, partnerID, session.ID, timestamp, captureAt); err != nil {
+		if cancelErr := h.syncSessions.Cancel(ctx, session.ID); cancelErr != nil {
+			log.Ctx(ctx).Error().Err(cancelErr).Str("session_id", session.ID).Msg("Failed to delete undelivered sync session")
+		}
+		switch {
+		case errors.Is(err, services.ErrPushSuppressed):
+			return h.sendErrorToUser(ctx, userID, wsErrPartnerInQuietHours)
+		case errors.Is(err, services.ErrPushMuted):
+			return h.sendErrorToUser(ctx, userID, wsErrPairMuted)
+		case errors.Is(err, domain.ErrPartnerOffline):
+			return h.sendErrorToUser(ctx, userID, middleware.ErrCodePartnerOffline)
+		}
+		return err
+	}
+	if err := h.pairService.RecordTrigger(ctx, pair, userID, partnerID, session.ID, timestamp); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("pair_id", pair.ID).Msg("Failed to record trigger_started")
+	}
+	return nil
 }
 
 // handlePhotoUploaded handles photo_uploaded message
 func (h *WebSocketHandler) handlePhotoUploaded(ctx context.Context, userID string, msg services.WSMessage) error {
-	if msg.PhotoID == "" || msg.S3URL == "" {
-		return h.sendErrorToUser(userID, "photo_id and s3_url are required")
+	// s3_url от клиента не используется: URL фото строит сервер по ключу
+	if msg.PhotoID == "" {
+		return h.sendErrorToUser(ctx, userID, wsErrMissingPhotoFields)
+	}
+	if !validation.IsUUID(msg.PhotoID) {
+		return h.sendErrorToUser(ctx, userID, middleware.ErrCodeInvalidID)
 	}
 
-	// Update photo S3 URL
-	if err := h.photoService.UpdatePhotoS3URL(ctx, msg.PhotoID, msg.S3URL); err != nil {
-		return h.sendErrorToUser(userID, "Failed to update photo")
+	if _, err := h.photoService.CompleteUpload(ctx, userID, msg.PhotoID); err != nil {
+		if code, ok := middleware.ServiceErrorCode(err); ok {
+			log.Ctx(ctx).Warn().
+				Err(err).
+				Str("photo_id", msg.PhotoID).
+				Msg("Photo upload confirmation rejected")
+			return h.sendErrorToUser(ctx, userID, code)
+		}
+		log.Ctx(ctx).Error().Err(err).Str("photo_id", msg.PhotoID).Msg("Failed to update photo")
+		return h.sendErrorToUser(ctx, userID, wsErrPhotoUpdateFailed)
 	}
 
-	log.Info().
-		Str("user_id", userID).
+	log.Ctx(ctx).Info().
 		Str("photo_id", msg.PhotoID).
 		Msg("Photo uploaded")
 
@@ -231,7 +569,7 @@ func (h *WebSocketHandler) handlePhotoUploaded(ctx context.Context, userID strin
 func (h *WebSocketHandler) handleCallPartner(ctx context.Context, userID string) error {
 	pair, err := h.pairService.GetPairByUserID(ctx, userID)
 	if err != nil {
-		return h.sendErrorToUser(userID, "You are not in a pair")
+		return h.sendErrorToUser(ctx, userID, middleware.ErrCodeNotInPair)
 	}
 
 	partnerID := pair.UserAID
@@ -246,18 +584,27 @@ func (h *WebSocketHandler) handleCallPartner(ctx context.Context, userID string)
 			Type: "partner_calling",
 		}
 		if err := h.hub.SendToUser(partnerID, msg); err != nil {
-			log.Error().Err(err).Str("partner_id", partnerID).Msg("Failed to send partner_calling via WS")
+			log.Ctx(ctx).Error().Err(err).Str("partner_id", partnerID).Msg("Failed to send partner_calling via WS")
 		}
 	} else {
-		pushToken, err := h.userService.GetPushToken(ctx, partnerID)
-		if err != nil || pushToken == nil || *pushToken == "" {
-			log.Warn().Str("partner_id", partnerID).Msg("Partner has no push token")
-			return h.sendErrorToUser(userID, "Partner has no push notifications enabled")
+		partner, err := h.userService.GetUser(ctx, partnerID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("partner_id", partnerID).Msg("Failed to get partner")
+			return h.sendErrorToUser(ctx, userID, wsErrPushFailed)
 		}
 
-		if err := h.pushService.SendCallNotification(*pushToken); err != nil {
-			log.Error().Err(err).Str("partner_id", partnerID).Msg("Failed to send push notification")
-			return h.sendErrorToUser(userID, "Failed to send push notification")
+		if err := h.pushService.SendCallNotification(partner); err != nil {
+			switch {
+			case errors.Is(err, services.ErrNoPushToken):
+				log.Ctx(ctx).Warn().Str("partner_id", partnerID).Msg("Partner has no push token")
+				return h.sendErrorToUser(ctx, userID, wsErrPartnerNoPush)
+			case errors.Is(err, services.ErrPushSuppressed):
+				return h.sendErrorToUser(ctx, userID, wsErrPartnerInQuietHours)
+			case errors.Is(err, services.ErrPushMuted):
+				return h.sendErrorToUser(ctx, userID, wsErrPairMuted)
+			}
+			log.Ctx(ctx).Error().Err(err).Str("partner_id", partnerID).Msg("Failed to send push notification")
+			return h.sendErrorToUser(ctx, userID, wsErrPushFailed)
 		}
 
 		deliveredVia = "push"
@@ -272,30 +619,90 @@ func (h *WebSocketHandler) handleCallPartner(ctx context.Context, userID string)
 	return h.hub.SendToUser(userID, response)
 }
 
+// handleRelay handles ephemeral messages like ready_state, which the hub
+// relays to the partner without storing them
+func (h *WebSocketHandler) handleRelay(ctx context.Context, userID string, msg services.WSMessage) error {
+	pair, err := h.pairService.GetPairByUserID(ctx, userID)
+	if err != nil {
+		return h.sendErrorToUser(ctx, userID, middleware.ErrCodeNotInPair)
+	}
+
+	partnerID := pair.UserAID
+	if partnerID == userID {
+		partnerID = pair.UserBID
+	}
+
+	if wait := h.hub.RelayToPartner(userID, partnerID, msg); wait > 0 {
+		log.Ctx(ctx).Debug().Str("type", msg.Type).Msg("Ephemeral message rate limit exceeded")
+		message := wsError(ctx, middleware.ErrCodeRateLimited)
+		message.Data = map[string]interface{}{"retry_after": int(math.Ceil(wait.Seconds()))}
+		return h.hub.SendToUser(userID, message)
+	}
+	return nil
+}
+
+// flagEnabled evaluates a feature flag for the user and their pair
+func (h *WebSocketHandler) flagEnabled(ctx context.Context, flag, userID string) bool {
+	var pairID string
+	if pair, err := h.pairService.GetPairByUserID(ctx, userID); err == nil && pair != nil {
+		pairID = pair.ID
+	}
+	return h.flagService.Enabled(flag, userID, pairID)
+}
+
 // sendError sends an error message to the WebSocket connection
-func (h *WebSocketHandler) sendError(conn *websocket.Conn, message string) {
-	log.Debug().
-		Str("error_message", message).
+func (h *WebSocketHandler) sendError(ctx context.Context, client *services.WSClient, code string) {
+	log.Ctx(ctx).Debug().
+		Str("error_code", code).
 		Msg("Sending error message via WebSocket")
 
-	msg := services.WSMessage{
-		Type:    "error",
-		Message: message,
-	}
-	data, _ := json.Marshal(msg)
-	conn.WriteMessage(websocket.TextMessage, data)
+	h.hub.SendToClient(client, wsError(ctx, code))
 }
 
 // sendErrorToUser sends an error message to a user
-func (h *WebSocketHandler) sendErrorToUser(userID, message string) error {
-	log.Debug().
-		Str("user_id", userID).
-		Str("error_message", message).
+func (h *WebSocketHandler) sendErrorToUser(ctx context.Context, userID, code string) error {
+	log.Ctx(ctx).Debug().
+		Str("error_code", code).
 		Msg("Sending error message to user via WebSocket")
 
-	msg := services.WSMessage{
+	return h.hub.SendToUser(userID, wsError(ctx, code))
+}
+
+// triggerLimited reports whether the user ran out of triggers, sending
+// them rate_limited with the seconds until they can trigger again
+func (h *WebSocketHandler) triggerLimited(ctx context.Context, userID string) (bool, error) {
+	if h.triggerLimiter == nil {
+		return false, nil
+	}
+	res, err := h.triggerLimiter.Allow(ctx, "trigger:"+userID)
+	if err != nil {
+		// Недоступность хранилища лимитов не должна блокировать триггеры
+		log.Ctx(ctx).Error().Err(err).Msg("Rate limit check failed")
+		return false, nil
+	}
+	if res.Allowed {
+		return false, nil
+	}
+
+	log.Ctx(ctx).Warn().Msg("Trigger rate limit exceeded")
+	message := wsError(ctx, middleware.ErrCodeRateLimited)
+	message.Data = map[string]interface{}{"retry_after": middleware.ResetSeconds(res)}
+	return true, h.hub.SendToUser(userID, message)
+}
+
+// wsError builds an error message with the text for code in the locale of
+// the connection
+func wsError(ctx context.Context, code string) services.WSMessage {
+	locale, _ := i18n.FromContext(ctx)
+	message := services.WSMessage{
 		Type:    "error",
-		Message: message,
+		Code:    code,
+		Message: i18n.Message(locale, code),
+	}
+	// Ошибка отвечает на сообщение v2 вместо ack
+	if reply, ok := ctx.Value(wsReplyKey{}).(*wsReply); ok {
+		reply.failed = true
+		message.ID = reply.id
 	}
-	return h.hub.SendToUser(userID, msg)
+	return message
 }
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sync-photo-backend/internal/services"
+)
+
+const (
+	// wsMaxMessageSize bounds incoming frames; larger ones close the connection
+	wsMaxMessageSize = 4 << 10
+	// wsMaxTimestampSkew bounds how far a trigger_photo timestamp may be from server time
+	wsMaxTimestampSkew = 24 * time.Hour
+	// wsMaxS3URLLength bounds the ignored s3_url older clients send
+	wsMaxS3URLLength = 2048
+	// wsMaxMessageIDLength bounds the id of v2 client messages
+	wsMaxMessageIDLength = 64
+)
+
+var (
+	// errInvalidMessage is returned for frames that aren't a valid client message
+	errInvalidMessage = errors.New("invalid message")
+	// errUnknownMessageType is returned for well-formed messages of unknown type
+	errUnknownMessageType = errors.New("unknown message type")
+)
+
+// wsPayload is the payload of a client message type. message validates it
+// and builds the message the handlers get.
+type wsPayload interface {
+	message(now time.Time) (services.WSMessage, error)
+}
+
+// triggerPhotoPayload is the trigger_photo payload
+type triggerPhotoPayload struct {
+	Timestamp int64 `json:"timestamp"` // unix milliseconds; 0 means now
+}
+
+func (p triggerPhotoPayload) message(now time.Time) (services.WSMessage, error) {
+	if p.Timestamp != 0 && !withinSkew(p.Timestamp, now) {
+		return services.WSMessage{}, fmt.Errorf("%w: timestamp is more than %s from server time", errInvalidMessage, wsMaxTimestampSkew)
+	}
+	return services.WSMessage{Type: "trigger_photo", Timestamp: p.Timestamp}, nil
+}
+
+// photoUploadedPayload is the photo_uploaded payload
+type photoUploadedPayload struct {
+	PhotoID string `json:"photo_id"`
+}
+
+func (p photoUploadedPayload) message(time.Time) (services.WSMessage, error) {
+	return services.WSMessage{Type: "photo_uploaded", PhotoID: p.PhotoID}, nil
+}
+
+// callPartnerPayload is the call_partner payload
+type callPartnerPayload struct{}
+
+func (p callPartnerPayload) message(time.Time) (services.WSMessage, error) {
+	return services.WSMessage{Type: "call_partner"}, nil
+}
+
+// readyStates are the states of a ready_state message
+var readyStates = map[string]bool{
+	"aiming":    true,
+	"countdown": true,
+	"uploading": true,
+	"idle":      true,
+}
+
+// readyStatePayload is the ready_state payload: what the user is doing
+// before the photo, relayed to the partner
+type readyStatePayload struct {
+	State string `json:"state"`
+}
+
+func (p readyStatePayload) message(time.Time) (services.WSMessage, error) {
+	if !readyStates[p.State] {
+		return services.WSMessage{}, fmt.Errorf("%w: unknown state %q", errInvalidMessage, p.State)
+	}
+	return services.WSMessage{Type: "ready_state", State: p.State}, nil
+}
+
+// ackPayload is the ack payload: the seq of the last stored event the
+// client received, which acks it and all before it
+type ackPayload struct {
+	Seq int64 `json:"seq"`
+}
+
+func (p ackPayload) message(time.Time) (services.WSMessage, error) {
+	if p.Seq <= 0 {
+		return services.WSMessage{}, fmt.Errorf("%w: seq must be positive", errInvalidMessage)
+	}
+	return services.WSMessage{Type: "ack", Seq: p.Seq}, nil
+}
+
+// v1 frames are flat: the type next to the payload fields
+
+// wsEnvelope is read first to pick the payload of the message type
+type wsEnvelope struct {
+	Type string `json:"type"`
+}
+
+// triggerPhotoMessage is a v1 trigger_photo frame
+type triggerPhotoMessage struct {
+	Type string `json:"type"`
+	triggerPhotoPayload
+}
+
+// photoUploadedMessage is a v1 photo_uploaded frame
+type photoUploadedMessage struct {
+	Type string `json:"type"`
+	photoUploadedPayload
+	S3URL string `json:"s3_url"` // ignored, see handlePhotoUploaded
+}
+
+func (p photoUploadedMessage) message(now time.Time) (services.WSMessage, error) {
+	if len(p.S3URL) > wsMaxS3URLLength {
+		return services.WSMessage{}, fmt.Errorf("%w: s3_url is longer than %d characters", errInvalidMessage, wsMaxS3URLLength)
+	}
+	return p.photoUploadedPayload.message(now)
+}
+
+// callPartnerMessage is a v1 call_partner frame
+type callPartnerMessage struct {
+	Type string `json:"type"`
+	callPartnerPayload
+}
+
+// readyStateMessage is a v1 ready_state frame
+type readyStateMessage struct {
+	Type string `json:"type"`
+	readyStatePayload
+}
+
+// ackMessage is a v1 ack frame
+type ackMessage struct {
+	Type string `json:"type"`
+	ackPayload
+}
+
+// parseWSMessage decodes a frame sent by a v1 client. Each message type has
+// its own payload; fields it doesn't have, trailing data and out-of-range
+// values make the frame invalid, so the hub only sees messages it knows.
+func parseWSMessage(data []byte, now time.Time) (services.WSMessage, error) {
+	var envelope wsEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return services.WSMessage{}, fmt.Errorf("%w: %v", errInvalidMessage, err)
+	}
+
+	switch envelope.Type {
+	case "trigger_photo":
+		return decodePayload[triggerPhotoMessage](data, now)
+	case "photo_uploaded":
+		return decodePayload[photoUploadedMessage](data, now)
+	case "call_partner":
+		return decodePayload[callPartnerMessage](data, now)
+	case "ready_state":
+		return decodePayload[readyStateMessage](data, now)
+	case "ack":
+		return decodePayload[ackMessage](data, now)
+	default:
+		return services.WSMessage{}, fmt.Errorf("%w: %q", errUnknownMessageType, envelope.Type)
+	}
+}
+
+// parseWSMessageV2 decodes a frame sent by a v2 client: a
+// services.WSEnvelope with the payload of its type, validated like v1
+// frames. The returned message carries the envelope's id, also when the
+// frame is invalid, so the error can answer it.
+func parseWSMessageV2(data []byte, now time.Time) (services.WSMessage, error) {
+	var envelope services.WSEnvelope
+	if err := decodeStrict(data, &envelope); err != nil {
+		return services.WSMessage{}, err
+	}
+	if len(envelope.ID) > wsMaxMessageIDLength {
+		return services.WSMessage{}, fmt.Errorf("%w: id is longer than %d characters", errInvalidMessage, wsMaxMessageIDLength)
+	}
+	reply := services.WSMessage{ID: envelope.ID}
+	if envelope.V != services.WSProtocolV2 {
+		return reply, fmt.Errorf("%w: v must be %d", errInvalidMessage, services.WSProtocolV2)
+	}
+
+	payload := []byte(envelope.Payload)
+	if len(payload) == 0 || string(payload) == "null" {
+		payload = []byte("{}")
+	}
+
+	var msg services.WSMessage
+	var err error
+	switch envelope.Type {
+	case "trigger_photo":
+		msg, err = decodePayload[triggerPhotoPayload](payload, now)
+	case "photo_uploaded":
+		msg, err = decodePayload[photoUploadedPayload](payload, now)
+	case "call_partner":
+		msg, err = decodePayload[callPartnerPayload](payload, now)
+	case "ready_state":
+		msg, err = decodePayload[readyStatePayload](payload, now)
+	case "ack":
+		msg, err = decodePayload[ackPayload](payload, now)
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownMessageType, envelope.Type)
+	}
+	if err != nil {
+		return reply, err
+	}
+	msg.ID = envelope.ID
+	return msg, nil
+}
+
+// authPayload is the payload of the auth message a client that didn't pass
+// a token in the handshake sends first
+type authPayload struct {
+	Token string `json:"token"`
+}
+
+// authMessage is a v1 auth frame
+type authMessage struct {
+	Type string `json:"type"`
+	authPayload
+}
+
+// parseWSAuth decodes the auth frame in the client's protocol version and
+// returns its token and, for v2, its id. Any other message is invalid.
+func parseWSAuth(protocol int, data []byte) (token, id string, err error) {
+	var payload authPayload
+	if protocol == services.WSProtocolV2 {
+		var envelope services.WSEnvelope
+		if err := decodeStrict(data, &envelope); err != nil {
+			return "", "", err
+		}
+		if envelope.V != services.WSProtocolV2 || envelope.Type != "auth" {
+			return "", "", fmt.Errorf("%w: expected a v%d auth message", errInvalidMessage, services.WSProtocolV2)
+		}
+		if len(envelope.ID) > wsMaxMessageIDLength {
+			return "", "", fmt.Errorf("%w: id is longer than %d characters", errInvalidMessage, wsMaxMessageIDLength)
+		}
+		if err := decodeStrict(envelope.Payload, &payload); err != nil {
+			return "", "", err
+		}
+		id = envelope.ID
+	} else {
+		var msg authMessage
+		if err := decodeStrict(data, &msg); err != nil {
+			return "", "", err
+		}
+		if msg.Type != "auth" {
+			return "", "", fmt.Errorf("%w: expected an auth message", errInvalidMessage)
+		}
+		payload = msg.authPayload
+	}
+	if payload.Token == "" {
+		return "", "", fmt.Errorf("%w: token is required", errInvalidMessage)
+	}
+	return payload.Token, id, nil
+}
+
+// decodePayload strictly decodes data into a P and validates it
+func decodePayload[P wsPayload](data []byte, now time.Time) (services.WSMessage, error) {
+	var payload P
+	if err := decodeStrict(data, &payload); err != nil {
+		return services.WSMessage{}, err
+	}
+	return payload.message(now)
+}
+
+// decodeStrict decodes a single JSON object into v, rejecting unknown
+// fields and anything after the object
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidMessage, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("%w: unexpected data after the message", errInvalidMessage)
+	}
+	return nil
+}
+
+// withinSkew reports whether the unix-millisecond timestamp is within
+// wsMaxTimestampSkew of now
+func withinSkew(timestamp int64, now time.Time) bool {
+	skew := now.Sub(time.UnixMilli(timestamp))
+	return skew >= -wsMaxTimestampSkew && skew <= wsMaxTimestampSkew
+}
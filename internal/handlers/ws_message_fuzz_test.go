@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/repository/memory"
+	"sync-photo-backend/internal/services"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+// fuzzSyncType is the type of the message sync sends through the hub
+const fuzzSyncType = "fuzz_sync"
+
+// fuzzPair is a pair of users connected to the hub of a handler: user A by
+// v1, user B by v2
+type fuzzPair struct {
+	handler *WebSocketHandler
+	hub     *services.WSHub
+	userA   string
+	userB   string
+	// photoID is a pending photo of user A, for photo_uploaded
+	photoID string
+	// synced receives the fuzz_sync messages each user reads
+	synced map[string]chan struct{}
+}
+
+// sync waits until both users have read everything the hub sent them so
+// far. Without it the fuzzer outpaces the clients, whose queues fill up and
+// get them evicted.
+func (p *fuzzPair) sync(t *testing.T) {
+	t.Helper()
+
+	for _, userID := range []string{p.userA, p.userB} {
+		if err := p.hub.SendToUser(userID, services.WSMessage{Type: fuzzSyncType}); err != nil {
+			t.Fatalf("sync %s: %v", userID, err)
+		}
+		select {
+		case <-p.synced[userID]:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("sync %s: timed out", userID)
+		}
+	}
+}
+
+// newFuzzPair returns a WebSocket handler with services backed by the
+// memory repositories, wired as in serve, and a pair of users connected to
+// its hub. The clients read and drop everything the hub sends them.
+func newFuzzPair(f *testing.F) *fuzzPair {
+	f.Helper()
+
+	// Хаб и обработчик пишут в лог каждое сообщение
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	f.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	pairRepo := memory.NewPairRepository(store)
+	userService := services.NewUserService(userRepo, "fuzz-secret")
+	eventLog := services.NewEventLog(memory.NewDomainEventRepository(store), pairRepo)
+	pairService := services.NewPairService(pairRepo, userRepo).WithEventLog(eventLog)
+
+	objectStore, err := services.NewFileObjectStore(f.TempDir(), "http://localhost/dev/storage")
+	if err != nil {
+		f.Fatal(err)
+	}
+	photoService := services.NewPhotoService(memory.NewPhotoRepository(store), pairRepo, objectStore, time.Second, 1, 5, time.Minute).
+		WithEventLog(eventLog)
+
+	hub := services.NewWSHub(pairService).WithLastSeen(userRepo)
+	syncSessions := services.NewSyncSessionService(memory.NewSyncSessionRepository(store), pairRepo, photoService, hub)
+	photoService.WithSyncSessions(syncSessions)
+	pairSettings := services.NewPairSettingsService(memory.NewPairSettingsRepository(store), pairRepo, userRepo, 3*time.Second)
+
+	// Без Start уведомления только встают в очередь
+	pushService, err := services.NewLogPushService(config.PushConfig{
+		Workers:                  1,
+		QueueSize:                1000,
+		BatchSize:                50,
+		FlushInterval:            100 * time.Millisecond,
+		TriggerPriority:          "high",
+		TriggerInterruptionLevel: "active",
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	pushService.WithPairSettings(pairSettings)
+	hub.WithPushFallback(userRepo, pushService)
+
+	handler := NewWebSocketHandler(hub, userService, pairService, pairSettings, photoService, syncSessions,
+		pushService, services.NewFlagService(config.FlagsConfig{}), nil, nil)
+
+	ctx := context.Background()
+	userA, err := userService.CreateUser(ctx, config.DefaultAppID)
+	if err != nil {
+		f.Fatal(err)
+	}
+	userB, err := userService.CreateUser(ctx, config.DefaultAppID)
+	if err != nil {
+		f.Fatal(err)
+	}
+	if _, err := pairService.CreatePair(ctx, userB.ID, userA.Code); err != nil {
+		f.Fatal(err)
+	}
+	upload, err := photoService.GetPreSignedURL(ctx, userA.ID, "fuzz.jpg", "image/jpeg", 1024, "")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client, err := hub.Register(r.URL.Query().Get("user"), conn)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		defer hub.Unregister(client)
+		client.ReadPump(func([]byte) {})
+	}))
+	f.Cleanup(server.Close)
+
+	return &fuzzPair{
+		handler: handler,
+		hub:     hub,
+		userA:   userA.ID,
+		userB:   userB.ID,
+		photoID: upload.PhotoID,
+		synced: map[string]chan struct{}{
+			userA.ID: connect(f, server, hub, userA.ID, nil),
+			userB.ID: connect(f, server, hub, userB.ID, []string{services.WSSubprotocolV2}),
+		},
+	}
+}
+
+// connect dials server as the user with the subprotocols and waits until
+// the hub has the user online. The connection is read until closed; the
+// returned channel receives the fuzz_sync messages.
+func connect(f *testing.F, server *httptest.Server, hub *services.WSHub, userID string, subprotocols []string) chan struct{} {
+	f.Helper()
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?user=" + userID
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		f.Fatalf("dial %s: %v", userID, err)
+	}
+	f.Cleanup(func() { conn.Close() })
+	synced := make(chan struct{})
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			// Тип в корне кадра в обеих версиях протокола
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(data, &msg) == nil && msg.Type == fuzzSyncType {
+				synced <- struct{}{}
+			}
+		}
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); !hub.IsOnline(userID); {
+		if time.Now().After(deadline) {
+			f.Fatalf("user %s not registered in the hub", userID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return synced
+}
+
+// FuzzHandleMessage parses frames as a connection of either protocol
+// version does and handles the accepted ones as a partner of a connected
+// pair:
+//
+//	go test -run '^$' -fuzz FuzzHandleMessage ./internal/handlers
+//
+// It fails if a parser accepts a frame the handlers don't expect, or if
+// handleMessage panics or returns an error, which the client would get as
+// an internal error.
+func FuzzHandleMessage(f *testing.F) {
+	var frames []wsGoldenFrame
+	readGolden(f, "ws_client_frames.json", &frames)
+	for _, frame := range frames {
+		f.Add([]byte(frame.V1), false)
+		f.Add([]byte(frame.V2), true)
+	}
+	pair := newFuzzPair(f)
+	f.Add([]byte(`{"type":"photo_uploaded","photo_id":"`+pair.photoID+`"}`), false)
+	f.Add([]byte(`{"v":2,"type":"photo_uploaded","id":"up","payload":{"photo_id":"`+pair.photoID+`"}}`), true)
+	f.Add([]byte(`{"type":"ready_state","state":"sleeping"}`), false)
+	f.Add([]byte(`{"v":1,"type":"ack","payload":{"seq":1}}`), true)
+	f.Add([]byte(`{"type":"trigger_photo","timestamp":-1}`), false)
+	f.Add([]byte(`{"type":`), false)
+
+	f.Fuzz(func(t *testing.T, data []byte, v2 bool) {
+		if len(data) > wsMaxMessageSize {
+			// Такие кадры gorilla/websocket отбрасывает до разбора
+			return
+		}
+		parse, userID := parseWSMessage, pair.userA
+		if v2 {
+			parse, userID = parseWSMessageV2, pair.userB
+		}
+		msg, err := parse(data, wsGoldenNow)
+		if err != nil {
+			return
+		}
+
+		if len(msg.ID) > wsMaxMessageIDLength {
+			t.Fatalf("id of %d characters accepted", len(msg.ID))
+		}
+		switch msg.Type {
+		case "trigger_photo":
+			if msg.Timestamp != 0 && !withinSkew(msg.Timestamp, wsGoldenNow) {
+				t.Fatalf("timestamp %d accepted", msg.Timestamp)
+			}
+		case "ready_state":
+			if !readyStates[msg.State] {
+				t.Fatalf("state %q accepted", msg.State)
+			}
+		case "ack":
+			if msg.Seq <= 0 {
+				t.Fatalf("seq %d accepted", msg.Seq)
+			}
+		case "photo_uploaded", "call_partner":
+		default:
+			t.Fatalf("type %q accepted", msg.Type)
+		}
+
+		ctx, _ := withReply(context.Background(), msg.ID)
+		ctx, cancel := context.WithTimeout(ctx, wsMessageTimeout)
+		defer cancel()
+		if err := pair.handler.handleMessage(ctx, userID, msg); err != nil {
+			t.Fatalf("handleMessage %s: %v", data, err)
+		}
+		pair.sync(t)
+	})
+}
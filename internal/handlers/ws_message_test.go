@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"sync-photo-backend/internal/services"
+)
+
+// wsGoldenNow is the server time the golden client frames are parsed at
+var wsGoldenNow = time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// wsGoldenFrame is a message and its frames in both protocol versions, as
+// stored in testdata/ws_*_frames.json
+type wsGoldenFrame struct {
+	Name string `json:"name"`
+	// ID is the id of the v2 client frame
+	ID      string             `json:"id"`
+	Message services.WSMessage `json:"message"`
+	V1      json.RawMessage    `json:"v1"`
+	V2      json.RawMessage    `json:"v2"`
+}
+
+// readGolden decodes testdata/name into v
+func readGolden(t testing.TB, name string, v interface{}) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode %s: %v", name, err)
+	}
+}
+
+// assertJSONEqual fails the test unless got and want hold the same JSON
+// value; key order and spacing don't matter
+func assertJSONEqual(t *testing.T, got, want []byte) {
+	t.Helper()
+
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("decode %s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("decode %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		var compact bytes.Buffer
+		json.Compact(&compact, want)
+		t.Errorf("frame = %s\nwant    %s", got, compact.Bytes())
+	}
+}
+
+// TestWSClientFrames parses the golden frames clients send and encodes the
+// parsed messages back into the same frames
+func TestWSClientFrames(t *testing.T) {
+	var frames []wsGoldenFrame
+	readGolden(t, "ws_client_frames.json", &frames)
+
+	for _, frame := range frames {
+		t.Run(frame.Name, func(t *testing.T) {
+			msg, err := parseWSMessage(frame.V1, wsGoldenNow)
+			if err != nil {
+				t.Fatalf("parseWSMessage: %v", err)
+			}
+			if !reflect.DeepEqual(msg, frame.Message) {
+				t.Errorf("parseWSMessage = %+v, want %+v", msg, frame.Message)
+			}
+			encoded, err := services.EncodeWSMessage(services.WSProtocolV1, msg)
+			if err != nil {
+				t.Fatalf("EncodeWSMessage v1: %v", err)
+			}
+			assertJSONEqual(t, encoded, frame.V1)
+
+			want := frame.Message
+			want.ID = frame.ID
+			msg, err = parseWSMessageV2(frame.V2, wsGoldenNow)
+			if err != nil {
+				t.Fatalf("parseWSMessageV2: %v", err)
+			}
+			if !reflect.DeepEqual(msg, want) {
+				t.Errorf("parseWSMessageV2 = %+v, want %+v", msg, want)
+			}
+			encoded, err = services.EncodeWSMessage(services.WSProtocolV2, msg)
+			if err != nil {
+				t.Fatalf("EncodeWSMessage v2: %v", err)
+			}
+			assertJSONEqual(t, encoded, frame.V2)
+		})
+	}
+}
+
+// TestWSServerFrames encodes the messages the server sends into the golden
+// frames and decodes the frames back into the messages, as clients do
+func TestWSServerFrames(t *testing.T) {
+	var frames []wsGoldenFrame
+	readGolden(t, "ws_server_frames.json", &frames)
+
+	for _, frame := range frames {
+		t.Run(frame.Name, func(t *testing.T) {
+			encoded, err := services.EncodeWSMessage(services.WSProtocolV1, frame.Message)
+			if err != nil {
+				t.Fatalf("EncodeWSMessage v1: %v", err)
+			}
+			assertJSONEqual(t, encoded, frame.V1)
+
+			var msg services.WSMessage
+			if err := json.Unmarshal(frame.V1, &msg); err != nil {
+				t.Fatalf("decode v1 frame: %v", err)
+			}
+			if !reflect.DeepEqual(msg, frame.Message) {
+				t.Errorf("v1 frame = %+v, want %+v", msg, frame.Message)
+			}
+
+			encoded, err = services.EncodeWSMessage(services.WSProtocolV2, frame.Message)
+			if err != nil {
+				t.Fatalf("EncodeWSMessage v2: %v", err)
+			}
+			assertJSONEqual(t, encoded, frame.V2)
+
+			var envelope services.WSEnvelope
+			if err := json.Unmarshal(frame.V2, &envelope); err != nil {
+				t.Fatalf("decode v2 frame: %v", err)
+			}
+			msg = services.WSMessage{}
+			if len(envelope.Payload) > 0 {
+				if err := json.Unmarshal(envelope.Payload, &msg); err != nil {
+					t.Fatalf("decode v2 payload: %v", err)
+				}
+			}
+			msg.Type, msg.ID = envelope.Type, envelope.ID
+			if envelope.V != services.WSProtocolV2 {
+				t.Errorf("v = %d, want %d", envelope.V, services.WSProtocolV2)
+			}
+			if !reflect.DeepEqual(msg, frame.Message) {
+				t.Errorf("v2 frame = %+v, want %+v", msg, frame.Message)
+			}
+		})
+	}
+}
+
+// TestWSAuthFrames parses the golden auth frames of both versions
+func TestWSAuthFrames(t *testing.T) {
+	var frames struct {
+		Token string          `json:"token"`
+		ID    string          `json:"id"`
+		V1    json.RawMessage `json:"v1"`
+		V2    json.RawMessage `json:"v2"`
+	}
+	readGolden(t, "ws_auth_frames.json", &frames)
+
+	token, id, err := parseWSAuth(services.WSProtocolV1, frames.V1)
+	if err != nil {
+		t.Fatalf("parseWSAuth v1: %v", err)
+	}
+	if token != frames.Token || id != "" {
+		t.Errorf("parseWSAuth v1 = %q, %q; want %q, \"\"", token, id, frames.Token)
+	}
+
+	token, id, err = parseWSAuth(services.WSProtocolV2, frames.V2)
+	if err != nil {
+		t.Fatalf("parseWSAuth v2: %v", err)
+	}
+	if token != frames.Token || id != frames.ID {
+		t.Errorf("parseWSAuth v2 = %q, %q; want %q, %q", token, id, frames.Token, frames.ID)
+	}
+
+	// Кадр одной версии не принимается соединением другой
+	if _, _, err := parseWSAuth(services.WSProtocolV1, frames.V2); err == nil {
+		t.Error("parseWSAuth v1 accepted a v2 frame")
+	}
+	if _, _, err := parseWSAuth(services.WSProtocolV2, frames.V1); err == nil {
+		t.Error("parseWSAuth v2 accepted a v1 frame")
+	}
+}
@@ -0,0 +1,171 @@
+package hubbackend
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Backend connects the WebSocket hubs of several replicas: it records which
+// instance holds each user's connection and carries messages between
+// instances. Payloads are opaque to the backend.
+type Backend interface {
+	// InstanceID identifies this replica
+	InstanceID() string
+	// Subscribe delivers messages published to this instance until ctx is
+	// cancelled
+	Subscribe(ctx context.Context) <-chan []byte
+	// Publish sends a message to the instance. Delivery is fire-and-forget:
+	// a message for an instance that isn't subscribed is dropped.
+	Publish(ctx context.Context, instanceID string, payload []byte) error
+	// ClaimPresence marks the user as connected to this instance and
+	// returns the instance that held them before, "" if none
+	ClaimPresence(ctx context.Context, userID string) (string, error)
+	// ReleasePresence removes the user's presence if it belongs to this
+	// instance, so a user who already reconnected elsewhere stays online
+	ReleasePresence(ctx context.Context, userID string) error
+	// RefreshPresence keeps the presence of users connected here alive.
+	// Presence claimed by another instance in the meantime is left alone.
+	RefreshPresence(ctx context.Context, userIDs []string) error
+	// RefreshInterval is how often RefreshPresence has to run, 0 if
+	// presence doesn't expire
+	RefreshInterval() time.Duration
+	// Owner returns the instance holding the user's connection, "" if offline
+	Owner(ctx context.Context, userID string) (string, error)
+}
+
+// MemoryNetwork connects hubs running in one process. Presence doesn't
+// expire and nothing is shared with other processes; use RedisBackend for
+// multi-instance deployments.
+type MemoryNetwork struct {
+	mu          sync.Mutex
+	presence    map[string]string
+	subscribers map[string]*subscriber
+}
+
+// subscriber is the subscription of an instance to its messages
+type subscriber struct {
+	messages chan []byte
+	done     <-chan struct{}
+}
+
+// memorySubscriberQueue is the number of messages queued for an instance
+// before Publish waits for it
+const memorySubscriberQueue = 256
+
+// NewMemoryNetwork creates a network without instances
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		presence:    make(map[string]string),
+		subscribers: make(map[string]*subscriber),
+	}
+}
+
+// Backend returns the backend of an instance on the network
+func (n *MemoryNetwork) Backend(instanceID string) *MemoryBackend {
+	return &MemoryBackend{network: n, instanceID: instanceID}
+}
+
+// Subscribed reports whether the instance receives messages
+func (n *MemoryNetwork) Subscribed(instanceID string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, ok := n.subscribers[instanceID]
+	return ok
+}
+
+// MemoryBackend is the Backend of one instance on a MemoryNetwork
+type MemoryBackend struct {
+	network    *MemoryNetwork
+	instanceID string
+}
+
+// InstanceID implements Backend
+func (b *MemoryBackend) InstanceID() string { return b.instanceID }
+
+// Subscribe implements Backend. A later subscription of the instance
+// replaces this one.
+func (b *MemoryBackend) Subscribe(ctx context.Context) <-chan []byte {
+	sub := &subscriber{messages: make(chan []byte, memorySubscriberQueue), done: ctx.Done()}
+
+	n := b.network
+	n.mu.Lock()
+	n.subscribers[b.instanceID] = sub
+	n.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		if n.subscribers[b.instanceID] == sub {
+			delete(n.subscribers, b.instanceID)
+		}
+		n.mu.Unlock()
+	}()
+	return sub.messages
+}
+
+// Publish implements Backend
+func (b *MemoryBackend) Publish(ctx context.Context, instanceID string, payload []byte) error {
+	b.network.mu.Lock()
+	sub, ok := b.network.subscribers[instanceID]
+	b.network.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	select {
+	case sub.messages <- payload:
+		return nil
+	case <-sub.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ClaimPresence implements Backend
+func (b *MemoryBackend) ClaimPresence(_ context.Context, userID string) (string, error) {
+	b.network.mu.Lock()
+	defer b.network.mu.Unlock()
+
+	previous := b.network.presence[userID]
+	b.network.presence[userID] = b.instanceID
+	return previous, nil
+}
+
+// ReleasePresence implements Backend
+func (b *MemoryBackend) ReleasePresence(_ context.Context, userID string) error {
+	b.network.mu.Lock()
+	defer b.network.mu.Unlock()
+
+	if b.network.presence[userID] == b.instanceID {
+		delete(b.network.presence, userID)
+	}
+	return nil
+}
+
+// RefreshPresence implements Backend. Presence on the network doesn't
+// expire, so only users with no presence at all are claimed again.
+func (b *MemoryBackend) RefreshPresence(_ context.Context, userIDs []string) error {
+	b.network.mu.Lock()
+	defer b.network.mu.Unlock()
+
+	for _, userID := range userIDs {
+		if _, ok := b.network.presence[userID]; !ok {
+			b.network.presence[userID] = b.instanceID
+		}
+	}
+	return nil
+}
+
+// RefreshInterval implements Backend
+func (b *MemoryBackend) RefreshInterval() time.Duration { return 0 }
+
+// Owner implements Backend
+func (b *MemoryBackend) Owner(_ context.Context, userID string) (string, error) {
+	b.network.mu.Lock()
+	defer b.network.mu.Unlock()
+
+	return b.network.presence[userID], nil
+}
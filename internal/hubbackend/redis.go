@@ -0,0 +1,153 @@
+package hubbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// releasePresence deletes a presence key only if it still belongs to this
+// instance, so a user who already reconnected elsewhere stays online
+var releasePresence = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// refreshPresence extends the presence keys in KEYS that still belong to
+// this instance, ARGV[1], by ARGV[2] milliseconds and recreates those that
+// expired. A key claimed by another instance in the meantime is left alone:
+// the user reconnected there and this instance only awaits the kick.
+var refreshPresence = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local owner = redis.call("GET", key)
+	if owner == ARGV[1] then
+		redis.call("PEXPIRE", key, ARGV[2])
+	elseif not owner then
+		redis.call("SET", key, ARGV[1], "PX", ARGV[2], "NX")
+	end
+end
+return 0
+`)
+
+// presenceRefreshBatch bounds the keys refreshed by one script call, so a
+// replica with many connections doesn't block Redis for long
+const presenceRefreshBatch = 500
+
+// RedisBackend shares presence between replicas through Redis keys with a
+// TTL and carries messages over pub/sub
+type RedisBackend struct {
+	client      *redis.Client
+	instanceID  string
+	presenceTTL time.Duration
+}
+
+// NewRedisBackend creates the backend of an instance; presence of its users
+// expires after presenceTTL unless refreshed
+func NewRedisBackend(client *redis.Client, instanceID string, presenceTTL time.Duration) *RedisBackend {
+	return &RedisBackend{
+		client:      client,
+		instanceID:  instanceID,
+		presenceTTL: presenceTTL,
+	}
+}
+
+func presenceKey(userID string) string         { return "presence:" + userID }
+func instanceChannel(instanceID string) string { return "hub:" + instanceID }
+
+// InstanceID implements Backend
+func (b *RedisBackend) InstanceID() string { return b.instanceID }
+
+// Subscribe implements Backend. The subscription reconnects on its own when
+// Redis is unavailable.
+func (b *RedisBackend) Subscribe(ctx context.Context) <-chan []byte {
+	sub := b.client.Subscribe(ctx, instanceChannel(b.instanceID))
+	messages := make(chan []byte)
+	go func() {
+		defer sub.Close()
+		defer close(messages)
+
+		received := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages
+}
+
+// Publish implements Backend
+func (b *RedisBackend) Publish(ctx context.Context, instanceID string, payload []byte) error {
+	if err := b.client.Publish(ctx, instanceChannel(instanceID), payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish to instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// ClaimPresence implements Backend
+func (b *RedisBackend) ClaimPresence(ctx context.Context, userID string) (string, error) {
+	previous, err := b.client.SetArgs(ctx, presenceKey(userID), b.instanceID, redis.SetArgs{
+		TTL: b.presenceTTL,
+		Get: true,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("failed to store presence: %w", err)
+	}
+	return previous, nil
+}
+
+// ReleasePresence implements Backend
+func (b *RedisBackend) ReleasePresence(ctx context.Context, userID string) error {
+	if err := releasePresence.Run(ctx, b.client, []string{presenceKey(userID)}, b.instanceID).Err(); err != nil {
+		return fmt.Errorf("failed to release presence: %w", err)
+	}
+	return nil
+}
+
+// RefreshPresence implements Backend. Keys are renewed in batches, each
+// one a single script call.
+func (b *RedisBackend) RefreshPresence(ctx context.Context, userIDs []string) error {
+	ttl := strconv.FormatInt(b.presenceTTL.Milliseconds(), 10)
+	for batch := range slices.Chunk(userIDs, presenceRefreshBatch) {
+		keys := make([]string, len(batch))
+		for i, userID := range batch {
+			keys[i] = presenceKey(userID)
+		}
+		if err := refreshPresence.Run(ctx, b.client, keys, b.instanceID, ttl).Err(); err != nil {
+			return fmt.Errorf("failed to refresh presence: %w", err)
+		}
+	}
+	return nil
+}
+
+// RefreshInterval implements Backend: keys are renewed three times per TTL
+func (b *RedisBackend) RefreshInterval() time.Duration { return b.presenceTTL / 3 }
+
+// Owner implements Backend
+func (b *RedisBackend) Owner(ctx context.Context, userID string) (string, error) {
+	instanceID, err := b.client.Get(ctx, presenceKey(userID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get presence: %w", err)
+	}
+	return instanceID, nil
+}
@@ -0,0 +1,137 @@
+package hubbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testPresenceTTL is the presence TTL of the test backends
+const testPresenceTTL = 30 * time.Second
+
+// newTestBackends returns Redis backends sharing a miniredis, one per
+// instance ID
+func newTestBackends(t *testing.T, instanceIDs ...string) (*miniredis.Miniredis, []*RedisBackend) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	backends := make([]*RedisBackend, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		backends[i] = NewRedisBackend(client, instanceID, testPresenceTTL)
+	}
+	return mr, backends
+}
+
+// assertOwner fails the test unless the user's presence key holds want,
+// "" meaning no key
+func assertOwner(t *testing.T, mr *miniredis.Miniredis, userID, want string) {
+	t.Helper()
+
+	got, err := mr.Get(presenceKey(userID))
+	if err != nil && err != miniredis.ErrKeyNotFound {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("presence of %s = %q, want %q", userID, got, want)
+	}
+}
+
+func TestRedisPresence(t *testing.T) {
+	mr, backends := newTestBackends(t, "a", "b")
+	a, b := backends[0], backends[1]
+	ctx := context.Background()
+
+	if previous, err := a.ClaimPresence(ctx, "user"); err != nil || previous != "" {
+		t.Fatalf("first claim = %q, %v; want no previous owner", previous, err)
+	}
+	if previous, err := b.ClaimPresence(ctx, "user"); err != nil || previous != "a" {
+		t.Fatalf("claim after reconnect = %q, %v; want a", previous, err)
+	}
+
+	// Отключение на старой реплике не снимает новое присутствие
+	if err := a.ReleasePresence(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	assertOwner(t, mr, "user", "b")
+	if owner, err := a.Owner(ctx, "user"); err != nil || owner != "b" {
+		t.Errorf("owner = %q, %v; want b", owner, err)
+	}
+
+	if err := b.ReleasePresence(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	assertOwner(t, mr, "user", "")
+	if owner, err := a.Owner(ctx, "user"); err != nil || owner != "" {
+		t.Errorf("owner of offline user = %q, %v; want none", owner, err)
+	}
+}
+
+// TestRedisRefreshPresence checks that an instance renews only the presence
+// it owns: once the user reconnected to another instance, the old one must
+// not take the key back before the kick reaches it
+func TestRedisRefreshPresence(t *testing.T) {
+	mr, backends := newTestBackends(t, "a", "b")
+	a, b := backends[0], backends[1]
+	ctx := context.Background()
+
+	for _, userID := range []string{"moved", "expired", "kept"} {
+		if _, err := a.ClaimPresence(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Пользователь переподключился к b, ключ истек у a
+	if _, err := b.ClaimPresence(ctx, "moved"); err != nil {
+		t.Fatal(err)
+	}
+	mr.Del(presenceKey("expired"))
+	mr.FastForward(testPresenceTTL / 2)
+
+	if err := a.RefreshPresence(ctx, []string{"moved", "expired", "kept"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOwner(t, mr, "moved", "b")
+	assertOwner(t, mr, "expired", "a")
+	assertOwner(t, mr, "kept", "a")
+	for _, userID := range []string{"expired", "kept"} {
+		if ttl := mr.TTL(presenceKey(userID)); ttl != testPresenceTTL {
+			t.Errorf("presence TTL of %s = %s, want %s", userID, ttl, testPresenceTTL)
+		}
+	}
+	if ttl := mr.TTL(presenceKey("moved")); ttl != testPresenceTTL/2 {
+		t.Errorf("presence TTL of moved = %s, want %s left of b's claim", ttl, testPresenceTTL/2)
+	}
+}
+
+func TestRedisPublish(t *testing.T) {
+	_, backends := newTestBackends(t, "a", "b")
+	a, b := backends[0], backends[1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := b.Subscribe(ctx)
+
+	// Подписка в Redis появляется асинхронно, до нее сообщения теряются
+	timeout := time.After(5 * time.Second)
+	for {
+		if err := a.Publish(ctx, "b", []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case payload := <-messages:
+			if string(payload) != "hello" {
+				t.Errorf("payload = %q, want hello", payload)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("message not delivered")
+		}
+	}
+}
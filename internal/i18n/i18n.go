@@ -0,0 +1,104 @@
+// Package i18n translates the human-readable text sent with error codes.
+// Messages live in locales/<locale>.json keyed by code; the locale of a
+// request comes from Accept-Language, falling back to the one the user
+// saved and then to English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// Default is the locale used when nothing better matches; every code must
+// have a message in it
+const Default = "en"
+
+// Supported lists the locales with a catalog, the default first
+var Supported = []string{Default, "ru"}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	catalog = loadCatalog()
+	matcher = newMatcher()
+)
+
+func loadCatalog() map[string]map[string]string {
+	catalog := make(map[string]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		data, err := locales.ReadFile("locales/" + locale + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		catalog[locale] = messages
+	}
+	return catalog
+}
+
+func newMatcher() language.Matcher {
+	tags := make([]language.Tag, 0, len(Supported))
+	for _, locale := range Supported {
+		tags = append(tags, language.MustParse(locale))
+	}
+	return language.NewMatcher(tags)
+}
+
+// IsSupported reports whether locale has a catalog
+func IsSupported(locale string) bool {
+	return slices.Contains(Supported, locale)
+}
+
+// Negotiate picks the supported locale best matching the Accept-Language
+// header, then the user's saved locale, then Default. ok is false if
+// neither matched.
+func Negotiate(acceptLanguage, userLocale string) (locale string, ok bool) {
+	if acceptLanguage != "" {
+		tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+		if err == nil && len(tags) > 0 {
+			_, index, confidence := matcher.Match(tags...)
+			if confidence != language.No {
+				return Supported[index], true
+			}
+		}
+	}
+	if IsSupported(userLocale) {
+		return userLocale, true
+	}
+	return Default, false
+}
+
+// Message returns the text for code in locale, falling back to Default.
+// It returns "" for codes without a message.
+func Message(locale, code string) string {
+	if message, ok := catalog[locale][code]; ok {
+		return message
+	}
+	return catalog[Default][code]
+}
+
+type contextKey struct{}
+
+// WithLocale returns a context carrying the locale of the request
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale of the request; ok is false if it wasn't
+// negotiated, in which case Default is returned
+func FromContext(ctx context.Context) (locale string, ok bool) {
+	locale, ok = ctx.Value(contextKey{}).(string)
+	if !ok {
+		return Default, false
+	}
+	return locale, true
+}
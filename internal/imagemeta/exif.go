@@ -0,0 +1,200 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// EXIF tags read by readEXIF
+const (
+	tagOrientation        = 0x0112
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+// EXIF value types
+const (
+	typeASCII = 2
+	typeShort = 3
+	typeLong  = 4
+)
+
+// maxIFDEntries bounds the entries read from one directory of a damaged file
+const maxIFDEntries = 512
+
+// exifData is the part of EXIF that Read uses
+type exifData struct {
+	// orientation is 1 (as stored) to 8; 0 if missing
+	orientation int
+	takenAt     *time.Time
+}
+
+// jpegEXIF returns the TIFF structure of the JPEG's EXIF segment, nil if
+// data isn't a JPEG or has no EXIF before the image data
+func jpegEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Заполняющий байт перед маркером
+			i++
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Дальше сжатые данные (SOS) или конец файла (EOI)
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Маркеры без длины
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+// ifdEntry is a directory entry; value holds the value itself if it fits
+// into four bytes, the offset of the value otherwise
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffReader reads directories of a TIFF structure, bounds-checking every
+// offset since the file comes from the client
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// readEXIF extracts the orientation and capture time from a TIFF
+// structure; false if it isn't one
+func readEXIF(data []byte) (exifData, bool) {
+	var exif exifData
+	if len(data) < 8 {
+		return exif, false
+	}
+	var t tiffReader
+	switch string(data[:4]) {
+	case "II*\x00":
+		t = tiffReader{data: data, order: binary.LittleEndian}
+	case "MM\x00*":
+		t = tiffReader{data: data, order: binary.BigEndian}
+	default:
+		return exif, false
+	}
+
+	var exifIFD uint32
+	for _, entry := range t.entries(t.order.Uint32(data[4:])) {
+		switch entry.tag {
+		case tagOrientation:
+			if entry.typ == typeShort {
+				exif.orientation = int(t.order.Uint16(entry.value))
+			}
+		case tagExifIFD:
+			if entry.typ == typeLong {
+				exifIFD = t.order.Uint32(entry.value)
+			}
+		}
+	}
+	if exifIFD == 0 {
+		return exif, true
+	}
+
+	var dateTime, offset string
+	for _, entry := range t.entries(exifIFD) {
+		switch entry.tag {
+		case tagDateTimeOriginal:
+			dateTime = t.ascii(entry)
+		case tagOffsetTimeOriginal:
+			offset = t.ascii(entry)
+		}
+	}
+	exif.takenAt = parseDateTime(dateTime, offset)
+	return exif, true
+}
+
+// entries returns the entries of the directory at offset; a directory
+// that doesn't fit into the data is cut short
+func (t tiffReader) entries(offset uint32) []ifdEntry {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	count = min(count, maxIFDEntries)
+
+	entries := make([]ifdEntry, 0, count)
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(t.data) {
+			break
+		}
+		raw := t.data[start : start+12]
+		entries = append(entries, ifdEntry{
+			tag:   t.order.Uint16(raw[0:]),
+			typ:   t.order.Uint16(raw[2:]),
+			count: t.order.Uint32(raw[4:]),
+			value: raw[8:12],
+		})
+	}
+	return entries
+}
+
+// ascii returns an ASCII value without the trailing NULs, "" if it's not
+// ASCII or doesn't fit into the data
+func (t tiffReader) ascii(entry ifdEntry) string {
+	if entry.typ != typeASCII {
+		return ""
+	}
+	value := entry.value
+	if entry.count > 4 {
+		offset := uint64(t.order.Uint32(entry.value))
+		if offset+uint64(entry.count) > uint64(len(t.data)) {
+			return ""
+		}
+		value = t.data[offset : offset+uint64(entry.count)]
+	} else {
+		value = value[:entry.count]
+	}
+	return strings.TrimRight(string(value), "\x00 ")
+}
+
+// parseDateTime parses DateTimeOriginal, "2006:01:02 15:04:05" in the
+// device's local time. Without OffsetTimeOriginal, which older devices
+// don't write, the local time is taken as UTC.
+func parseDateTime(dateTime, offset string) *time.Time {
+	if dateTime == "" {
+		return nil
+	}
+	layout := "2006:01:02 15:04:05"
+	if offset != "" {
+		dateTime += offset
+		layout += "-07:00"
+	}
+	takenAt, err := time.Parse(layout, dateTime)
+	if err != nil {
+		// Пустые даты вида "0000:00:00 00:00:00" и мусор
+		return nil
+	}
+	takenAt = takenAt.UTC()
+	return &takenAt
+}
@@ -0,0 +1,49 @@
+// Package imagemeta reads the dimensions and capture time of a photo from
+// the leading bytes of its file, without decoding the pixels.
+package imagemeta
+
+import (
+	"bytes"
+	"image"
+	"time"
+
+	// Форматы, которые клиенты могут загрузить
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// HeadSize is how many leading bytes of a file Read needs. In JPEG files
+// the EXIF, ICC profile and XMP segments come before the frame header.
+const HeadSize = 256 << 10
+
+// Metadata describes a photo as shown to the user
+type Metadata struct {
+	// Width and Height are the display dimensions, with EXIF rotation
+	// applied; zero if the format is unknown or the header was cut off
+	Width  int
+	Height int
+	// TakenAt is the EXIF capture time, nil if the file has none
+	TakenAt *time.Time
+}
+
+// Read returns what it can find in head, the first bytes of an image file.
+// Unknown formats and damaged headers leave fields empty rather than fail,
+// since the metadata is optional.
+func Read(head []byte) Metadata {
+	var meta Metadata
+	if config, _, err := image.DecodeConfig(bytes.NewReader(head)); err == nil {
+		meta.Width, meta.Height = config.Width, config.Height
+	}
+
+	exif, ok := readEXIF(jpegEXIF(head))
+	if !ok {
+		return meta
+	}
+	// Ориентации 5-8 поворачивают снимок на 90 градусов
+	if exif.orientation >= 5 && exif.orientation <= 8 {
+		meta.Width, meta.Height = meta.Height, meta.Width
+	}
+	meta.TakenAt = exif.takenAt
+	return meta
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbPoolConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "connections"),
+		"Database pool connections by state (acquired, idle, constructing).",
+		[]string{"pool", "state"}, nil,
+	)
+	dbPoolMaxConns = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "max_connections"),
+		"Maximum size of the database pool.",
+		[]string{"pool"}, nil,
+	)
+	dbPoolAcquires = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "acquires_total"),
+		"Connections acquired from the database pool.",
+		[]string{"pool"}, nil,
+	)
+	dbPoolEmptyAcquires = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "empty_acquires_total"),
+		"Acquires that had to wait for a connection because the pool was empty.",
+		[]string{"pool"}, nil,
+	)
+	dbPoolAcquireSeconds = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "db_pool", "acquire_seconds_total"),
+		"Total time spent acquiring connections from the database pool.",
+		[]string{"pool"}, nil,
+	)
+)
+
+// dbPoolCollector reads the pool statistics on every scrape
+type dbPoolCollector struct {
+	name string
+	pool *pgxpool.Pool
+}
+
+// RegisterDBPool exports the statistics of the pool labelled with name,
+// e.g. primary or replica
+func RegisterDBPool(name string, pool *pgxpool.Pool) error {
+	return prometheus.Register(&dbPoolCollector{name: name, pool: pool})
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbPoolConns
+	ch <- dbPoolMaxConns
+	ch <- dbPoolAcquires
+	ch <- dbPoolEmptyAcquires
+	ch <- dbPoolAcquireSeconds
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue, float64(stat.AcquiredConns()), c.name, "acquired")
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue, float64(stat.IdleConns()), c.name, "idle")
+	ch <- prometheus.MustNewConstMetric(dbPoolConns, prometheus.GaugeValue, float64(stat.ConstructingConns()), c.name, "constructing")
+	ch <- prometheus.MustNewConstMetric(dbPoolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()), c.name)
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquires, prometheus.CounterValue, float64(stat.AcquireCount()), c.name)
+	ch <- prometheus.MustNewConstMetric(dbPoolEmptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()), c.name)
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquireSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds(), c.name)
+}
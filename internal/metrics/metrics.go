@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "syncphoto"
+
+// PushNotifications counts push notifications by type, provider and result
+var PushNotifications = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "push_notifications_total",
+		Help:      "Push notifications by type, provider and result (sent, delivered, failed, suppressed).",
+	},
+	[]string{"type", "provider", "result"},
+)
+
+// StorageOperations counts S3 operations by name and result (ok, error, failed, rejected, canceled)
+var StorageOperations = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_operations_total",
+		Help:      "S3 operations by name and result (ok, error, failed, rejected, canceled).",
+	},
+	[]string{"operation", "result"},
+)
+
+// StorageCircuitOpen is 1 while the storage circuit breaker is open or half-open
+var StorageCircuitOpen = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_circuit_open",
+		Help:      "1 while the storage circuit breaker is open or half-open, 0 when closed.",
+	},
+)
+
+// CacheRequests counts repository cache lookups by cache name and result (hit, miss)
+var CacheRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Repository cache lookups by cache name and result (hit, miss).",
+	},
+	[]string{"cache", "result"},
+)
+
+// DBQueryDuration observes database query latency by repository method and status
+var DBQueryDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by repository method and status (ok, error).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	},
+	[]string{"method", "status"},
+)
+
+// Jobs counts background jobs by kind and result (enqueued, done, retried, failed)
+var Jobs = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Background jobs by kind and result (enqueued, done, retried, failed).",
+	},
+	[]string{"kind", "result"},
+)
+
+// ActiveUsers is the number of active users in the last rolled up day by period (daily, weekly)
+var ActiveUsers = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_users",
+		Help:      "Active users in the last rolled up day by period (daily, weekly).",
+	},
+	[]string{"period"},
+)
+
+// DailyNewPairs is the number of pairs created in the last rolled up day
+var DailyNewPairs = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "daily_new_pairs",
+		Help:      "Pairs created in the last rolled up day.",
+	},
+)
+
+// DailyMomentsCompleted is the number of moments completed in the last rolled up day
+var DailyMomentsCompleted = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "daily_moments_completed",
+		Help:      "Moments with photos from both partners in the last rolled up day.",
+	},
+)
+
+// StorageUsedBytes is the size of the photo bucket at the last rollup
+var StorageUsedBytes = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "storage_used_bytes",
+		Help:      "Total size of objects in the photo bucket at the last rollup.",
+	},
+)
+
+// WSConnections is the number of WebSocket connections open on this instance
+var WSConnections = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_connections",
+		Help:      "WebSocket connections open on this instance.",
+	},
+)
+
+// WSMessages counts WebSocket messages by direction (sent, received) and
+// type; received messages that can't be parsed are counted as invalid or
+// unknown
+var WSMessages = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_messages_total",
+		Help:      "WebSocket messages by direction (sent, received) and type.",
+	},
+	[]string{"direction", "type"},
+)
+
+// WSSlowClientEvictions counts WebSocket clients disconnected because
+// their send queue filled up
+var WSSlowClientEvictions = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_slow_client_evictions_total",
+		Help:      "WebSocket clients disconnected because they could not keep up with their messages.",
+	},
+)
+
+// WSAuth counts authenticated WebSocket connections by where the client
+// sent its token: subprotocol, message or query
+var WSAuth = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_auth_total",
+		Help:      "Authenticated WebSocket connections by token source.",
+	},
+	[]string{"source"},
+)
+
+// WSReplayedEvents counts stored WebSocket events sent again when their
+// user reconnected before acking them
+var WSReplayedEvents = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_replayed_events_total",
+		Help:      "Stored WebSocket events replayed on reconnect.",
+	},
+)
+
+// WSHeartbeatTimeouts counts WebSocket clients disconnected because they
+// stopped answering pings
+var WSHeartbeatTimeouts = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_heartbeat_timeouts_total",
+		Help:      "WebSocket clients disconnected because they missed too many pongs.",
+	},
+)
+
+// DailyWSPeakConnections is the WebSocket connection peak of the last rolled up day
+var DailyWSPeakConnections = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "daily_ws_peak_connections",
+		Help:      "Peak concurrent WebSocket connections across instances in the last rolled up day.",
+	},
+)
+
+// ClientEvents counts accepted client analytics events by name
+var ClientEvents = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_events_total",
+		Help:      "Accepted client analytics events by name.",
+	},
+	[]string{"name"},
+)
+
+// HTTPRequestDuration observes HTTP request latency by method, route
+// pattern and status code
+var HTTPRequestDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	},
+	[]string{"method", "route", "status"},
+)
+
+// PhotoUploads counts photo uploads by stage: started when the upload URL
+// is issued, completed when the upload is verified in storage, rejected
+// when the object is missing or not the declared image
+var PhotoUploads = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "photo_uploads_total",
+		Help:      "Photo uploads by stage (started, completed, rejected).",
+	},
+	[]string{"stage"},
+)
+
+// SyncSessions counts sync sessions by outcome: completed once both photos
+// are confirmed, missed when a countdown session passed its deadline
+var SyncSessions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_sessions_total",
+		Help:      "Sync sessions by outcome (completed, missed).",
+	},
+	[]string{"result"},
+)
+
+// UploadsReaped counts what the reaper of abandoned uploads removed: photo
+// rows and the objects that were uploaded for them
+var UploadsReaped = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_reaped_total",
+		Help:      "Abandoned uploads removed by the reaper, by item (photo, object).",
+	},
+	[]string{"item"},
+)
+
+// UploadBytesReaped is the total size of the objects of abandoned uploads
+var UploadBytesReaped = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_reaped_total",
+		Help:      "Total size of the objects of abandoned uploads removed by the reaper.",
+	},
+)
+
+// Handler returns the HTTP handler serving metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/services"
+)
+
+// Activity records the authenticated user as active for usage statistics.
+// Must run after AuthMiddleware.
+func Activity(statsService *services.StatsService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID := GetUserID(r.Context()); userID != "" {
+				statsService.RecordActivity(r.Context(), userID)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"sync-photo-backend/internal/services"
+)
+
+// AdminMiddleware protects admin endpoints. It accepts either the static
+// admin token from config or an admin JWT issued by "create-admin-token".
+func AdminMiddleware(token string, userService *services.UserService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || provided == "" {
+				respondError(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			if _, err := AuthenticateAdmin(provided, token, userService); err != nil {
+				respondError(w, "Invalid admin token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthenticateAdmin checks a bearer token against the static admin token and
+// admin JWTs and returns the caller: "admin" for the static token, the JWT
+// subject otherwise. The gRPC server uses it too.
+func AuthenticateAdmin(provided, token string, userService *services.UserService) (string, error) {
+	if token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+		return "admin", nil
+	}
+	return userService.ValidateAdminJWT(provided)
+}
@@ -2,13 +2,21 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/i18n"
+	"sync-photo-backend/internal/models"
 	"sync-photo-backend/internal/services"
 )
 
+// AppIDHeader names the white-label app a new user is created in
+const AppIDHeader = "X-App-ID"
+
 type contextKey string
 
 const userIDKey contextKey = "user_id"
@@ -30,18 +38,61 @@ func AuthMiddleware(userService *services.UserService) func(http.Handler) http.H
 			}
 
 			token := parts[1]
-			userID, err := userService.ValidateJWT(token)
+			claims, err := userService.ValidateJWT(token)
 			if err != nil {
 				respondError(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			setAccessLogUserID(r.Context(), claims.UserID)
+			errreport.SetUser(r.Context(), claims.UserID)
+
+			// Токен сессии отзывается при выходе раньше, чем истекает
+			active, err := userService.SessionActive(r.Context(), claims)
+			if err != nil {
+				if !RespondServiceError(w, err, "Failed to authenticate") {
+					errreport.Capture(r.Context(), err)
+				}
+				return
+			}
+			if !active {
+				respondError(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// Токены удаленных аккаунтов остаются валидными, поэтому аккаунт проверяется по базе
+			user, err := userService.ActiveUser(r.Context(), claims.UserID)
+			if errors.Is(err, domain.ErrUserNotFound) {
+				respondError(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				if !RespondServiceError(w, err, "Failed to authenticate") {
+					errreport.Capture(r.Context(), err)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			if _, ok := i18n.FromContext(ctx); !ok {
+				ctx = userLocale(ctx, w, user)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// userLocale applies the locale the user saved, for clients that don't send
+// a supported Accept-Language
+func userLocale(ctx context.Context, w http.ResponseWriter, user *models.User) context.Context {
+	locale, ok := services.SavedLocale(user)
+	if !ok {
+		return ctx
+	}
+	w.Header().Set(ContentLanguageHeader, locale)
+	return i18n.WithLocale(ctx, locale)
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(ctx context.Context) string {
 	userID, ok := ctx.Value(userIDKey).(string)
@@ -51,17 +102,49 @@ func GetUserID(ctx context.Context) string {
 	return userID
 }
 
-// respondError sends an error response
+// Machine-readable error codes sent by middleware. /api/v2 handlers use the
+// same vocabulary so clients can switch on code for every error.
+const (
+	ErrCodeInvalidRequest   = "invalid_request"
+	ErrCodeUnauthorized     = "unauthorized"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeMethodNotAllowed = "method_not_allowed"
+	ErrCodeConflict         = "conflict"
+	ErrCodeBodyTooLarge     = "body_too_large"
+	ErrCodeUnprocessable    = "unprocessable"
+	ErrCodeRateLimited      = "rate_limited"
+	ErrCodeTimeout          = "timeout"
+	ErrCodeInternal         = "internal"
+)
+
+// errorCodes maps the statuses middleware responds with to their codes
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            ErrCodeInvalidRequest,
+	http.StatusUnauthorized:          ErrCodeUnauthorized,
+	http.StatusNotFound:              ErrCodeNotFound,
+	http.StatusMethodNotAllowed:      ErrCodeMethodNotAllowed,
+	http.StatusConflict:              ErrCodeConflict,
+	http.StatusRequestEntityTooLarge: ErrCodeBodyTooLarge,
+	http.StatusUnprocessableEntity:   ErrCodeUnprocessable,
+	http.StatusTooManyRequests:       ErrCodeRateLimited,
+	http.StatusGatewayTimeout:        ErrCodeTimeout,
+	http.StatusInternalServerError:   ErrCodeInternal,
+}
+
+// respondError sends an error response with the code of the status
 func respondError(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	w.Write([]byte(`{"error":"` + message + `"}`))
+	respondErrorCode(w, errorCodes[statusCode], message, statusCode)
 }
 
-// ValidateWebSocketToken validates JWT token from WebSocket query parameter
-func ValidateWebSocketToken(token string, userService *services.UserService) (string, error) {
-	if token == "" {
-		return "", fmt.Errorf("token required")
-	}
-	return userService.ValidateJWT(token)
+// respondErrorCode sends an error response with the given code and its
+// localized text
+func respondErrorCode(w http.ResponseWriter, code, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":      message,
+		"code":       code,
+		"message":    Message(w, code),
+		"request_id": w.Header().Get(RequestIDHeader),
+	})
 }
@@ -0,0 +1,19 @@
+package middleware
+
+import "net/http"
+
+// MaxBodySize limits the request body to n bytes. Reads past the limit
+// fail with *http.MaxBytesError, so a client can't stream an unbounded
+// body into a decoder.
+func MaxBodySize(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				respondError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
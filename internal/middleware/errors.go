@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/services"
+)
+
+// Error codes of expected service failures, shared by /api/v1 and /api/v2
+const (
+	ErrCodeInvalidPartnerCode   = "invalid_partner_code"
+	ErrCodeInvalidCursor        = "invalid_cursor"
+	ErrCodeInvalidID            = "invalid_id"
+	ErrCodeUserNotFound         = "user_not_found"
+	ErrCodePairNotFound         = "pair_not_found"
+	ErrCodePhotoNotFound        = "photo_not_found"
+	ErrCodeSyncSessionNotFound  = "sync_session_not_found"
+	ErrCodeSyncSessionMissed    = "sync_session_missed"
+	ErrCodePartnerNotFound      = "partner_not_found"
+	ErrCodeNotInPair            = "not_in_pair"
+	ErrCodeNotPairMember        = "not_pair_member"
+	ErrCodeSelfPair             = "self_pair"
+	ErrCodeAlreadyPaired        = "already_paired"
+	ErrCodePartnerAlreadyPaired = "partner_already_paired"
+	ErrCodePartnerOffline       = "partner_offline"
+	ErrCodeStorageUnavailable   = "storage_unavailable"
+	ErrCodeUnsupportedLocale    = "unsupported_locale"
+	ErrCodeUnknownApp           = "unknown_app"
+	ErrCodeNotPhotoOwner        = "not_photo_owner"
+	ErrCodePhotoNotUploaded     = "photo_not_uploaded"
+	ErrCodeInvalidPhotoUpload   = "invalid_photo_upload"
+	ErrCodeUnsupportedPhotoType = "unsupported_photo_type"
+	ErrCodePhotoTooLarge        = "photo_too_large"
+	ErrCodeAccountDeleted       = "account_deleted"
+	ErrCodePreconditionFailed   = "precondition_failed"
+	ErrCodeInvalidRefreshToken  = "invalid_refresh_token"
+)
+
+// serviceErrors maps the errors services return for expected failures to
+// their responses. The message is the error itself; with detail set it is
+// the whole wrapped error, which explains what exactly was invalid.
+var serviceErrors = []struct {
+	err    error
+	status int
+	code   string
+	detail bool
+}{
+	{domain.ErrInvalidPartnerCode, http.StatusBadRequest, ErrCodeInvalidPartnerCode, false},
+	{services.ErrInvalidCursor, http.StatusBadRequest, ErrCodeInvalidCursor, false},
+	{domain.ErrInvalidID, http.StatusBadRequest, ErrCodeInvalidID, true},
+	{services.ErrInvalidEvent, http.StatusBadRequest, ErrCodeInvalidRequest, true},
+	{services.ErrInvalidPairSettings, http.StatusBadRequest, ErrCodeInvalidRequest, true},
+	{services.ErrUploadSizeRequired, http.StatusBadRequest, ErrCodeInvalidRequest, false},
+	{services.ErrUnsupportedLocale, http.StatusBadRequest, ErrCodeUnsupportedLocale, true},
+	{services.ErrUnknownApp, http.StatusBadRequest, ErrCodeUnknownApp, true},
+	{domain.ErrInvalidRefreshToken, http.StatusUnauthorized, ErrCodeInvalidRefreshToken, false},
+	{domain.ErrNotPairMember, http.StatusForbidden, ErrCodeNotPairMember, false},
+	{domain.ErrNotPhotoOwner, http.StatusForbidden, ErrCodeNotPhotoOwner, false},
+	{domain.ErrUserDeleted, http.StatusForbidden, ErrCodeAccountDeleted, false},
+	{domain.ErrNotInPair, http.StatusNotFound, ErrCodeNotInPair, false},
+	{domain.ErrPartnerNotFound, http.StatusNotFound, ErrCodePartnerNotFound, false},
+	{domain.ErrUserNotFound, http.StatusNotFound, ErrCodeUserNotFound, false},
+	{domain.ErrPairNotFound, http.StatusNotFound, ErrCodePairNotFound, false},
+	{domain.ErrPhotoNotFound, http.StatusNotFound, ErrCodePhotoNotFound, false},
+	{domain.ErrSyncSessionNotFound, http.StatusNotFound, ErrCodeSyncSessionNotFound, false},
+	{domain.ErrSelfPair, http.StatusConflict, ErrCodeSelfPair, false},
+	{domain.ErrAlreadyPaired, http.StatusConflict, ErrCodeAlreadyPaired, false},
+	{domain.ErrPartnerAlreadyPaired, http.StatusConflict, ErrCodePartnerAlreadyPaired, false},
+	{domain.ErrPartnerOffline, http.StatusConflict, ErrCodePartnerOffline, false},
+	{domain.ErrSyncSessionMissed, http.StatusConflict, ErrCodeSyncSessionMissed, false},
+	{domain.ErrPhotoNotUploaded, http.StatusConflict, ErrCodePhotoNotUploaded, false},
+	{domain.ErrInvalidPhotoUpload, http.StatusUnprocessableEntity, ErrCodeInvalidPhotoUpload, true},
+	{domain.ErrUnsupportedPhotoType, http.StatusUnsupportedMediaType, ErrCodeUnsupportedPhotoType, true},
+	{domain.ErrPhotoTooLarge, http.StatusRequestEntityTooLarge, ErrCodePhotoTooLarge, true},
+	{domain.ErrPreconditionFailed, http.StatusPreconditionFailed, ErrCodePreconditionFailed, false},
+	{services.ErrStorageUnavailable, http.StatusServiceUnavailable, ErrCodeStorageUnavailable, false},
+}
+
+// ServiceErrorCode returns the code of an expected service failure, for
+// transports without HTTP statuses; ok is false for any other error
+func ServiceErrorCode(err error) (code string, ok bool) {
+	for _, mapping := range serviceErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping.code, true
+		}
+	}
+	return "", false
+}
+
+// RespondServiceError responds with the status and code of an expected
+// service failure and reports whether err was one. Any other error is
+// answered with a 500 and message, so internals never reach the client;
+// the caller logs and reports it.
+func RespondServiceError(w http.ResponseWriter, err error, message string) bool {
+	for _, mapping := range serviceErrors {
+		if !errors.Is(err, mapping.err) {
+			continue
+		}
+		text := mapping.err.Error()
+		if mapping.detail {
+			text = err.Error()
+		}
+		if mapping.status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "30")
+		}
+		respondErrorCode(w, mapping.code, text, mapping.status)
+		return true
+	}
+	respondErrorCode(w, ErrCodeInternal, message, http.StatusInternalServerError)
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/config"
+)
+
+// FaultHeader marks responses affected by fault injection, so injected
+// failures aren't mistaken for real ones while debugging a client
+const FaultHeader = "X-Fault-Injected"
+
+// injectedStatuses are the failures a client is expected to retry
+var injectedStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+}
+
+// Faults delays and fails a share of requests as configured in
+// debug.faults. It does nothing when fault injection is disabled.
+func Faults(cfg config.FaultsConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Latency > 0 && rand.Float64() < cfg.LatencyRate {
+				w.Header().Add(FaultHeader, "latency")
+				select {
+				case <-time.After(cfg.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			if rand.Float64() < cfg.ErrorRate {
+				w.Header().Add(FaultHeader, "error")
+				respondError(w, "Injected fault", injectedStatuses[rand.IntN(len(injectedStatuses))])
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// replayedHeaders are the response headers stored with the body and
+// replayed with it, like the Location of a created resource
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// responseRecorder captures the response so it can be stored for replay
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the stored response when a client retries a request
+// with the same Idempotency-Key header. Must run after AuthMiddleware.
+func Idempotency(idempotencyService *services.IdempotencyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				respondError(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				respondError(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			ctx := r.Context()
+			userID := GetUserID(ctx)
+
+			record, err := idempotencyService.Begin(ctx, userID, key, requestHash)
+			if err != nil {
+				switch {
+				case errors.Is(err, services.ErrIdempotencyKeyReused):
+					respondError(w, err.Error(), http.StatusUnprocessableEntity)
+				case errors.Is(err, services.ErrIdempotencyInProgress):
+					respondError(w, err.Error(), http.StatusConflict)
+				default:
+					log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to check idempotency key")
+					respondError(w, "Failed to process request", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if record != nil {
+				// Ответы, сохраненные до белого списка заголовков, - JSON
+				w.Header().Set("Content-Type", "application/json")
+				for name, value := range record.ResponseHeaders {
+					w.Header().Set(name, value)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(*record.StatusCode)
+				w.Write(record.ResponseBody)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			// Сохраняем ответ даже если контекст запроса уже отменен
+			saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+
+			if rec.status >= http.StatusInternalServerError || rec.status == 0 {
+				// Ошибку сервера клиент должен иметь возможность повторить
+				if err := idempotencyService.Abort(saveCtx, userID, key); err != nil {
+					log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to release idempotency key")
+				}
+				return
+			}
+			headers := make(map[string]string)
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+			if err := idempotencyService.Complete(saveCtx, userID, key, rec.status, headers, rec.body.Bytes()); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to store idempotent response")
+			}
+		})
+	}
+}
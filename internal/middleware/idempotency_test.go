@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sync-photo-backend/internal/repository/memory"
+	"sync-photo-backend/internal/services"
+)
+
+// TestIdempotencyReplayHeaders checks that a replayed response keeps the
+// headers of the original one, like the Location of a created pair
+func TestIdempotencyReplayHeaders(t *testing.T) {
+	calls := 0
+	create := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/api/v2/pairs/pair-1")
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("X-Not-Replayed", "1")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"pair-1"}`))
+	})
+	service := services.NewIdempotencyService(memory.NewIdempotencyRepository(memory.NewStore()))
+	handler := Idempotency(service)(create)
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/pairs", strings.NewReader(`{"partner_code":"ABC123"}`))
+		req.Header.Set(idempotencyHeader, "key-1")
+		req = req.WithContext(context.WithValue(req.Context(), userIDKey, "user-1"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	post()
+	replay := post()
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if replay.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", replay.Code, http.StatusCreated)
+	}
+	if got := replay.Body.String(); got != `{"id":"pair-1"}` {
+		t.Errorf("body = %s", got)
+	}
+	for name, want := range map[string]string{
+		"Idempotent-Replayed": "true",
+		"Location":            "/api/v2/pairs/pair-1",
+		"ETag":                `"1"`,
+		"Content-Type":        "application/json",
+		"X-Not-Replayed":      "",
+	} {
+		if got := replay.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
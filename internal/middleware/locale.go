@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/i18n"
+)
+
+// ContentLanguageHeader is the response header carrying the negotiated locale
+const ContentLanguageHeader = "Content-Language"
+
+// Locale negotiates the locale of the request from Accept-Language and
+// reports it in the Content-Language response header, which error responses
+// read like they read the request ID. Without a matching Accept-Language
+// the locale stays the default until AuthMiddleware finds the user's saved one.
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale, ok := i18n.Negotiate(r.Header.Get("Accept-Language"), "")
+		w.Header().Set(ContentLanguageHeader, locale)
+		if ok {
+			r = r.WithContext(i18n.WithLocale(r.Context(), locale))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Message returns the localized text for an error code in the locale of
+// the response, "" for codes without a message
+func Message(w http.ResponseWriter, code string) string {
+	return i18n.Message(w.Header().Get(ContentLanguageHeader), code)
+}
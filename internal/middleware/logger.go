@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type accessLogKey struct{}
+
+// accessLogInfo carries values discovered by inner middleware (e.g. the
+// authenticated user) back out to the access logger
+type accessLogInfo struct {
+	userID string
+}
+
+// AccessLogger logs one structured event per HTTP request
+func AccessLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &accessLogInfo{}
+		ctx := context.WithValue(r.Context(), accessLogKey{}, info)
+
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		var event *zerolog.Event
+		switch {
+		case status >= http.StatusInternalServerError:
+			event = log.Error()
+		case status >= http.StatusBadRequest:
+			event = log.Warn()
+		default:
+			event = log.Info()
+		}
+
+		route := ""
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+
+		event = event.
+			Str("method", r.Method).
+			Str("route", route).
+			Str("path", r.URL.Path).
+			Int("status", status).
+			Int("bytes", ww.BytesWritten()).
+			Dur("latency", time.Since(start)).
+			Str("remote_ip", r.RemoteAddr)
+
+		if requestID := chiMiddleware.GetReqID(ctx); requestID != "" {
+			event = event.Str("request_id", requestID)
+		}
+		if info.userID != "" {
+			event = event.Str("user_id", info.userID)
+		}
+
+		event.Msg("HTTP request")
+	})
+}
+
+// setAccessLogUserID records the authenticated user for the access log
+func setAccessLogUserID(ctx context.Context, userID string) {
+	if info, ok := ctx.Value(accessLogKey{}).(*accessLogInfo); ok {
+		info.userID = userID
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"sync-photo-backend/internal/services"
+)
+
+// ErrCodeMaintenance tells clients to show a "back soon" screen and retry
+// after Retry-After seconds
+const ErrCodeMaintenance = "maintenance"
+
+// MaintenanceResponse is returned by API routes while maintenance mode is on
+type MaintenanceResponse struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	Message    string `json:"message,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	RetryAfter int    `json:"retry_after"` // seconds
+}
+
+// Maintenance rejects requests with 503 while maintenance mode is on.
+// Admin, health and metrics routes must not use it.
+func Maintenance(maintenance *services.MaintenanceService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := maintenance.Status()
+			if !status.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter := int(status.RetryAfter.Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(MaintenanceResponse{
+				Error:      status.Message,
+				Code:       ErrCodeMaintenance,
+				Message:    Message(w, ErrCodeMaintenance),
+				RequestID:  w.Header().Get(RequestIDHeader),
+				RetryAfter: retryAfter,
+			})
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"sync-photo-backend/internal/ratelimit"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RateLimitByIP limits requests per client IP. Must run after RealIP, which
+// resolves the client behind trusted proxies.
+func RateLimitByIP(limiter ratelimit.Limiter) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) string {
+		if addr, ok := parseAddr(r.RemoteAddr); ok {
+			return "ip:" + addr.String()
+		}
+		return "ip:" + r.RemoteAddr
+	})
+}
+
+// RateLimitByUser limits requests per authenticated user; scope keeps
+// the counters of different routes apart. Must run after AuthMiddleware.
+func RateLimitByUser(limiter ratelimit.Limiter, scope string) func(http.Handler) http.Handler {
+	return rateLimit(limiter, func(r *http.Request) string {
+		return scope + ":" + GetUserID(r.Context())
+	})
+}
+
+// rateLimit rejects requests over the limit of their key with 429 and
+// Retry-After
+func rateLimit(limiter ratelimit.Limiter, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := limiter.Allow(r.Context(), key(r))
+			if err != nil {
+				// Недоступность хранилища лимитов не должна ронять API
+				log.Ctx(r.Context()).Error().Err(err).Msg("Rate limit check failed")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setRateLimitHeaders(w, res)
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ResetSeconds(res)))
+				respondError(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ConcurrencyLimit caps the number of requests processed at the same time
+// across the instance. Long-lived connections like /ws must not use it.
+func ConcurrencyLimit(max int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if max <= 0 {
+			return next
+		}
+		slots := make(chan struct{}, max)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "1")
+				respondError(w, "Server is busy, try again later", http.StatusTooManyRequests)
+			}
+		})
+	}
+}
+
+// setRateLimitHeaders sets the IETF draft RateLimit-* headers
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ResetSeconds(res)))
+}
+
+// ResetSeconds returns the whole seconds until the limit resets, the
+// value of Retry-After
+func ResetSeconds(res ratelimit.Result) int {
+	return int(math.Ceil(res.Reset.Seconds()))
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5123", nil, "", "203.0.113.7"},
+		{"spoofed forwarded from untrusted peer", "203.0.113.7:5123", []string{"198.51.100.1"}, "", "203.0.113.7"},
+		{"spoofed real ip from untrusted peer", "203.0.113.7:5123", nil, "198.51.100.1", "203.0.113.7"},
+		{"client behind proxy", "10.0.0.2:443", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"client prepends a spoofed hop", "10.0.0.2:443", []string{"198.51.100.1, 203.0.113.7"}, "", "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:443", []string{"203.0.113.7, 10.0.0.3", "10.0.0.4"}, "", "203.0.113.7"},
+		{"garbage hop", "10.0.0.2:443", []string{"203.0.113.7, nonsense, 10.0.0.3"}, "", "10.0.0.3"},
+		{"real ip from proxy", "10.0.0.2:443", nil, "203.0.113.7", "203.0.113.7"},
+		{"proxy without headers", "10.0.0.2:443", nil, "", "10.0.0.2"},
+		{"ipv4-mapped peer", "[::ffff:203.0.113.7]:5123", nil, "", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"sync-photo-backend/internal/errreport"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer turns a panicking handler into a JSON 500 with the request ID,
+// which the mobile client can parse, instead of an empty response. The
+// panic and its stack are logged and sent to the error tracker.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// Штатный способ оборвать ответ, net/http его не логирует
+				panic(p)
+			}
+
+			errreport.CapturePanic(r.Context(), p, "http.handler",
+				"method", r.Method, "path", r.URL.Path)
+
+			// Ответ уже начат или соединение передано WebSocket
+			if ww.Status() != 0 || r.Header.Get("Upgrade") != "" {
+				return
+			}
+			respondError(ww, "Internal server error", http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
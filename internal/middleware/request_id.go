@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader is the response header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns chi's request ID in the X-Request-ID response header and
+// attaches it to the request-scoped logger (log.Ctx). Must run after
+// chiMiddleware.RequestID.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		requestID := chiMiddleware.GetReqID(ctx)
+		if requestID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := log.With().Str("request_id", requestID).Logger()
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(ctx)))
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// allowMethods are the methods MethodNotAllowed checks for the Allow header
+var allowMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// NotFound answers requests to unknown paths with a JSON error
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(w, "Not found", http.StatusNotFound)
+}
+
+// MethodNotAllowed answers requests whose path is routed for other methods
+// with a JSON error. chi only fills the Allow header in its default handler,
+// so the allowed methods are found by matching the path against routes.
+func MethodNotAllowed(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range allowMethods {
+			if routes.Match(chi.NewRouteContext(), method, r.URL.Path) {
+				allowed = append(allowed, method)
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
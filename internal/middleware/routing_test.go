@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newRoutingTestRouter routes a few paths with the NotFound and
+// MethodNotAllowed handlers installed as in serve
+func newRoutingTestRouter() http.Handler {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.NotFound(NotFound)
+	r.MethodNotAllowed(MethodNotAllowed(r))
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Post("/users", ok)
+		r.Get("/users/me", ok)
+		r.Patch("/users/me", ok)
+		r.Delete("/users/me", ok)
+	})
+	return r
+}
+
+func TestNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRoutingTestRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/unknown", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	assertErrorBody(t, rec, ErrCodeNotFound, "Not found")
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		path      string
+		method    string
+		wantAllow string
+	}{
+		{"/api/v2/users", http.MethodGet, "POST"},
+		{"/api/v2/users/me", http.MethodPost, "GET, PATCH, DELETE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newRoutingTestRouter().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			assertErrorBody(t, rec, ErrCodeMethodNotAllowed, "Method not allowed")
+		})
+	}
+}
+
+// assertErrorBody checks that the response is a JSON error with the code
+// and message
+func assertErrorBody(t *testing.T, rec *httptest.ResponseRecorder, wantCode, wantError string) {
+	t.Helper()
+
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body, err)
+	}
+	if body["code"] != wantCode {
+		t.Errorf("code = %q, want %q", body["code"], wantCode)
+	}
+	if body["error"] != wantError {
+		t.Errorf("error = %q, want %q", body["error"], wantError)
+	}
+}
@@ -1,30 +1,359 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id"`
-	Code      string    `json:"code"`
-	Token     string    `json:"token"`
-	PushToken *string   `json:"push_token,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                  string    `json:"id"`
+	AppID               string    `json:"app_id"`
+	Code                string    `json:"code"`
+	Token               string    `json:"-"` // only sent in the response to creating the user
+	RefreshToken        string    `json:"-"` // same; empty unless sessions are enabled
+	PushToken           *string   `json:"push_token,omitempty"`
+	QuietHoursStart     *string   `json:"quiet_hours_start,omitempty"` // "HH:MM" in Timezone
+	QuietHoursEnd       *string   `json:"quiet_hours_end,omitempty"`   // "HH:MM" in Timezone
+	Timezone            string    `json:"timezone"`
+	AlwaysAllowTriggers bool      `json:"always_allow_triggers"`
+	TimeSensitive       bool      `json:"time_sensitive_triggers"`
+	Locale              *string   `json:"locale,omitempty"` // nil uses Accept-Language or English
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	// DeletedAt is set while a deleted account can still be restored
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// LastSeenAt is when the user was last connected by WebSocket. It
+	// changes without UpdatedAt, so it's sent in partner_status only and
+	// isn't kept by the user cache; read it with UserRepository.LastSeen.
+	LastSeenAt *time.Time `json:"-"`
 }
 
 // Pair represents a pair of users
 type Pair struct {
 	ID        string    `json:"id"`
+	AppID     string    `json:"app_id"`
 	UserAID   string    `json:"user_a_id"`
 	UserBID   string    `json:"user_b_id"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set once the pair is broken; its photos are kept and
+	// come back if the same users pair again
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // Photo represents a photo taken by a user in a pair
 type Photo struct {
+	ID     string `json:"id"`
+	PairID string `json:"pair_id"`
+	UserID string `json:"user_id"`
+	S3Key  string `json:"-"`
+	S3URL  string `json:"s3_url"` // built from S3Key by PhotoService, not stored
+	// SessionID is the sync session the photo was taken for, if any
+	SessionID *string `json:"session_id,omitempty"`
+	// SizeBytes and ContentType describe the stored object once uploaded;
+	// until then ContentType is the one declared for the upload, if any
+	SizeBytes   int64  `json:"size_bytes,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Width and Height are the display dimensions read from the uploaded
+	// file, EXIF rotation applied; absent for formats the server can't read
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// DeviceTakenAt is the capture time from the file's EXIF, if any;
+	// TakenAt is when the server issued the upload
+	DeviceTakenAt *time.Time `json:"device_taken_at,omitempty"`
+	// UploadedAt is set once the object was verified in storage; photos
+	// without it are pending and not listed
+	UploadedAt *time.Time `json:"uploaded_at,omitempty"`
+	TakenAt    time.Time  `json:"taken_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	// ReactionCount and CommentCount are filled in by PhotoService for
+	// listings, not stored
+	ReactionCount int `json:"reaction_count"`
+	CommentCount  int `json:"comment_count"`
+}
+
+// PhotoUpload is what storage reports about an uploaded photo object and
+// what was read from its header
+type PhotoUpload struct {
+	SizeBytes     int64
+	ContentType   string
+	Width         int
+	Height        int
+	DeviceTakenAt *time.Time
+	UploadedAt    time.Time
+}
+
+// SyncSession is one trigger_photo: the photos both partners upload for it
+// reference the session, which completes once both halves are confirmed
+type SyncSession struct {
+	ID          string `json:"id"`
+	PairID      string `json:"pair_id"`
+	InitiatorID string `json:"initiator_id"`
+	// TriggeredAt is the trigger time sent by the initiator, or when the server got it
+	TriggeredAt time.Time `json:"triggered_at"`
+	// CaptureAt is when the countdown told both partners to take their
+	// photos, nil if the partner was triggered by push without one
+	CaptureAt *time.Time `json:"capture_at,omitempty"`
+	// Deadline is when both halves must be uploaded by; a session still
+	// incomplete then is marked missed at MissedAt and gets no more photos
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	MissedAt    *time.Time `json:"missed_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// Photos are the confirmed halves, the initiator's first
+	Photos []*Photo `json:"photos"`
+}
+
+// Composite is the photos of both partners of one moment stitched into one image
+type Composite struct {
+	ID     string `json:"id"`
+	PairID string `json:"pair_id"`
+	S3Key  string `json:"-"`
+	S3URL  string `json:"s3_url"` // built from S3Key by CompositorService, not stored
+	// PhotoIDs are the stitched photos, left to right or top to bottom
+	PhotoIDs  []string  `json:"photo_ids"`
+	Layout    string    `json:"layout"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reaction is an emoji a user put on a photo of their pair; each emoji
+// counts once per user
+type Reaction struct {
 	ID        string    `json:"id"`
-	PairID    string    `json:"pair_id"`
+	PhotoID   string    `json:"photo_id"`
 	UserID    string    `json:"user_id"`
-	S3URL     string    `json:"s3_url"`
-	TakenAt   time.Time `json:"taken_at"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Comment is a text a user left on a photo of their pair
+type Comment struct {
+	ID        string    `json:"id"`
+	PhotoID   string    `json:"photo_id"`
+	UserID    string    `json:"user_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptSettings is how a pair gets its daily photo prompt
+type PromptSettings struct {
+	PairID  string `json:"-"`
+	Enabled bool   `json:"enabled"`
+	// Time is the local "HH:MM" of the prompt in the timezone of the pair,
+	// see PairSettings; nil means prompts.time
+	Time      *string   `json:"time"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PairSettings are the options both partners of a pair share
+type PairSettings struct {
+	PairID string
+	// MuteStart and MuteEnd are the local "HH:MM" between which no push
+	// about the pair is sent, as in quiet hours; nil turns muting off
+	MuteStart *string
+	MuteEnd   *string
+	// Countdown is the countdown before a capture; nil means capture.countdown
+	Countdown *time.Duration
+	// Timezone is the IANA zone of the pair's local times, including its
+	// daily prompt; nil means the timezone of user A
+	Timezone *string
+	// AutoComposite stitches every completed moment of the pair
+	AutoComposite bool
+	UpdatedAt     time.Time
+}
+
+// DailyPrompt is a photo prompt sent to a pair. It is completed once both
+// partners uploaded a photo before it expired.
+type DailyPrompt struct {
+	ID     string `json:"id"`
+	PairID string `json:"pair_id"`
+	// Day is the pair's local date the prompt was sent on
+	Day              time.Time  `json:"day"`
+	Prompt           string     `json:"prompt"`
+	SentAt           time.Time  `json:"sent_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	UserACompletedAt *time.Time `json:"user_a_completed_at,omitempty"`
+	UserBCompletedAt *time.Time `json:"user_b_completed_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+}
+
+// DuePrompt is a pair whose prompt for its local Day is due
+type DuePrompt struct {
+	Pair *Pair
+	Day  time.Time
+}
+
+// PhotoCounts is how many reactions and comments a photo has
+type PhotoCounts struct {
+	Reactions int
+	Comments  int
+}
+
+// RefreshToken is one refresh token of a session. Refreshing revokes it and
+// issues the next token of the same session.
+type RefreshToken struct {
+	ID        string
+	SessionID string
+	UserID    string
+	// TokenHash is the SHA-256 of the token; the token itself isn't stored
+	TokenHash []byte
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// IdempotencyRecord stores the response to a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	UserID       string
+	Key          string
+	RequestHash  string
+	StatusCode   *int // nil while the original request is in progress
+	ResponseBody []byte
+	// ResponseHeaders are the replayed headers of the response, see
+	// middleware.Idempotency
+	ResponseHeaders map[string]string
+	CreatedAt       time.Time
+}
+
+// Job statuses
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// Job is a unit of background work stored in the jobs queue
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// OutboxEvent is a side effect (WebSocket/push notification) recorded in the
+// same transaction as the domain change that caused it
+type OutboxEvent struct {
+	ID        string
+	Type      string
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// OutboxResult is the outcome of handling one outbox event
+type OutboxResult struct {
+	// Err is nil when the event was delivered
+	Err error
+	// RetryAt is when to try again; zero means give up and mark the event processed
+	RetryAt time.Time
+}
+
+// UserEvent is a WebSocket message kept for a user until their client acks
+// it, so that it is replayed when they reconnect
+type UserEvent struct {
+	Seq       int64
+	UserID    string
+	Type      string
+	Message   json.RawMessage
+	CreatedAt time.Time
+	AckedAt   *time.Time
+}
+
+// DomainEvent is an entry of the append-only domain event log. Payload is
+// the JSON of the typed payload for Type.
+type DomainEvent struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	AppID      string          `json:"app_id"`
+	UserID     *string         `json:"user_id,omitempty"`
+	PairID     *string         `json:"pair_id,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// MomentWindow is how close photos of both partners must be to count as one moment
+const MomentWindow = 2 * time.Minute
+
+// Moment groups photos of a pair taken close together, normally one photo
+// per partner after a trigger
+type Moment struct {
+	ID        string    `json:"id"` // ID of the earliest photo
+	Photos    []*Photo  `json:"photos"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	// Complete is true when both partners contributed a photo
+	Complete bool `json:"complete"`
+}
+
+// DailyStats is the nightly rollup of service usage for one UTC day
+type DailyStats struct {
+	Day               time.Time `json:"day"`
+	DailyActiveUsers  int       `json:"daily_active_users"`
+	WeeklyActiveUsers int       `json:"weekly_active_users"`
+	NewUsers          int       `json:"new_users"`
+	NewPairs          int       `json:"new_pairs"`
+	MomentsCompleted  int       `json:"moments_completed"`
+	StorageObjects    int64     `json:"storage_objects"`
+	StorageBytes      int64     `json:"storage_bytes"`
+	WSPeakConnections int       `json:"ws_peak_connections"`
+	ComputedAt        time.Time `json:"computed_at"`
+}
+
+// ClientEvent is an analytics event reported by the mobile client
+type ClientEvent struct {
+	ID         string                 `json:"id"`
+	UserID     string                 `json:"user_id"`
+	Name       string                 `json:"name"`
+	Properties map[string]interface{} `json:"properties"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	ReceivedAt time.Time              `json:"received_at"`
+}
+
+// Webhook is an endpoint configured by an admin to receive domain events
+type Webhook struct {
+	ID string `json:"id"`
+	// URL receives a signed POST for every subscribed event
+	URL string `json:"url"`
+	// Secret signs payloads; it is only returned when the webhook is created
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events"`
+	// AppID limits the webhook to one app; nil receives events of all apps
+	AppID     *string   `json:"app_id,omitempty"`
+	Enabled   bool      `json:"enabled"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent to a webhook, kept as the delivery log
+type WebhookDelivery struct {
+	ID        string          `json:"id"`
+	WebhookID string          `json:"webhook_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	// ResponseStatus is the HTTP status of the last attempt, if one was received
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	LastError      *string   `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
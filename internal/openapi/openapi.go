@@ -0,0 +1,331 @@
+// Package openapi builds an OpenAPI 3 document from route annotations and
+// the Go types used as request and response bodies
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI specification version of generated documents
+const Version = "3.0.3"
+
+// Security requirements of a route
+const (
+	AuthNone  = ""
+	AuthUser  = "bearerAuth" // user token from POST /api/v1/users
+	AuthAdmin = "adminAuth"  // static admin token or admin JWT
+)
+
+// Document is the root of an OpenAPI document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+
+	types map[string]reflect.Type // component name -> Go type
+}
+
+// Info holds the API metadata
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how a route authenticates
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation describes one method on a path
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes one response status of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by the API
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Route annotates one HTTP endpoint. Request and response bodies are given
+// as zero values of their Go types, e.g. CreatePairRequest{}.
+type Route struct {
+	Method  string
+	Path    string // chi-style pattern; {param} segments become path parameters
+	ID      string
+	Summary string
+	Tag     string
+	Auth    string
+	Query   []Parameter
+	Headers []Parameter
+	Request interface{}
+	// Responses maps status codes to body types; nil means no body
+	Responses map[int]interface{}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Build creates the document for routes
+func Build(title, version string, routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]map[string]*Operation),
+		types:   make(map[string]reflect.Type),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*SecurityScheme{
+				AuthUser: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "User token returned by POST /api/v1/users",
+				},
+				AuthAdmin: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "Static admin token from the config or a token printed by create-admin-token",
+				},
+			},
+		},
+	}
+
+	for _, route := range routes {
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = make(map[string]*Operation)
+		}
+		doc.Paths[route.Path][strings.ToLower(route.Method)] = doc.operation(route)
+	}
+	return doc
+}
+
+func (d *Document) operation(route Route) *Operation {
+	op := &Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Auth != AuthNone {
+		op.Security = []map[string][]string{{route.Auth: {}}}
+	}
+
+	for _, name := range pathParams(route.Path) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, params := range []struct {
+		in   string
+		list []Parameter
+	}{{"query", route.Query}, {"header", route.Headers}} {
+		for i := range params.list {
+			p := params.list[i]
+			p.In = params.in
+			if p.Schema == nil {
+				p.Schema = &Schema{Type: "string"}
+			}
+			op.Parameters = append(op.Parameters, &p)
+		}
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(d.schema(reflect.TypeOf(route.Request))),
+		}
+	}
+
+	for status, body := range route.Responses {
+		response := &Response{Description: http.StatusText(status)}
+		if body != nil {
+			response.Content = jsonContent(d.schema(reflect.TypeOf(body)))
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	return op
+}
+
+// schema returns the schema of t, registering named structs as components
+func (d *Document) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := d.schema(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := d.componentName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// Заглушка до заполнения защищает от рекурсивных типов
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// interface{} accepts any JSON value
+		return &Schema{}
+	}
+}
+
+// componentName names the component of t. Types sharing a name across
+// packages, e.g. v1 and v2 DTOs, are prefixed with their package name.
+func (d *Document) componentName(t reflect.Type) string {
+	name := t.Name()
+	if owner, ok := d.types[name]; ok && owner != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	if _, ok := d.types[name]; !ok {
+		d.types[name] = t
+	}
+	return name
+}
+
+// structSchema describes the JSON object encoding/json produces for t
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Как и encoding/json, поля встроенной структуры без тега поднимаются наверх
+		if name == "" && field.Anonymous && derefStruct(field.Type) {
+			embedded := d.structSchema(deref(field.Type))
+			for embeddedName, property := range embedded.Properties {
+				s.Properties[embeddedName] = property
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = d.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// deref returns the type t points to, t itself if it isn't a pointer
+func deref(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// derefStruct reports whether t is a struct or a pointer to one
+func derefStruct(t reflect.Type) bool {
+	return deref(t).Kind() == reflect.Struct
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// pathParams returns the names of {param} segments in a chi pattern
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"))
+		}
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+// Package pagination reads the page size and position of list requests and
+// links the neighbouring pages in the Link header (RFC 8288, formerly 5988).
+// Every list endpoint bounds its page size with its own Limits.
+package pagination
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Limits are the page sizes of a list endpoint
+type Limits struct {
+	Default int
+	Max     int
+}
+
+// Clamp returns limit within the endpoint's bounds; 0 or less means Default
+func (l Limits) Clamp(limit int) int {
+	if limit <= 0 {
+		return l.Default
+	}
+	if limit > l.Max {
+		return l.Max
+	}
+	return limit
+}
+
+// Limit reads ?limit= of a request clamped to limits. Values that aren't
+// numbers mean the default, as /api/v1 has always treated them.
+func Limit(query url.Values, limits Limits) int {
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	return limits.Clamp(limit)
+}
+
+// Offset reads ?limit= and ?offset= of an offset-paginated request; like
+// Limit it ignores values that aren't numbers
+func Offset(query url.Values, limits Limits) (limit, offset int) {
+	offset, _ = strconv.Atoi(query.Get("offset"))
+	return Limit(query, limits), max(offset, 0)
+}
+
+// OffsetLinks sets the Link header to the next and previous pages of an
+// offset-paginated list of total items
+func OffsetLinks(w http.ResponseWriter, r *http.Request, limit, offset, total int) {
+	if offset+limit < total {
+		addLink(w, r, "next", map[string]string{
+			"limit":  strconv.Itoa(limit),
+			"offset": strconv.Itoa(offset + limit),
+		})
+	}
+	if offset > 0 {
+		addLink(w, r, "prev", map[string]string{
+			"limit":  strconv.Itoa(limit),
+			"offset": strconv.Itoa(max(offset-limit, 0)),
+		})
+	}
+}
+
+// CursorLinks sets the Link header to the next page of a cursor-paginated
+// list; next is "" on the last page. Cursors only go forward, so there is
+// no prev link.
+func CursorLinks(w http.ResponseWriter, r *http.Request, next string) {
+	if next != "" {
+		addLink(w, r, "next", map[string]string{"cursor": next})
+	}
+}
+
+// addLink adds a link to the request's URL with params replaced
+func addLink(w http.ResponseWriter, r *http.Request, rel string, params map[string]string) {
+	query := r.URL.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	target := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Add("Link", "<"+target.String()+`>; rel="`+rel+`"`)
+}
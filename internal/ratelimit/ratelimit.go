@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // time until the current window resets
+}
+
+// Limiter counts requests per key in fixed windows
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+	// SetLimit changes the limit at runtime (config reload)
+	SetLimit(limit int, window time.Duration)
+}
+
+// window is a single fixed-window counter
+type window struct {
+	start time.Time
+	count int
+}
+
+// MemoryLimiter is an in-process fixed-window limiter. Counters are not
+// shared between replicas; use RedisLimiter for multi-instance deployments.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	period  time.Duration
+	windows map[string]*window
+	lastGC  time.Time
+}
+
+// NewMemoryLimiter creates an in-memory limiter allowing limit requests per period
+func NewMemoryLimiter(limit int, period time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		limit:   limit,
+		period:  period,
+		windows: make(map[string]*window),
+		lastGC:  time.Now(),
+	}
+}
+
+// Allow implements Limiter
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.gc(now)
+
+	w, exists := l.windows[key]
+	if !exists || now.Sub(w.start) >= l.period {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	w.count++
+
+	return result(l.limit, w.count, w.start.Add(l.period).Sub(now)), nil
+}
+
+// SetLimit implements Limiter
+func (l *MemoryLimiter) SetLimit(limit int, period time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.period = period
+}
+
+// gc drops expired windows so the map doesn't grow with every client IP
+func (l *MemoryLimiter) gc(now time.Time) {
+	if now.Sub(l.lastGC) < l.period {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.period {
+			delete(l.windows, key)
+		}
+	}
+	l.lastGC = now
+}
+
+func result(limit, count int, reset time.Duration) Result {
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset,
+	}
+}
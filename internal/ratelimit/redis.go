@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLimiter is a fixed-window limiter shared by all replicas through Redis
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+
+	mu     sync.RWMutex
+	limit  int
+	period time.Duration
+}
+
+// NewRedisLimiter creates a Redis-backed limiter; prefix namespaces its keys
+func NewRedisLimiter(client *redis.Client, prefix string, limit int, period time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+		period: period,
+	}
+}
+
+// Allow implements Limiter
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	l.mu.RLock()
+	limit, period := l.limit, l.period
+	l.mu.RUnlock()
+
+	now := time.Now()
+	windowStart := now.Truncate(period)
+	redisKey := fmt.Sprintf("%s:%s:%d", l.prefix, key, windowStart.Unix())
+
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, redisKey)
+	pipe.Expire(ctx, redisKey, period)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Result{}, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+
+	return result(limit, int(incr.Val()), windowStart.Add(period).Sub(now)), nil
+}
+
+// SetLimit implements Limiter
+func (l *RedisLimiter) SetLimit(limit int, period time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.period = period
+}
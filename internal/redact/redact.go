@@ -0,0 +1,58 @@
+// Package redact removes credentials from text before it is logged: JWTs,
+// refresh and push tokens, pre-signed URL signatures and token query parameters. The
+// logger writes through Writer, so access and service logs are covered
+// even when a field carries a secret by accident.
+package redact
+
+import (
+	"io"
+	"regexp"
+)
+
+// Placeholder replaces redacted values
+const Placeholder = "[REDACTED]"
+
+var (
+	// jwtPattern matches JWTs: base64url header and payload starting with {"
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*`)
+
+	// secretFieldPattern matches JSON string fields that always hold secrets
+	secretFieldPattern = regexp.MustCompile(`"(token|refresh_token|push_token|device_token|secret|authorization|upload_url|ws_token)":"(?:[^"\\]|\\.)*"`)
+
+	// secretParamPattern matches query parameters that hold secrets: the
+	// token of WebSocket handshakes and the signature of pre-signed URLs
+	secretParamPattern = regexp.MustCompile(`(?i)([?&](?:token|x-amz-signature|x-amz-credential|x-amz-security-token)=)[^&"\s\\]*`)
+)
+
+// String returns s with credentials replaced by Placeholder
+func String(s string) string {
+	return string(Bytes([]byte(s)))
+}
+
+// Bytes returns b with credentials replaced by Placeholder. b is returned
+// unchanged if it holds none.
+func Bytes(b []byte) []byte {
+	b = secretFieldPattern.ReplaceAll(b, []byte(`"$1":"`+Placeholder+`"`))
+	b = jwtPattern.ReplaceAll(b, []byte(Placeholder))
+	b = secretParamPattern.ReplaceAll(b, []byte("${1}"+Placeholder))
+	return b
+}
+
+// writer redacts every write before passing it on
+type writer struct {
+	out io.Writer
+}
+
+// Writer returns a writer that redacts credentials in what is written to
+// out. zerolog writes one event per call, so a secret never spans writes.
+func Writer(out io.Writer) io.Writer {
+	return writer{out: out}
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(Bytes(p)); err != nil {
+		return 0, err
+	}
+	// Вызывающий сравнивает n с длиной своего буфера, а не отредактированного
+	return len(p), nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AppRepository handles database operations for white-label apps
+type AppRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAppRepository creates a new app repository
+func NewAppRepository(db *pgxpool.Pool) *AppRepository {
+	return &AppRepository{db: db}
+}
+
+// Upsert creates the app or updates its name. Apps are never deleted here
+// since users and pairs reference them.
+func (r *AppRepository) Upsert(ctx context.Context, id, name string) error {
+	query := `
+		INSERT INTO apps (id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name
+	`
+	if _, err := r.db.Exec(ctx, query, id, name); err != nil {
+		return fmt.Errorf("failed to upsert app %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"sync-photo-backend/internal/cache"
+	"sync-photo-backend/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// cacheLayer is an optional read-through cache in front of repository lookups.
+// A nil *cacheLayer disables caching. Cache errors are logged and the
+// database is used instead, so a cache outage never fails a request.
+type cacheLayer struct {
+	cache cache.Cache
+	ttl   time.Duration
+	name  string
+}
+
+// get decodes the cached value for key into dst and reports whether it was
+// found. Lookups in a unit of work bypass the cache, so they see its writes.
+func (c *cacheLayer) get(ctx context.Context, key string, dst interface{}) bool {
+	if c == nil || inUnitOfWork(ctx) {
+		return false
+	}
+
+	data, err := c.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Cache lookup failed")
+		}
+		metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+		return false
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to decode cached value")
+		metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+		return false
+	}
+
+	metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+	return true
+}
+
+// set stores v under key. Values read in a unit of work aren't cached,
+// since it may roll back.
+func (c *cacheLayer) set(ctx context.Context, key string, v interface{}) {
+	if c == nil || inUnitOfWork(ctx) {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := c.cache.Set(ctx, key, data, c.ttl); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to store cached value")
+	}
+}
+
+// invalidate removes keys after a write, once the unit of work in ctx commits
+func (c *cacheLayer) invalidate(ctx context.Context, keys ...string) {
+	if c == nil {
+		return
+	}
+
+	afterCommit(ctx, func(ctx context.Context) {
+		if err := c.cache.Delete(ctx, keys...); err != nil {
+			log.Ctx(ctx).Error().Err(err).Strs("keys", keys).Msg("Failed to invalidate cache")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CompositeRepository handles database operations for composites
+type CompositeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCompositeRepository creates a new composite repository
+func NewCompositeRepository(db *pgxpool.Pool) *CompositeRepository {
+	return &CompositeRepository{db: db}
+}
+
+// Create stores the composite. Returns false if one with the same ID
+// already exists, so a retried compositing job doesn't store it twice.
+func (r *CompositeRepository) Create(ctx context.Context, composite *models.Composite) (bool, error) {
+	query := `
+		INSERT INTO composites (id, pair_id, s3_key, photo_ids, layout, width, height, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		composite.ID, composite.PairID, composite.S3Key, composite.PhotoIDs,
+		composite.Layout, composite.Width, composite.Height, composite.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create composite: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ListByPair returns a page of the pair's composites, newest first, and their total
+func (r *CompositeRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.Composite, int, error) {
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM composites WHERE pair_id = $1`, pairID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count composites: %w", err)
+	}
+
+	query := `
+		SELECT id, pair_id, s3_key, photo_ids::text[], layout, width, height, created_at
+		FROM composites
+		WHERE pair_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, pairID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get composites: %w", err)
+	}
+	defer rows.Close()
+
+	composites := []*models.Composite{}
+	for rows.Next() {
+		var composite models.Composite
+		err := rows.Scan(
+			&composite.ID, &composite.PairID, &composite.S3Key, &composite.PhotoIDs,
+			&composite.Layout, &composite.Width, &composite.Height, &composite.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan composite: %w", err)
+		}
+		composites = append(composites, &composite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating composites: %w", err)
+	}
+	return composites, total, nil
+}
+
+// ListKeysByPair returns the object keys of all the pair's composites
+func (r *CompositeRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT s3_key FROM composites WHERE pair_id = $1`, pairID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite keys: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list composite keys: %w", err)
+	}
+	return keys, nil
+}
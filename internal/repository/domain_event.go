@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const domainEventColumns = `seq, id, type, app_id, user_id, pair_id, payload, occurred_at`
+
+// insertDomainEvents appends events using q, so callers can record them in
+// the same transaction as the change they describe
+func insertDomainEvents(ctx context.Context, q execer, events []*models.DomainEvent) error {
+	query := `
+		INSERT INTO domain_events (id, type, app_id, user_id, pair_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for _, event := range events {
+		_, err := q.Exec(ctx, query,
+			event.ID, event.Type, event.AppID, event.UserID, event.PairID, event.Payload, event.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to append domain event: %w", err)
+		}
+	}
+	return nil
+}
+
+// DomainEventRepository handles database operations for the domain event log
+type DomainEventRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewDomainEventRepository creates a new domain event repository
+func NewDomainEventRepository(db *pgxpool.Pool) *DomainEventRepository {
+	return &DomainEventRepository{db: db}
+}
+
+// Append records events that aren't tied to a row change
+func (r *DomainEventRepository) Append(ctx context.Context, events ...*models.DomainEvent) error {
+	return insertDomainEvents(ctx, conn(ctx, r.db), events)
+}
+
+// ListByPair returns the pair's events with seq below beforeSeq, newest
+// first. beforeSeq 0 starts from the newest event.
+func (r *DomainEventRepository) ListByPair(ctx context.Context, pairID string, beforeSeq int64, limit int) ([]*models.DomainEvent, error) {
+	query := `
+		SELECT ` + domainEventColumns + `
+		FROM domain_events
+		WHERE pair_id = $1 AND ($2 = 0 OR seq < $2)
+		ORDER BY seq DESC
+		LIMIT $3
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, pairID, beforeSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain events: %w", err)
+	}
+	return collectDomainEvents(rows)
+}
+
+// EnsureConsumer registers a consumer positioned at the end of the log, so a
+// new consumer only sees events appended after it first started
+func (r *DomainEventRepository) EnsureConsumer(ctx context.Context, name string) error {
+	query := `
+		INSERT INTO domain_event_consumers (name, last_seq)
+		SELECT $1, COALESCE(MAX(seq), 0) FROM domain_events
+		ON CONFLICT (name) DO NOTHING
+	`
+	if _, err := r.db.Exec(ctx, query, name); err != nil {
+		return fmt.Errorf("failed to register domain event consumer: %w", err)
+	}
+	return nil
+}
+
+// Consume passes the consumer's next events to handle in log order and
+// advances its position past every event handled without error. Events
+// younger than settle are left for later: seq is assigned at insert, so a
+// transaction still in flight may commit a lower seq after a higher one.
+// The consumer row stays locked meanwhile, so with several instances each
+// event is handled by one of them. Returns the number of events handled.
+func (r *DomainEventRepository) Consume(ctx context.Context, name string, settle time.Duration, limit int, handle func(*models.DomainEvent) error) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var lastSeq int64
+	query := `SELECT last_seq FROM domain_event_consumers WHERE name = $1 FOR UPDATE SKIP LOCKED`
+	if err := tx.QueryRow(ctx, query, name).Scan(&lastSeq); err != nil {
+		if err == pgx.ErrNoRows {
+			// Обрабатывает другой экземпляр
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to lock domain event consumer: %w", err)
+	}
+
+	query = `
+		SELECT ` + domainEventColumns + `
+		FROM domain_events
+		WHERE seq > $1 AND occurred_at < $2
+		ORDER BY seq
+		LIMIT $3
+	`
+	rows, err := tx.Query(ctx, query, lastSeq, time.Now().Add(-settle), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read domain events: %w", err)
+	}
+	events, err := collectDomainEvents(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	var handleErr error
+	for _, event := range events {
+		if handleErr = handle(event); handleErr != nil {
+			break
+		}
+		lastSeq = event.Seq
+		handled++
+	}
+
+	if handled > 0 {
+		query = `UPDATE domain_event_consumers SET last_seq = $2, updated_at = NOW() WHERE name = $1`
+		if _, err := tx.Exec(ctx, query, name, lastSeq); err != nil {
+			return 0, fmt.Errorf("failed to advance domain event consumer: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return 0, fmt.Errorf("failed to advance domain event consumer: %w", err)
+		}
+	}
+	return handled, handleErr
+}
+
+func collectDomainEvents(rows pgx.Rows) ([]*models.DomainEvent, error) {
+	defer rows.Close()
+
+	events := []*models.DomainEvent{}
+	for rows.Next() {
+		var event models.DomainEvent
+		err := rows.Scan(
+			&event.Seq, &event.ID, &event.Type, &event.AppID, &event.UserID, &event.PairID,
+			&event.Payload, &event.OccurredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan domain event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating domain events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventRepository handles database operations for client analytics events
+type EventRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewEventRepository creates a new event repository
+func NewEventRepository(db *pgxpool.Pool) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// CreateBatch inserts events in one round trip
+func (r *EventRepository) CreateBatch(ctx context.Context, events []*models.ClientEvent) error {
+	query := `
+		INSERT INTO client_events (id, user_id, name, properties, occurred_at, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		properties, err := json.Marshal(event.Properties)
+		if err != nil {
+			return fmt.Errorf("failed to encode event properties: %w", err)
+		}
+		batch.Queue(query, event.ID, event.UserID, event.Name, properties, event.OccurredAt, event.ReceivedAt)
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to create events: %w", err)
+	}
+	return nil
+}
+
+// DeleteReceivedBefore deletes events received before the given time
+func (r *EventRepository) DeleteReceivedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM client_events WHERE received_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys
+type IdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve inserts an in-progress record for the key. It returns false if a
+// record that is newer than expiredBefore already exists.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userID, key, requestHash string, expiredBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			response_headers = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at < $4
+	`
+	result, err := r.db.Exec(ctx, query, userID, key, requestHash, expiredBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// Get retrieves the record for a key
+func (r *IdempotencyRepository) Get(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT user_id, key, request_hash, status_code, response_body, response_headers, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`
+	var record models.IdempotencyRecord
+	err := r.db.QueryRow(ctx, query, userID, key).Scan(
+		&record.UserID, &record.Key, &record.RequestHash,
+		&record.StatusCode, &record.ResponseBody, &record.ResponseHeaders, &record.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("idempotency key not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response for a reserved key
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, headers map[string]string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, response_body = $2, response_headers = $3
+		WHERE user_id = $4 AND key = $5
+	`
+	_, err := r.db.Exec(ctx, query, statusCode, body, headers, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key so the request can be retried
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	_, err := r.db.Exec(ctx, query, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes keys created before the given time
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE created_at < $1`
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "sync-photo-backend/internal/services"
+
+// The pgx repositories implement the storage interfaces services depend on
+var (
+	_ services.UnitOfWork             = (*TxManager)(nil)
+	_ services.UserRepository         = (*UserRepository)(nil)
+	_ services.PairRepository         = (*PairRepository)(nil)
+	_ services.PhotoRepository        = (*PhotoRepository)(nil)
+	_ services.CompositeRepository    = (*CompositeRepository)(nil)
+	_ services.SyncSessionRepository  = (*SyncSessionRepository)(nil)
+	_ services.ReactionRepository     = (*ReactionRepository)(nil)
+	_ services.PromptRepository       = (*PromptRepository)(nil)
+	_ services.PairSettingsRepository = (*PairSettingsRepository)(nil)
+	_ services.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+	_ services.DomainEventRepository  = (*DomainEventRepository)(nil)
+	_ services.OutboxRepository       = (*OutboxRepository)(nil)
+	_ services.UserEventRepository    = (*UserEventRepository)(nil)
+	_ services.JobRepository          = (*JobRepository)(nil)
+	_ services.IdempotencyRepository  = (*IdempotencyRepository)(nil)
+	_ services.StatsRepository        = (*StatsRepository)(nil)
+	_ services.EventRepository        = (*EventRepository)(nil)
+	_ services.WebhookRepository      = (*WebhookRepository)(nil)
+)
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at`
+
+// JobRepository handles database operations for background jobs
+type JobRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *pgxpool.Pool) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// Enqueue inserts a pending job. Returns false if a job with the same ID
+// already exists, which lets callers deduplicate jobs by a derived ID.
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	query := `
+		INSERT INTO jobs (id, kind, payload, status, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		job.ID, job.Kind, job.Payload, job.Status, job.MaxAttempts, job.RunAt, job.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// Claim locks the next due pending job and marks it running.
+// Returns nil if no job is due. Concurrent workers never claim the same job.
+func (r *JobRepository) Claim(ctx context.Context) (*models.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = 'pending' AND run_at <= NOW()
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	job, err := scanJob(r.db.QueryRow(ctx, query))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Complete marks a job done
+func (r *JobRepository) Complete(ctx context.Context, id string) error {
+	query := `UPDATE jobs SET status = 'done', locked_at = NULL, updated_at = NOW() WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Retry returns a failed attempt to the queue to run again at runAt
+func (r *JobRepository) Retry(ctx context.Context, id, lastError string, runAt time.Time) error {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', last_error = $2, run_at = $3, locked_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, lastError, runAt); err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	return nil
+}
+
+// Fail marks a job as permanently failed
+func (r *JobRepository) Fail(ctx context.Context, id, lastError string) error {
+	query := `
+		UPDATE jobs
+		SET status = 'failed', last_error = $2, locked_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("failed to mark job failed: %w", err)
+	}
+	return nil
+}
+
+// RequeueStale returns running jobs locked before lockedBefore to the queue.
+// Such jobs belonged to a worker that crashed or was killed mid-run.
+func (r *JobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'pending', locked_at = NULL, updated_at = NOW()
+		WHERE status = 'running' AND locked_at < $1
+	`
+	result, err := r.db.Exec(ctx, query, lockedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// DeleteFinished deletes done jobs last updated before the given time
+func (r *JobRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE status = 'done' AND updated_at < $1`
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// List returns the most recently updated jobs, optionally filtered by status
+func (r *JobRepository) List(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	query := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE $1 = '' OR status = $1
+		ORDER BY updated_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func scanJob(row pgx.Row) (*models.Job, error) {
+	var job models.Job
+	err := row.Scan(
+		&job.ID, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.RunAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
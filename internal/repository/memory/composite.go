@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"sync-photo-backend/internal/models"
+)
+
+// CompositeRepository keeps composites in a Store
+type CompositeRepository struct {
+	s *Store
+}
+
+// NewCompositeRepository creates a new memory composite repository
+func NewCompositeRepository(s *Store) *CompositeRepository {
+	return &CompositeRepository{s: s}
+}
+
+// Create stores the composite; false means one with its ID already exists
+func (r *CompositeRepository) Create(ctx context.Context, composite *models.Composite) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, stored := range r.s.composites {
+		if stored.ID == composite.ID {
+			return false, nil
+		}
+	}
+	stored := *composite
+	stored.PhotoIDs = slices.Clone(composite.PhotoIDs)
+	r.s.composites = append(r.s.composites, &stored)
+	return true, nil
+}
+
+// ListByPair returns a page of the pair's composites, newest first, and their total
+func (r *CompositeRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.Composite, int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	composites := []*models.Composite{}
+	for i := len(r.s.composites) - 1; i >= 0; i-- {
+		if stored := r.s.composites[i]; stored.PairID == pairID {
+			found := *stored
+			found.PhotoIDs = slices.Clone(stored.PhotoIDs)
+			composites = append(composites, &found)
+		}
+	}
+	total := len(composites)
+	if offset > total {
+		offset = total
+	}
+	return page(composites[offset:], limit), total, nil
+}
+
+// ListKeysByPair returns the object keys of all the pair's composites
+func (r *CompositeRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	keys := []string{}
+	for _, stored := range r.s.composites {
+		if stored.PairID == pairID {
+			keys = append(keys, stored.S3Key)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// DomainEventRepository keeps the domain event log in a Store
+type DomainEventRepository struct {
+	s *Store
+}
+
+// NewDomainEventRepository creates a new memory domain event repository
+func NewDomainEventRepository(s *Store) *DomainEventRepository {
+	return &DomainEventRepository{s: s}
+}
+
+// Append records events that aren't tied to a change
+func (r *DomainEventRepository) Append(ctx context.Context, events ...*models.DomainEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.appendDomainEvents(events)
+	return nil
+}
+
+// ListByPair returns the pair's events with seq below beforeSeq, newest
+// first. beforeSeq 0 starts from the newest event.
+func (r *DomainEventRepository) ListByPair(ctx context.Context, pairID string, beforeSeq int64, limit int) ([]*models.DomainEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	events := []*models.DomainEvent{}
+	for i := len(r.s.domainEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		event := r.s.domainEvents[i]
+		if event.PairID == nil || *event.PairID != pairID {
+			continue
+		}
+		if beforeSeq != 0 && event.Seq >= beforeSeq {
+			continue
+		}
+		found := *event
+		events = append(events, &found)
+	}
+	return events, nil
+}
+
+// EnsureConsumer registers a consumer positioned at the end of the log, so a
+// new consumer only sees events appended after it first started
+func (r *DomainEventRepository) EnsureConsumer(ctx context.Context, name string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.consumers[name]; !ok {
+		r.s.consumers[name] = int64(len(r.s.domainEvents))
+	}
+	return nil
+}
+
+// Consume passes the consumer's next events older than settle to handle in
+// log order and advances its position past every event handled without
+// error. Events are handled outside the lock so handle may use the other
+// repositories; a concurrent Consume by the same consumer returns 0 like
+// the locked consumer row does in PostgreSQL.
+func (r *DomainEventRepository) Consume(ctx context.Context, name string, settle time.Duration, limit int, handle func(*models.DomainEvent) error) (int, error) {
+	r.s.mu.Lock()
+	lastSeq, ok := r.s.consumers[name]
+	if !ok || r.s.consuming[name] {
+		r.s.mu.Unlock()
+		return 0, nil
+	}
+	settledBefore := time.Now().Add(-settle)
+	events := []*models.DomainEvent{}
+	for _, event := range r.s.domainEvents[lastSeq:] {
+		if len(events) == limit || !event.OccurredAt.Before(settledBefore) {
+			break
+		}
+		found := *event
+		events = append(events, &found)
+	}
+	r.s.consuming[name] = true
+	r.s.mu.Unlock()
+
+	handled := 0
+	var handleErr error
+	for _, event := range events {
+		if handleErr = handle(event); handleErr != nil {
+			break
+		}
+		lastSeq = event.Seq
+		handled++
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.consumers[name] = lastSeq
+	delete(r.s.consuming, name)
+	return handled, handleErr
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// EventRepository keeps client analytics events in a Store
+type EventRepository struct {
+	s *Store
+}
+
+// NewEventRepository creates a new memory event repository
+func NewEventRepository(s *Store) *EventRepository {
+	return &EventRepository{s: s}
+}
+
+// CreateBatch stores events
+func (r *EventRepository) CreateBatch(ctx context.Context, events []*models.ClientEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, event := range events {
+		stored := *event
+		r.s.clientEvents = append(r.s.clientEvents, &stored)
+	}
+	return nil
+}
+
+// DeleteReceivedBefore deletes events received before the given time
+func (r *EventRepository) DeleteReceivedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	kept := r.s.clientEvents[:0]
+	var deleted int64
+	for _, event := range r.s.clientEvents {
+		if event.ReceivedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.s.clientEvents = kept
+	return deleted, nil
+}
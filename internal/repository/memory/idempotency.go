@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// idempotencyKey identifies a record; keys are scoped to the user
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// IdempotencyRepository keeps idempotency keys in a Store
+type IdempotencyRepository struct {
+	s *Store
+}
+
+// NewIdempotencyRepository creates a new memory idempotency repository
+func NewIdempotencyRepository(s *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{s: s}
+}
+
+// Reserve stores an in-progress record for the key. It returns false if a
+// record that is newer than expiredBefore already exists.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userID, key, requestHash string, expiredBefore time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	id := idempotencyKey{userID: userID, key: key}
+	if record, ok := r.s.idempotency[id]; ok && !record.CreatedAt.Before(expiredBefore) {
+		return false, nil
+	}
+	r.s.idempotency[id] = &models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now().UTC(),
+	}
+	return true, nil
+}
+
+// Get retrieves the record for a key
+func (r *IdempotencyRepository) Get(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	record, ok := r.s.idempotency[idempotencyKey{userID: userID, key: key}]
+	if !ok {
+		return nil, fmt.Errorf("idempotency key not found: %w", pgx.ErrNoRows)
+	}
+	found := *record
+	return &found, nil
+}
+
+// Complete stores the response for a reserved key
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, headers map[string]string, body []byte) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	id := idempotencyKey{userID: userID, key: key}
+	if record, ok := r.s.idempotency[id]; ok {
+		completed := *record
+		completed.StatusCode = &statusCode
+		completed.ResponseBody = body
+		completed.ResponseHeaders = headers
+		r.s.idempotency[id] = &completed
+	}
+	return nil
+}
+
+// Delete removes a key so the request can be retried
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID, key string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.idempotency, idempotencyKey{userID: userID, key: key})
+	return nil
+}
+
+// DeleteExpired removes keys created before the given time
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var deleted int64
+	for id, record := range r.s.idempotency {
+		if record.CreatedAt.Before(before) {
+			delete(r.s.idempotency, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
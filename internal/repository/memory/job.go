@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// jobEntry is a job with the time it was claimed by a worker
+type jobEntry struct {
+	job      models.Job
+	lockedAt time.Time
+}
+
+// JobRepository keeps background jobs in a Store
+type JobRepository struct {
+	s *Store
+}
+
+// NewJobRepository creates a new memory job repository
+func NewJobRepository(s *Store) *JobRepository {
+	return &JobRepository{s: s}
+}
+
+// Enqueue stores a pending job. Returns false if a job with the same ID
+// already exists.
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.jobs[job.ID]; ok {
+		return false, nil
+	}
+	entry := &jobEntry{job: *job}
+	entry.job.Attempts = 0
+	entry.job.LastError = nil
+	entry.job.UpdatedAt = job.CreatedAt
+	r.s.jobs[job.ID] = entry
+	return true, nil
+}
+
+// Claim marks the next due pending job running. Returns nil if no job is due.
+func (r *JobRepository) Claim(ctx context.Context) (*models.Job, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var next *jobEntry
+	for _, entry := range r.s.jobs {
+		if entry.job.Status != models.JobStatusPending || entry.job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || entry.job.RunAt.Before(next.job.RunAt) {
+			next = entry
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.job.Status = models.JobStatusRunning
+	next.job.Attempts++
+	next.job.UpdatedAt = now
+	next.lockedAt = now
+
+	claimed := next.job
+	return &claimed, nil
+}
+
+// Complete marks a job done
+func (r *JobRepository) Complete(ctx context.Context, id string) error {
+	r.update(id, func(entry *jobEntry) {
+		entry.job.Status = models.JobStatusDone
+	})
+	return nil
+}
+
+// Retry returns a failed attempt to the queue to run again at runAt
+func (r *JobRepository) Retry(ctx context.Context, id, lastError string, runAt time.Time) error {
+	r.update(id, func(entry *jobEntry) {
+		entry.job.Status = models.JobStatusPending
+		entry.job.LastError = &lastError
+		entry.job.RunAt = runAt
+	})
+	return nil
+}
+
+// Fail marks a job as permanently failed
+func (r *JobRepository) Fail(ctx context.Context, id, lastError string) error {
+	r.update(id, func(entry *jobEntry) {
+		entry.job.Status = models.JobStatusFailed
+		entry.job.LastError = &lastError
+	})
+	return nil
+}
+
+// update applies change to a job and unlocks it; unknown jobs are ignored
+func (r *JobRepository) update(id string, change func(*jobEntry)) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	entry, ok := r.s.jobs[id]
+	if !ok {
+		return
+	}
+	change(entry)
+	entry.lockedAt = time.Time{}
+	entry.job.UpdatedAt = time.Now().UTC()
+}
+
+// RequeueStale returns running jobs locked before lockedBefore to the queue
+func (r *JobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var requeued int64
+	for _, entry := range r.s.jobs {
+		if entry.job.Status == models.JobStatusRunning && entry.lockedAt.Before(lockedBefore) {
+			entry.job.Status = models.JobStatusPending
+			entry.job.UpdatedAt = time.Now().UTC()
+			entry.lockedAt = time.Time{}
+			requeued++
+		}
+	}
+	return requeued, nil
+}
+
+// DeleteFinished deletes done jobs last updated before the given time
+func (r *JobRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var deleted int64
+	for id, entry := range r.s.jobs {
+		if entry.job.Status == models.JobStatusDone && entry.job.UpdatedAt.Before(before) {
+			delete(r.s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// List returns the most recently updated jobs, optionally filtered by status
+func (r *JobRepository) List(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	jobs := []*models.Job{}
+	for _, entry := range r.s.jobs {
+		if status == "" || entry.job.Status == status {
+			job := entry.job
+			jobs = append(jobs, &job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+	})
+	return page(jobs, limit), nil
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ObjectStore is a fake services.ObjectStore keeping objects in memory, so
+// PhotoService can be exercised without S3
+type ObjectStore struct {
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+	err          error
+}
+
+// NewObjectStore creates an empty object store
+func NewObjectStore() *ObjectStore {
+	return &ObjectStore{objects: map[string][]byte{}, contentTypes: map[string]string{}}
+}
+
+// FailWith makes every following call return err, e.g. to exercise the
+// retries and circuit breaker of PhotoService; nil restores the store
+func (s *ObjectStore) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Object returns the stored object and whether it exists
+func (s *ObjectStore) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	return slices.Clone(data), ok
+}
+
+// PresignPut returns a fake upload URL; nothing is stored until Put
+func (s *ObjectStore) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.URL(key) + "?expires=" + expires.String(), nil
+}
+
+// PresignGet returns a fake download URL
+func (s *ObjectStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.URL(key) + "?expires=" + expires.String(), nil
+}
+
+func (s *ObjectStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.objects[key] = slices.Clone(data)
+	s.contentTypes[key] = contentType
+	return nil
+}
+
+func (s *ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s does not exist", key)
+	}
+	return slices.Clone(data), nil
+}
+
+func (s *ObjectStore) GetPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return data[:min(int64(len(data)), n)], nil
+}
+
+func (s *ObjectStore) Stat(ctx context.Context, key string) (size int64, contentType string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, "", s.err
+	}
+	data, ok := s.objects[key]
+	if !ok {
+		return 0, "", fmt.Errorf("object %s: %w", key, fs.ErrNotExist)
+	}
+	return int64(len(data)), s.contentTypes[key], nil
+}
+
+func (s *ObjectStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.objects, key)
+	delete(s.contentTypes, key)
+	return nil
+}
+
+func (s *ObjectStore) Usage(ctx context.Context) (objects, bytes int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	for _, data := range s.objects {
+		objects++
+		bytes += int64(len(data))
+	}
+	return objects, bytes, nil
+}
+
+func (s *ObjectStore) Probe(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *ObjectStore) URL(key string) string {
+	return "https://storage.invalid/" + key
+}
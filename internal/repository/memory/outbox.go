@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// outboxEntry is an outbox event with its delivery state
+type outboxEntry struct {
+	event         *models.OutboxEvent
+	nextAttemptAt time.Time
+	processedAt   time.Time
+	// locked is set while a relay handles the event
+	locked bool
+}
+
+// OutboxRepository keeps the notification outbox in a Store
+type OutboxRepository struct {
+	s *Store
+}
+
+// NewOutboxRepository creates a new memory outbox repository
+func NewOutboxRepository(s *Store) *OutboxRepository {
+	return &OutboxRepository{s: s}
+}
+
+// Process passes up to limit due events to handle and records the results.
+// Events are handled outside the lock and are skipped by concurrent calls
+// meanwhile.
+func (r *OutboxRepository) Process(ctx context.Context, limit int, handle func(*models.OutboxEvent) models.OutboxResult) (int, error) {
+	r.s.mu.Lock()
+	now := time.Now().UTC()
+	var entries []*outboxEntry
+	for _, entry := range r.s.outbox {
+		if len(entries) == limit {
+			break
+		}
+		if entry.locked || !entry.processedAt.IsZero() || entry.nextAttemptAt.After(now) {
+			continue
+		}
+		entry.locked = true
+		entries = append(entries, entry)
+	}
+	events := make([]*models.OutboxEvent, len(entries))
+	for i, entry := range entries {
+		event := *entry.event
+		events[i] = &event
+	}
+	r.s.mu.Unlock()
+
+	results := make([]models.OutboxResult, len(events))
+	for i, event := range events {
+		results[i] = handle(event)
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i, entry := range entries {
+		entry.locked = false
+		entry.event.Attempts++
+		if results[i].Err != nil && !results[i].RetryAt.IsZero() {
+			entry.nextAttemptAt = results[i].RetryAt
+		} else {
+			entry.processedAt = time.Now().UTC()
+		}
+	}
+	return len(events), nil
+}
+
+// DeleteProcessed deletes events processed before the given time
+func (r *OutboxRepository) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	kept := r.s.outbox[:0]
+	var deleted int64
+	for _, entry := range r.s.outbox {
+		if !entry.processedAt.IsZero() && entry.processedAt.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	r.s.outbox = kept
+	return deleted, nil
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// PairRepository keeps pairs in a Store
+type PairRepository struct {
+	s *Store
+}
+
+// NewPairRepository creates a new memory pair repository
+func NewPairRepository(s *Store) *PairRepository {
+	return &PairRepository{s: s}
+}
+
+// Create creates a new pair together with its domain and outbox events
+func (r *PairRepository) Create(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	pair.UpdatedAt = pair.CreatedAt
+	stored := *pair
+	r.s.pairs[pair.ID] = &stored
+	r.s.appendDomainEvents([]*models.DomainEvent{event})
+	r.s.appendOutboxEvents(outbox)
+	return nil
+}
+
+// GetByID retrieves an active pair by ID
+func (r *PairRepository) GetByID(ctx context.Context, id string) (*models.Pair, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	pair, ok := r.s.pairs[id]
+	if !ok || pair.DeletedAt != nil {
+		return nil, notFound(domain.ErrPairNotFound)
+	}
+	found := *pair
+	return &found, nil
+}
+
+// GetByUserID retrieves the active pair of a user
+func (r *PairRepository) GetByUserID(ctx context.Context, userID string) (*models.Pair, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if pair := r.s.pairOf(userID); pair != nil {
+		found := *pair
+		return &found, nil
+	}
+	return nil, notFound(domain.ErrPairNotFound)
+}
+
+// GetByUsers retrieves the pair of two users, deleted or not
+func (r *PairRepository) GetByUsers(ctx context.Context, userAID, userBID string) (*models.Pair, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, pair := range r.s.pairs {
+		if pair.UserAID == userAID && pair.UserBID == userBID {
+			found := *pair
+			return &found, nil
+		}
+	}
+	return nil, notFound(domain.ErrPairNotFound)
+}
+
+// ListByUserID returns all pairs of a user, deleted ones included, newest first
+func (r *PairRepository) ListByUserID(ctx context.Context, userID string) ([]*models.Pair, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	pairs := []*models.Pair{}
+	for _, pair := range r.s.pairs {
+		if pair.UserAID == userID || pair.UserBID == userID {
+			found := *pair
+			pairs = append(pairs, &found)
+		}
+	}
+	slices.SortFunc(pairs, func(a, b *models.Pair) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return pairs, nil
+}
+
+// Delete marks an active pair deleted, keeping its photos, together with its domain and outbox events
+func (r *PairRepository) Delete(ctx context.Context, id string, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	pair, ok := r.s.pairs[id]
+	if !ok || pair.DeletedAt != nil {
+		return domain.ErrPairNotFound
+	}
+	now := time.Now().UTC()
+	deleted := *pair
+	deleted.DeletedAt = &now
+	deleted.UpdatedAt = now
+	r.s.pairs[id] = &deleted
+	r.s.appendDomainEvents([]*models.DomainEvent{event})
+	r.s.appendOutboxEvents(outbox)
+	return nil
+}
+
+// Restore makes a deleted pair active again together with its domain and outbox events
+func (r *PairRepository) Restore(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.pairs[pair.ID]
+	if !ok || stored.DeletedAt == nil {
+		return domain.ErrPairNotFound
+	}
+	restored := *stored
+	restored.DeletedAt = nil
+	restored.UpdatedAt = time.Now().UTC()
+	r.s.pairs[pair.ID] = &restored
+	pair.UpdatedAt = restored.UpdatedAt
+	pair.DeletedAt = nil
+	r.s.appendDomainEvents([]*models.DomainEvent{event})
+	r.s.appendOutboxEvents(outbox)
+	return nil
+}
+
+// UserHasPair checks if a user is already in an active pair
+func (r *PairRepository) UserHasPair(ctx context.Context, userID string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.pairOf(userID) != nil, nil
+}
+
+// pairOf returns the user's active pair or nil; the caller holds the lock
+func (s *Store) pairOf(userID string) *models.Pair {
+	for _, pair := range s.pairs {
+		if pair.DeletedAt == nil && (pair.UserAID == userID || pair.UserBID == userID) {
+			return pair
+		}
+	}
+	return nil
+}
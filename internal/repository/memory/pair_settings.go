@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// PairSettingsRepository keeps the settings partners share in a Store
+type PairSettingsRepository struct {
+	s *Store
+}
+
+// NewPairSettingsRepository creates a new memory pair settings repository
+func NewPairSettingsRepository(s *Store) *PairSettingsRepository {
+	return &PairSettingsRepository{s: s}
+}
+
+// Get returns the pair's settings; a pair that never saved any gets the
+// defaults with a zero UpdatedAt
+func (r *PairSettingsRepository) Get(ctx context.Context, pairID string) (*models.PairSettings, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if stored, ok := r.s.pairSettings[pairID]; ok {
+		found := *stored
+		return &found, nil
+	}
+	return &models.PairSettings{PairID: pairID, AutoComposite: true}, nil
+}
+
+// Save stores the pair's settings if they are still at the updatedAt they
+// were read at and sets the new UpdatedAt
+func (r *PairSettingsRepository) Save(ctx context.Context, settings *models.PairSettings, updatedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var current time.Time
+	if stored, ok := r.s.pairSettings[settings.PairID]; ok {
+		current = stored.UpdatedAt
+	}
+	if !current.Equal(updatedAt) {
+		return domain.ErrPreconditionFailed
+	}
+	stored := *settings
+	stored.UpdatedAt = time.Now().UTC()
+	r.s.pairSettings[settings.PairID] = &stored
+	settings.UpdatedAt = stored.UpdatedAt
+	return nil
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// PhotoRepository keeps photos in a Store
+type PhotoRepository struct {
+	s *Store
+}
+
+// NewPhotoRepository creates a new memory photo repository
+func NewPhotoRepository(s *Store) *PhotoRepository {
+	return &PhotoRepository{s: s}
+}
+
+// Create creates a new photo together with its domain events
+func (r *PhotoRepository) Create(ctx context.Context, photo *models.Photo, events ...*models.DomainEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	photo.UpdatedAt = photo.CreatedAt
+	stored := *photo
+	r.s.photos[photo.ID] = &stored
+	r.s.appendDomainEvents(events)
+	return nil
+}
+
+// GetByID retrieves a photo by ID, pending or not
+func (r *PhotoRepository) GetByID(ctx context.Context, id string) (*models.Photo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	photo, ok := r.s.photos[id]
+	if !ok {
+		return nil, notFound(domain.ErrPhotoNotFound)
+	}
+	found := *photo
+	return &found, nil
+}
+
+// GetByPairID retrieves uploaded photos by pair ID with pagination
+func (r *PhotoRepository) GetByPairID(ctx context.Context, pairID string, limit, offset int) ([]*models.Photo, int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	photos := r.s.pairPhotos(pairID, func(*models.Photo) bool { return true })
+	sortNewestFirst(photos)
+	total := len(photos)
+	if offset > total {
+		offset = total
+	}
+	return page(photos[offset:], limit), total, nil
+}
+
+// ListByPairBefore returns up to limit uploaded photos of the pair taken before
+// the (takenAt, id) position, newest first. A zero takenAt starts from the newest photo.
+func (r *PhotoRepository) ListByPairBefore(ctx context.Context, pairID string, takenAt time.Time, id string, limit int) ([]*models.Photo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	photos := r.s.pairPhotos(pairID, func(photo *models.Photo) bool {
+		if takenAt.IsZero() {
+			return true
+		}
+		return photo.TakenAt.Before(takenAt) || (photo.TakenAt.Equal(takenAt) && photo.ID < id)
+	})
+	sortNewestFirst(photos)
+	return page(photos, limit), nil
+}
+
+// Confirm records the verified upload of a pending photo and stores the
+// events built by confirmed. confirmed runs without the lock, since it reads
+// other repositories of the same Store. A photo uploaded before is returned
+// unchanged with false.
+func (r *PhotoRepository) Confirm(
+	ctx context.Context,
+	photoID string,
+	upload models.PhotoUpload,
+	confirmed func(*models.Photo) (*models.DomainEvent, []*models.OutboxEvent, error),
+) (*models.Photo, bool, error) {
+	found, err := r.GetByID(ctx, photoID)
+	if err != nil {
+		return nil, false, err
+	}
+	if found.UploadedAt != nil {
+		return found, false, nil
+	}
+
+	found.SizeBytes = upload.SizeBytes
+	found.ContentType = upload.ContentType
+	found.Width = upload.Width
+	found.Height = upload.Height
+	found.DeviceTakenAt = upload.DeviceTakenAt
+	found.UploadedAt = &upload.UploadedAt
+	found.UpdatedAt = upload.UploadedAt
+
+	event, outbox, err := confirmed(found)
+	if err != nil {
+		return nil, false, err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	stored, ok := r.s.photos[photoID]
+	if !ok {
+		return nil, false, notFound(domain.ErrPhotoNotFound)
+	}
+	// Параллельное подтверждение успело раньше
+	if stored.UploadedAt != nil {
+		current := *stored
+		return &current, false, nil
+	}
+	updated := *found
+	r.s.photos[photoID] = &updated
+	r.s.appendDomainEvents([]*models.DomainEvent{event})
+	r.s.appendOutboxEvents(outbox)
+	return found, true, nil
+}
+
+// DeletePending deletes up to limit photos created before createdBefore
+// whose upload was never completed and whose IDs removed returns. removed
+// runs under the lock, like the row locks in PostgreSQL, so it must not use
+// the Store.
+func (r *PhotoRepository) DeletePending(ctx context.Context, createdBefore time.Time, limit int, removed func([]*models.Photo) []string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	pending := []*models.Photo{}
+	for _, photo := range r.s.photos {
+		if photo.UploadedAt == nil && photo.CreatedAt.Before(createdBefore) {
+			found := *photo
+			pending = append(pending, &found)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	pending = page(pending, limit)
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	deleted := 0
+	for _, id := range removed(pending) {
+		if _, ok := r.s.photos[id]; ok {
+			delete(r.s.photos, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// ListKeysByPair returns the object keys of all the pair's photos, pending
+// uploads included
+func (r *PhotoRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	keys := []string{}
+	for _, photo := range r.s.photos {
+		if photo.PairID == pairID {
+			keys = append(keys, photo.S3Key)
+		}
+	}
+	return keys, nil
+}
+
+// ListAround returns the pair's uploaded photos taken no more than window before or
+// after takenAt, oldest first
+func (r *PhotoRepository) ListAround(ctx context.Context, pairID string, takenAt time.Time, window time.Duration) ([]*models.Photo, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	from, to := takenAt.Add(-window), takenAt.Add(window)
+	photos := r.s.pairPhotos(pairID, func(photo *models.Photo) bool {
+		return !photo.TakenAt.Before(from) && !photo.TakenAt.After(to)
+	})
+	sort.Slice(photos, func(i, j int) bool {
+		if !photos[i].TakenAt.Equal(photos[j].TakenAt) {
+			return photos[i].TakenAt.Before(photos[j].TakenAt)
+		}
+		return photos[i].ID < photos[j].ID
+	})
+	return photos, nil
+}
+
+// pairPhotos returns copies of the pair's uploaded photos matching keep; the
+// caller holds the lock
+func (s *Store) pairPhotos(pairID string, keep func(*models.Photo) bool) []*models.Photo {
+	photos := []*models.Photo{}
+	for _, photo := range s.photos {
+		if photo.PairID == pairID && photo.UploadedAt != nil && keep(photo) {
+			found := *photo
+			photos = append(photos, &found)
+		}
+	}
+	return photos
+}
+
+// sortNewestFirst orders photos by (taken_at, id) descending like the
+// PostgreSQL queries
+func sortNewestFirst(photos []*models.Photo) {
+	sort.Slice(photos, func(i, j int) bool {
+		if !photos[i].TakenAt.Equal(photos[j].TakenAt) {
+			return photos[i].TakenAt.After(photos[j].TakenAt)
+		}
+		return photos[i].ID > photos[j].ID
+	})
+}
+
+// page returns at most limit leading items; a non-positive limit returns none
+// like LIMIT 0
+func page[T any](items []T, limit int) []T {
+	if limit < 0 {
+		limit = 0
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
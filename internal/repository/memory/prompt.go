@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/repository"
+)
+
+// PromptRepository keeps daily photo prompts and their settings in a Store
+type PromptRepository struct {
+	s *Store
+}
+
+// NewPromptRepository creates a new memory prompt repository
+func NewPromptRepository(s *Store) *PromptRepository {
+	return &PromptRepository{s: s}
+}
+
+// GetSettings returns the pair's prompt settings; a pair that never saved
+// any gets prompts enabled at the default time
+func (r *PromptRepository) GetSettings(ctx context.Context, pairID string) (*models.PromptSettings, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if stored, ok := r.s.promptSettings[pairID]; ok {
+		found := *stored
+		return &found, nil
+	}
+	return &models.PromptSettings{PairID: pairID, Enabled: true}, nil
+}
+
+// SaveSettings stores the pair's prompt settings
+func (r *PromptRepository) SaveSettings(ctx context.Context, settings *models.PromptSettings) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *settings
+	r.s.promptSettings[settings.PairID] = &stored
+	return nil
+}
+
+// ListDue returns up to limit active pairs with prompts enabled whose local
+// time at now is past their prompt time, defaultTime unless they chose
+// one, and which have no prompt for that local day yet
+func (r *PromptRepository) ListDue(ctx context.Context, now time.Time, defaultTime string, limit int) ([]*models.DuePrompt, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	due := []*models.DuePrompt{}
+	for _, pair := range r.s.pairs {
+		if pair.DeletedAt != nil {
+			continue
+		}
+		settings := r.s.promptSettings[pair.ID]
+		if settings != nil && !settings.Enabled {
+			continue
+		}
+
+		promptTime, timezone := defaultTime, "UTC"
+		if user, ok := r.s.users[pair.UserAID]; ok {
+			timezone = user.Timezone
+		}
+		if settings != nil && settings.Time != nil {
+			promptTime = *settings.Time
+		}
+		if pairSettings, ok := r.s.pairSettings[pair.ID]; ok && pairSettings.Timezone != nil {
+			timezone = *pairSettings.Timezone
+		}
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			continue
+		}
+
+		// "HH:MM" с ведущими нулями сравниваются как строки
+		local := now.In(loc)
+		if local.Format("15:04") < promptTime {
+			continue
+		}
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		sent := slices.ContainsFunc(r.s.dailyPrompts, func(prompt *models.DailyPrompt) bool {
+			return prompt.PairID == pair.ID && prompt.Day.Equal(day)
+		})
+		if sent {
+			continue
+		}
+
+		found := *pair
+		due = append(due, &models.DuePrompt{Pair: &found, Day: day})
+	}
+	slices.SortFunc(due, func(a, b *models.DuePrompt) int {
+		return strings.Compare(a.Pair.ID, b.Pair.ID)
+	})
+	return page(due, limit), nil
+}
+
+// Create stores the prompt with the outbox events announcing it. Returns
+// false without writing anything if the pair already has a prompt for the
+// day.
+func (r *PromptRepository) Create(ctx context.Context, prompt *models.DailyPrompt, outbox ...*models.OutboxEvent) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, stored := range r.s.dailyPrompts {
+		if stored.PairID == prompt.PairID && stored.Day.Equal(prompt.Day) {
+			return false, nil
+		}
+	}
+	stored := *prompt
+	r.s.dailyPrompts = append(r.s.dailyPrompts, &stored)
+	r.s.appendOutboxEvents(outbox)
+	return true, nil
+}
+
+// Complete records a photo user A, or user B if userA is false, uploaded
+// at at for the pair's prompt open at that time. It returns the prompt, nil
+// if none was open, and whether this completed it for both partners.
+func (r *PromptRepository) Complete(ctx context.Context, pairID string, userA bool, at time.Time) (*models.DailyPrompt, bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var open *models.DailyPrompt
+	for _, stored := range r.s.dailyPrompts {
+		if stored.PairID != pairID || stored.SentAt.After(at) || !stored.ExpiresAt.After(at) {
+			continue
+		}
+		if open == nil || stored.SentAt.After(open.SentAt) {
+			open = stored
+		}
+	}
+	if open == nil {
+		return nil, false, nil
+	}
+
+	_, completed := repository.MarkPromptCompleted(open, userA, at)
+	found := *open
+	return &found, completed, nil
+}
+
+// ListByPair returns all prompts of the pair, newest first
+func (r *PromptRepository) ListByPair(ctx context.Context, pairID string) ([]*models.DailyPrompt, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	prompts := []*models.DailyPrompt{}
+	for _, stored := range r.s.dailyPrompts {
+		if stored.PairID == pairID {
+			found := *stored
+			prompts = append(prompts, &found)
+		}
+	}
+	slices.SortFunc(prompts, func(a, b *models.DailyPrompt) int {
+		return b.Day.Compare(a.Day)
+	})
+	return prompts, nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+
+	"sync-photo-backend/internal/models"
+)
+
+// ReactionRepository keeps photo reactions and comments in a Store
+type ReactionRepository struct {
+	s *Store
+}
+
+// NewReactionRepository creates a new memory reaction repository
+func NewReactionRepository(s *Store) *ReactionRepository {
+	return &ReactionRepository{s: s}
+}
+
+// AddReaction stores the reaction; false means the user already put the
+// same emoji on the photo
+func (r *ReactionRepository) AddReaction(ctx context.Context, reaction *models.Reaction) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, stored := range r.s.reactions {
+		if stored.PhotoID == reaction.PhotoID && stored.UserID == reaction.UserID && stored.Emoji == reaction.Emoji {
+			return false, nil
+		}
+	}
+	stored := *reaction
+	r.s.reactions = append(r.s.reactions, &stored)
+	return true, nil
+}
+
+// AddComment stores the comment
+func (r *ReactionRepository) AddComment(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *comment
+	r.s.comments = append(r.s.comments, &stored)
+	return nil
+}
+
+// ListReactions returns the reactions on the photo, oldest first
+func (r *ReactionRepository) ListReactions(ctx context.Context, photoID string) ([]*models.Reaction, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	reactions := []*models.Reaction{}
+	for _, stored := range r.s.reactions {
+		if stored.PhotoID == photoID {
+			found := *stored
+			reactions = append(reactions, &found)
+		}
+	}
+	return reactions, nil
+}
+
+// ListComments returns a page of the photo's comments, oldest first, and their total
+func (r *ReactionRepository) ListComments(ctx context.Context, photoID string, limit, offset int) ([]*models.Comment, int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	comments := []*models.Comment{}
+	for _, stored := range r.s.comments {
+		if stored.PhotoID == photoID {
+			found := *stored
+			comments = append(comments, &found)
+		}
+	}
+	total := len(comments)
+	if offset > total {
+		offset = total
+	}
+	return page(comments[offset:], limit), total, nil
+}
+
+// Counts returns the reaction and comment counts of the photos; photos
+// without either are missing from the map
+func (r *ReactionRepository) Counts(ctx context.Context, photoIDs []string) (map[string]models.PhotoCounts, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(photoIDs))
+	for _, id := range photoIDs {
+		wanted[id] = true
+	}
+	counts := make(map[string]models.PhotoCounts, len(photoIDs))
+	for _, reaction := range r.s.reactions {
+		if wanted[reaction.PhotoID] {
+			c := counts[reaction.PhotoID]
+			c.Reactions++
+			counts[reaction.PhotoID] = c
+		}
+	}
+	for _, comment := range r.s.comments {
+		if wanted[comment.PhotoID] {
+			c := counts[comment.PhotoID]
+			c.Comments++
+			counts[comment.PhotoID] = c
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// RefreshTokenRepository keeps refresh tokens in a Store
+type RefreshTokenRepository struct {
+	s *Store
+}
+
+// NewRefreshTokenRepository creates a new memory refresh token repository
+func NewRefreshTokenRepository(s *Store) *RefreshTokenRepository {
+	return &RefreshTokenRepository{s: s}
+}
+
+// Create stores the first refresh token of a session
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *token
+	r.s.refreshTokens[token.ID] = &stored
+	return nil
+}
+
+// GetByHash finds a refresh token by the hash of its value, revoked and
+// expired ones included
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash []byte) (*models.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, token := range r.s.refreshTokens {
+		if bytes.Equal(token.TokenHash, hash) {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, notFound(domain.ErrInvalidRefreshToken)
+}
+
+// Rotate revokes the current token and stores the next one; false means
+// the current token was already revoked
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current, ok := r.s.refreshTokens[currentID]
+	if !ok || current.RevokedAt != nil {
+		return false, nil
+	}
+	revokedAt := next.CreatedAt
+	current.RevokedAt = &revokedAt
+	stored := *next
+	r.s.refreshTokens[next.ID] = &stored
+	return true, nil
+}
+
+// RevokeSession revokes every token of the session
+func (r *RefreshTokenRepository) RevokeSession(ctx context.Context, sessionID string, revokedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, token := range r.s.refreshTokens {
+		if token.SessionID == sessionID && token.RevokedAt == nil {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// RevokeUser revokes every token of every session of the user
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, token := range r.s.refreshTokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// SessionActive reports whether the session has a token that is neither
+// revoked nor expired at now
+func (r *RefreshTokenRepository) SessionActive(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, token := range r.s.refreshTokens {
+		if token.SessionID == sessionID && token.RevokedAt == nil && token.ExpiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var deleted int64
+	for id, token := range r.s.refreshTokens {
+		if token.ExpiresAt.Before(before) {
+			delete(r.s.refreshTokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// StatsRepository keeps usage activity and daily rollups in a Store
+type StatsRepository struct {
+	s *Store
+}
+
+// NewStatsRepository creates a new memory stats repository
+func NewStatsRepository(s *Store) *StatsRepository {
+	return &StatsRepository{s: s}
+}
+
+// RecordActivity marks the user as active on day
+func (r *StatsRepository) RecordActivity(ctx context.Context, userID string, day time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key := dayKey(day)
+	if r.s.activity[key] == nil {
+		r.s.activity[key] = map[string]bool{}
+	}
+	r.s.activity[key][userID] = true
+	return nil
+}
+
+// RecordConnectionPeak raises the instance's WebSocket connection peak for day
+func (r *StatsRepository) RecordConnectionPeak(ctx context.Context, day time.Time, instanceID string, peak int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key := dayKey(day)
+	if r.s.peaks[key] == nil {
+		r.s.peaks[key] = map[string]int{}
+	}
+	r.s.peaks[key][instanceID] = max(r.s.peaks[key][instanceID], peak)
+	return nil
+}
+
+// Rollup computes the statistics for day from the activity records and the
+// domain event log and stores them, replacing an earlier rollup of the same day
+func (r *StatsRepository) Rollup(ctx context.Context, day time.Time, storageObjects, storageBytes int64) (*models.DailyStats, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	date := day.UTC().Truncate(24 * time.Hour)
+	key := dayKey(date)
+
+	weekly := map[string]bool{}
+	for i := 0; i < 7; i++ {
+		for userID := range r.s.activity[dayKey(date.AddDate(0, 0, -i))] {
+			weekly[userID] = true
+		}
+	}
+	counts := map[string]int{}
+	for _, event := range r.s.domainEvents {
+		if dayKey(event.OccurredAt) == key {
+			counts[event.Type]++
+		}
+	}
+	peak := 0
+	for _, instancePeak := range r.s.peaks[key] {
+		peak += instancePeak
+	}
+
+	stats := &models.DailyStats{
+		Day:               date,
+		DailyActiveUsers:  len(r.s.activity[key]),
+		WeeklyActiveUsers: len(weekly),
+		NewUsers:          counts["user_created"],
+		NewPairs:          counts["pair_created"],
+		MomentsCompleted:  counts["moment_completed"],
+		StorageObjects:    storageObjects,
+		StorageBytes:      storageBytes,
+		WSPeakConnections: peak,
+		ComputedAt:        time.Now().UTC(),
+	}
+	r.s.dailyStats[key] = stats
+
+	result := *stats
+	return &result, nil
+}
+
+// List returns rollups of the most recent days, newest first
+func (r *StatsRepository) List(ctx context.Context, days int) ([]*models.DailyStats, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	result := []*models.DailyStats{}
+	for _, stats := range r.s.dailyStats {
+		found := *stats
+		result = append(result, &found)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day.After(result[j].Day)
+	})
+	return page(result, days), nil
+}
+
+// DeleteActivityBefore deletes activity records older than day; rollups keep the counts
+func (r *StatsRepository) DeleteActivityBefore(ctx context.Context, day time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	before := dayKey(day)
+	var deleted int64
+	for key, users := range r.s.activity {
+		if key < before {
+			deleted += int64(len(users))
+			delete(r.s.activity, key)
+		}
+	}
+	for key := range r.s.peaks {
+		if key < before {
+			delete(r.s.peaks, key)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,171 @@
+// Package memory implements the repositories in process memory for dev
+// mode, so the server runs without PostgreSQL. Data is lost on restart.
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Store holds the data of all memory repositories. Repositories created
+// from one Store see each other's writes, and a change is stored together
+// with its domain and outbox events under one lock, like a transaction.
+type Store struct {
+	mu sync.Mutex
+
+	users  map[string]*models.User
+	pairs  map[string]*models.Pair
+	photos map[string]*models.Photo
+	// anonymized holds the IDs of users scrubbed by Anonymize
+	anonymized map[string]bool
+	// composites are kept in creation order
+	composites []*models.Composite
+	// syncSessions are kept in creation order
+	syncSessions []*syncSessionEntry
+	// reactions and comments are kept in creation order
+	reactions []*models.Reaction
+	comments  []*models.Comment
+	// promptSettings are keyed by pair ID; dailyPrompts are kept in
+	// creation order
+	promptSettings map[string]*models.PromptSettings
+	dailyPrompts   []*models.DailyPrompt
+	// pairSettings are keyed by pair ID
+	pairSettings map[string]*models.PairSettings
+	// refreshTokens are keyed by ID
+	refreshTokens map[string]*models.RefreshToken
+	// userEvents are kept in seq order
+	userEvents   []*models.UserEvent
+	userEventSeq int64
+
+	domainEvents []*models.DomainEvent
+	consumers    map[string]int64
+	consuming    map[string]bool
+	outbox       []*outboxEntry
+
+	jobs        map[string]*jobEntry
+	idempotency map[idempotencyKey]*models.IdempotencyRecord
+
+	// activity holds user IDs by UTC day
+	activity   map[string]map[string]bool
+	peaks      map[string]map[string]int
+	dailyStats map[string]*models.DailyStats
+
+	clientEvents []*models.ClientEvent
+
+	webhooks   map[string]*models.Webhook
+	deliveries map[string]*models.WebhookDelivery
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		users:          map[string]*models.User{},
+		anonymized:     map[string]bool{},
+		pairs:          map[string]*models.Pair{},
+		photos:         map[string]*models.Photo{},
+		refreshTokens:  map[string]*models.RefreshToken{},
+		promptSettings: map[string]*models.PromptSettings{},
+		pairSettings:   map[string]*models.PairSettings{},
+		consumers:      map[string]int64{},
+		consuming:      map[string]bool{},
+		jobs:           map[string]*jobEntry{},
+		idempotency:    map[idempotencyKey]*models.IdempotencyRecord{},
+		activity:       map[string]map[string]bool{},
+		peaks:          map[string]map[string]int{},
+		dailyStats:     map[string]*models.DailyStats{},
+		webhooks:       map[string]*models.Webhook{},
+		deliveries:     map[string]*models.WebhookDelivery{},
+	}
+}
+
+// notFound wraps the domain error and pgx.ErrNoRows like the PostgreSQL
+// repositories do, so callers checking for either behave the same in dev mode
+func notFound(err error) error {
+	return fmt.Errorf("%w: %w", err, pgx.ErrNoRows)
+}
+
+// dayKey identifies the UTC day of t
+func dayKey(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// deletePair removes the pair with its photos, their reactions and
+// comments, composites, sync sessions, daily prompts and settings, like
+// the foreign keys do in PostgreSQL; the caller holds the lock
+func (s *Store) deletePair(pairID string) {
+	delete(s.pairs, pairID)
+	for photoID, photo := range s.photos {
+		if photo.PairID == pairID {
+			delete(s.photos, photoID)
+		}
+	}
+	s.reactions = slices.DeleteFunc(s.reactions, func(reaction *models.Reaction) bool {
+		_, ok := s.photos[reaction.PhotoID]
+		return !ok
+	})
+	s.comments = slices.DeleteFunc(s.comments, func(comment *models.Comment) bool {
+		_, ok := s.photos[comment.PhotoID]
+		return !ok
+	})
+	s.composites = slices.DeleteFunc(s.composites, func(composite *models.Composite) bool {
+		return composite.PairID == pairID
+	})
+	s.deleteSyncSessions(func(session *models.SyncSession) bool {
+		return session.PairID == pairID
+	})
+	delete(s.promptSettings, pairID)
+	delete(s.pairSettings, pairID)
+	s.dailyPrompts = slices.DeleteFunc(s.dailyPrompts, func(prompt *models.DailyPrompt) bool {
+		return prompt.PairID == pairID
+	})
+}
+
+// deleteUserEvents removes the user's events; the caller holds the lock
+func (s *Store) deleteUserEvents(userID string) {
+	s.userEvents = slices.DeleteFunc(s.userEvents, func(event *models.UserEvent) bool {
+		return event.UserID == userID
+	})
+}
+
+// deleteSyncSessions removes the sessions matching del and unlinks their
+// photos; the caller holds the lock
+func (s *Store) deleteSyncSessions(del func(*models.SyncSession) bool) {
+	s.syncSessions = slices.DeleteFunc(s.syncSessions, func(entry *syncSessionEntry) bool {
+		if !del(&entry.session) {
+			return false
+		}
+		for _, photo := range s.photos {
+			if photo.SessionID != nil && *photo.SessionID == entry.session.ID {
+				photo.SessionID = nil
+			}
+		}
+		return true
+	})
+}
+
+// appendDomainEvents assigns seq numbers and stores copies of events; the
+// caller holds the lock
+func (s *Store) appendDomainEvents(events []*models.DomainEvent) {
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		stored := *event
+		stored.Seq = int64(len(s.domainEvents)) + 1
+		s.domainEvents = append(s.domainEvents, &stored)
+	}
+}
+
+// appendOutboxEvents stores copies of events; the caller holds the lock
+func (s *Store) appendOutboxEvents(events []*models.OutboxEvent) {
+	for _, event := range events {
+		stored := *event
+		s.outbox = append(s.outbox, &outboxEntry{event: &stored, nextAttemptAt: event.CreatedAt})
+	}
+}
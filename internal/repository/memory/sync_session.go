@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// syncSessionEntry is a stored session with the IDs of its halves; Photos
+// are filled in from the store on every read
+type syncSessionEntry struct {
+	session          models.SyncSession
+	initiatorPhotoID string
+	partnerPhotoID   string
+}
+
+// SyncSessionRepository keeps sync sessions in a Store
+type SyncSessionRepository struct {
+	s *Store
+}
+
+// NewSyncSessionRepository creates a new memory sync session repository
+func NewSyncSessionRepository(s *Store) *SyncSessionRepository {
+	return &SyncSessionRepository{s: s}
+}
+
+// Create stores a new session without photos
+func (r *SyncSessionRepository) Create(ctx context.Context, session *models.SyncSession) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	entry := &syncSessionEntry{session: *session}
+	entry.session.Photos = nil
+	r.s.syncSessions = append(r.s.syncSessions, entry)
+	return nil
+}
+
+// GetByID retrieves a session with its photos
+func (r *SyncSessionRepository) GetByID(ctx context.Context, id string) (*models.SyncSession, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, entry := range r.s.syncSessions {
+		if entry.session.ID == id {
+			return r.withPhotos(entry), nil
+		}
+	}
+	return nil, notFound(domain.ErrSyncSessionNotFound)
+}
+
+// ListByPair returns a page of the pair's sessions with their photos,
+// newest first, and their total
+func (r *SyncSessionRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.SyncSession, int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	sessions := []*models.SyncSession{}
+	for i := len(r.s.syncSessions) - 1; i >= 0; i-- {
+		if entry := r.s.syncSessions[i]; entry.session.PairID == pairID {
+			sessions = append(sessions, r.withPhotos(entry))
+		}
+	}
+	total := len(sessions)
+	if offset > total {
+		offset = total
+	}
+	return page(sessions[offset:], limit), total, nil
+}
+
+// AttachPhoto makes the photo its owner's half of an open session and
+// completes it once both halves are there; true only for the completing call.
+// Missed sessions don't change.
+func (r *SyncSessionRepository) AttachPhoto(ctx context.Context, sessionID string, photo *models.Photo, completedAt time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, entry := range r.s.syncSessions {
+		if entry.session.ID != sessionID {
+			continue
+		}
+		if entry.session.CompletedAt != nil || entry.session.MissedAt != nil {
+			return false, nil
+		}
+		if photo.UserID == entry.session.InitiatorID {
+			entry.initiatorPhotoID = photo.ID
+		} else {
+			entry.partnerPhotoID = photo.ID
+		}
+		if entry.initiatorPhotoID == "" || entry.partnerPhotoID == "" {
+			return false, nil
+		}
+		at := completedAt
+		entry.session.CompletedAt = &at
+		return true, nil
+	}
+	return false, nil
+}
+
+// MarkMissed marks a session that is still incomplete as missed; false if
+// it completed, was already missed or doesn't exist
+func (r *SyncSessionRepository) MarkMissed(ctx context.Context, id string, missedAt time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, entry := range r.s.syncSessions {
+		if entry.session.ID != id {
+			continue
+		}
+		if entry.session.CompletedAt != nil || entry.session.MissedAt != nil {
+			return false, nil
+		}
+		at := missedAt
+		entry.session.MissedAt = &at
+		return true, nil
+	}
+	return false, nil
+}
+
+// Delete removes a session; its photos keep existing without it
+func (r *SyncSessionRepository) Delete(ctx context.Context, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.deleteSyncSessions(func(session *models.SyncSession) bool {
+		return session.ID == id
+	})
+	return nil
+}
+
+// withPhotos copies the session with its photos that still exist; the
+// caller holds the lock
+func (r *SyncSessionRepository) withPhotos(entry *syncSessionEntry) *models.SyncSession {
+	session := entry.session
+	session.Photos = []*models.Photo{}
+	for _, photoID := range []string{entry.initiatorPhotoID, entry.partnerPhotoID} {
+		if photo, ok := r.s.photos[photoID]; ok {
+			found := *photo
+			session.Photos = append(session.Photos, &found)
+		}
+	}
+	return &session
+}
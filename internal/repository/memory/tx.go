@@ -0,0 +1,18 @@
+package memory
+
+import "context"
+
+// UnitOfWork runs flows spanning several repositories. Each repository
+// call is stored under its own lock, and nothing is rolled back if fn
+// fails, which is enough for dev mode.
+type UnitOfWork struct{}
+
+// NewUnitOfWork creates a new unit of work
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+// Do runs fn
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
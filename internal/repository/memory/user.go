@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// UserRepository keeps users in a Store
+type UserRepository struct {
+	s *Store
+}
+
+// NewUserRepository creates a new memory user repository
+func NewUserRepository(s *Store) *UserRepository {
+	return &UserRepository{s: s}
+}
+
+// Create creates a new user together with its domain events
+func (r *UserRepository) Create(ctx context.Context, user *models.User, events ...*models.DomainEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	r.s.users[user.ID] = &stored
+	r.s.appendDomainEvents(events)
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[id]
+	if !ok {
+		return nil, notFound(domain.ErrUserNotFound)
+	}
+	found := *user
+	return &found, nil
+}
+
+// GetForUpdate retrieves a user by ID; the store has no row locks
+func (r *UserRepository) GetForUpdate(ctx context.Context, id string) (*models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+// GetByCode retrieves a user of the app by pairing code; deleted users are
+// reported as not found
+func (r *UserRepository) GetByCode(ctx context.Context, appID, code string) (*models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, user := range r.s.users {
+		if user.AppID == appID && user.Code == code && user.DeletedAt == nil {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, notFound(domain.ErrUserNotFound)
+}
+
+// CodeExists checks if a pairing code is taken in any app
+func (r *UserRepository) CodeExists(ctx context.Context, code string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, user := range r.s.users {
+		if user.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UpdatePushToken updates the push token for a user
+func (r *UserRepository) UpdatePushToken(ctx context.Context, userID string, pushToken *string) error {
+	r.update(userID, func(user *models.User) {
+		user.PushToken = pushToken
+	})
+	return nil
+}
+
+// UpdateQuietHours updates the user's quiet hours
+func (r *UserRepository) UpdateQuietHours(ctx context.Context, userID string, start, end *string, timezone string, alwaysAllowTriggers bool) error {
+	found := r.update(userID, func(user *models.User) {
+		user.QuietHoursStart = start
+		user.QuietHoursEnd = end
+		user.Timezone = timezone
+		user.AlwaysAllowTriggers = alwaysAllowTriggers
+	})
+	if !found {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateLocale updates the locale of user-facing messages
+func (r *UserRepository) UpdateLocale(ctx context.Context, userID string, locale *string) error {
+	found := r.update(userID, func(user *models.User) {
+		user.Locale = locale
+	})
+	if !found {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateTimeSensitiveTriggers updates whether triggers are time-sensitive pushes
+func (r *UserRepository) UpdateTimeSensitiveTriggers(ctx context.Context, userID string, enabled bool) error {
+	found := r.update(userID, func(user *models.User) {
+		user.TimeSensitive = enabled
+	})
+	if !found {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateSettings writes the user's settings if the user is still at
+// updatedAt; a user changed meanwhile gets domain.ErrPreconditionFailed
+func (r *UserRepository) UpdateSettings(ctx context.Context, user *models.User, updatedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.users[user.ID]
+	if !ok || stored.DeletedAt != nil {
+		return domain.ErrUserNotFound
+	}
+	if !stored.UpdatedAt.Equal(updatedAt) {
+		return domain.ErrPreconditionFailed
+	}
+	updated := *stored
+	updated.QuietHoursStart = user.QuietHoursStart
+	updated.QuietHoursEnd = user.QuietHoursEnd
+	updated.Timezone = user.Timezone
+	updated.AlwaysAllowTriggers = user.AlwaysAllowTriggers
+	updated.TimeSensitive = user.TimeSensitive
+	updated.Locale = user.Locale
+	updated.UpdatedAt = time.Now().UTC()
+	r.s.users[user.ID] = &updated
+	user.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// SoftDelete marks the user deleted at deletedAt and clears the push token
+func (r *UserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok || user.DeletedAt != nil {
+		return domain.ErrUserNotFound
+	}
+	updated := *user
+	updated.DeletedAt = &deletedAt
+	updated.PushToken = nil
+	updated.UpdatedAt = time.Now().UTC()
+	r.s.users[userID] = &updated
+	return nil
+}
+
+// Restore clears the deletion mark; users that aren't deleted are reported
+// as not found
+func (r *UserRepository) Restore(ctx context.Context, userID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok || user.DeletedAt == nil || r.s.anonymized[userID] {
+		return domain.ErrUserNotFound
+	}
+	updated := *user
+	updated.DeletedAt = nil
+	updated.UpdatedAt = time.Now().UTC()
+	r.s.users[userID] = &updated
+	return nil
+}
+
+// TouchLastSeen moves the user's last seen time forward to at, keeping
+// UpdatedAt
+func (r *UserRepository) TouchLastSeen(ctx context.Context, userID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok || (user.LastSeenAt != nil && !at.After(*user.LastSeenAt)) {
+		return nil
+	}
+	updated := *user
+	updated.LastSeenAt = &at
+	r.s.users[userID] = &updated
+	return nil
+}
+
+// LastSeen returns the user's last seen time, nil if they never connected
+func (r *UserRepository) LastSeen(ctx context.Context, userID string) (*time.Time, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok {
+		return nil, notFound(domain.ErrUserNotFound)
+	}
+	if user.LastSeenAt == nil {
+		return nil, nil
+	}
+	lastSeen := *user.LastSeenAt
+	return &lastSeen, nil
+}
+
+// Purge removes the user if it was deleted no later than deletedBefore,
+// together with its pairs, deleted ones included, and their photos
+func (r *UserRepository) Purge(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok || user.DeletedAt == nil || user.DeletedAt.After(deletedBefore) {
+		return false, nil
+	}
+	delete(r.s.users, userID)
+	r.s.deleteUserEvents(userID)
+	for id, token := range r.s.refreshTokens {
+		if token.UserID == userID {
+			delete(r.s.refreshTokens, id)
+		}
+	}
+	for id, pair := range r.s.pairs {
+		if pair.UserAID == userID || pair.UserBID == userID {
+			r.s.deletePair(id)
+		}
+	}
+	return true, nil
+}
+
+// Anonymize scrubs the user if it was deleted no later than deletedBefore,
+// keeping it deleted for good with its pairs and their photos, and removes
+// its refresh tokens and WebSocket events
+func (r *UserRepository) Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok || user.DeletedAt == nil || user.DeletedAt.After(deletedBefore) || r.s.anonymized[userID] {
+		return false, nil
+	}
+	r.s.users[userID] = &models.User{
+		ID:        user.ID,
+		AppID:     user.AppID,
+		Code:      user.Code,
+		Token:     "anonymized:" + user.ID,
+		Timezone:  "UTC",
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: time.Now().UTC(),
+		DeletedAt: user.DeletedAt,
+	}
+	r.s.anonymized[userID] = true
+	r.s.deleteUserEvents(userID)
+	for id, token := range r.s.refreshTokens {
+		if token.UserID == userID {
+			delete(r.s.refreshTokens, id)
+		}
+	}
+	return true, nil
+}
+
+// update applies change to a copy of the user and reports whether the
+// user exists
+func (r *UserRepository) update(userID string, change func(*models.User)) bool {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok {
+		return false
+	}
+	updated := *user
+	change(&updated)
+	updated.UpdatedAt = time.Now().UTC()
+	r.s.users[userID] = &updated
+	return true
+}
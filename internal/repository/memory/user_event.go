@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// UserEventRepository keeps the WebSocket events of users in a Store
+type UserEventRepository struct {
+	s *Store
+}
+
+// NewUserEventRepository creates a new memory user event repository
+func NewUserEventRepository(s *Store) *UserEventRepository {
+	return &UserEventRepository{s: s}
+}
+
+// Append stores the event and sets its seq
+func (r *UserEventRepository) Append(ctx context.Context, event *models.UserEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.userEventSeq++
+	event.Seq = r.s.userEventSeq
+	stored := *event
+	r.s.userEvents = append(r.s.userEvents, &stored)
+	return nil
+}
+
+// ListPending returns the user's events created after since that weren't
+// acked, in seq order
+func (r *UserEventRepository) ListPending(ctx context.Context, userID string, since time.Time) ([]*models.UserEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	events := []*models.UserEvent{}
+	for _, stored := range r.s.userEvents {
+		if stored.UserID == userID && stored.AckedAt == nil && stored.CreatedAt.After(since) {
+			found := *stored
+			events = append(events, &found)
+		}
+	}
+	return events, nil
+}
+
+// Ack marks the user's events up to seq as acked
+func (r *UserEventRepository) Ack(ctx context.Context, userID string, seq int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, stored := range r.s.userEvents {
+		if stored.UserID == userID && stored.Seq <= seq && stored.AckedAt == nil {
+			stored.AckedAt = &now
+		}
+	}
+	return nil
+}
+
+// DeleteBefore deletes events created before the given time, acked or not
+func (r *UserEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	count := len(r.s.userEvents)
+	r.s.userEvents = slices.DeleteFunc(r.s.userEvents, func(event *models.UserEvent) bool {
+		return event.CreatedAt.Before(before)
+	})
+	return int64(count - len(r.s.userEvents)), nil
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+)
+
+// WebhookRepository keeps webhooks and their deliveries in a Store
+type WebhookRepository struct {
+	s *Store
+}
+
+// NewWebhookRepository creates a new memory webhook repository
+func NewWebhookRepository(s *Store) *WebhookRepository {
+	return &WebhookRepository{s: s}
+}
+
+// Create creates a new webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+// Update saves the webhook's URL, events, app and enabled flag
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.webhooks[webhook.ID]
+	if !ok {
+		return domain.ErrWebhookNotFound
+	}
+	updated := copyWebhook(webhook)
+	updated.Secret = stored.Secret
+	updated.CreatedAt = stored.CreatedAt
+	r.s.webhooks[webhook.ID] = updated
+	return nil
+}
+
+// GetByID retrieves a webhook by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id string) (*models.Webhook, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	webhook, ok := r.s.webhooks[id]
+	if !ok {
+		return nil, domain.ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// List returns all webhooks, newest first
+func (r *WebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	return r.list(func(*models.Webhook) bool { return true }), nil
+}
+
+// ListSubscribed returns enabled webhooks subscribed to the event of the app
+func (r *WebhookRepository) ListSubscribed(ctx context.Context, eventType, appID string) ([]*models.Webhook, error) {
+	return r.list(func(webhook *models.Webhook) bool {
+		return webhook.Enabled &&
+			slices.Contains(webhook.Events, eventType) &&
+			(webhook.AppID == nil || *webhook.AppID == appID)
+	}), nil
+}
+
+func (r *WebhookRepository) list(keep func(*models.Webhook) bool) []*models.Webhook {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	webhooks := []*models.Webhook{}
+	for _, webhook := range r.s.webhooks {
+		if keep(webhook) {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.After(webhooks[j].CreatedAt)
+	})
+	return webhooks
+}
+
+// Delete deletes a webhook together with its delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.webhooks[id]; !ok {
+		return domain.ErrWebhookNotFound
+	}
+	delete(r.s.webhooks, id)
+	for deliveryID, delivery := range r.s.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// CreateDelivery records a pending delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *delivery
+	stored.Attempts = 0
+	stored.UpdatedAt = delivery.CreatedAt
+	r.s.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delivery, ok := r.s.deliveries[id]
+	if !ok {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	found := *delivery
+	return &found, nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id, status string, responseStatus *int, lastError *string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delivery, ok := r.s.deliveries[id]
+	if !ok {
+		return nil
+	}
+	updated := *delivery
+	updated.Status = status
+	updated.Attempts++
+	updated.ResponseStatus = responseStatus
+	updated.LastError = lastError
+	updated.UpdatedAt = time.Now().UTC()
+	r.s.deliveries[id] = &updated
+	return nil
+}
+
+// ListDeliveries returns the webhook's most recent deliveries, optionally
+// filtered by status
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID, status string, limit int) ([]*models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, delivery := range r.s.deliveries {
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			found := *delivery
+			deliveries = append(deliveries, &found)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	return page(deliveries, limit), nil
+}
+
+// DeleteDeliveries deletes deliveries created before the given time
+func (r *WebhookRepository) DeleteDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var deleted int64
+	for id, delivery := range r.s.deliveries {
+		if delivery.CreatedAt.Before(before) {
+			delete(r.s.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copyWebhook copies the webhook so callers can't modify the stored events
+func copyWebhook(webhook *models.Webhook) *models.Webhook {
+	found := *webhook
+	found.Events = slices.Clone(webhook.Events)
+	return &found
+}
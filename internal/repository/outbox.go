@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// execer is implemented by both *pgxpool.Pool and pgx.Tx
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// insertOutboxEvents writes events using q, so callers can include them in
+// the transaction that performs the domain change
+func insertOutboxEvents(ctx context.Context, q execer, events []*models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	for _, event := range events {
+		if _, err := q.Exec(ctx, query, event.ID, event.Type, event.Payload, event.CreatedAt); err != nil {
+			return fmt.Errorf("failed to write outbox event: %w", err)
+		}
+	}
+	return nil
+}
+
+// OutboxRepository handles database operations for the relay side of the outbox
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Process locks up to limit due events, passes each to handle and records the
+// results in one transaction. Locked events are skipped by other relays.
+func (r *OutboxRepository) Process(ctx context.Context, limit int, handle func(*models.OutboxEvent) models.OutboxResult) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT id, event_type, payload, attempts, created_at
+		FROM outbox_events
+		WHERE processed_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select outbox events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.OutboxEvent, error) {
+		var event models.OutboxEvent
+		err := row.Scan(&event.ID, &event.Type, &event.Payload, &event.Attempts, &event.CreatedAt)
+		return &event, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan outbox events: %w", err)
+	}
+
+	for _, event := range events {
+		result := handle(event)
+		if err := markOutboxEvent(ctx, tx, event.ID, result); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	return len(events), nil
+}
+
+func markOutboxEvent(ctx context.Context, tx pgx.Tx, id string, result models.OutboxResult) error {
+	var lastError *string
+	if result.Err != nil {
+		msg := result.Err.Error()
+		lastError = &msg
+	}
+
+	var err error
+	if result.Err != nil && !result.RetryAt.IsZero() {
+		_, err = tx.Exec(ctx, `
+			UPDATE outbox_events
+			SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+			WHERE id = $1
+		`, id, lastError, result.RetryAt)
+	} else {
+		_, err = tx.Exec(ctx, `
+			UPDATE outbox_events
+			SET attempts = attempts + 1, last_error = $2, processed_at = NOW()
+			WHERE id = $1
+		`, id, lastError)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update outbox event: %w", err)
+	}
+	return nil
+}
+
+// DeleteProcessed deletes events processed before the given time
+func (r *OutboxRepository) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM outbox_events WHERE processed_at < $1`
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed outbox events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"sync-photo-backend/internal/cache"
+	"sync-photo-backend/internal/domain"
 	"sync-photo-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -12,7 +15,8 @@ import (
 
 // PairRepository handles database operations for pairs
 type PairRepository struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	cache *cacheLayer
 }
 
 // NewPairRepository creates a new pair repository
@@ -20,78 +24,230 @@ func NewPairRepository(db *pgxpool.Pool) *PairRepository {
 	return &PairRepository{db: db}
 }
 
-// Create creates a new pair
-func (r *PairRepository) Create(ctx context.Context, pair *models.Pair) error {
+// WithCache enables caching of pair lookups by ID and by user ID.
+// Entries are invalidated when a pair is created or deleted.
+func (r *PairRepository) WithCache(c cache.Cache, ttl time.Duration) *PairRepository {
+	r.cache = &cacheLayer{cache: c, ttl: ttl, name: "pair"}
+	return r
+}
+
+// Ключи версионированы, чтобы записи без updated_at не читались
+func pairByIDKey(id string) string         { return "pair:v2:id:" + id }
+func pairByUserIDKey(userID string) string { return "pair:v2:user:" + userID }
+
+const pairColumns = `id, app_id, user_a_id, user_b_id, created_at, updated_at, deleted_at`
+
+func scanPair(row pgx.Row) (*models.Pair, error) {
+	var pair models.Pair
+	err := row.Scan(
+		&pair.ID, &pair.AppID, &pair.UserAID, &pair.UserBID, &pair.CreatedAt, &pair.UpdatedAt, &pair.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pair, nil
+}
+
+// Create creates a new pair. The domain event and outbox events are written
+// in the same transaction.
+func (r *PairRepository) Create(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO pairs (id, user_a_id, user_b_id, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO pairs (id, app_id, user_a_id, user_b_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
 	`
-	_, err := r.db.Exec(ctx, query, pair.ID, pair.UserAID, pair.UserBID, pair.CreatedAt)
+	_, err = tx.Exec(ctx, query, pair.ID, pair.AppID, pair.UserAID, pair.UserBID, pair.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create pair: %w", err)
 	}
+	if err := insertDomainEvents(ctx, tx, []*models.DomainEvent{event}); err != nil {
+		return err
+	}
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to create pair: %w", err)
+	}
+	pair.UpdatedAt = pair.CreatedAt
+	// Сбросить закешированное "нет пары" у обоих пользователей
+	r.cache.invalidate(ctx, pairByUserIDKey(pair.UserAID), pairByUserIDKey(pair.UserBID))
 	return nil
 }
 
-// GetByID retrieves a pair by ID
+// GetByID retrieves an active pair by ID
 func (r *PairRepository) GetByID(ctx context.Context, id string) (*models.Pair, error) {
-	query := `
-		SELECT id, user_a_id, user_b_id, created_at
-		FROM pairs
-		WHERE id = $1
-	`
-	var pair models.Pair
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&pair.ID, &pair.UserAID, &pair.UserBID, &pair.CreatedAt,
-	)
+	var cached models.Pair
+	if r.cache.get(ctx, pairByIDKey(id), &cached) {
+		return &cached, nil
+	}
+
+	query := `SELECT ` + pairColumns + ` FROM pairs WHERE id = $1 AND deleted_at IS NULL`
+	pair, err := scanPair(conn(ctx, r.db).QueryRow(ctx, query, id))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("pair not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", domain.ErrPairNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get pair: %w", err)
 	}
-	return &pair, nil
+	r.cache.set(ctx, pairByIDKey(id), pair)
+	return pair, nil
 }
 
-// GetByUserID retrieves a pair by user ID
+// GetByUserID retrieves the active pair of a user
 func (r *PairRepository) GetByUserID(ctx context.Context, userID string) (*models.Pair, error) {
+	// Отсутствие пары тоже кешируется (как null)
+	var cached *models.Pair
+	if r.cache.get(ctx, pairByUserIDKey(userID), &cached) {
+		if cached == nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrPairNotFound, pgx.ErrNoRows)
+		}
+		return cached, nil
+	}
+
 	query := `
-		SELECT id, user_a_id, user_b_id, created_at
+		SELECT ` + pairColumns + `
 		FROM pairs
-		WHERE user_a_id = $1 OR user_b_id = $1
+		WHERE (user_a_id = $1 OR user_b_id = $1) AND deleted_at IS NULL
 		LIMIT 1
 	`
-	var pair models.Pair
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&pair.ID, &pair.UserAID, &pair.UserBID, &pair.CreatedAt,
-	)
+	pair, err := scanPair(conn(ctx, r.db).QueryRow(ctx, query, userID))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("pair not found: %w", err)
+			r.cache.set(ctx, pairByUserIDKey(userID), nil)
+			return nil, fmt.Errorf("%w: %w", domain.ErrPairNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get pair by user id: %w", err)
 	}
-	return &pair, nil
+	r.cache.set(ctx, pairByUserIDKey(userID), pair)
+	return pair, nil
 }
 
-// Delete deletes a pair by ID
-func (r *PairRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM pairs WHERE id = $1`
-	result, err := r.db.Exec(ctx, query, id)
+// GetByUsers retrieves the pair of two users, deleted or not. userAID is
+// the lexicographically smaller ID, as in Create.
+func (r *PairRepository) GetByUsers(ctx context.Context, userAID, userBID string) (*models.Pair, error) {
+	query := `SELECT ` + pairColumns + ` FROM pairs WHERE user_a_id = $1 AND user_b_id = $2`
+	pair, err := scanPair(conn(ctx, r.db).QueryRow(ctx, query, userAID, userBID))
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %w", domain.ErrPairNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get pair by users: %w", err)
+	}
+	return pair, nil
+}
+
+// ListByUserID returns all pairs of a user, deleted ones included, newest first
+func (r *PairRepository) ListByUserID(ctx context.Context, userID string) ([]*models.Pair, error) {
+	query := `
+		SELECT ` + pairColumns + `
+		FROM pairs
+		WHERE user_a_id = $1 OR user_b_id = $1
+		ORDER BY created_at DESC, id
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pairs: %w", err)
+	}
+	defer rows.Close()
+
+	pairs := []*models.Pair{}
+	for rows.Next() {
+		pair, err := scanPair(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pair: %w", err)
+		}
+		pairs = append(pairs, pair)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pairs: %w", err)
+	}
+	return pairs, nil
+}
+
+// Delete marks an active pair deleted; its photos, composites and sessions
+// are kept for Restore. The domain event and outbox events are written in
+// the same transaction.
+func (r *PairRepository) Delete(ctx context.Context, id string, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE pairs SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING user_a_id, user_b_id
+	`
+	var userAID, userBID string
+	err = tx.QueryRow(ctx, query, id).Scan(&userAID, &userBID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("%w: %w", domain.ErrPairNotFound, err)
+		}
+		return fmt.Errorf("failed to delete pair: %w", err)
+	}
+	if err := insertDomainEvents(ctx, tx, []*models.DomainEvent{event}); err != nil {
+		return err
+	}
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to delete pair: %w", err)
 	}
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("pair not found")
+	r.cache.invalidate(ctx, pairByIDKey(id), pairByUserIDKey(userAID), pairByUserIDKey(userBID))
+	return nil
+}
+
+// Restore makes a deleted pair active again and sets pair.UpdatedAt and
+// pair.DeletedAt. The domain event and outbox events are written in the
+// same transaction.
+func (r *PairRepository) Restore(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE pairs SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING updated_at
+	`
+	var updatedAt time.Time
+	if err := tx.QueryRow(ctx, query, pair.ID).Scan(&updatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("%w: %w", domain.ErrPairNotFound, err)
+		}
+		return fmt.Errorf("failed to restore pair: %w", err)
+	}
+	if err := insertDomainEvents(ctx, tx, []*models.DomainEvent{event}); err != nil {
+		return err
+	}
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to restore pair: %w", err)
 	}
+	pair.UpdatedAt = updatedAt
+	pair.DeletedAt = nil
+	r.cache.invalidate(ctx, pairByIDKey(pair.ID), pairByUserIDKey(pair.UserAID), pairByUserIDKey(pair.UserBID))
 	return nil
 }
 
-// UserHasPair checks if a user is already in a pair
+// UserHasPair checks if a user is already in an active pair
 func (r *PairRepository) UserHasPair(ctx context.Context, userID string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM pairs WHERE user_a_id = $1 OR user_b_id = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM pairs WHERE (user_a_id = $1 OR user_b_id = $1) AND deleted_at IS NULL)`
 	var exists bool
-	err := r.db.QueryRow(ctx, query, userID).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if user has pair: %w", err)
 	}
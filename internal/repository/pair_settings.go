@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PairSettingsRepository handles database operations for the settings
+// partners share
+type PairSettingsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPairSettingsRepository creates a new pair settings repository
+func NewPairSettingsRepository(db *pgxpool.Pool) *PairSettingsRepository {
+	return &PairSettingsRepository{db: db}
+}
+
+// Get returns the pair's settings; a pair that never saved any gets the
+// defaults with a zero UpdatedAt
+func (r *PairSettingsRepository) Get(ctx context.Context, pairID string) (*models.PairSettings, error) {
+	settings := models.PairSettings{PairID: pairID}
+	var countdownMS *int64
+	query := `
+		SELECT mute_start, mute_end, countdown_ms, timezone, auto_composite, updated_at
+		FROM pair_settings WHERE pair_id = $1
+	`
+	err := conn(ctx, r.db).QueryRow(ctx, query, pairID).Scan(
+		&settings.MuteStart, &settings.MuteEnd, &countdownMS, &settings.Timezone,
+		&settings.AutoComposite, &settings.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.PairSettings{PairID: pairID, AutoComposite: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair settings: %w", err)
+	}
+	if countdownMS != nil {
+		countdown := time.Duration(*countdownMS) * time.Millisecond
+		settings.Countdown = &countdown
+	}
+	return &settings, nil
+}
+
+// Save stores the pair's settings if they are still at the updatedAt they
+// were read at, zero for settings never saved, and sets the new UpdatedAt.
+// Settings changed meanwhile get domain.ErrPreconditionFailed.
+func (r *PairSettingsRepository) Save(ctx context.Context, settings *models.PairSettings, updatedAt time.Time) error {
+	var countdownMS *int64
+	if settings.Countdown != nil {
+		ms := settings.Countdown.Milliseconds()
+		countdownMS = &ms
+	}
+	query := `
+		INSERT INTO pair_settings (pair_id, mute_start, mute_end, countdown_ms, timezone, auto_composite, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (pair_id) DO UPDATE SET
+			mute_start = EXCLUDED.mute_start,
+			mute_end = EXCLUDED.mute_end,
+			countdown_ms = EXCLUDED.countdown_ms,
+			timezone = EXCLUDED.timezone,
+			auto_composite = EXCLUDED.auto_composite,
+			updated_at = EXCLUDED.updated_at
+		WHERE pair_settings.updated_at = $7
+		RETURNING updated_at
+	`
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		settings.PairID, settings.MuteStart, settings.MuteEnd, countdownMS, settings.Timezone,
+		settings.AutoComposite, updatedAt,
+	).Scan(&settings.UpdatedAt)
+	// Строка уже есть и изменилась после чтения
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrPreconditionFailed
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save pair settings: %w", err)
+	}
+	return nil
+}
@@ -3,78 +3,106 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"sync-photo-backend/internal/domain"
 	"sync-photo-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// photoColumns are the columns photo queries select; scan them with scanPhoto
+const photoColumns = `id, pair_id, user_id, s3_key, session_id,
+	COALESCE(size_bytes, 0), COALESCE(content_type, ''),
+	COALESCE(width, 0), COALESCE(height, 0), device_taken_at, uploaded_at,
+	taken_at, created_at, updated_at`
+
 // PhotoRepository handles database operations for photos
 type PhotoRepository struct {
 	db *pgxpool.Pool
+	// read serves read-only queries; it is db unless a replica is configured
+	read *pgxpool.Pool
 }
 
 // NewPhotoRepository creates a new photo repository
 func NewPhotoRepository(db *pgxpool.Pool) *PhotoRepository {
-	return &PhotoRepository{db: db}
+	return &PhotoRepository{db: db, read: db}
+}
+
+// WithReadReplica routes photo lookups and counts to a read replica.
+// A nil replica keeps all queries on the primary.
+func (r *PhotoRepository) WithReadReplica(replica *pgxpool.Pool) *PhotoRepository {
+	if replica != nil {
+		r.read = replica
+	}
+	return r
 }
 
-// Create creates a new photo
-func (r *PhotoRepository) Create(ctx context.Context, photo *models.Photo) error {
+// Create creates a new photo. Domain events are appended in the same transaction.
+func (r *PhotoRepository) Create(ctx context.Context, photo *models.Photo, events ...*models.DomainEvent) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO photos (id, pair_id, user_id, s3_url, taken_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO photos (
+			id, pair_id, user_id, s3_key, session_id, size_bytes, content_type,
+			width, height, device_taken_at, uploaded_at, taken_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, 0), $10, $11, $12, $13, $13)
 	`
-	_, err := r.db.Exec(ctx, query,
-		photo.ID, photo.PairID, photo.UserID, photo.S3URL, photo.TakenAt, photo.CreatedAt,
+	_, err = tx.Exec(ctx, query,
+		photo.ID, photo.PairID, photo.UserID, photo.S3Key, photo.SessionID, photo.SizeBytes, photo.ContentType,
+		photo.Width, photo.Height, photo.DeviceTakenAt, photo.UploadedAt, photo.TakenAt, photo.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create photo: %w", err)
 	}
+	if err := insertDomainEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to create photo: %w", err)
+	}
+	photo.UpdatedAt = photo.CreatedAt
 	return nil
 }
 
-// GetByID retrieves a photo by ID
+// GetByID retrieves a photo by ID, pending or not
 func (r *PhotoRepository) GetByID(ctx context.Context, id string) (*models.Photo, error) {
-	query := `
-		SELECT id, pair_id, user_id, s3_url, taken_at, created_at
-		FROM photos
-		WHERE id = $1
-	`
-	var photo models.Photo
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&photo.ID, &photo.PairID, &photo.UserID, &photo.S3URL,
-		&photo.TakenAt, &photo.CreatedAt,
-	)
+	query := `SELECT ` + photoColumns + ` FROM photos WHERE id = $1`
+	photo, err := scanPhoto(conn(ctx, r.read).QueryRow(ctx, query, id))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("photo not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", domain.ErrPhotoNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get photo: %w", err)
 	}
-	return &photo, nil
+	return photo, nil
 }
 
-// GetByPairID retrieves photos by pair ID with pagination
+// GetByPairID retrieves uploaded photos by pair ID with pagination
 func (r *PhotoRepository) GetByPairID(ctx context.Context, pairID string, limit, offset int) ([]*models.Photo, int, error) {
 	// Get total count
-	countQuery := `SELECT COUNT(*) FROM photos WHERE pair_id = $1`
+	countQuery := `SELECT COUNT(*) FROM photos WHERE pair_id = $1 AND uploaded_at IS NOT NULL`
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, pairID).Scan(&total)
+	err := conn(ctx, r.read).QueryRow(ctx, countQuery, pairID).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count photos: %w", err)
 	}
 
 	// Get photos
 	query := `
-		SELECT id, pair_id, user_id, s3_url, taken_at, created_at
+		SELECT ` + photoColumns + `
 		FROM photos
-		WHERE pair_id = $1
+		WHERE pair_id = $1 AND uploaded_at IS NOT NULL
 		ORDER BY taken_at DESC
 		LIMIT $2 OFFSET $3
 	`
-	rows, err := r.db.Query(ctx, query, pairID, limit, offset)
+	rows, err := conn(ctx, r.read).Query(ctx, query, pairID, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get photos: %w", err)
 	}
@@ -82,15 +110,11 @@ func (r *PhotoRepository) GetByPairID(ctx context.Context, pairID string, limit,
 
 	var photos []*models.Photo
 	for rows.Next() {
-		var photo models.Photo
-		err := rows.Scan(
-			&photo.ID, &photo.PairID, &photo.UserID, &photo.S3URL,
-			&photo.TakenAt, &photo.CreatedAt,
-		)
+		photo, err := scanPhoto(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan photo: %w", err)
 		}
-		photos = append(photos, &photo)
+		photos = append(photos, photo)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -100,15 +124,213 @@ func (r *PhotoRepository) GetByPairID(ctx context.Context, pairID string, limit,
 	return photos, total, nil
 }
 
-// UpdateS3URL updates the S3 URL for a photo
-func (r *PhotoRepository) UpdateS3URL(ctx context.Context, photoID, s3URL string) error {
-	query := `UPDATE photos SET s3_url = $1 WHERE id = $2`
-	result, err := r.db.Exec(ctx, query, s3URL, photoID)
+// ListByPairBefore returns up to limit uploaded photos of the pair taken before
+// the (takenAt, id) position, newest first. A zero takenAt starts from the newest photo.
+func (r *PhotoRepository) ListByPairBefore(ctx context.Context, pairID string, takenAt time.Time, id string, limit int) ([]*models.Photo, error) {
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos
+		WHERE pair_id = $1 AND uploaded_at IS NOT NULL
+			AND ($2::timestamptz IS NULL OR (taken_at, id) < ($2, $3::uuid))
+		ORDER BY taken_at DESC, id DESC
+		LIMIT $4
+	`
+	var before *time.Time
+	if !takenAt.IsZero() {
+		before = &takenAt
+	}
+	var beforeID *string
+	if id != "" {
+		beforeID = &id
+	}
+
+	rows, err := conn(ctx, r.read).Query(ctx, query, pairID, before, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPhotos(rows)
+}
+
+// Confirm records the verified upload of a pending photo and returns the
+// photo. The domain event and outbox events built by confirmed are written
+// while the row is locked. A photo uploaded before is returned unchanged
+// with false, without calling confirmed.
+func (r *PhotoRepository) Confirm(
+	ctx context.Context,
+	photoID string,
+	upload models.PhotoUpload,
+	confirmed func(*models.Photo) (*models.DomainEvent, []*models.OutboxEvent, error),
+) (*models.Photo, bool, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to update photo s3_url: %w", err)
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("photo not found")
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + photoColumns + ` FROM photos WHERE id = $1 FOR UPDATE`
+	photo, err := scanPhoto(tx.QueryRow(ctx, query, photoID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, false, fmt.Errorf("%w: %w", domain.ErrPhotoNotFound, err)
+		}
+		return nil, false, fmt.Errorf("failed to get photo: %w", err)
 	}
-	return nil
+	// Повторное подтверждение (ретрай клиента) ничего не меняет
+	if photo.UploadedAt != nil {
+		return photo, false, nil
+	}
+
+	photo.SizeBytes = upload.SizeBytes
+	photo.ContentType = upload.ContentType
+	photo.Width = upload.Width
+	photo.Height = upload.Height
+	photo.DeviceTakenAt = upload.DeviceTakenAt
+	photo.UploadedAt = &upload.UploadedAt
+	photo.UpdatedAt = upload.UploadedAt
+
+	event, outbox, err := confirmed(photo)
+	if err != nil {
+		return nil, false, err
+	}
+
+	update := `
+		UPDATE photos
+		SET size_bytes = $2, content_type = NULLIF($3, ''),
+			width = NULLIF($4, 0), height = NULLIF($5, 0), device_taken_at = $6,
+			uploaded_at = $7, updated_at = $7
+		WHERE id = $1
+	`
+	_, err = tx.Exec(ctx, update,
+		photo.ID, photo.SizeBytes, photo.ContentType,
+		photo.Width, photo.Height, photo.DeviceTakenAt, upload.UploadedAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to confirm photo: %w", err)
+	}
+	if err := insertDomainEvents(ctx, tx, []*models.DomainEvent{event}); err != nil {
+		return nil, false, err
+	}
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to confirm photo: %w", err)
+	}
+	return photo, true, nil
+}
+
+// DeletePending deletes up to limit photos created before createdBefore
+// whose upload was never completed. The rows stay locked while removed
+// deletes their objects, so a concurrent Confirm waits and then finds no
+// photo; only the photos whose IDs removed returns are deleted, the rest
+// are retried later. Returns the number of deleted photos.
+func (r *PhotoRepository) DeletePending(ctx context.Context, createdBefore time.Time, limit int, removed func([]*models.Photo) []string) (int, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// SKIP LOCKED: фото, которое сейчас подтверждается, и работа других инстансов пропускаются
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos
+		WHERE uploaded_at IS NULL AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, createdBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending photos: %w", err)
+	}
+	photos, err := collectPhotos(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	if len(photos) == 0 {
+		return 0, nil
+	}
+
+	ids := removed(photos)
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := tx.Exec(ctx, `DELETE FROM photos WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete pending photos: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete pending photos: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// ListKeysByPair returns the object keys of all the pair's photos,
+// pending uploads included
+func (r *PhotoRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT s3_key FROM photos WHERE pair_id = $1`, pairID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo keys: %w", err)
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photo keys: %w", err)
+	}
+	return keys, nil
+}
+
+// ListAround returns the pair's uploaded photos taken no more than window
+// before or after takenAt, oldest first. It reads from the primary so a
+// photo updated just now is included.
+func (r *PhotoRepository) ListAround(ctx context.Context, pairID string, takenAt time.Time, window time.Duration) ([]*models.Photo, error) {
+	query := `
+		SELECT ` + photoColumns + `
+		FROM photos
+		WHERE pair_id = $1 AND uploaded_at IS NOT NULL AND taken_at BETWEEN $2 AND $3
+		ORDER BY taken_at, id
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, pairID, takenAt.Add(-window), takenAt.Add(window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+	defer rows.Close()
+
+	return collectPhotos(rows)
+}
+
+// scanPhoto scans a row of photoColumns
+func scanPhoto(row pgx.Row) (*models.Photo, error) {
+	var photo models.Photo
+	err := row.Scan(
+		&photo.ID, &photo.PairID, &photo.UserID, &photo.S3Key, &photo.SessionID,
+		&photo.SizeBytes, &photo.ContentType,
+		&photo.Width, &photo.Height, &photo.DeviceTakenAt, &photo.UploadedAt,
+		&photo.TakenAt, &photo.CreatedAt, &photo.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// collectPhotos scans all rows of photoColumns
+func collectPhotos(rows pgx.Rows) ([]*models.Photo, error) {
+	photos := []*models.Photo{}
+	for rows.Next() {
+		photo, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photos = append(photos, photo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating photos: %w", err)
+	}
+
+	return photos, nil
 }
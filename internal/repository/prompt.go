@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const dailyPromptColumns = `id, pair_id, day, prompt, sent_at, expires_at,
+	user_a_completed_at, user_b_completed_at, completed_at`
+
+func scanDailyPrompt(row pgx.Row) (*models.DailyPrompt, error) {
+	var prompt models.DailyPrompt
+	err := row.Scan(
+		&prompt.ID, &prompt.PairID, &prompt.Day, &prompt.Prompt, &prompt.SentAt, &prompt.ExpiresAt,
+		&prompt.UserACompletedAt, &prompt.UserBCompletedAt, &prompt.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// MarkPromptCompleted records that user A, or user B if userA is false,
+// uploaded a photo for the prompt at at. It reports whether that changed
+// the prompt and whether it completed it for both partners.
+func MarkPromptCompleted(prompt *models.DailyPrompt, userA bool, at time.Time) (changed, completed bool) {
+	own := &prompt.UserBCompletedAt
+	if userA {
+		own = &prompt.UserACompletedAt
+	}
+	if *own != nil {
+		return false, false
+	}
+	*own = &at
+	if prompt.UserACompletedAt != nil && prompt.UserBCompletedAt != nil {
+		prompt.CompletedAt = &at
+		return true, true
+	}
+	return true, false
+}
+
+// PromptRepository handles database operations for daily photo prompts
+type PromptRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPromptRepository creates a new prompt repository
+func NewPromptRepository(db *pgxpool.Pool) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+// GetSettings returns the pair's prompt settings; a pair that never saved
+// any gets prompts enabled at the default time
+func (r *PromptRepository) GetSettings(ctx context.Context, pairID string) (*models.PromptSettings, error) {
+	settings := models.PromptSettings{PairID: pairID}
+	query := `SELECT enabled, local_time, updated_at FROM prompt_settings WHERE pair_id = $1`
+	err := conn(ctx, r.db).QueryRow(ctx, query, pairID).Scan(
+		&settings.Enabled, &settings.Time, &settings.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.PromptSettings{PairID: pairID, Enabled: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// SaveSettings stores the pair's prompt settings
+func (r *PromptRepository) SaveSettings(ctx context.Context, settings *models.PromptSettings) error {
+	query := `
+		INSERT INTO prompt_settings (pair_id, enabled, local_time, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (pair_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			local_time = EXCLUDED.local_time,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		settings.PairID, settings.Enabled, settings.Time, settings.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save prompt settings: %w", err)
+	}
+	return nil
+}
+
+// ListDue returns up to limit active pairs with prompts enabled whose local
+// time at now is past their prompt time, defaultTime unless they chose
+// one, and which have no prompt for that local day yet
+func (r *PromptRepository) ListDue(ctx context.Context, now time.Time, defaultTime string, limit int) ([]*models.DuePrompt, error) {
+	query := `
+		SELECT p.id, p.app_id, p.user_a_id, p.user_b_id, p.created_at, p.updated_at, p.deleted_at,
+			l.local::date
+		FROM pairs p
+		JOIN users u ON u.id = p.user_a_id
+		LEFT JOIN prompt_settings s ON s.pair_id = p.id
+		LEFT JOIN pair_settings ps ON ps.pair_id = p.id
+		CROSS JOIN LATERAL (SELECT $1::timestamptz AT TIME ZONE COALESCE(ps.timezone, u.timezone) AS local) l
+		WHERE p.deleted_at IS NULL
+			AND COALESCE(s.enabled, TRUE)
+			AND l.local::time >= COALESCE(s.local_time, $2)::time
+			AND NOT EXISTS (
+				SELECT 1 FROM daily_prompts d WHERE d.pair_id = p.id AND d.day = l.local::date
+			)
+		ORDER BY p.id
+		LIMIT $3
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, now, defaultTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pairs due for a prompt: %w", err)
+	}
+	defer rows.Close()
+
+	due := []*models.DuePrompt{}
+	for rows.Next() {
+		var pair models.Pair
+		var day time.Time
+		err := rows.Scan(
+			&pair.ID, &pair.AppID, &pair.UserAID, &pair.UserBID, &pair.CreatedAt, &pair.UpdatedAt, &pair.DeletedAt,
+			&day,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pair due for a prompt: %w", err)
+		}
+		due = append(due, &models.DuePrompt{Pair: &pair, Day: day})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pairs due for a prompt: %w", err)
+	}
+	return due, nil
+}
+
+// Create stores the prompt with the outbox events announcing it. Returns
+// false without writing anything if the pair already has a prompt for the
+// day, e.g. sent by another instance.
+func (r *PromptRepository) Create(ctx context.Context, prompt *models.DailyPrompt, outbox ...*models.OutboxEvent) (bool, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO daily_prompts (id, pair_id, day, prompt, sent_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (pair_id, day) DO NOTHING
+	`
+	result, err := tx.Exec(ctx, query,
+		prompt.ID, prompt.PairID, prompt.Day, prompt.Prompt, prompt.SentAt, prompt.ExpiresAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create daily prompt: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	if err := insertOutboxEvents(ctx, tx, outbox); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to create daily prompt: %w", err)
+	}
+	return true, nil
+}
+
+// Complete records a photo user A, or user B if userA is false, uploaded
+// at at for the pair's prompt open at that time. It returns the prompt, nil
+// if none was open, and whether this completed it for both partners.
+func (r *PromptRepository) Complete(ctx context.Context, pairID string, userA bool, at time.Time) (*models.DailyPrompt, bool, error) {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка строки не дает партнерам одновременно не заметить друг друга
+	query := `
+		SELECT ` + dailyPromptColumns + `
+		FROM daily_prompts
+		WHERE pair_id = $1 AND sent_at <= $2 AND expires_at > $2
+		ORDER BY sent_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+	prompt, err := scanDailyPrompt(tx.QueryRow(ctx, query, pairID, at))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get open daily prompt: %w", err)
+	}
+
+	changed, completed := MarkPromptCompleted(prompt, userA, at)
+	if !changed {
+		return prompt, false, nil
+	}
+	query = `
+		UPDATE daily_prompts
+		SET user_a_completed_at = $2, user_b_completed_at = $3, completed_at = $4
+		WHERE id = $1
+	`
+	_, err = tx.Exec(ctx, query, prompt.ID, prompt.UserACompletedAt, prompt.UserBCompletedAt, prompt.CompletedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to complete daily prompt: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to complete daily prompt: %w", err)
+	}
+	return prompt, completed, nil
+}
+
+// ListByPair returns all prompts of the pair, newest first
+func (r *PromptRepository) ListByPair(ctx context.Context, pairID string) ([]*models.DailyPrompt, error) {
+	query := `SELECT ` + dailyPromptColumns + ` FROM daily_prompts WHERE pair_id = $1 ORDER BY day DESC`
+	rows, err := conn(ctx, r.db).Query(ctx, query, pairID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily prompts: %w", err)
+	}
+	defer rows.Close()
+
+	prompts := []*models.DailyPrompt{}
+	for rows.Next() {
+		prompt, err := scanDailyPrompt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan daily prompt: %w", err)
+		}
+		prompts = append(prompts, prompt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list daily prompts: %w", err)
+	}
+	return prompts, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// repositoryPkg is the import path used to find the calling repository method
+var repositoryPkg = reflect.TypeOf(QueryTracer{}).PkgPath() + "."
+
+type queryStartKey struct{}
+
+// queryStart is kept in the context between TraceQueryStart and TraceQueryEnd
+type queryStart struct {
+	method string
+	sql    string
+	start  time.Time
+}
+
+// QueryTracer records per-query latency by repository method and logs
+// queries slower than SlowThreshold, to catch missing indexes as data grows
+type QueryTracer struct {
+	SlowThreshold time.Duration
+}
+
+// NewQueryTracer creates a query tracer; a non-positive threshold disables slow-query logging
+func NewQueryTracer(slowThreshold time.Duration) *QueryTracer {
+	return &QueryTracer{SlowThreshold: slowThreshold}
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{
+		method: callerMethod(),
+		sql:    data.SQL,
+		start:  time.Now(),
+	})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(q.start)
+
+	status := "ok"
+	if data.Err != nil && data.Err != pgx.ErrNoRows {
+		status = "error"
+	}
+	metrics.DBQueryDuration.WithLabelValues(q.method, status).Observe(elapsed.Seconds())
+
+	if t.SlowThreshold > 0 && elapsed >= t.SlowThreshold {
+		log.Ctx(ctx).Warn().
+			Str("method", q.method).
+			Dur("duration", elapsed).
+			Str("sql", strings.Join(strings.Fields(q.sql), " ")).
+			Err(data.Err).
+			Msg("Slow query")
+	}
+}
+
+// callerMethod returns the repository method running the query, e.g.
+// "PairRepository.GetByUserID", or "other" for queries issued elsewhere
+func callerMethod() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, repositoryPkg); ok && !strings.HasPrefix(name, "(*QueryTracer)") {
+			// "(*PairRepository).GetByID.func1" -> "PairRepository.GetByID"
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			if i := strings.Index(name, ".func"); i > 0 {
+				name = name[:i]
+			}
+			return name
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReactionRepository handles database operations for photo reactions and comments
+type ReactionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewReactionRepository creates a new reaction repository
+func NewReactionRepository(db *pgxpool.Pool) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// AddReaction stores the reaction. Returns false if the user already put
+// the same emoji on the photo.
+func (r *ReactionRepository) AddReaction(ctx context.Context, reaction *models.Reaction) (bool, error) {
+	query := `
+		INSERT INTO photo_reactions (id, photo_id, user_id, emoji, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (photo_id, user_id, emoji) DO NOTHING
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		reaction.ID, reaction.PhotoID, reaction.UserID, reaction.Emoji, reaction.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create reaction: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// AddComment stores the comment
+func (r *ReactionRepository) AddComment(ctx context.Context, comment *models.Comment) error {
+	query := `
+		INSERT INTO photo_comments (id, photo_id, user_id, text, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		comment.ID, comment.PhotoID, comment.UserID, comment.Text, comment.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+// ListReactions returns the reactions on the photo, oldest first
+func (r *ReactionRepository) ListReactions(ctx context.Context, photoID string) ([]*models.Reaction, error) {
+	query := `
+		SELECT id, photo_id, user_id, emoji, created_at
+		FROM photo_reactions
+		WHERE photo_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, photoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	defer rows.Close()
+
+	reactions := []*models.Reaction{}
+	for rows.Next() {
+		var reaction models.Reaction
+		err := rows.Scan(&reaction.ID, &reaction.PhotoID, &reaction.UserID, &reaction.Emoji, &reaction.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		reactions = append(reactions, &reaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reactions: %w", err)
+	}
+	return reactions, nil
+}
+
+// ListComments returns a page of the photo's comments, oldest first, and their total
+func (r *ReactionRepository) ListComments(ctx context.Context, photoID string, limit, offset int) ([]*models.Comment, int, error) {
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM photo_comments WHERE photo_id = $1`, photoID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	query := `
+		SELECT id, photo_id, user_id, text, created_at
+		FROM photo_comments
+		WHERE photo_id = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, photoID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []*models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		err := rows.Scan(&comment.ID, &comment.PhotoID, &comment.UserID, &comment.Text, &comment.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, &comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+// Counts returns the reaction and comment counts of the photos; photos
+// without either are missing from the map
+func (r *ReactionRepository) Counts(ctx context.Context, photoIDs []string) (map[string]models.PhotoCounts, error) {
+	counts := make(map[string]models.PhotoCounts, len(photoIDs))
+	if len(photoIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT photo_id, SUM(reactions)::int, SUM(comments)::int
+		FROM (
+			SELECT photo_id, COUNT(*) AS reactions, 0 AS comments
+			FROM photo_reactions WHERE photo_id = ANY($1)
+			GROUP BY photo_id
+			UNION ALL
+			SELECT photo_id, 0, COUNT(*)
+			FROM photo_comments WHERE photo_id = ANY($1)
+			GROUP BY photo_id
+		) counts
+		GROUP BY photo_id
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, photoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var photoID string
+		var photoCounts models.PhotoCounts
+		if err := rows.Scan(&photoID, &photoCounts.Reactions, &photoCounts.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction counts: %w", err)
+		}
+		counts[photoID] = photoCounts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reaction counts: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RefreshTokenRepository handles database operations for refresh tokens
+type RefreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create stores the first refresh token of a session
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, session_id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		token.ID, token.SessionID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash finds a refresh token by the hash of its value, revoked and
+// expired ones included
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash []byte) (*models.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, user_id, token_hash, expires_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+	var token models.RefreshToken
+	err := conn(ctx, r.db).QueryRow(ctx, query, hash).Scan(
+		&token.ID, &token.SessionID, &token.UserID, &token.TokenHash,
+		&token.ExpiresAt, &token.RevokedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidRefreshToken, err)
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// Rotate revokes the current token and stores the next one in one
+// statement. Returns false if the current token was already revoked, so
+// of two concurrent refreshes with one token only the first succeeds.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $7
+			WHERE id = $1 AND revoked_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO refresh_tokens (id, session_id, user_id, token_hash, expires_at, created_at)
+		SELECT $2, $3, $4, $5, $6, $7 FROM revoked
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		currentID, next.ID, next.SessionID, next.UserID, next.TokenHash, next.ExpiresAt, next.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// RevokeSession revokes every token of the session
+func (r *RefreshTokenRepository) RevokeSession(ctx context.Context, sessionID string, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE session_id = $1 AND revoked_at IS NULL`
+	_, err := conn(ctx, r.db).Exec(ctx, query, sessionID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes every token of every session of the user
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := conn(ctx, r.db).Exec(ctx, query, userID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// SessionActive reports whether the session has a token that is neither
+// revoked nor expired at now
+func (r *RefreshTokenRepository) SessionActive(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		)
+	`
+	var active bool
+	if err := conn(ctx, r.db).QueryRow(ctx, query, sessionID, now).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
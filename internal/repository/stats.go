@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const dailyStatsColumns = `day, daily_active_users, weekly_active_users, new_users, new_pairs,
+	moments_completed, storage_objects, storage_bytes, ws_peak_connections, computed_at`
+
+// StatsRepository handles database operations for usage statistics
+type StatsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *pgxpool.Pool) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// RecordActivity marks the user as active on day
+func (r *StatsRepository) RecordActivity(ctx context.Context, userID string, day time.Time) error {
+	query := `
+		INSERT INTO user_activity (user_id, day)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := r.db.Exec(ctx, query, userID, day); err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+	return nil
+}
+
+// RecordConnectionPeak raises the instance's WebSocket connection peak for day
+func (r *StatsRepository) RecordConnectionPeak(ctx context.Context, day time.Time, instanceID string, peak int) error {
+	query := `
+		INSERT INTO ws_connection_peaks (day, instance_id, peak)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (day, instance_id) DO UPDATE SET peak = GREATEST(ws_connection_peaks.peak, EXCLUDED.peak)
+	`
+	if _, err := r.db.Exec(ctx, query, day, instanceID, peak); err != nil {
+		return fmt.Errorf("failed to record connection peak: %w", err)
+	}
+	return nil
+}
+
+// Rollup computes the statistics for day from the raw tables and the domain
+// event log and stores them, replacing an earlier rollup of the same day.
+// Storage usage is measured by the caller because it lives in S3.
+func (r *StatsRepository) Rollup(ctx context.Context, day time.Time, storageObjects, storageBytes int64) (*models.DailyStats, error) {
+	// Пик по всем экземплярам - сумма пиков каждого, т.е. оценка сверху
+	query := `
+		INSERT INTO daily_stats (` + dailyStatsColumns + `)
+		SELECT
+			$1::date,
+			(SELECT COUNT(*) FROM user_activity WHERE day = $1::date),
+			(SELECT COUNT(DISTINCT user_id) FROM user_activity WHERE day > $1::date - 7 AND day <= $1::date),
+			(SELECT COUNT(*) FROM domain_events WHERE type = 'user_created' AND occurred_at >= $1::date AND occurred_at < $1::date + 1),
+			(SELECT COUNT(*) FROM domain_events WHERE type = 'pair_created' AND occurred_at >= $1::date AND occurred_at < $1::date + 1),
+			(SELECT COUNT(*) FROM domain_events WHERE type = 'moment_completed' AND occurred_at >= $1::date AND occurred_at < $1::date + 1),
+			$2,
+			$3,
+			(SELECT COALESCE(SUM(peak), 0) FROM ws_connection_peaks WHERE day = $1::date),
+			NOW()
+		ON CONFLICT (day) DO UPDATE SET
+			daily_active_users = EXCLUDED.daily_active_users,
+			weekly_active_users = EXCLUDED.weekly_active_users,
+			new_users = EXCLUDED.new_users,
+			new_pairs = EXCLUDED.new_pairs,
+			moments_completed = EXCLUDED.moments_completed,
+			storage_objects = EXCLUDED.storage_objects,
+			storage_bytes = EXCLUDED.storage_bytes,
+			ws_peak_connections = EXCLUDED.ws_peak_connections,
+			computed_at = EXCLUDED.computed_at
+		RETURNING ` + dailyStatsColumns
+	stats, err := scanDailyStats(r.db.QueryRow(ctx, query, day, storageObjects, storageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll up stats: %w", err)
+	}
+	return stats, nil
+}
+
+// List returns rollups of the most recent days, newest first
+func (r *StatsRepository) List(ctx context.Context, days int) ([]*models.DailyStats, error) {
+	query := `
+		SELECT ` + dailyStatsColumns + `
+		FROM daily_stats
+		ORDER BY day DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stats: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*models.DailyStats{}
+	for rows.Next() {
+		stats, err := scanDailyStats(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stats: %w", err)
+		}
+		result = append(result, stats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stats: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteActivityBefore deletes activity records older than day; rollups keep the counts
+func (r *StatsRepository) DeleteActivityBefore(ctx context.Context, day time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM user_activity WHERE day < $1`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old activity: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, `DELETE FROM ws_connection_peaks WHERE day < $1`, day); err != nil {
+		return 0, fmt.Errorf("failed to delete old connection peaks: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func scanDailyStats(row pgx.Row) (*models.DailyStats, error) {
+	var stats models.DailyStats
+	err := row.Scan(
+		&stats.Day, &stats.DailyActiveUsers, &stats.WeeklyActiveUsers, &stats.NewUsers, &stats.NewPairs,
+		&stats.MomentsCompleted, &stats.StorageObjects, &stats.StorageBytes, &stats.WSPeakConnections,
+		&stats.ComputedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
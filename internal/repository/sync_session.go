@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// syncSessionSelect reads sessions with their photos; scan rows with scanSyncSession
+const syncSessionSelect = `
+	SELECT s.id, s.pair_id, s.initiator_id, s.triggered_at,
+		s.capture_at, s.deadline, s.missed_at, s.completed_at, s.created_at,
+		ip.id, ip.pair_id, ip.user_id, ip.s3_key, ip.session_id,
+		ip.size_bytes, ip.content_type, ip.width, ip.height, ip.device_taken_at, ip.uploaded_at, ip.taken_at, ip.created_at, ip.updated_at,
+		pp.id, pp.pair_id, pp.user_id, pp.s3_key, pp.session_id,
+		pp.size_bytes, pp.content_type, pp.width, pp.height, pp.device_taken_at, pp.uploaded_at, pp.taken_at, pp.created_at, pp.updated_at
+	FROM sync_sessions s
+	LEFT JOIN photos ip ON ip.id = s.initiator_photo_id
+	LEFT JOIN photos pp ON pp.id = s.partner_photo_id
+`
+
+// SyncSessionRepository handles database operations for sync sessions
+type SyncSessionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSyncSessionRepository creates a new sync session repository
+func NewSyncSessionRepository(db *pgxpool.Pool) *SyncSessionRepository {
+	return &SyncSessionRepository{db: db}
+}
+
+// Create stores a new session without photos
+func (r *SyncSessionRepository) Create(ctx context.Context, session *models.SyncSession) error {
+	query := `
+		INSERT INTO sync_sessions (id, pair_id, initiator_id, triggered_at, capture_at, deadline, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		session.ID, session.PairID, session.InitiatorID, session.TriggeredAt,
+		session.CaptureAt, session.Deadline, session.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sync session: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a session with its photos
+func (r *SyncSessionRepository) GetByID(ctx context.Context, id string) (*models.SyncSession, error) {
+	session, err := scanSyncSession(conn(ctx, r.db).QueryRow(ctx, syncSessionSelect+`WHERE s.id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %w", domain.ErrSyncSessionNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get sync session: %w", err)
+	}
+	return session, nil
+}
+
+// ListByPair returns a page of the pair's sessions with their photos,
+// newest first, and their total
+func (r *SyncSessionRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.SyncSession, int, error) {
+	var total int
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM sync_sessions WHERE pair_id = $1`, pairID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count sync sessions: %w", err)
+	}
+
+	query := syncSessionSelect + `
+		WHERE s.pair_id = $1
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, pairID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get sync sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.SyncSession{}
+	for rows.Next() {
+		session, err := scanSyncSession(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan sync session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating sync sessions: %w", err)
+	}
+	return sessions, total, nil
+}
+
+// AttachPhoto makes the photo its owner's half of an open session, replacing
+// an earlier one, and completes the session once both halves are there.
+// Returns true only for the call that completed it; photos confirmed after
+// that or after the session was missed don't change the session.
+func (r *SyncSessionRepository) AttachPhoto(ctx context.Context, sessionID string, photo *models.Photo, completedAt time.Time) (bool, error) {
+	query := `
+		UPDATE sync_sessions SET
+			initiator_photo_id = CASE WHEN initiator_id = $2 THEN $3 ELSE initiator_photo_id END,
+			partner_photo_id = CASE WHEN initiator_id <> $2 THEN $3 ELSE partner_photo_id END,
+			completed_at = CASE
+				WHEN (initiator_id = $2 OR initiator_photo_id IS NOT NULL)
+					AND (initiator_id <> $2 OR partner_photo_id IS NOT NULL)
+				THEN $4::timestamptz
+			END
+		WHERE id = $1 AND completed_at IS NULL AND missed_at IS NULL
+		RETURNING completed_at IS NOT NULL
+	`
+	var completed bool
+	err := conn(ctx, r.db).QueryRow(ctx, query, sessionID, photo.UserID, photo.ID, completedAt).Scan(&completed)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to attach photo to sync session: %w", err)
+	}
+	return completed, nil
+}
+
+// MarkMissed marks a session that is still incomplete as missed; false if
+// it completed, was already missed or doesn't exist
+func (r *SyncSessionRepository) MarkMissed(ctx context.Context, id string, missedAt time.Time) (bool, error) {
+	query := `
+		UPDATE sync_sessions SET missed_at = $2
+		WHERE id = $1 AND completed_at IS NULL AND missed_at IS NULL
+	`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, missedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark sync session missed: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Delete removes a session; its photos keep existing without it
+func (r *SyncSessionRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM sync_sessions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sync session: %w", err)
+	}
+	return nil
+}
+
+// sessionPhoto receives the columns of a LEFT JOINed photo, all NULL when
+// the session has no photo on that side
+type sessionPhoto struct {
+	id, pairID, userID, s3Key, sessionID, contentType        *string
+	sizeBytes                                                *int64
+	width, height                                            *int
+	deviceTakenAt, uploadedAt, takenAt, createdAt, updatedAt *time.Time
+}
+
+func (p *sessionPhoto) dest() []interface{} {
+	return []interface{}{
+		&p.id, &p.pairID, &p.userID, &p.s3Key, &p.sessionID,
+		&p.sizeBytes, &p.contentType, &p.width, &p.height, &p.deviceTakenAt,
+		&p.uploadedAt, &p.takenAt, &p.createdAt, &p.updatedAt,
+	}
+}
+
+func (p *sessionPhoto) photo() *models.Photo {
+	if p.id == nil {
+		return nil
+	}
+	photo := &models.Photo{
+		ID:            *p.id,
+		PairID:        *p.pairID,
+		UserID:        *p.userID,
+		S3Key:         *p.s3Key,
+		SessionID:     p.sessionID,
+		DeviceTakenAt: p.deviceTakenAt,
+		UploadedAt:    p.uploadedAt,
+		TakenAt:       *p.takenAt,
+		CreatedAt:     *p.createdAt,
+		UpdatedAt:     *p.updatedAt,
+	}
+	if p.sizeBytes != nil {
+		photo.SizeBytes = *p.sizeBytes
+	}
+	if p.contentType != nil {
+		photo.ContentType = *p.contentType
+	}
+	if p.width != nil && p.height != nil {
+		photo.Width, photo.Height = *p.width, *p.height
+	}
+	return photo
+}
+
+// scanSyncSession scans a row of syncSessionSelect
+func scanSyncSession(row pgx.Row) (*models.SyncSession, error) {
+	var session models.SyncSession
+	var initiator, partner sessionPhoto
+	dest := []interface{}{
+		&session.ID, &session.PairID, &session.InitiatorID,
+		&session.TriggeredAt, &session.CaptureAt, &session.Deadline, &session.MissedAt,
+		&session.CompletedAt, &session.CreatedAt,
+	}
+	dest = append(dest, initiator.dest()...)
+	dest = append(dest, partner.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	session.Photos = []*models.Photo{}
+	for _, side := range []*sessionPhoto{&initiator, &partner} {
+		if photo := side.photo(); photo != nil {
+			session.Photos = append(session.Photos, photo)
+		}
+	}
+	return &session, nil
+}
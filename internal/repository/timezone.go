@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ConfigureUTC makes connections created with cfg work in UTC: NOW() and
+// date arithmetic of the session use it, and scanned timestamptz values
+// are returned in time.UTC rather than the server's local zone
+func ConfigureUTC(cfg *pgx.ConnConfig) {
+	if cfg.RuntimeParams == nil {
+		cfg.RuntimeParams = map[string]string{}
+	}
+	cfg.RuntimeParams["timezone"] = "UTC"
+}
+
+// RegisterUTC scans timestamptz values of conn in time.UTC; it is meant
+// for pgxpool.Config.AfterConnect
+func RegisterUTC(_ context.Context, conn *pgx.Conn) error {
+	conn.TypeMap().RegisterType(&pgtype.Type{
+		Name:  "timestamptz",
+		OID:   pgtype.TimestamptzOID,
+		Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is implemented by both *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// unitOfWork is the transaction TxManager.Do passes to repositories in the context
+type unitOfWork struct {
+	tx pgx.Tx
+	// afterCommit runs once the transaction commits
+	afterCommit []func(ctx context.Context)
+}
+
+// TxManager runs flows spanning several repositories in one transaction
+type TxManager struct {
+	db *pgxpool.Pool
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *pgxpool.Pool) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Do runs fn in a transaction committed if fn returns nil and rolled back
+// otherwise. Repository calls made with the context passed to fn join it;
+// their own transactions become savepoints. Do inside fn joins the outer
+// transaction.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	uow := &unitOfWork{tx: tx}
+	if err := fn(context.WithValue(ctx, txKey{}, uow)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, f := range uow.afterCommit {
+		f(ctx)
+	}
+	return nil
+}
+
+// conn returns the transaction of the unit of work in ctx, db outside one
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if uow, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		return uow.tx
+	}
+	return db
+}
+
+// inUnitOfWork reports whether ctx carries a transaction of TxManager
+func inUnitOfWork(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*unitOfWork)
+	return ok
+}
+
+// afterCommit runs fn once the unit of work in ctx commits, right away
+// outside one. Nothing runs if it rolls back.
+func afterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if uow, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		uow.afterCommit = append(uow.afterCommit, fn)
+		return
+	}
+	fn(ctx)
+}
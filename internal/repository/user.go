@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"sync-photo-backend/internal/cache"
+	"sync-photo-backend/internal/domain"
 	"sync-photo-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
@@ -13,60 +16,142 @@ import (
 // UserRepository handles database operations for users
 type UserRepository struct {
 	db *pgxpool.Pool
+	// read serves read-only queries; it is db unless a replica is configured
+	read  *pgxpool.Pool
+	cache *cacheLayer
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *pgxpool.Pool) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{db: db, read: db}
 }
 
-// Create creates a new user
-func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+// WithReadReplica routes partner code lookups to a read replica. Lookups by
+// ID and code uniqueness checks stay on the primary since they must see
+// the user's own writes. A nil replica keeps all queries on the primary.
+func (r *UserRepository) WithReadReplica(replica *pgxpool.Pool) *UserRepository {
+	if replica != nil {
+		r.read = replica
+	}
+	return r
+}
+
+// WithCache enables caching of user lookups by ID.
+// Entries are invalidated when the user's settings are updated.
+func (r *UserRepository) WithCache(c cache.Cache, ttl time.Duration) *UserRepository {
+	r.cache = &cacheLayer{cache: c, ttl: ttl, name: "user"}
+	return r
+}
+
+// userByIDKey is versioned so entries cached before users had app_id,
+// deleted_at and updated_at are ignored
+func userByIDKey(id string) string { return "user:v4:id:" + id }
+
+// Create creates a new user. Domain events are appended in the same transaction.
+func (r *UserRepository) Create(ctx context.Context, user *models.User, events ...*models.DomainEvent) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		INSERT INTO users (id, code, token, push_token, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, app_id, code, token, push_token, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
 	`
-	_, err := r.db.Exec(ctx, query, user.ID, user.Code, user.Token, user.PushToken, user.CreatedAt)
+	_, err = tx.Exec(ctx, query, user.ID, user.AppID, user.Code, user.Token, user.PushToken, user.Timezone, user.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
+	if err := insertDomainEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	user.UpdatedAt = user.CreatedAt
 	return nil
 }
 
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	var cached models.User
+	if r.cache.get(ctx, userByIDKey(id), &cached) {
+		return &cached, nil
+	}
+
 	query := `
-		SELECT id, code, token, push_token, created_at
+		SELECT id, app_id, code, token, push_token, quiet_hours_start, quiet_hours_end,
+			timezone, always_allow_triggers, time_sensitive_triggers, locale, created_at, updated_at, deleted_at,
+			last_seen_at
 		FROM users
 		WHERE id = $1
 	`
 	var user models.User
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID, &user.Code, &user.Token, &user.PushToken, &user.CreatedAt,
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&user.ID, &user.AppID, &user.Code, &user.Token, &user.PushToken,
+		&user.QuietHoursStart, &user.QuietHoursEnd, &user.Timezone, &user.AlwaysAllowTriggers,
+		&user.TimeSensitive, &user.Locale, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.LastSeenAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", domain.ErrUserNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	r.cache.set(ctx, userByIDKey(id), &user)
+	return &user, nil
+}
+
+// GetForUpdate retrieves a user by ID from the primary, bypassing the
+// cache, and locks its row until the transaction ends. Run it in a
+// transaction.
+func (r *UserRepository) GetForUpdate(ctx context.Context, id string) (*models.User, error) {
+	query := `
+		SELECT id, app_id, code, token, push_token, quiet_hours_start, quiet_hours_end,
+			timezone, always_allow_triggers, time_sensitive_triggers, locale, created_at, updated_at, deleted_at,
+			last_seen_at
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var user models.User
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&user.ID, &user.AppID, &user.Code, &user.Token, &user.PushToken,
+		&user.QuietHoursStart, &user.QuietHoursEnd, &user.Timezone, &user.AlwaysAllowTriggers,
+		&user.TimeSensitive, &user.Locale, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.LastSeenAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUserNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
 	return &user, nil
 }
 
-// GetByCode retrieves a user by code
-func (r *UserRepository) GetByCode(ctx context.Context, code string) (*models.User, error) {
+// GetByCode retrieves a user of the app by code. Users of other apps and
+// deleted users are reported as not found.
+func (r *UserRepository) GetByCode(ctx context.Context, appID, code string) (*models.User, error) {
 	query := `
-		SELECT id, code, token, push_token, created_at
+		SELECT id, app_id, code, token, push_token, quiet_hours_start, quiet_hours_end,
+			timezone, always_allow_triggers, time_sensitive_triggers, locale, created_at, updated_at, deleted_at,
+			last_seen_at
 		FROM users
-		WHERE code = $1
+		WHERE app_id = $1 AND code = $2 AND deleted_at IS NULL
 	`
 	var user models.User
-	err := r.db.QueryRow(ctx, query, code).Scan(
-		&user.ID, &user.Code, &user.Token, &user.PushToken, &user.CreatedAt,
+	err := conn(ctx, r.read).QueryRow(ctx, query, appID, code).Scan(
+		&user.ID, &user.AppID, &user.Code, &user.Token, &user.PushToken,
+		&user.QuietHoursStart, &user.QuietHoursEnd, &user.Timezone, &user.AlwaysAllowTriggers,
+		&user.TimeSensitive, &user.Locale, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+		&user.LastSeenAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", domain.ErrUserNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to get user by code: %w", err)
 	}
@@ -77,7 +162,7 @@ func (r *UserRepository) GetByCode(ctx context.Context, code string) (*models.Us
 func (r *UserRepository) CodeExists(ctx context.Context, code string) (bool, error) {
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE code = $1)`
 	var exists bool
-	err := r.db.QueryRow(ctx, query, code).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, query, code).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check code existence: %w", err)
 	}
@@ -86,10 +171,205 @@ func (r *UserRepository) CodeExists(ctx context.Context, code string) (bool, err
 
 // UpdatePushToken updates the push token for a user
 func (r *UserRepository) UpdatePushToken(ctx context.Context, userID string, pushToken *string) error {
-	query := `UPDATE users SET push_token = $1 WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, pushToken, userID)
+	query := `UPDATE users SET push_token = $1, updated_at = NOW() WHERE id = $2`
+	_, err := conn(ctx, r.db).Exec(ctx, query, pushToken, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update push token: %w", err)
 	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	return nil
+}
+
+// UpdateQuietHours updates the quiet hours settings for a user
+func (r *UserRepository) UpdateQuietHours(ctx context.Context, userID string, start, end *string, timezone string, alwaysAllowTriggers bool) error {
+	query := `
+		UPDATE users
+		SET quiet_hours_start = $1, quiet_hours_end = $2, timezone = $3, always_allow_triggers = $4,
+			updated_at = NOW()
+		WHERE id = $5
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query, start, end, timezone, alwaysAllowTriggers, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update quiet hours: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateLocale updates the locale of user-facing messages; nil clears it
+func (r *UserRepository) UpdateLocale(ctx context.Context, userID string, locale *string) error {
+	query := `UPDATE users SET locale = $1, updated_at = NOW() WHERE id = $2`
+	result, err := conn(ctx, r.db).Exec(ctx, query, locale, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update locale: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateTimeSensitiveTriggers updates whether trigger pushes may break through Focus modes
+func (r *UserRepository) UpdateTimeSensitiveTriggers(ctx context.Context, userID string, enabled bool) error {
+	query := `UPDATE users SET time_sensitive_triggers = $1, updated_at = NOW() WHERE id = $2`
+	result, err := conn(ctx, r.db).Exec(ctx, query, enabled, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update trigger alerts: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
 	return nil
 }
+
+// UpdateSettings writes the user's quiet hours, trigger alerts and locale
+// if the user is still at the updatedAt it was read at, and sets the new
+// UpdatedAt. A user changed meanwhile gets domain.ErrPreconditionFailed.
+func (r *UserRepository) UpdateSettings(ctx context.Context, user *models.User, updatedAt time.Time) error {
+	query := `
+		UPDATE users
+		SET quiet_hours_start = $2, quiet_hours_end = $3, timezone = $4, always_allow_triggers = $5,
+			time_sensitive_triggers = $6, locale = $7, updated_at = NOW()
+		WHERE id = $1 AND updated_at = $8 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		user.ID, user.QuietHoursStart, user.QuietHoursEnd, user.Timezone, user.AlwaysAllowTriggers,
+		user.TimeSensitive, user.Locale, updatedAt,
+	).Scan(&user.UpdatedAt)
+	if err == nil {
+		r.cache.invalidate(ctx, userByIDKey(user.ID))
+		return nil
+	}
+	if err != pgx.ErrNoRows {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+
+	var exists bool
+	query = `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+	if err := conn(ctx, r.db).QueryRow(ctx, query, user.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+	if !exists {
+		return domain.ErrUserNotFound
+	}
+	return domain.ErrPreconditionFailed
+}
+
+// SoftDelete marks the user deleted at deletedAt and clears the push token,
+// so the account gets no pushes while it can be restored
+func (r *UserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	query := `UPDATE users SET deleted_at = $1, push_token = NULL, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
+	result, err := conn(ctx, r.db).Exec(ctx, query, deletedAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// Restore clears the deletion mark; users that aren't deleted or were
+// anonymized are reported as not found
+func (r *UserRepository) Restore(ctx context.Context, userID string) error {
+	query := `
+		UPDATE users SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND anonymized_at IS NULL
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// TouchLastSeen moves the user's last_seen_at forward to at; an earlier
+// time, e.g. of a connection replaced meanwhile, doesn't move it back.
+// updated_at stays, since the profile didn't change.
+func (r *UserRepository) TouchLastSeen(ctx context.Context, userID string, at time.Time) error {
+	query := `UPDATE users SET last_seen_at = GREATEST(last_seen_at, $2) WHERE id = $1`
+	if _, err := conn(ctx, r.db).Exec(ctx, query, userID, at); err != nil {
+		return fmt.Errorf("failed to update last seen: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	return nil
+}
+
+// LastSeen returns the user's last_seen_at, nil if they never connected.
+// It reads the primary and bypasses the cache, whose users don't keep it.
+func (r *UserRepository) LastSeen(ctx context.Context, userID string) (*time.Time, error) {
+	query := `SELECT last_seen_at FROM users WHERE id = $1`
+	var lastSeen *time.Time
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&lastSeen)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUserNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to get last seen: %w", err)
+	}
+	return lastSeen, nil
+}
+
+// Purge removes the user if it was deleted no later than deletedBefore and
+// reports whether it did. Its pairs, deleted ones included, and their photos
+// are removed by ON DELETE CASCADE.
+func (r *UserRepository) Purge(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	query := `DELETE FROM users WHERE id = $1 AND deleted_at <= $2`
+	result, err := conn(ctx, r.db).Exec(ctx, query, userID, deletedBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to purge user: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	return result.RowsAffected() > 0, nil
+}
+
+// Anonymize scrubs the user if it was deleted no later than deletedBefore
+// and reports whether it did. The row stays, deleted for good, so its pairs
+// and their photos are kept; its settings, refresh tokens and WebSocket
+// events are removed. Run it in a transaction.
+func (r *UserRepository) Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	query := `
+		UPDATE users SET
+			token = 'anonymized:' || id,
+			push_token = NULL,
+			quiet_hours_start = NULL,
+			quiet_hours_end = NULL,
+			timezone = 'UTC',
+			always_allow_triggers = FALSE,
+			time_sensitive_triggers = FALSE,
+			locale = NULL,
+			last_seen_at = NULL,
+			anonymized_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at <= $2 AND anonymized_at IS NULL
+	`
+	result, err := conn(ctx, r.db).Exec(ctx, query, userID, deletedBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	r.cache.invalidate(ctx, userByIDKey(userID))
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	query = `DELETE FROM refresh_tokens WHERE user_id = $1`
+	if _, err := conn(ctx, r.db).Exec(ctx, query, userID); err != nil {
+		return false, fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+	query = `DELETE FROM user_events WHERE user_id = $1`
+	if _, err := conn(ctx, r.db).Exec(ctx, query, userID); err != nil {
+		return false, fmt.Errorf("failed to delete user events: %w", err)
+	}
+	return true, nil
+}
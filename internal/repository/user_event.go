@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UserEventRepository handles database operations for the WebSocket events
+// kept for users until their clients ack them
+type UserEventRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewUserEventRepository creates a new user event repository
+func NewUserEventRepository(db *pgxpool.Pool) *UserEventRepository {
+	return &UserEventRepository{db: db}
+}
+
+// Append stores the event and sets its seq
+func (r *UserEventRepository) Append(ctx context.Context, event *models.UserEvent) error {
+	query := `
+		INSERT INTO user_events (user_id, event_type, message, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING seq
+	`
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		event.UserID, event.Type, event.Message, event.CreatedAt,
+	).Scan(&event.Seq)
+	if err != nil {
+		return fmt.Errorf("failed to append user event: %w", err)
+	}
+	return nil
+}
+
+// ListPending returns the user's events created after since that weren't
+// acked, in seq order
+func (r *UserEventRepository) ListPending(ctx context.Context, userID string, since time.Time) ([]*models.UserEvent, error) {
+	query := `
+		SELECT seq, user_id, event_type, message, created_at, acked_at
+		FROM user_events
+		WHERE user_id = $1 AND acked_at IS NULL AND created_at > $2
+		ORDER BY seq
+	`
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending user events: %w", err)
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.UserEvent, error) {
+		var event models.UserEvent
+		err := row.Scan(&event.Seq, &event.UserID, &event.Type, &event.Message, &event.CreatedAt, &event.AckedAt)
+		return &event, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user events: %w", err)
+	}
+	return events, nil
+}
+
+// Ack marks the user's events up to seq as acked
+func (r *UserEventRepository) Ack(ctx context.Context, userID string, seq int64) error {
+	query := `UPDATE user_events SET acked_at = NOW() WHERE user_id = $1 AND seq <= $2 AND acked_at IS NULL`
+	if _, err := conn(ctx, r.db).Exec(ctx, query, userID, seq); err != nil {
+		return fmt.Errorf("failed to ack user events: %w", err)
+	}
+	return nil
+}
+
+// DeleteBefore deletes events created before the given time, acked or not
+func (r *UserEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM user_events WHERE created_at < $1`
+	result, err := conn(ctx, r.db).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // клиенты могут загрузить PNG
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// CompositorConsumer is the name of the event log consumer stitching moments
+const CompositorConsumer = "compositor"
+
+// Composite layouts
+const (
+	CompositeHorizontal = "horizontal"
+	CompositeVertical   = "vertical"
+)
+
+const (
+	// compositeJob is the job kind stitching one moment
+	compositeJob = "composite"
+	// compositeMaxSide bounds the shared side of the photos on a composite:
+	// the height side by side, the width one above the other
+	compositeMaxSide = 2048
+)
+
+// CompositeLimits bound the pages of composites
+var CompositeLimits = pagination.Limits{Default: 20, Max: 50}
+
+// compositePayload is the payload of a composite job
+type compositePayload struct {
+	// EventID is the moment_completed event; the composite ID is derived
+	// from it, so a retried job doesn't store the composite twice
+	EventID  string   `json:"event_id"`
+	PairID   string   `json:"pair_id"`
+	PhotoIDs []string `json:"photo_ids"`
+}
+
+// CompositorService stitches the photos of both partners of a completed
+// moment into one image. Moments come from the event log, stitching runs
+// in the job runner, so it is retried and doesn't slow down uploads.
+type CompositorService struct {
+	repo         CompositeRepository
+	photoRepo    PhotoRepository
+	pairRepo     PairRepository
+	settingsRepo PairSettingsRepository
+	photos       *PhotoService
+	jobRunner    *JobRunner
+	cfg          config.CompositesConfig
+}
+
+// NewCompositorService creates a compositor keeping composites in the photo
+// store and registers the composite job handler. Moments of pairs that
+// turned auto_composite off in settingsRepo are not stitched.
+func NewCompositorService(
+	repo CompositeRepository,
+	photoRepo PhotoRepository,
+	pairRepo PairRepository,
+	settingsRepo PairSettingsRepository,
+	photos *PhotoService,
+	jobRunner *JobRunner,
+	cfg config.CompositesConfig,
+) *CompositorService {
+	s := &CompositorService{
+		repo:         repo,
+		photoRepo:    photoRepo,
+		pairRepo:     pairRepo,
+		settingsRepo: settingsRepo,
+		photos:       photos,
+		jobRunner:    jobRunner,
+		cfg:          cfg,
+	}
+	jobRunner.Register(compositeJob, s.compose)
+	return s
+}
+
+// Enqueue queues stitching of a completed moment unless the pair turned
+// auto_composite off. It is the event log consumer for composites: an
+// error means nothing was queued and the event is retried.
+func (s *CompositorService) Enqueue(ctx context.Context, event *models.DomainEvent) error {
+	if event.Type != EventMomentCompleted {
+		return nil
+	}
+	payload, err := DecodeDomainEvent(event)
+	if err != nil {
+		return err
+	}
+	moment := payload.(*MomentCompletedPayload)
+
+	settings, err := s.settingsRepo.Get(ctx, moment.PairID)
+	if err != nil {
+		return fmt.Errorf("failed to get pair settings: %w", err)
+	}
+	if !settings.AutoComposite {
+		log.Ctx(ctx).Debug().Str("pair_id", moment.PairID).Str("event_id", event.ID).Msg("Auto composite is off for the pair")
+		return nil
+	}
+
+	_, err = s.jobRunner.EnqueueOnce(ctx, compositeJob, event.ID, compositePayload{
+		EventID:  event.ID,
+		PairID:   moment.PairID,
+		PhotoIDs: moment.PhotoIDs,
+	}, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to enqueue composite: %w", err)
+	}
+	return nil
+}
+
+// compose stitches the latest photo of each partner in the moment, user A
+// first, uploads the composite and stores it. Moments of deleted pairs and
+// photos that can't be decoded are skipped, since retrying won't help.
+func (s *CompositorService) compose(ctx context.Context, raw json.RawMessage) error {
+	var payload compositePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("failed to decode composite payload: %w", err)
+	}
+	logger := log.Ctx(ctx).With().Str("pair_id", payload.PairID).Str("event_id", payload.EventID).Logger()
+
+	pair, err := s.pairRepo.GetByID(ctx, payload.PairID)
+	if errors.Is(err, domain.ErrPairNotFound) {
+		logger.Info().Msg("Pair deleted before its moment was stitched")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pair: %w", err)
+	}
+
+	latest := make(map[string]*models.Photo, 2)
+	for _, photoID := range payload.PhotoIDs {
+		photo, err := s.photoRepo.GetByID(ctx, photoID)
+		if errors.Is(err, domain.ErrPhotoNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get photo: %w", err)
+		}
+		if current, ok := latest[photo.UserID]; !ok || photo.TakenAt.After(current.TakenAt) {
+			latest[photo.UserID] = photo
+		}
+	}
+	first, second := latest[pair.UserAID], latest[pair.UserBID]
+	if first == nil || second == nil {
+		logger.Info().Msg("Moment no longer has photos of both partners")
+		return nil
+	}
+
+	images := make([]image.Image, 0, 2)
+	for _, photo := range []*models.Photo{first, second} {
+		data, err := s.photos.readObject(ctx, photo.S3Key)
+		if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			logger.Warn().Err(err).Str("photo_id", photo.ID).Msg("Photo can't be decoded, moment is not stitched")
+			return nil
+		}
+		images = append(images, img)
+	}
+
+	stitched := stitch(images[0], images[1], s.cfg.Layout)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, stitched, &jpeg.Options{Quality: s.cfg.Quality}); err != nil {
+		return fmt.Errorf("failed to encode composite: %w", err)
+	}
+
+	composite := &models.Composite{
+		ID:        uuid.NewSHA1(uuid.NameSpaceOID, []byte(compositeJob+":"+payload.EventID)).String(),
+		PairID:    pair.ID,
+		PhotoIDs:  []string{first.ID, second.ID},
+		Layout:    s.cfg.Layout,
+		Width:     stitched.Bounds().Dx(),
+		Height:    stitched.Bounds().Dy(),
+		CreatedAt: time.Now().UTC(),
+	}
+	composite.S3Key = s.photos.compositeKey(pair, composite.ID)
+	if err := s.photos.writeObject(ctx, composite.S3Key, buf.Bytes(), "image/jpeg"); err != nil {
+		return err
+	}
+	if _, err := s.repo.Create(ctx, composite); err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("composite_id", composite.ID).
+		Int("width", composite.Width).
+		Int("height", composite.Height).
+		Int("bytes", buf.Len()).
+		Msg("Moment stitched")
+	return nil
+}
+
+// ListByUser returns a page of the composites of the user's pair, newest
+// first, and their total
+func (s *CompositorService) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.Composite, int, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	composites, total, err := s.repo.ListByPair(ctx, pair.ID, CompositeLimits.Clamp(limit), max(offset, 0))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, composite := range composites {
+		if composite.S3URL, err = s.photos.GetPresignedViewURL(ctx, composite.S3Key); err != nil {
+			return nil, 0, err
+		}
+	}
+	return composites, total, nil
+}
+
+// stitch scales both images to a shared height (horizontal) or width
+// (vertical), at most compositeMaxSide and no larger than the smaller
+// image, and places them next to each other
+func stitch(first, second image.Image, layout string) *image.RGBA {
+	a, b := first.Bounds(), second.Bounds()
+
+	if layout == CompositeVertical {
+		side := min(a.Dx(), b.Dx(), compositeMaxSide)
+		firstHeight, secondHeight := a.Dy()*side/a.Dx(), b.Dy()*side/b.Dx()
+		dst := image.NewRGBA(image.Rect(0, 0, side, firstHeight+secondHeight))
+		draw.Draw(dst, image.Rect(0, 0, side, firstHeight), scale(first, side, firstHeight), image.Point{}, draw.Src)
+		draw.Draw(dst, image.Rect(0, firstHeight, side, firstHeight+secondHeight), scale(second, side, secondHeight), image.Point{}, draw.Src)
+		return dst
+	}
+
+	side := min(a.Dy(), b.Dy(), compositeMaxSide)
+	firstWidth, secondWidth := a.Dx()*side/a.Dy(), b.Dx()*side/b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, firstWidth+secondWidth, side))
+	draw.Draw(dst, image.Rect(0, 0, firstWidth, side), scale(first, firstWidth, side), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(firstWidth, 0, firstWidth+secondWidth, side), scale(second, secondWidth, side), image.Point{}, draw.Src)
+	return dst
+}
+
+// scale resizes src to width x height, averaging the source pixels covered
+// by each destination pixel; stitch only ever scales down
+func scale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if bounds.Dx() == width && bounds.Dy() == height {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * bounds.Dy() / height
+		y1 := max((y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * bounds.Dx() / width
+			x1 := max((x+1)*bounds.Dx()/width, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := range sum {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			pixel := dst.Pix[y*dst.Stride+x*4:]
+			for c := range sum {
+				pixel[c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// pairObjectKeys returns the object keys of all the pair's composites
+func (s *CompositorService) pairObjectKeys(ctx context.Context, pairID string) ([]string, error) {
+	return s.repo.ListKeysByPair(ctx, pairID)
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Domain event types
+const (
+	EventUserCreated     = "user_created"
+	EventPairCreated     = "pair_created"
+	EventPairDeleted     = "pair_deleted"
+	EventTriggerStarted  = "trigger_started"
+	EventPhotoConfirmed  = "photo_confirmed"
+	EventMomentCompleted = "moment_completed"
+)
+
+const (
+	// eventLogPollInterval is how often consumers check for new events
+	eventLogPollInterval = time.Second
+	// eventLogSettle is how old an event must be before consumers see it, so
+	// transactions that got a lower seq have committed by then
+	eventLogSettle    = 2 * time.Second
+	eventLogBatchSize = 100
+)
+
+// UserCreatedPayload is the payload of user_created events
+type UserCreatedPayload struct {
+	UserID string `json:"user_id"`
+	AppID  string `json:"app_id"`
+}
+
+// PairPayload is the payload of pair_created and pair_deleted events
+type PairPayload struct {
+	PairID  string `json:"pair_id"`
+	UserAID string `json:"user_a_id"`
+	UserBID string `json:"user_b_id"`
+	// InitiatorID is the user who created or broke the pair
+	InitiatorID string `json:"initiator_id"`
+	// Restored is set when pairing again restored a deleted pair
+	Restored bool `json:"restored,omitempty"`
+}
+
+// TriggerStartedPayload is the payload of trigger_started events, recorded
+// when take_photo is sent to both partners
+type TriggerStartedPayload struct {
+	PairID      string `json:"pair_id"`
+	InitiatorID string `json:"initiator_id"`
+	PartnerID   string `json:"partner_id"`
+	// SessionID is the sync session the trigger started
+	SessionID string `json:"session_id,omitempty"`
+	// Timestamp is the client's trigger time in Unix milliseconds, if sent
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// PhotoConfirmedPayload is the payload of photo_confirmed events, recorded
+// when an uploaded photo is stored
+type PhotoConfirmedPayload struct {
+	PhotoID string    `json:"photo_id"`
+	PairID  string    `json:"pair_id"`
+	UserID  string    `json:"user_id"`
+	S3URL   string    `json:"s3_url"`
+	TakenAt time.Time `json:"taken_at"`
+}
+
+// MomentCompletedPayload is the payload of moment_completed events, recorded
+// when both partners have a photo of the same moment
+type MomentCompletedPayload struct {
+	PairID   string   `json:"pair_id"`
+	PhotoIDs []string `json:"photo_ids"`
+}
+
+// newDomainEvent builds a domain event with a JSON payload. Empty userID or
+// pairID are stored as NULL.
+func newDomainEvent(eventType, appID, userID, pairID string, payload interface{}) (*models.DomainEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	event := &models.DomainEvent{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		AppID:      appID,
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	}
+	if userID != "" {
+		event.UserID = &userID
+	}
+	if pairID != "" {
+		event.PairID = &pairID
+	}
+	return event, nil
+}
+
+// DecodeDomainEvent returns the typed payload of event, e.g. *PairPayload
+// for pair_created
+func DecodeDomainEvent(event *models.DomainEvent) (interface{}, error) {
+	var payload interface{}
+	switch event.Type {
+	case EventUserCreated:
+		payload = &UserCreatedPayload{}
+	case EventPairCreated, EventPairDeleted:
+		payload = &PairPayload{}
+	case EventTriggerStarted:
+		payload = &TriggerStartedPayload{}
+	case EventPhotoConfirmed:
+		payload = &PhotoConfirmedPayload{}
+	case EventMomentCompleted:
+		payload = &MomentCompletedPayload{}
+	default:
+		return nil, fmt.Errorf("unknown domain event type %q", event.Type)
+	}
+	if err := json.Unmarshal(event.Payload, payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	return payload, nil
+}
+
+// DomainEventHandler handles one event of the log. Returning an error stops
+// the consumer at that event; it is retried on the next poll.
+type DomainEventHandler func(ctx context.Context, event *models.DomainEvent) error
+
+type domainEventConsumer struct {
+	name    string
+	handler DomainEventHandler
+	ready   bool
+}
+
+// EventLog appends domain events that aren't part of a repository
+// transaction, feeds the log to consumers such as webhook dispatch, and
+// serves the pair activity feed
+type EventLog struct {
+	repo      DomainEventRepository
+	pairRepo  PairRepository
+	consumers []*domainEventConsumer
+}
+
+// NewEventLog creates an event log; call Subscribe before Run
+func NewEventLog(repo DomainEventRepository, pairRepo PairRepository) *EventLog {
+	return &EventLog{
+		repo:     repo,
+		pairRepo: pairRepo,
+	}
+}
+
+// Subscribe registers a consumer. Its position is stored under name, so a
+// restarted server continues where it stopped; a new consumer starts at the
+// end of the log.
+func (l *EventLog) Subscribe(name string, handler DomainEventHandler) {
+	l.consumers = append(l.consumers, &domainEventConsumer{name: name, handler: handler})
+}
+
+// Append records a domain event
+func (l *EventLog) Append(ctx context.Context, eventType, appID, userID, pairID string, payload interface{}) error {
+	event, err := newDomainEvent(eventType, appID, userID, pairID, payload)
+	if err != nil {
+		return err
+	}
+	return l.repo.Append(ctx, event)
+}
+
+// ActivityCursor is a position in a pair's activity feed, which is ordered
+// by seq, newest first. The zero cursor is the start of the feed.
+type ActivityCursor struct {
+	Seq int64
+}
+
+// String encodes the cursor as an opaque token for clients
+func (c ActivityCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte("seq_" + strconv.FormatInt(c.Seq, 10)))
+}
+
+// ParseActivityCursor decodes a token made by ActivityCursor.String; an
+// empty token is the start of the feed
+func ParseActivityCursor(token string) (ActivityCursor, error) {
+	if token == "" {
+		return ActivityCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ActivityCursor{}, ErrInvalidCursor
+	}
+	seqStr, ok := strings.CutPrefix(string(raw), "seq_")
+	if !ok {
+		return ActivityCursor{}, ErrInvalidCursor
+	}
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil || seq <= 0 {
+		return ActivityCursor{}, ErrInvalidCursor
+	}
+	return ActivityCursor{Seq: seq}, nil
+}
+
+// Activity returns up to limit events of the user's pair after the cursor,
+// newest first. The returned cursor is nil on the last page.
+func (l *EventLog) Activity(ctx context.Context, userID string, after ActivityCursor, limit int) ([]*models.DomainEvent, *ActivityCursor, error) {
+	ctx, span := tracing.Start(ctx, "EventLog.Activity")
+	defer span.End()
+
+	pair, err := pairOfUser(ctx, l.pairRepo, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit = FeedLimits.Clamp(limit)
+
+	// Лишняя строка показывает, есть ли следующая страница
+	events, err := l.repo.ListByPair(ctx, pair.ID, after.Seq, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(events) <= limit {
+		return events, nil, nil
+	}
+	events = events[:limit]
+	return events, &ActivityCursor{Seq: events[limit-1].Seq}, nil
+}
+
+// Run feeds new events to the consumers until ctx is cancelled
+func (l *EventLog) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventLogPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, consumer := range l.consumers {
+			l.consume(context.WithoutCancel(ctx), consumer)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// consume passes the consumer all events available now, one batch at a time
+func (l *EventLog) consume(ctx context.Context, consumer *domainEventConsumer) {
+	logger := log.With().Str("consumer", consumer.name).Logger()
+
+	if !consumer.ready {
+		if err := l.repo.EnsureConsumer(ctx, consumer.name); err != nil {
+			logger.Error().Err(err).Msg("Failed to register domain event consumer")
+			return
+		}
+		consumer.ready = true
+	}
+
+	for {
+		var handlerErr error
+		handled, err := l.repo.Consume(ctx, consumer.name, eventLogSettle, eventLogBatchSize, func(event *models.DomainEvent) error {
+			if handlerErr = consumer.handler(ctx, event); handlerErr != nil {
+				logger.Error().Err(handlerErr).
+					Int64("seq", event.Seq).
+					Str("event_type", event.Type).
+					Msg("Failed to handle domain event, will retry")
+			}
+			return handlerErr
+		})
+		if err != nil {
+			if err != handlerErr {
+				logger.Error().Err(err).Msg("Failed to consume domain events")
+			}
+			return
+		}
+		// Неполная пачка - новых событий пока нет
+		if handled < eventLogBatchSize {
+			return
+		}
+	}
+}
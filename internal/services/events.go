@@ -0,0 +1,253 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidEvent is returned when a batch or one of its events does not match the schema
+var ErrInvalidEvent = errors.New("invalid event")
+
+const (
+	// eventMaxAge rejects events queued on the device for too long
+	eventMaxAge = 7 * 24 * time.Hour
+	// eventMaxClockSkew tolerates device clocks running ahead of the server
+	eventMaxClockSkew = time.Hour
+	eventMaxStringLen = 256
+)
+
+// Property types of client events
+const (
+	eventString = "string"
+	eventNumber = "number"
+	eventBool   = "bool"
+)
+
+// eventProperty describes one property of a client event
+type eventProperty struct {
+	kind     string
+	required bool
+}
+
+// eventSchemas lists the accepted client events and their properties
+var eventSchemas = map[string]map[string]eventProperty{
+	"camera_opened": {
+		"source": {kind: eventString}, // trigger, push, manual
+	},
+	"trigger_tapped": {
+		"partner_online": {kind: eventBool, required: true},
+	},
+	"upload_failed": {
+		"reason":      {kind: eventString, required: true},
+		"http_status": {kind: eventNumber},
+		"attempt":     {kind: eventNumber},
+		"bytes":       {kind: eventNumber},
+	},
+}
+
+// EventInput is a client event as sent in POST /api/v1/events
+type EventInput struct {
+	Name       string                 `json:"name"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// EventSink receives validated client events
+type EventSink interface {
+	Write(ctx context.Context, events []*models.ClientEvent) error
+}
+
+// EventService validates client analytics events and passes them to the configured sink
+type EventService struct {
+	sink         EventSink
+	repo         EventRepository
+	storesEvents bool
+	maxBatchSize int
+	retention    time.Duration
+}
+
+// NewEventService creates an event service writing to the sink chosen in cfg
+func NewEventService(repo EventRepository, cfg config.EventsConfig) *EventService {
+	s := &EventService{
+		repo:         repo,
+		maxBatchSize: cfg.MaxBatchSize,
+		retention:    cfg.Retention,
+	}
+	switch cfg.Sink {
+	case "http":
+		s.sink = &httpEventSink{
+			url:    cfg.SinkURL,
+			client: &http.Client{Timeout: cfg.SinkTimeout},
+		}
+	default:
+		s.sink = &dbEventSink{repo: repo}
+		s.storesEvents = true
+	}
+	return s
+}
+
+// Ingest validates a batch and writes it to the sink. A batch containing an
+// invalid event is rejected as a whole so the client can fix and resend it.
+func (s *EventService) Ingest(ctx context.Context, userID string, inputs []EventInput) (int, error) {
+	if len(inputs) == 0 {
+		return 0, fmt.Errorf("%w: events must not be empty", ErrInvalidEvent)
+	}
+	if len(inputs) > s.maxBatchSize {
+		return 0, fmt.Errorf("%w: at most %d events per request, got %d", ErrInvalidEvent, s.maxBatchSize, len(inputs))
+	}
+
+	now := time.Now().UTC()
+	events := make([]*models.ClientEvent, 0, len(inputs))
+	for i, input := range inputs {
+		if err := validateEvent(input, now); err != nil {
+			return 0, fmt.Errorf("%w: events[%d]: %v", ErrInvalidEvent, i, err)
+		}
+
+		properties := input.Properties
+		if properties == nil {
+			properties = map[string]interface{}{}
+		}
+		events = append(events, &models.ClientEvent{
+			ID:         uuid.New().String(),
+			UserID:     userID,
+			Name:       input.Name,
+			Properties: properties,
+			OccurredAt: input.OccurredAt.UTC(),
+			ReceivedAt: now,
+		})
+	}
+
+	if err := s.sink.Write(ctx, events); err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		metrics.ClientEvents.WithLabelValues(event.Name).Inc()
+	}
+	return len(events), nil
+}
+
+// RunCleanup periodically deletes events older than the retention period
+// from the db sink until ctx is cancelled
+func (s *EventService) RunCleanup(ctx context.Context, interval time.Duration) {
+	if !s.storesEvents {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteReceivedBefore(ctx, time.Now().Add(-s.retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete old client events")
+				continue
+			}
+			if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Old client events deleted")
+			}
+		}
+	}
+}
+
+// validateEvent checks an event against its schema
+func validateEvent(input EventInput, now time.Time) error {
+	schema, ok := eventSchemas[input.Name]
+	if !ok {
+		return fmt.Errorf("unknown event %q", input.Name)
+	}
+
+	switch {
+	case input.OccurredAt.IsZero():
+		return errors.New("occurred_at is required")
+	case input.OccurredAt.After(now.Add(eventMaxClockSkew)):
+		return errors.New("occurred_at is in the future")
+	case input.OccurredAt.Before(now.Add(-eventMaxAge)):
+		return errors.New("occurred_at is too old")
+	}
+
+	for name, value := range input.Properties {
+		property, ok := schema[name]
+		if !ok {
+			return fmt.Errorf("unknown property %q", name)
+		}
+		if !eventValueMatches(property.kind, value) {
+			return fmt.Errorf("property %q must be a %s", name, property.kind)
+		}
+	}
+	for name, property := range schema {
+		if _, ok := input.Properties[name]; property.required && !ok {
+			return fmt.Errorf("property %q is required", name)
+		}
+	}
+	return nil
+}
+
+// eventValueMatches reports whether a decoded JSON value has the expected kind
+func eventValueMatches(kind string, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return kind == eventString && len(v) <= eventMaxStringLen
+	case float64:
+		return kind == eventNumber
+	case bool:
+		return kind == eventBool
+	default:
+		return false
+	}
+}
+
+// dbEventSink stores events in the client_events table
+type dbEventSink struct {
+	repo EventRepository
+}
+
+func (s *dbEventSink) Write(ctx context.Context, events []*models.ClientEvent) error {
+	return s.repo.CreateBatch(ctx, events)
+}
+
+// httpEventSink forwards batches to an external collector as a JSON POST
+type httpEventSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpEventSink) Write(ctx context.Context, events []*models.ClientEvent) error {
+	body, err := json.Marshal(map[string]interface{}{"events": events})
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create sink request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to forward events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrInvalidObjectKey is returned for keys escaping the storage directory
+var ErrInvalidObjectKey = errors.New("invalid object key")
+
+// FileObjectStore keeps photos in a local directory for dev mode. Clients
+// upload to and download from the server itself under baseURL, see
+// handlers.DevStorageHandler. Upload URLs aren't signed, so it must never
+// be reachable from outside a development machine.
+type FileObjectStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewFileObjectStore creates the directory if needed and serves objects
+// under baseURL, e.g. "http://localhost:8080/dev/storage"
+func NewFileObjectStore(dir, baseURL string) (*FileObjectStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &FileObjectStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir returns the directory holding the objects
+func (s *FileObjectStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileObjectStore) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+// PresignGet returns the unsigned URL; dev storage serves objects to anyone
+func (s *FileObjectStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *FileObjectStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.Write(key, bytes.NewReader(data))
+}
+
+// Write stores the object read from r, replacing an existing one
+func (s *FileObjectStore) Write(key string, r io.Reader) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	// Пишем во временный файл, чтобы прерванная загрузка не оставила половину фото
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+func (s *FileObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(name)
+}
+
+func (s *FileObjectStore) GetPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, n))
+}
+
+// Stat sniffs the content type from the file, since dev uploads don't keep
+// the header they were sent with
+func (s *FileObjectStore) Stat(ctx context.Context, key string) (size int64, contentType string, err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return 0, "", err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	return info.Size(), sniffContentType(file), nil
+}
+
+func (s *FileObjectStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileObjectStore) Usage(ctx context.Context) (objects, bytes int64, err error) {
+	err = filepath.WalkDir(s.dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects++
+		bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to walk storage directory: %w", err)
+	}
+	return objects, bytes, nil
+}
+
+func (s *FileObjectStore) Probe(ctx context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s.dir)
+	}
+	return nil
+}
+
+func (s *FileObjectStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path returns the file of the object, rejecting keys outside the directory
+func (s *FileObjectStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", ErrInvalidObjectKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// sniffContentType detects the content type from the leading bytes of r.
+// Formats the detection doesn't know, e.g. HEIC, give an empty type.
+func sniffContentType(r io.Reader) string {
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(r, head)
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "application/octet-stream" {
+		return ""
+	}
+	return contentType
+}
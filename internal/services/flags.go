@@ -0,0 +1,127 @@
+package services
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"sync-photo-backend/internal/config"
+)
+
+// Feature flags consulted in code
+const (
+	// FlagCallPartner gates the call_partner WebSocket message
+	FlagCallPartner = "call_partner"
+)
+
+// defaultFlags holds the value of flags consulted in code when they are
+// not configured, so a new flag is added without changing behavior and
+// an existing feature can still be killed from the config file
+var defaultFlags = map[string]bool{
+	FlagCallPartner: true,
+}
+
+// flagRule is a parsed config.FlagConfig
+type flagRule struct {
+	enabled bool
+	percent int
+	users   map[string]struct{}
+	pairs   map[string]struct{}
+}
+
+// FlagService evaluates feature flags for a user and their pair. Rules are
+// read from the config file and replaced on reload.
+type FlagService struct {
+	mu    sync.RWMutex
+	rules map[string]flagRule
+}
+
+// NewFlagService creates a flag service from the flags config section
+func NewFlagService(flags config.FlagsConfig) *FlagService {
+	s := &FlagService{}
+	s.Update(flags)
+	return s
+}
+
+// Update replaces all flag rules
+func (s *FlagService) Update(flags config.FlagsConfig) {
+	rules := make(map[string]flagRule, len(flags))
+	for name, flag := range flags {
+		rule := flagRule{
+			enabled: flag.Enabled,
+			percent: flag.Percent,
+			users:   make(map[string]struct{}, len(flag.Users)),
+			pairs:   make(map[string]struct{}, len(flag.Pairs)),
+		}
+		for _, userID := range flag.Users {
+			rule.users[userID] = struct{}{}
+		}
+		for _, pairID := range flag.Pairs {
+			rule.pairs[pairID] = struct{}{}
+		}
+		rules[name] = rule
+	}
+
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+}
+
+// Enabled reports whether the flag is on for the user. pairID may be empty
+// for users without a pair; otherwise both partners always get the same result.
+func (s *FlagService) Enabled(name, userID, pairID string) bool {
+	s.mu.RLock()
+	rule, ok := s.rules[name]
+	s.mu.RUnlock()
+
+	if !ok {
+		return defaultFlags[name]
+	}
+	return rule.matches(name, userID, pairID)
+}
+
+// Evaluate returns the value of every known flag for the user
+func (s *FlagService) Evaluate(userID, pairID string) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]bool, len(defaultFlags)+len(s.rules))
+	for name, value := range defaultFlags {
+		result[name] = value
+	}
+	for name, rule := range s.rules {
+		result[name] = rule.matches(name, userID, pairID)
+	}
+	return result
+}
+
+func (r flagRule) matches(name, userID, pairID string) bool {
+	if !r.enabled {
+		return false
+	}
+	if _, ok := r.users[userID]; ok {
+		return true
+	}
+	if pairID != "" {
+		if _, ok := r.pairs[pairID]; ok {
+			return true
+		}
+	}
+
+	// Пара попадает в раскатку целиком, чтобы у партнеров был один набор функций
+	subject := pairID
+	if subject == "" {
+		subject = userID
+	}
+	return rolloutBucket(name, subject) < r.percent
+}
+
+// rolloutBucket maps a subject to a stable bucket in [0, 100) per flag, so
+// raising the percentage only adds subjects and different flags are
+// rolled out to different subjects
+func rolloutBucket(name, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32() % 100)
+}
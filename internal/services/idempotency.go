@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sync-photo-backend/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+// idempotencyTTL is how long responses are kept for replay
+const idempotencyTTL = 24 * time.Hour
+
+// ErrIdempotencyKeyReused is returned when a key is reused for a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrIdempotencyInProgress is returned while the original request is still running
+var ErrIdempotencyInProgress = errors.New("a request with this idempotency key is in progress")
+
+// IdempotencyService lets clients safely retry mutating requests
+type IdempotencyService struct {
+	repo IdempotencyRepository
+}
+
+// NewIdempotencyService creates a new idempotency service
+func NewIdempotencyService(repo IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{repo: repo}
+}
+
+// Begin reserves the key for a request. If the key already holds a completed
+// response for the same request, that record is returned for replay; a nil
+// record means the caller owns the key and must Complete or Abort it.
+func (s *IdempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*models.IdempotencyRecord, error) {
+	reserved, err := s.repo.Reserve(ctx, userID, key, requestHash, time.Now().Add(-idempotencyTTL))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repo.Get(ctx, userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return record, nil
+}
+
+// Complete stores the response for replay
+func (s *IdempotencyService) Complete(ctx context.Context, userID, key string, statusCode int, headers map[string]string, body []byte) error {
+	return s.repo.Complete(ctx, userID, key, statusCode, headers, body)
+}
+
+// Abort releases the key so the client can retry the request
+func (s *IdempotencyService) Abort(ctx context.Context, userID, key string) error {
+	return s.repo.Delete(ctx, userID, key)
+}
+
+// RunCleanup periodically deletes expired keys until ctx is cancelled
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx, time.Now().Add(-idempotencyTTL))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete expired idempotency keys")
+				continue
+			}
+			if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Expired idempotency keys deleted")
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// jobRetentionPeriod is how long finished jobs are kept for the admin listing
+	jobRetentionPeriod  = 7 * 24 * time.Hour
+	jobMaintenanceEvery = time.Minute
+	jobMaxBackoff       = time.Hour
+)
+
+// ErrUnknownJobKind is returned when enqueuing a job kind with no registered handler
+var ErrUnknownJobKind = errors.New("unknown job kind")
+
+// JobHandler processes a job payload. Returning an error schedules a retry
+// until the job runs out of attempts.
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobRunner executes background jobs stored in Postgres. Jobs survive
+// restarts, are retried with exponential backoff and are claimed with
+// SKIP LOCKED, so several instances can share the queue.
+type JobRunner struct {
+	repo JobRepository
+	cfg  config.JobsConfig
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewJobRunner creates a job runner; register handlers, then call Start
+func NewJobRunner(repo JobRepository, cfg config.JobsConfig) *JobRunner {
+	return &JobRunner{
+		repo:     repo,
+		cfg:      cfg,
+		handlers: make(map[string]JobHandler),
+	}
+}
+
+// Register sets the handler for a job kind
+func (r *JobRunner) Register(kind string, handler JobHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[kind] = handler
+}
+
+// Enqueue schedules a job to run as soon as a worker is free
+func (r *JobRunner) Enqueue(ctx context.Context, kind string, payload interface{}) (*models.Job, error) {
+	return r.EnqueueAt(ctx, kind, payload, time.Now())
+}
+
+// EnqueueAt schedules a job to run at runAt
+func (r *JobRunner) EnqueueAt(ctx context.Context, kind string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	return r.enqueue(ctx, uuid.New().String(), kind, payload, runAt)
+}
+
+// EnqueueOnce schedules a job identified by key to run at runAt. The job is
+// only created once per kind and key, even when several instances enqueue
+// it; finished jobs keep their key until they are deleted.
+func (r *JobRunner) EnqueueOnce(ctx context.Context, kind, key string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	id := uuid.NewSHA1(uuid.NameSpaceOID, []byte(kind+":"+key)).String()
+	return r.enqueue(ctx, id, kind, payload, runAt)
+}
+
+func (r *JobRunner) enqueue(ctx context.Context, id, kind string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	if r.handler(kind) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobKind, kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := &models.Job{
+		ID:          id,
+		Kind:        kind,
+		Payload:     data,
+		Status:      models.JobStatusPending,
+		MaxAttempts: r.cfg.MaxAttempts,
+		RunAt:       runAt.UTC(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	created, err := r.repo.Enqueue(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		metrics.Jobs.WithLabelValues(kind, "enqueued").Inc()
+	}
+	return job, nil
+}
+
+// AdminListLimits bound the admin listings of jobs and webhook deliveries
+var AdminListLimits = pagination.Limits{Default: 50, Max: 500}
+
+// List returns recent jobs for the admin listing
+func (r *JobRunner) List(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	return r.repo.List(ctx, status, AdminListLimits.Clamp(limit))
+}
+
+// Start launches the workers and the maintenance loop
+func (r *JobRunner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+
+	for i := 0; i < r.cfg.Workers; i++ {
+		r.wg.Add(1)
+		go r.work(ctx)
+	}
+
+	r.wg.Add(1)
+	go r.maintain(ctx)
+
+	log.Info().Int("workers", r.cfg.Workers).Msg("Job runner started")
+}
+
+// Shutdown stops claiming new jobs and waits for running ones to finish
+func (r *JobRunner) Shutdown(ctx context.Context) error {
+	if r.stop == nil {
+		return nil
+	}
+	r.stop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info().Msg("Job runner stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work claims and runs jobs until ctx is cancelled
+func (r *JobRunner) work(ctx context.Context) {
+	defer r.wg.Done()
+
+	for {
+		job, err := r.repo.Claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to claim job")
+		}
+		if job != nil {
+			r.run(job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// run executes a claimed job and records the outcome. Running jobs are not
+// interrupted by shutdown; they are bounded by the job timeout instead.
+func (r *JobRunner) run(job *models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+
+	logger := log.With().
+		Str("job_id", job.ID).
+		Str("kind", job.Kind).
+		Int("attempt", job.Attempts).
+		Logger()
+
+	handler := r.handler(job.Kind)
+	if handler == nil {
+		logger.Error().Msg("No handler registered for job kind")
+		r.finish(job, "failed", r.repo.Fail(ctx, job.ID, ErrUnknownJobKind.Error()))
+		return
+	}
+
+	start := time.Now()
+	err := handler(logger.WithContext(ctx), job.Payload)
+	if err == nil {
+		logger.Debug().Dur("duration", time.Since(start)).Msg("Job completed")
+		r.finish(job, "done", r.repo.Complete(ctx, job.ID))
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		logger.Error().Err(err).Msg("Job failed, no attempts left")
+		errreport.Capture(ctx, err, "job_kind", job.Kind, "job_id", job.ID)
+		r.finish(job, "failed", r.repo.Fail(ctx, job.ID, err.Error()))
+		return
+	}
+
+	delay := jobBackoff(job.Attempts)
+	logger.Warn().Err(err).Dur("retry_in", delay).Msg("Job failed, will retry")
+	r.finish(job, "retried", r.repo.Retry(ctx, job.ID, err.Error(), time.Now().Add(delay)))
+}
+
+// finish records the job outcome metric and logs a failed state update
+func (r *JobRunner) finish(job *models.Job, result string, err error) {
+	metrics.Jobs.WithLabelValues(job.Kind, result).Inc()
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Failed to update job state")
+	}
+}
+
+// maintain requeues jobs abandoned by crashed workers and deletes old finished jobs
+func (r *JobRunner) maintain(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(jobMaintenanceEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Задача дольше двух таймаутов в running - ее воркер умер
+			requeued, err := r.repo.RequeueStale(ctx, time.Now().Add(-2*r.cfg.Timeout))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to requeue stale jobs")
+			} else if requeued > 0 {
+				log.Warn().Int64("requeued", requeued).Msg("Stale jobs returned to the queue")
+			}
+
+			deleted, err := r.repo.DeleteFinished(ctx, time.Now().Add(-jobRetentionPeriod))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete finished jobs")
+			} else if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Finished jobs deleted")
+			}
+		}
+	}
+}
+
+func (r *JobRunner) handler(kind string) JobHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.handlers[kind]
+}
+
+// jobBackoff returns the delay before retrying after the given attempt
+func jobBackoff(attempt int) time.Duration {
+	delay := 5 * time.Second << attempt
+	if delay > jobMaxBackoff || delay <= 0 {
+		return jobMaxBackoff
+	}
+	return delay
+}
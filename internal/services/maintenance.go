@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// maintenanceKey holds the admin override shared by all replicas
+const maintenanceKey = "maintenance"
+
+// maintenancePollInterval is how quickly other replicas pick up an override
+const maintenancePollInterval = 5 * time.Second
+
+// MaintenanceStatus is the effective maintenance state
+type MaintenanceStatus struct {
+	Enabled    bool
+	Message    string
+	RetryAfter time.Duration
+}
+
+// maintenanceOverride is the state set through the admin API
+type maintenanceOverride struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// MaintenanceService tracks maintenance mode. It is on when either the
+// config file or the admin API turns it on; in cluster mode the admin
+// override is shared through Redis.
+type MaintenanceService struct {
+	mu       sync.RWMutex
+	cfg      config.MaintenanceConfig
+	override maintenanceOverride
+	redis    *redis.Client
+	onEnable []func(MaintenanceStatus)
+}
+
+// NewMaintenanceService creates a maintenance service from the config section
+func NewMaintenanceService(cfg config.MaintenanceConfig) *MaintenanceService {
+	return &MaintenanceService{cfg: cfg}
+}
+
+// WithRedis shares the admin override between replicas. Call Run to pick
+// up overrides set on other replicas.
+func (s *MaintenanceService) WithRedis(client *redis.Client) *MaintenanceService {
+	s.redis = client
+	return s
+}
+
+// OnEnable registers a function called when maintenance mode turns on
+func (s *MaintenanceService) OnEnable(fn func(MaintenanceStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEnable = append(s.onEnable, fn)
+}
+
+// Status returns the effective maintenance state
+func (s *MaintenanceService) Status() MaintenanceStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status()
+}
+
+func (s *MaintenanceService) status() MaintenanceStatus {
+	status := MaintenanceStatus{
+		Enabled:    s.cfg.Enabled || s.override.Enabled,
+		Message:    s.cfg.Message,
+		RetryAfter: s.cfg.RetryAfter,
+	}
+	if s.override.Enabled && s.override.Message != "" {
+		status.Message = s.override.Message
+	}
+	return status
+}
+
+// Update applies a reloaded config section
+func (s *MaintenanceService) Update(cfg config.MaintenanceConfig) {
+	s.apply(func() { s.cfg = cfg })
+}
+
+// Set turns the admin override on or off. An empty message keeps the one
+// from the config. Maintenance enabled in the config file stays on until
+// the file is changed and reloaded.
+func (s *MaintenanceService) Set(ctx context.Context, enabled bool, message string) (MaintenanceStatus, error) {
+	override := maintenanceOverride{Enabled: enabled, Message: message}
+
+	if s.redis != nil {
+		data, err := json.Marshal(override)
+		if err != nil {
+			return MaintenanceStatus{}, fmt.Errorf("failed to encode maintenance state: %w", err)
+		}
+		if err := s.redis.Set(ctx, maintenanceKey, data, 0).Err(); err != nil {
+			return MaintenanceStatus{}, fmt.Errorf("failed to store maintenance state: %w", err)
+		}
+	}
+
+	s.apply(func() { s.override = override })
+	return s.Status(), nil
+}
+
+// Run polls the override shared through Redis until ctx is cancelled
+func (s *MaintenanceService) Run(ctx context.Context) {
+	if s.redis == nil {
+		return
+	}
+
+	ticker := time.NewTicker(maintenancePollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync loads the override stored by any replica
+func (s *MaintenanceService) sync(ctx context.Context) {
+	data, err := s.redis.Get(ctx, maintenanceKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		s.apply(func() { s.override = maintenanceOverride{} })
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load maintenance state")
+		return
+	}
+
+	var override maintenanceOverride
+	if err := json.Unmarshal(data, &override); err != nil {
+		log.Error().Err(err).Msg("Failed to decode maintenance state")
+		return
+	}
+	s.apply(func() { s.override = override })
+}
+
+// apply changes the state under the lock and notifies OnEnable subscribers
+// when maintenance mode turns on
+func (s *MaintenanceService) apply(change func()) {
+	s.mu.Lock()
+	wasEnabled := s.status().Enabled
+	change()
+	status := s.status()
+	hooks := s.onEnable
+	s.mu.Unlock()
+
+	if status.Enabled == wasEnabled {
+		return
+	}
+
+	log.Warn().Bool("enabled", status.Enabled).Str("message", status.Message).Msg("Maintenance mode changed")
+	if status.Enabled {
+		for _, hook := range hooks {
+			hook(status)
+		}
+	}
+}
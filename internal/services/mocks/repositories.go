@@ -0,0 +1,1998 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repositories.go -destination=mocks/repositories.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	models "sync-photo-backend/internal/models"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+	isgomock struct{}
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUnitOfWorkMockRecorder) Do(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUnitOfWork)(nil).Do), ctx, fn)
+}
+
+// MockUserRepository is a mock of UserRepository interface.
+type MockUserRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockUserRepositoryMockRecorder is the mock recorder for MockUserRepository.
+type MockUserRepositoryMockRecorder struct {
+	mock *MockUserRepository
+}
+
+// NewMockUserRepository creates a new mock instance.
+func NewMockUserRepository(ctrl *gomock.Controller) *MockUserRepository {
+	mock := &MockUserRepository{ctrl: ctrl}
+	mock.recorder = &MockUserRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Anonymize mocks base method.
+func (m *MockUserRepository) Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Anonymize", ctx, userID, deletedBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Anonymize indicates an expected call of Anonymize.
+func (mr *MockUserRepositoryMockRecorder) Anonymize(ctx, userID, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Anonymize", reflect.TypeOf((*MockUserRepository)(nil).Anonymize), ctx, userID, deletedBefore)
+}
+
+// CodeExists mocks base method.
+func (m *MockUserRepository) CodeExists(ctx context.Context, code string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CodeExists", ctx, code)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CodeExists indicates an expected call of CodeExists.
+func (mr *MockUserRepositoryMockRecorder) CodeExists(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CodeExists", reflect.TypeOf((*MockUserRepository)(nil).CodeExists), ctx, code)
+}
+
+// Create mocks base method.
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User, events ...*models.DomainEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, user}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserRepositoryMockRecorder) Create(ctx, user any, events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, user}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), varargs...)
+}
+
+// GetByCode mocks base method.
+func (m *MockUserRepository) GetByCode(ctx context.Context, appID, code string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", ctx, appID, code)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockUserRepositoryMockRecorder) GetByCode(ctx, appID, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockUserRepository)(nil).GetByCode), ctx, appID, code)
+}
+
+// GetByID mocks base method.
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
+}
+
+// GetForUpdate mocks base method.
+func (m *MockUserRepository) GetForUpdate(ctx context.Context, id string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetForUpdate), ctx, id)
+}
+
+// LastSeen mocks base method.
+func (m *MockUserRepository) LastSeen(ctx context.Context, userID string) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastSeen", ctx, userID)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastSeen indicates an expected call of LastSeen.
+func (mr *MockUserRepositoryMockRecorder) LastSeen(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSeen", reflect.TypeOf((*MockUserRepository)(nil).LastSeen), ctx, userID)
+}
+
+// Purge mocks base method.
+func (m *MockUserRepository) Purge(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, userID, deletedBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUserRepositoryMockRecorder) Purge(ctx, userID, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUserRepository)(nil).Purge), ctx, userID, deletedBefore)
+}
+
+// Restore mocks base method.
+func (m *MockUserRepository) Restore(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserRepositoryMockRecorder) Restore(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserRepository)(nil).Restore), ctx, userID)
+}
+
+// SoftDelete mocks base method.
+func (m *MockUserRepository) SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", ctx, userID, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockUserRepositoryMockRecorder) SoftDelete(ctx, userID, deletedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockUserRepository)(nil).SoftDelete), ctx, userID, deletedAt)
+}
+
+// TouchLastSeen mocks base method.
+func (m *MockUserRepository) TouchLastSeen(ctx context.Context, userID string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchLastSeen", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchLastSeen indicates an expected call of TouchLastSeen.
+func (mr *MockUserRepositoryMockRecorder) TouchLastSeen(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchLastSeen", reflect.TypeOf((*MockUserRepository)(nil).TouchLastSeen), ctx, userID, at)
+}
+
+// UpdateLocale mocks base method.
+func (m *MockUserRepository) UpdateLocale(ctx context.Context, userID string, locale *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLocale", ctx, userID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLocale indicates an expected call of UpdateLocale.
+func (mr *MockUserRepositoryMockRecorder) UpdateLocale(ctx, userID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocale", reflect.TypeOf((*MockUserRepository)(nil).UpdateLocale), ctx, userID, locale)
+}
+
+// UpdatePushToken mocks base method.
+func (m *MockUserRepository) UpdatePushToken(ctx context.Context, userID string, pushToken *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePushToken", ctx, userID, pushToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePushToken indicates an expected call of UpdatePushToken.
+func (mr *MockUserRepositoryMockRecorder) UpdatePushToken(ctx, userID, pushToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePushToken", reflect.TypeOf((*MockUserRepository)(nil).UpdatePushToken), ctx, userID, pushToken)
+}
+
+// UpdateQuietHours mocks base method.
+func (m *MockUserRepository) UpdateQuietHours(ctx context.Context, userID string, start, end *string, timezone string, alwaysAllowTriggers bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateQuietHours", ctx, userID, start, end, timezone, alwaysAllowTriggers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateQuietHours indicates an expected call of UpdateQuietHours.
+func (mr *MockUserRepositoryMockRecorder) UpdateQuietHours(ctx, userID, start, end, timezone, alwaysAllowTriggers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuietHours", reflect.TypeOf((*MockUserRepository)(nil).UpdateQuietHours), ctx, userID, start, end, timezone, alwaysAllowTriggers)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockUserRepository) UpdateSettings(ctx context.Context, user *models.User, updatedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", ctx, user, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockUserRepositoryMockRecorder) UpdateSettings(ctx, user, updatedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockUserRepository)(nil).UpdateSettings), ctx, user, updatedAt)
+}
+
+// UpdateTimeSensitiveTriggers mocks base method.
+func (m *MockUserRepository) UpdateTimeSensitiveTriggers(ctx context.Context, userID string, enabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTimeSensitiveTriggers", ctx, userID, enabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTimeSensitiveTriggers indicates an expected call of UpdateTimeSensitiveTriggers.
+func (mr *MockUserRepositoryMockRecorder) UpdateTimeSensitiveTriggers(ctx, userID, enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTimeSensitiveTriggers", reflect.TypeOf((*MockUserRepository)(nil).UpdateTimeSensitiveTriggers), ctx, userID, enabled)
+}
+
+// MockPairRepository is a mock of PairRepository interface.
+type MockPairRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPairRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPairRepositoryMockRecorder is the mock recorder for MockPairRepository.
+type MockPairRepositoryMockRecorder struct {
+	mock *MockPairRepository
+}
+
+// NewMockPairRepository creates a new mock instance.
+func NewMockPairRepository(ctrl *gomock.Controller) *MockPairRepository {
+	mock := &MockPairRepository{ctrl: ctrl}
+	mock.recorder = &MockPairRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPairRepository) EXPECT() *MockPairRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPairRepository) Create(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, pair, event}
+	for _, a := range outbox {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPairRepositoryMockRecorder) Create(ctx, pair, event any, outbox ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, pair, event}, outbox...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPairRepository)(nil).Create), varargs...)
+}
+
+// Delete mocks base method.
+func (m *MockPairRepository) Delete(ctx context.Context, id string, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, id, event}
+	for _, a := range outbox {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPairRepositoryMockRecorder) Delete(ctx, id, event any, outbox ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, id, event}, outbox...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPairRepository)(nil).Delete), varargs...)
+}
+
+// GetByID mocks base method.
+func (m *MockPairRepository) GetByID(ctx context.Context, id string) (*models.Pair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Pair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPairRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPairRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByUserID mocks base method.
+func (m *MockPairRepository) GetByUserID(ctx context.Context, userID string) (*models.Pair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", ctx, userID)
+	ret0, _ := ret[0].(*models.Pair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockPairRepositoryMockRecorder) GetByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockPairRepository)(nil).GetByUserID), ctx, userID)
+}
+
+// GetByUsers mocks base method.
+func (m *MockPairRepository) GetByUsers(ctx context.Context, userAID, userBID string) (*models.Pair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUsers", ctx, userAID, userBID)
+	ret0, _ := ret[0].(*models.Pair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUsers indicates an expected call of GetByUsers.
+func (mr *MockPairRepositoryMockRecorder) GetByUsers(ctx, userAID, userBID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsers", reflect.TypeOf((*MockPairRepository)(nil).GetByUsers), ctx, userAID, userBID)
+}
+
+// ListByUserID mocks base method.
+func (m *MockPairRepository) ListByUserID(ctx context.Context, userID string) ([]*models.Pair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserID", ctx, userID)
+	ret0, _ := ret[0].([]*models.Pair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByUserID indicates an expected call of ListByUserID.
+func (mr *MockPairRepositoryMockRecorder) ListByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserID", reflect.TypeOf((*MockPairRepository)(nil).ListByUserID), ctx, userID)
+}
+
+// Restore mocks base method.
+func (m *MockPairRepository) Restore(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, pair, event}
+	for _, a := range outbox {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Restore", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPairRepositoryMockRecorder) Restore(ctx, pair, event any, outbox ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, pair, event}, outbox...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPairRepository)(nil).Restore), varargs...)
+}
+
+// UserHasPair mocks base method.
+func (m *MockPairRepository) UserHasPair(ctx context.Context, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserHasPair", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserHasPair indicates an expected call of UserHasPair.
+func (mr *MockPairRepositoryMockRecorder) UserHasPair(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserHasPair", reflect.TypeOf((*MockPairRepository)(nil).UserHasPair), ctx, userID)
+}
+
+// MockPhotoRepository is a mock of PhotoRepository interface.
+type MockPhotoRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPhotoRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPhotoRepositoryMockRecorder is the mock recorder for MockPhotoRepository.
+type MockPhotoRepositoryMockRecorder struct {
+	mock *MockPhotoRepository
+}
+
+// NewMockPhotoRepository creates a new mock instance.
+func NewMockPhotoRepository(ctrl *gomock.Controller) *MockPhotoRepository {
+	mock := &MockPhotoRepository{ctrl: ctrl}
+	mock.recorder = &MockPhotoRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPhotoRepository) EXPECT() *MockPhotoRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Confirm mocks base method.
+func (m *MockPhotoRepository) Confirm(ctx context.Context, photoID string, upload models.PhotoUpload, confirmed func(*models.Photo) (*models.DomainEvent, []*models.OutboxEvent, error)) (*models.Photo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, photoID, upload, confirmed)
+	ret0, _ := ret[0].(*models.Photo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockPhotoRepositoryMockRecorder) Confirm(ctx, photoID, upload, confirmed any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockPhotoRepository)(nil).Confirm), ctx, photoID, upload, confirmed)
+}
+
+// Create mocks base method.
+func (m *MockPhotoRepository) Create(ctx context.Context, photo *models.Photo, events ...*models.DomainEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, photo}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPhotoRepositoryMockRecorder) Create(ctx, photo any, events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, photo}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPhotoRepository)(nil).Create), varargs...)
+}
+
+// DeletePending mocks base method.
+func (m *MockPhotoRepository) DeletePending(ctx context.Context, createdBefore time.Time, limit int, removed func([]*models.Photo) []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePending", ctx, createdBefore, limit, removed)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePending indicates an expected call of DeletePending.
+func (mr *MockPhotoRepositoryMockRecorder) DeletePending(ctx, createdBefore, limit, removed any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePending", reflect.TypeOf((*MockPhotoRepository)(nil).DeletePending), ctx, createdBefore, limit, removed)
+}
+
+// GetByID mocks base method.
+func (m *MockPhotoRepository) GetByID(ctx context.Context, id string) (*models.Photo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Photo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPhotoRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPhotoRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByPairID mocks base method.
+func (m *MockPhotoRepository) GetByPairID(ctx context.Context, pairID string, limit, offset int) ([]*models.Photo, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPairID", ctx, pairID, limit, offset)
+	ret0, _ := ret[0].([]*models.Photo)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByPairID indicates an expected call of GetByPairID.
+func (mr *MockPhotoRepositoryMockRecorder) GetByPairID(ctx, pairID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPairID", reflect.TypeOf((*MockPhotoRepository)(nil).GetByPairID), ctx, pairID, limit, offset)
+}
+
+// ListAround mocks base method.
+func (m *MockPhotoRepository) ListAround(ctx context.Context, pairID string, takenAt time.Time, window time.Duration) ([]*models.Photo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAround", ctx, pairID, takenAt, window)
+	ret0, _ := ret[0].([]*models.Photo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAround indicates an expected call of ListAround.
+func (mr *MockPhotoRepositoryMockRecorder) ListAround(ctx, pairID, takenAt, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAround", reflect.TypeOf((*MockPhotoRepository)(nil).ListAround), ctx, pairID, takenAt, window)
+}
+
+// ListByPairBefore mocks base method.
+func (m *MockPhotoRepository) ListByPairBefore(ctx context.Context, pairID string, takenAt time.Time, id string, limit int) ([]*models.Photo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPairBefore", ctx, pairID, takenAt, id, limit)
+	ret0, _ := ret[0].([]*models.Photo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPairBefore indicates an expected call of ListByPairBefore.
+func (mr *MockPhotoRepositoryMockRecorder) ListByPairBefore(ctx, pairID, takenAt, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPairBefore", reflect.TypeOf((*MockPhotoRepository)(nil).ListByPairBefore), ctx, pairID, takenAt, id, limit)
+}
+
+// ListKeysByPair mocks base method.
+func (m *MockPhotoRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeysByPair", ctx, pairID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeysByPair indicates an expected call of ListKeysByPair.
+func (mr *MockPhotoRepositoryMockRecorder) ListKeysByPair(ctx, pairID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeysByPair", reflect.TypeOf((*MockPhotoRepository)(nil).ListKeysByPair), ctx, pairID)
+}
+
+// MockCompositeRepository is a mock of CompositeRepository interface.
+type MockCompositeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCompositeRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCompositeRepositoryMockRecorder is the mock recorder for MockCompositeRepository.
+type MockCompositeRepositoryMockRecorder struct {
+	mock *MockCompositeRepository
+}
+
+// NewMockCompositeRepository creates a new mock instance.
+func NewMockCompositeRepository(ctrl *gomock.Controller) *MockCompositeRepository {
+	mock := &MockCompositeRepository{ctrl: ctrl}
+	mock.recorder = &MockCompositeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCompositeRepository) EXPECT() *MockCompositeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCompositeRepository) Create(ctx context.Context, composite *models.Composite) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, composite)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCompositeRepositoryMockRecorder) Create(ctx, composite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCompositeRepository)(nil).Create), ctx, composite)
+}
+
+// ListByPair mocks base method.
+func (m *MockCompositeRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.Composite, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPair", ctx, pairID, limit, offset)
+	ret0, _ := ret[0].([]*models.Composite)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByPair indicates an expected call of ListByPair.
+func (mr *MockCompositeRepositoryMockRecorder) ListByPair(ctx, pairID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPair", reflect.TypeOf((*MockCompositeRepository)(nil).ListByPair), ctx, pairID, limit, offset)
+}
+
+// ListKeysByPair mocks base method.
+func (m *MockCompositeRepository) ListKeysByPair(ctx context.Context, pairID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeysByPair", ctx, pairID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeysByPair indicates an expected call of ListKeysByPair.
+func (mr *MockCompositeRepositoryMockRecorder) ListKeysByPair(ctx, pairID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeysByPair", reflect.TypeOf((*MockCompositeRepository)(nil).ListKeysByPair), ctx, pairID)
+}
+
+// MockSyncSessionRepository is a mock of SyncSessionRepository interface.
+type MockSyncSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSyncSessionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSyncSessionRepositoryMockRecorder is the mock recorder for MockSyncSessionRepository.
+type MockSyncSessionRepositoryMockRecorder struct {
+	mock *MockSyncSessionRepository
+}
+
+// NewMockSyncSessionRepository creates a new mock instance.
+func NewMockSyncSessionRepository(ctrl *gomock.Controller) *MockSyncSessionRepository {
+	mock := &MockSyncSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSyncSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSyncSessionRepository) EXPECT() *MockSyncSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AttachPhoto mocks base method.
+func (m *MockSyncSessionRepository) AttachPhoto(ctx context.Context, sessionID string, photo *models.Photo, completedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachPhoto", ctx, sessionID, photo, completedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachPhoto indicates an expected call of AttachPhoto.
+func (mr *MockSyncSessionRepositoryMockRecorder) AttachPhoto(ctx, sessionID, photo, completedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachPhoto", reflect.TypeOf((*MockSyncSessionRepository)(nil).AttachPhoto), ctx, sessionID, photo, completedAt)
+}
+
+// Create mocks base method.
+func (m *MockSyncSessionRepository) Create(ctx context.Context, session *models.SyncSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSyncSessionRepositoryMockRecorder) Create(ctx, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSyncSessionRepository)(nil).Create), ctx, session)
+}
+
+// Delete mocks base method.
+func (m *MockSyncSessionRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSyncSessionRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSyncSessionRepository)(nil).Delete), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockSyncSessionRepository) GetByID(ctx context.Context, id string) (*models.SyncSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.SyncSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockSyncSessionRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSyncSessionRepository)(nil).GetByID), ctx, id)
+}
+
+// ListByPair mocks base method.
+func (m *MockSyncSessionRepository) ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.SyncSession, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPair", ctx, pairID, limit, offset)
+	ret0, _ := ret[0].([]*models.SyncSession)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByPair indicates an expected call of ListByPair.
+func (mr *MockSyncSessionRepositoryMockRecorder) ListByPair(ctx, pairID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPair", reflect.TypeOf((*MockSyncSessionRepository)(nil).ListByPair), ctx, pairID, limit, offset)
+}
+
+// MarkMissed mocks base method.
+func (m *MockSyncSessionRepository) MarkMissed(ctx context.Context, id string, missedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMissed", ctx, id, missedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkMissed indicates an expected call of MarkMissed.
+func (mr *MockSyncSessionRepositoryMockRecorder) MarkMissed(ctx, id, missedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMissed", reflect.TypeOf((*MockSyncSessionRepository)(nil).MarkMissed), ctx, id, missedAt)
+}
+
+// MockReactionRepository is a mock of ReactionRepository interface.
+type MockReactionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReactionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockReactionRepositoryMockRecorder is the mock recorder for MockReactionRepository.
+type MockReactionRepositoryMockRecorder struct {
+	mock *MockReactionRepository
+}
+
+// NewMockReactionRepository creates a new mock instance.
+func NewMockReactionRepository(ctrl *gomock.Controller) *MockReactionRepository {
+	mock := &MockReactionRepository{ctrl: ctrl}
+	mock.recorder = &MockReactionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReactionRepository) EXPECT() *MockReactionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddComment mocks base method.
+func (m *MockReactionRepository) AddComment(ctx context.Context, comment *models.Comment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddComment", ctx, comment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddComment indicates an expected call of AddComment.
+func (mr *MockReactionRepositoryMockRecorder) AddComment(ctx, comment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockReactionRepository)(nil).AddComment), ctx, comment)
+}
+
+// AddReaction mocks base method.
+func (m *MockReactionRepository) AddReaction(ctx context.Context, reaction *models.Reaction) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, reaction)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockReactionRepositoryMockRecorder) AddReaction(ctx, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockReactionRepository)(nil).AddReaction), ctx, reaction)
+}
+
+// Counts mocks base method.
+func (m *MockReactionRepository) Counts(ctx context.Context, photoIDs []string) (map[string]models.PhotoCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Counts", ctx, photoIDs)
+	ret0, _ := ret[0].(map[string]models.PhotoCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Counts indicates an expected call of Counts.
+func (mr *MockReactionRepositoryMockRecorder) Counts(ctx, photoIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Counts", reflect.TypeOf((*MockReactionRepository)(nil).Counts), ctx, photoIDs)
+}
+
+// ListComments mocks base method.
+func (m *MockReactionRepository) ListComments(ctx context.Context, photoID string, limit, offset int) ([]*models.Comment, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListComments", ctx, photoID, limit, offset)
+	ret0, _ := ret[0].([]*models.Comment)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListComments indicates an expected call of ListComments.
+func (mr *MockReactionRepositoryMockRecorder) ListComments(ctx, photoID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListComments", reflect.TypeOf((*MockReactionRepository)(nil).ListComments), ctx, photoID, limit, offset)
+}
+
+// ListReactions mocks base method.
+func (m *MockReactionRepository) ListReactions(ctx context.Context, photoID string) ([]*models.Reaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReactions", ctx, photoID)
+	ret0, _ := ret[0].([]*models.Reaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReactions indicates an expected call of ListReactions.
+func (mr *MockReactionRepositoryMockRecorder) ListReactions(ctx, photoID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReactions", reflect.TypeOf((*MockReactionRepository)(nil).ListReactions), ctx, photoID)
+}
+
+// MockPromptRepository is a mock of PromptRepository interface.
+type MockPromptRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromptRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPromptRepositoryMockRecorder is the mock recorder for MockPromptRepository.
+type MockPromptRepositoryMockRecorder struct {
+	mock *MockPromptRepository
+}
+
+// NewMockPromptRepository creates a new mock instance.
+func NewMockPromptRepository(ctrl *gomock.Controller) *MockPromptRepository {
+	mock := &MockPromptRepository{ctrl: ctrl}
+	mock.recorder = &MockPromptRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromptRepository) EXPECT() *MockPromptRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockPromptRepository) Complete(ctx context.Context, pairID string, userA bool, at time.Time) (*models.DailyPrompt, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, pairID, userA, at)
+	ret0, _ := ret[0].(*models.DailyPrompt)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockPromptRepositoryMockRecorder) Complete(ctx, pairID, userA, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockPromptRepository)(nil).Complete), ctx, pairID, userA, at)
+}
+
+// Create mocks base method.
+func (m *MockPromptRepository) Create(ctx context.Context, prompt *models.DailyPrompt, outbox ...*models.OutboxEvent) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, prompt}
+	for _, a := range outbox {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPromptRepositoryMockRecorder) Create(ctx, prompt any, outbox ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, prompt}, outbox...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPromptRepository)(nil).Create), varargs...)
+}
+
+// GetSettings mocks base method.
+func (m *MockPromptRepository) GetSettings(ctx context.Context, pairID string) (*models.PromptSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, pairID)
+	ret0, _ := ret[0].(*models.PromptSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockPromptRepositoryMockRecorder) GetSettings(ctx, pairID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockPromptRepository)(nil).GetSettings), ctx, pairID)
+}
+
+// ListByPair mocks base method.
+func (m *MockPromptRepository) ListByPair(ctx context.Context, pairID string) ([]*models.DailyPrompt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPair", ctx, pairID)
+	ret0, _ := ret[0].([]*models.DailyPrompt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPair indicates an expected call of ListByPair.
+func (mr *MockPromptRepositoryMockRecorder) ListByPair(ctx, pairID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPair", reflect.TypeOf((*MockPromptRepository)(nil).ListByPair), ctx, pairID)
+}
+
+// ListDue mocks base method.
+func (m *MockPromptRepository) ListDue(ctx context.Context, now time.Time, defaultTime string, limit int) ([]*models.DuePrompt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDue", ctx, now, defaultTime, limit)
+	ret0, _ := ret[0].([]*models.DuePrompt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDue indicates an expected call of ListDue.
+func (mr *MockPromptRepositoryMockRecorder) ListDue(ctx, now, defaultTime, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDue", reflect.TypeOf((*MockPromptRepository)(nil).ListDue), ctx, now, defaultTime, limit)
+}
+
+// SaveSettings mocks base method.
+func (m *MockPromptRepository) SaveSettings(ctx context.Context, settings *models.PromptSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSettings", ctx, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSettings indicates an expected call of SaveSettings.
+func (mr *MockPromptRepositoryMockRecorder) SaveSettings(ctx, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSettings", reflect.TypeOf((*MockPromptRepository)(nil).SaveSettings), ctx, settings)
+}
+
+// MockPairSettingsRepository is a mock of PairSettingsRepository interface.
+type MockPairSettingsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPairSettingsRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockPairSettingsRepositoryMockRecorder is the mock recorder for MockPairSettingsRepository.
+type MockPairSettingsRepositoryMockRecorder struct {
+	mock *MockPairSettingsRepository
+}
+
+// NewMockPairSettingsRepository creates a new mock instance.
+func NewMockPairSettingsRepository(ctrl *gomock.Controller) *MockPairSettingsRepository {
+	mock := &MockPairSettingsRepository{ctrl: ctrl}
+	mock.recorder = &MockPairSettingsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPairSettingsRepository) EXPECT() *MockPairSettingsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockPairSettingsRepository) Get(ctx context.Context, pairID string) (*models.PairSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, pairID)
+	ret0, _ := ret[0].(*models.PairSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPairSettingsRepositoryMockRecorder) Get(ctx, pairID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPairSettingsRepository)(nil).Get), ctx, pairID)
+}
+
+// Save mocks base method.
+func (m *MockPairSettingsRepository) Save(ctx context.Context, settings *models.PairSettings, updatedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, settings, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockPairSettingsRepositoryMockRecorder) Save(ctx, settings, updatedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPairSettingsRepository)(nil).Save), ctx, settings, updatedAt)
+}
+
+// MockRefreshTokenRepository is a mock of RefreshTokenRepository interface.
+type MockRefreshTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokenRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockRefreshTokenRepositoryMockRecorder is the mock recorder for MockRefreshTokenRepository.
+type MockRefreshTokenRepositoryMockRecorder struct {
+	mock *MockRefreshTokenRepository
+}
+
+// NewMockRefreshTokenRepository creates a new mock instance.
+func NewMockRefreshTokenRepository(ctrl *gomock.Controller) *MockRefreshTokenRepository {
+	mock := &MockRefreshTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshTokenRepository) EXPECT() *MockRefreshTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefreshTokenRepositoryMockRecorder) Create(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefreshTokenRepository)(nil).Create), ctx, token)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockRefreshTokenRepositoryMockRecorder) DeleteExpired(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockRefreshTokenRepository)(nil).DeleteExpired), ctx, before)
+}
+
+// GetByHash mocks base method.
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, hash []byte) (*models.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", ctx, hash)
+	ret0, _ := ret[0].(*models.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockRefreshTokenRepositoryMockRecorder) GetByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockRefreshTokenRepository)(nil).GetByHash), ctx, hash)
+}
+
+// RevokeSession mocks base method.
+func (m *MockRefreshTokenRepository) RevokeSession(ctx context.Context, sessionID string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockRefreshTokenRepositoryMockRecorder) RevokeSession(ctx, sessionID, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockRefreshTokenRepository)(nil).RevokeSession), ctx, sessionID, revokedAt)
+}
+
+// RevokeUser mocks base method.
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID string, revokedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUser", ctx, userID, revokedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUser indicates an expected call of RevokeUser.
+func (mr *MockRefreshTokenRepositoryMockRecorder) RevokeUser(ctx, userID, revokedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUser", reflect.TypeOf((*MockRefreshTokenRepository)(nil).RevokeUser), ctx, userID, revokedAt)
+}
+
+// Rotate mocks base method.
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, currentID, next)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockRefreshTokenRepositoryMockRecorder) Rotate(ctx, currentID, next any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockRefreshTokenRepository)(nil).Rotate), ctx, currentID, next)
+}
+
+// SessionActive mocks base method.
+func (m *MockRefreshTokenRepository) SessionActive(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionActive", ctx, sessionID, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionActive indicates an expected call of SessionActive.
+func (mr *MockRefreshTokenRepositoryMockRecorder) SessionActive(ctx, sessionID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionActive", reflect.TypeOf((*MockRefreshTokenRepository)(nil).SessionActive), ctx, sessionID, now)
+}
+
+// MockDomainEventRepository is a mock of DomainEventRepository interface.
+type MockDomainEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDomainEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDomainEventRepositoryMockRecorder is the mock recorder for MockDomainEventRepository.
+type MockDomainEventRepositoryMockRecorder struct {
+	mock *MockDomainEventRepository
+}
+
+// NewMockDomainEventRepository creates a new mock instance.
+func NewMockDomainEventRepository(ctrl *gomock.Controller) *MockDomainEventRepository {
+	mock := &MockDomainEventRepository{ctrl: ctrl}
+	mock.recorder = &MockDomainEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDomainEventRepository) EXPECT() *MockDomainEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockDomainEventRepository) Append(ctx context.Context, events ...*models.DomainEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Append", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockDomainEventRepositoryMockRecorder) Append(ctx any, events ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockDomainEventRepository)(nil).Append), varargs...)
+}
+
+// Consume mocks base method.
+func (m *MockDomainEventRepository) Consume(ctx context.Context, name string, settle time.Duration, limit int, handle func(*models.DomainEvent) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, name, settle, limit, handle)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockDomainEventRepositoryMockRecorder) Consume(ctx, name, settle, limit, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockDomainEventRepository)(nil).Consume), ctx, name, settle, limit, handle)
+}
+
+// EnsureConsumer mocks base method.
+func (m *MockDomainEventRepository) EnsureConsumer(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureConsumer", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureConsumer indicates an expected call of EnsureConsumer.
+func (mr *MockDomainEventRepositoryMockRecorder) EnsureConsumer(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureConsumer", reflect.TypeOf((*MockDomainEventRepository)(nil).EnsureConsumer), ctx, name)
+}
+
+// ListByPair mocks base method.
+func (m *MockDomainEventRepository) ListByPair(ctx context.Context, pairID string, beforeSeq int64, limit int) ([]*models.DomainEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByPair", ctx, pairID, beforeSeq, limit)
+	ret0, _ := ret[0].([]*models.DomainEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByPair indicates an expected call of ListByPair.
+func (mr *MockDomainEventRepositoryMockRecorder) ListByPair(ctx, pairID, beforeSeq, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByPair", reflect.TypeOf((*MockDomainEventRepository)(nil).ListByPair), ctx, pairID, beforeSeq, limit)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteProcessed mocks base method.
+func (m *MockOutboxRepository) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProcessed", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteProcessed indicates an expected call of DeleteProcessed.
+func (mr *MockOutboxRepositoryMockRecorder) DeleteProcessed(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProcessed", reflect.TypeOf((*MockOutboxRepository)(nil).DeleteProcessed), ctx, before)
+}
+
+// Process mocks base method.
+func (m *MockOutboxRepository) Process(ctx context.Context, limit int, handle func(*models.OutboxEvent) models.OutboxResult) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Process", ctx, limit, handle)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Process indicates an expected call of Process.
+func (mr *MockOutboxRepositoryMockRecorder) Process(ctx, limit, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockOutboxRepository)(nil).Process), ctx, limit, handle)
+}
+
+// MockUserEventRepository is a mock of UserEventRepository interface.
+type MockUserEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockUserEventRepositoryMockRecorder is the mock recorder for MockUserEventRepository.
+type MockUserEventRepositoryMockRecorder struct {
+	mock *MockUserEventRepository
+}
+
+// NewMockUserEventRepository creates a new mock instance.
+func NewMockUserEventRepository(ctrl *gomock.Controller) *MockUserEventRepository {
+	mock := &MockUserEventRepository{ctrl: ctrl}
+	mock.recorder = &MockUserEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserEventRepository) EXPECT() *MockUserEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockUserEventRepository) Ack(ctx context.Context, userID string, seq int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx, userID, seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockUserEventRepositoryMockRecorder) Ack(ctx, userID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockUserEventRepository)(nil).Ack), ctx, userID, seq)
+}
+
+// Append mocks base method.
+func (m *MockUserEventRepository) Append(ctx context.Context, event *models.UserEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockUserEventRepositoryMockRecorder) Append(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockUserEventRepository)(nil).Append), ctx, event)
+}
+
+// DeleteBefore mocks base method.
+func (m *MockUserEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBefore indicates an expected call of DeleteBefore.
+func (mr *MockUserEventRepositoryMockRecorder) DeleteBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBefore", reflect.TypeOf((*MockUserEventRepository)(nil).DeleteBefore), ctx, before)
+}
+
+// ListPending mocks base method.
+func (m *MockUserEventRepository) ListPending(ctx context.Context, userID string, since time.Time) ([]*models.UserEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPending", ctx, userID, since)
+	ret0, _ := ret[0].([]*models.UserEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPending indicates an expected call of ListPending.
+func (mr *MockUserEventRepositoryMockRecorder) ListPending(ctx, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPending", reflect.TypeOf((*MockUserEventRepository)(nil).ListPending), ctx, userID, since)
+}
+
+// MockJobRepository is a mock of JobRepository interface.
+type MockJobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockJobRepositoryMockRecorder is the mock recorder for MockJobRepository.
+type MockJobRepositoryMockRecorder struct {
+	mock *MockJobRepository
+}
+
+// NewMockJobRepository creates a new mock instance.
+func NewMockJobRepository(ctrl *gomock.Controller) *MockJobRepository {
+	mock := &MockJobRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockJobRepository) Claim(ctx context.Context) (*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockJobRepositoryMockRecorder) Claim(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockJobRepository)(nil).Claim), ctx)
+}
+
+// Complete mocks base method.
+func (m *MockJobRepository) Complete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockJobRepositoryMockRecorder) Complete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockJobRepository)(nil).Complete), ctx, id)
+}
+
+// DeleteFinished mocks base method.
+func (m *MockJobRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFinished", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFinished indicates an expected call of DeleteFinished.
+func (mr *MockJobRepositoryMockRecorder) DeleteFinished(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFinished", reflect.TypeOf((*MockJobRepository)(nil).DeleteFinished), ctx, before)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, job)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobRepositoryMockRecorder) Enqueue(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobRepository)(nil).Enqueue), ctx, job)
+}
+
+// Fail mocks base method.
+func (m *MockJobRepository) Fail(ctx context.Context, id, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fail", ctx, id, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fail indicates an expected call of Fail.
+func (mr *MockJobRepositoryMockRecorder) Fail(ctx, id, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fail", reflect.TypeOf((*MockJobRepository)(nil).Fail), ctx, id, lastError)
+}
+
+// List mocks base method.
+func (m *MockJobRepository) List(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, status, limit)
+	ret0, _ := ret[0].([]*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockJobRepositoryMockRecorder) List(ctx, status, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockJobRepository)(nil).List), ctx, status, limit)
+}
+
+// RequeueStale mocks base method.
+func (m *MockJobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueStale", ctx, lockedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueStale indicates an expected call of RequeueStale.
+func (mr *MockJobRepositoryMockRecorder) RequeueStale(ctx, lockedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueStale", reflect.TypeOf((*MockJobRepository)(nil).RequeueStale), ctx, lockedBefore)
+}
+
+// Retry mocks base method.
+func (m *MockJobRepository) Retry(ctx context.Context, id, lastError string, runAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", ctx, id, lastError, runAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockJobRepositoryMockRecorder) Retry(ctx, id, lastError, runAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockJobRepository)(nil).Retry), ctx, id, lastError, runAt)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, headers map[string]string, body []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, userID, key, statusCode, headers, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyRepositoryMockRecorder) Complete(ctx, userID, key, statusCode, headers, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyRepository)(nil).Complete), ctx, userID, key, statusCode, headers, body)
+}
+
+// Delete mocks base method.
+func (m *MockIdempotencyRepository) Delete(ctx context.Context, userID, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIdempotencyRepositoryMockRecorder) Delete(ctx, userID, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIdempotencyRepository)(nil).Delete), ctx, userID, key)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteExpired(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteExpired), ctx, before)
+}
+
+// Get mocks base method.
+func (m *MockIdempotencyRepository) Get(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID, key)
+	ret0, _ := ret[0].(*models.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIdempotencyRepositoryMockRecorder) Get(ctx, userID, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdempotencyRepository)(nil).Get), ctx, userID, key)
+}
+
+// Reserve mocks base method.
+func (m *MockIdempotencyRepository) Reserve(ctx context.Context, userID, key, requestHash string, expiredBefore time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, userID, key, requestHash, expiredBefore)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIdempotencyRepositoryMockRecorder) Reserve(ctx, userID, key, requestHash, expiredBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIdempotencyRepository)(nil).Reserve), ctx, userID, key, requestHash, expiredBefore)
+}
+
+// MockStatsRepository is a mock of StatsRepository interface.
+type MockStatsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStatsRepositoryMockRecorder is the mock recorder for MockStatsRepository.
+type MockStatsRepositoryMockRecorder struct {
+	mock *MockStatsRepository
+}
+
+// NewMockStatsRepository creates a new mock instance.
+func NewMockStatsRepository(ctrl *gomock.Controller) *MockStatsRepository {
+	mock := &MockStatsRepository{ctrl: ctrl}
+	mock.recorder = &MockStatsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsRepository) EXPECT() *MockStatsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteActivityBefore mocks base method.
+func (m *MockStatsRepository) DeleteActivityBefore(ctx context.Context, day time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteActivityBefore", ctx, day)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteActivityBefore indicates an expected call of DeleteActivityBefore.
+func (mr *MockStatsRepositoryMockRecorder) DeleteActivityBefore(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActivityBefore", reflect.TypeOf((*MockStatsRepository)(nil).DeleteActivityBefore), ctx, day)
+}
+
+// List mocks base method.
+func (m *MockStatsRepository) List(ctx context.Context, days int) ([]*models.DailyStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, days)
+	ret0, _ := ret[0].([]*models.DailyStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockStatsRepositoryMockRecorder) List(ctx, days any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStatsRepository)(nil).List), ctx, days)
+}
+
+// RecordActivity mocks base method.
+func (m *MockStatsRepository) RecordActivity(ctx context.Context, userID string, day time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordActivity", ctx, userID, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordActivity indicates an expected call of RecordActivity.
+func (mr *MockStatsRepositoryMockRecorder) RecordActivity(ctx, userID, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordActivity", reflect.TypeOf((*MockStatsRepository)(nil).RecordActivity), ctx, userID, day)
+}
+
+// RecordConnectionPeak mocks base method.
+func (m *MockStatsRepository) RecordConnectionPeak(ctx context.Context, day time.Time, instanceID string, peak int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordConnectionPeak", ctx, day, instanceID, peak)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordConnectionPeak indicates an expected call of RecordConnectionPeak.
+func (mr *MockStatsRepositoryMockRecorder) RecordConnectionPeak(ctx, day, instanceID, peak any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordConnectionPeak", reflect.TypeOf((*MockStatsRepository)(nil).RecordConnectionPeak), ctx, day, instanceID, peak)
+}
+
+// Rollup mocks base method.
+func (m *MockStatsRepository) Rollup(ctx context.Context, day time.Time, storageObjects, storageBytes int64) (*models.DailyStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollup", ctx, day, storageObjects, storageBytes)
+	ret0, _ := ret[0].(*models.DailyStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rollup indicates an expected call of Rollup.
+func (mr *MockStatsRepositoryMockRecorder) Rollup(ctx, day, storageObjects, storageBytes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollup", reflect.TypeOf((*MockStatsRepository)(nil).Rollup), ctx, day, storageObjects, storageBytes)
+}
+
+// MockEventRepository is a mock of EventRepository interface.
+type MockEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockEventRepositoryMockRecorder is the mock recorder for MockEventRepository.
+type MockEventRepositoryMockRecorder struct {
+	mock *MockEventRepository
+}
+
+// NewMockEventRepository creates a new mock instance.
+func NewMockEventRepository(ctrl *gomock.Controller) *MockEventRepository {
+	mock := &MockEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateBatch mocks base method.
+func (m *MockEventRepository) CreateBatch(ctx context.Context, events []*models.ClientEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockEventRepositoryMockRecorder) CreateBatch(ctx, events any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockEventRepository)(nil).CreateBatch), ctx, events)
+}
+
+// DeleteReceivedBefore mocks base method.
+func (m *MockEventRepository) DeleteReceivedBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteReceivedBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteReceivedBefore indicates an expected call of DeleteReceivedBefore.
+func (mr *MockEventRepositoryMockRecorder) DeleteReceivedBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteReceivedBefore", reflect.TypeOf((*MockEventRepository)(nil).DeleteReceivedBefore), ctx, before)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookRepositoryMockRecorder) Create(ctx, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookRepository)(nil).Create), ctx, webhook)
+}
+
+// CreateDelivery mocks base method.
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDelivery indicates an expected call of CreateDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) CreateDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).CreateDelivery), ctx, delivery)
+}
+
+// Delete mocks base method.
+func (m *MockWebhookRepository) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebhookRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhookRepository)(nil).Delete), ctx, id)
+}
+
+// DeleteDeliveries mocks base method.
+func (m *MockWebhookRepository) DeleteDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeliveries", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeliveries indicates an expected call of DeleteDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteDeliveries(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteDeliveries), ctx, before)
+}
+
+// GetByID mocks base method.
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id string) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockWebhookRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWebhookRepository)(nil).GetByID), ctx, id)
+}
+
+// GetDelivery mocks base method.
+func (m *MockWebhookRepository) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelivery", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelivery indicates an expected call of GetDelivery.
+func (mr *MockWebhookRepositoryMockRecorder) GetDelivery(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelivery", reflect.TypeOf((*MockWebhookRepository)(nil).GetDelivery), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockWebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockWebhookRepositoryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockWebhookRepository)(nil).List), ctx)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, webhookID, status string, limit int) ([]*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, webhookID, status, limit)
+	ret0, _ := ret[0].([]*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ListDeliveries(ctx, webhookID, status, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ListDeliveries), ctx, webhookID, status, limit)
+}
+
+// ListSubscribed mocks base method.
+func (m *MockWebhookRepository) ListSubscribed(ctx context.Context, eventType, appID string) ([]*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscribed", ctx, eventType, appID)
+	ret0, _ := ret[0].([]*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscribed indicates an expected call of ListSubscribed.
+func (mr *MockWebhookRepositoryMockRecorder) ListSubscribed(ctx, eventType, appID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscribed", reflect.TypeOf((*MockWebhookRepository)(nil).ListSubscribed), ctx, eventType, appID)
+}
+
+// RecordAttempt mocks base method.
+func (m *MockWebhookRepository) RecordAttempt(ctx context.Context, id, status string, responseStatus *int, lastError *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAttempt", ctx, id, status, responseStatus, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAttempt indicates an expected call of RecordAttempt.
+func (mr *MockWebhookRepositoryMockRecorder) RecordAttempt(ctx, id, status, responseStatus, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAttempt", reflect.TypeOf((*MockWebhookRepository)(nil).RecordAttempt), ctx, id, status, responseStatus, lastError)
+}
+
+// Update mocks base method.
+func (m *MockWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookRepositoryMockRecorder) Update(ctx, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookRepository)(nil).Update), ctx, webhook)
+}
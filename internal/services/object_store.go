@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/tracing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ObjectStore is where photo files live. PhotoService wraps every call in
+// its retries and circuit breaker, so implementations shouldn't retry.
+type ObjectStore interface {
+	// PresignPut returns a URL the client uploads the object to with PUT.
+	// The size is the only length the upload may have, where the store can
+	// enforce it.
+	PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error)
+	// PresignGet returns a URL the client downloads the object from with GET
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Put uploads the object from the server side
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get downloads the object to the server
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetPrefix downloads at most the first n bytes of the object
+	GetPrefix(ctx context.Context, key string, n int64) ([]byte, error)
+	// Stat returns the size and content type of the object without
+	// downloading it; fails with fs.ErrNotExist if there is no object.
+	// contentType is empty if the store can't tell.
+	Stat(ctx context.Context, key string) (size int64, contentType string, err error)
+	// Delete removes the object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// Usage counts the stored objects and their total size
+	Usage(ctx context.Context) (objects, bytes int64, err error)
+	// Probe checks that the store is reachable
+	Probe(ctx context.Context) error
+	// URL returns the public URL of an object
+	URL(key string) string
+}
+
+var (
+	_ ObjectStore = (*S3ObjectStore)(nil)
+	_ ObjectStore = (*FileObjectStore)(nil)
+)
+
+// S3Endpoint is where photo URLs of an S3ObjectStore point to. It must
+// match the endpoint and addressing style of the client, which presigns
+// upload URLs the same way.
+type S3Endpoint struct {
+	// URL is the scheme and host, e.g. https://s3.ru1.storage.beget.cloud
+	URL string
+	// PathStyle addresses objects as URL/bucket/key instead of bucket.host/key
+	PathStyle bool
+}
+
+// S3ObjectStore keeps photos in a bucket reached through the S3 API: on AWS,
+// an S3-compatible endpoint or Google Cloud Storage. The client should not
+// retry on its own, see ObjectStore.
+type S3ObjectStore struct {
+	client   *s3.Client
+	bucket   string
+	endpoint S3Endpoint
+	// publicURL replaces the bucket's URL in photo URLs, e.g. a CDN
+	publicURL string
+}
+
+// NewS3ObjectStore creates an object store for the bucket
+func NewS3ObjectStore(client *s3.Client, bucket string, endpoint S3Endpoint) *S3ObjectStore {
+	endpoint.URL = strings.TrimSuffix(endpoint.URL, "/")
+	return &S3ObjectStore{client: client, bucket: bucket, endpoint: endpoint}
+}
+
+// WithPublicURL serves photos from base, e.g. a CDN in front of the bucket,
+// instead of the S3 endpoint. An empty base keeps the endpoint.
+func (s *S3ObjectStore) WithPublicURL(base string) *S3ObjectStore {
+	s.publicURL = strings.TrimSuffix(base, "/")
+	return s
+}
+
+// PresignPut signs an upload URL valid for expires. The size is signed as
+// Content-Length, so S3 rejects uploads of any other length: presigned PUT
+// has no content-length-range like POST policies.
+func (s *S3ObjectStore) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error) {
+	ctx, span := tracing.Start(ctx, "s3.PresignPutObject",
+		attribute.String("s3.bucket", s.bucket),
+		attribute.String("s3.key", key),
+	)
+	request, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expires
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+// PresignGet signs a download URL valid for expires. Photos served from a
+// public URL, e.g. a CloudFront domain, use that URL unsigned.
+func (s *S3ObjectStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if s.publicURL != "" {
+		return s.URL(key), nil
+	}
+	request, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expires
+	})
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+func (s *S3ObjectStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (s *S3ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *S3ObjectStore) GetPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "s3.GetObject",
+		attribute.String("s3.bucket", s.bucket),
+		attribute.String("s3.key", key),
+	)
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	// Хранилище без поддержки Range отдаст объект целиком
+	return io.ReadAll(io.LimitReader(out.Body, n))
+}
+
+func (s *S3ObjectStore) Stat(ctx context.Context, key string) (size int64, contentType string, err error) {
+	ctx, span := tracing.Start(ctx, "s3.HeadObject",
+		attribute.String("s3.bucket", s.bucket),
+		attribute.String("s3.key", key),
+	)
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	tracing.End(span, err)
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, "", fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+		}
+		return 0, "", err
+	}
+	return aws.ToInt64(out.ContentLength), aws.ToString(out.ContentType), nil
+}
+
+func (s *S3ObjectStore) Delete(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "s3.DeleteObject",
+		attribute.String("s3.bucket", s.bucket),
+		attribute.String("s3.key", key),
+	)
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	tracing.End(span, err)
+	return err
+}
+
+func (s *S3ObjectStore) Usage(ctx context.Context) (objects, bytes int64, err error) {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list bucket objects: %w", err)
+		}
+		for _, object := range page.Contents {
+			objects++
+			bytes += aws.ToInt64(object.Size)
+		}
+	}
+	return objects, bytes, nil
+}
+
+func (s *S3ObjectStore) Probe(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "s3.HeadBucket", attribute.String("s3.bucket", s.bucket))
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s.bucket),
+	})
+	tracing.End(span, err)
+	return err
+}
+
+// URL returns the object's URL: endpoint/bucket/key with path-style
+// addressing (Beget, MinIO), bucket.endpoint/key otherwise
+func (s *S3ObjectStore) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + key
+	}
+	if s.endpoint.PathStyle {
+		return fmt.Sprintf("%s/%s/%s", s.endpoint.URL, s.bucket, key)
+	}
+	scheme, host, _ := strings.Cut(s.endpoint.URL, "://")
+	return fmt.Sprintf("%s://%s.%s/%s", scheme, s.bucket, host, key)
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Outbox event types
+const (
+	OutboxPairCreated = "pair_created"
+	OutboxPairDeleted = "pair_deleted"
+	// OutboxPhotoUploaded tells the partner a photo became available
+	OutboxPhotoUploaded = "photo_uploaded"
+	// OutboxDailyPrompt sends both partners their daily prompt
+	OutboxDailyPrompt = "daily_prompt"
+)
+
+// outboxRetention is how long processed events are kept for debugging
+const outboxRetention = 7 * 24 * time.Hour
+
+// PairEvent is the payload of pair lifecycle outbox events
+type PairEvent struct {
+	PairID      string    `json:"pair_id"`
+	UserAID     string    `json:"user_a_id"`
+	UserBID     string    `json:"user_b_id"`
+	InitiatorID string    `json:"initiator_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Restored    bool      `json:"restored,omitempty"`
+}
+
+// partnerID returns the pair member who didn't initiate the change
+func (e PairEvent) partnerID() string {
+	if e.UserAID == e.InitiatorID {
+		return e.UserBID
+	}
+	return e.UserAID
+}
+
+// PhotoUploadedEvent is the payload of photo_uploaded outbox events
+type PhotoUploadedEvent struct {
+	PhotoID    string    `json:"photo_id"`
+	PairID     string    `json:"pair_id"`
+	UserID     string    `json:"user_id"`
+	PartnerID  string    `json:"partner_id"`
+	SessionID  string    `json:"session_id,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// newOutboxEvent builds an outbox event with a JSON payload
+func newOutboxEvent(eventType string, payload interface{}) (*models.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+	return &models.OutboxEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		Payload:   data,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// OutboxRelay delivers outbox events over WebSocket, falling back to push.
+// Delivery is at least once: an event is retried until it succeeds or runs
+// out of attempts, so clients may occasionally see a duplicate.
+type OutboxRelay struct {
+	repo        OutboxRepository
+	userRepo    UserRepository
+	hub         *WSHub
+	pushService *PushService
+	cfg         config.OutboxConfig
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewOutboxRelay creates an outbox relay; call Start to begin delivery
+func NewOutboxRelay(
+	repo OutboxRepository,
+	userRepo UserRepository,
+	hub *WSHub,
+	pushService *PushService,
+	cfg config.OutboxConfig,
+) *OutboxRelay {
+	return &OutboxRelay{
+		repo:        repo,
+		userRepo:    userRepo,
+		hub:         hub,
+		pushService: pushService,
+		cfg:         cfg,
+	}
+}
+
+// Start launches the relay loop
+func (r *OutboxRelay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+
+	r.wg.Add(1)
+	go r.run(ctx)
+}
+
+// Shutdown stops the relay after the current batch
+func (r *OutboxRelay) Shutdown(ctx context.Context) error {
+	if r.stop == nil {
+		return nil
+	}
+	r.stop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *OutboxRelay) run(ctx context.Context) {
+	defer r.wg.Done()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		processed, err := r.repo.Process(context.WithoutCancel(ctx), r.cfg.BatchSize, r.handle)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to process outbox events")
+		}
+
+		// Полная пачка - возможно, есть еще события, не ждать
+		if processed == r.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			deleted, err := r.repo.DeleteProcessed(ctx, time.Now().Add(-outboxRetention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete processed outbox events")
+			} else if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Processed outbox events deleted")
+			}
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// handle delivers one event and decides whether to retry it
+func (r *OutboxRelay) handle(event *models.OutboxEvent) models.OutboxResult {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	err := r.deliver(ctx, event)
+	if err == nil {
+		return models.OutboxResult{}
+	}
+
+	logger := log.With().
+		Err(err).
+		Str("event_id", event.ID).
+		Str("event_type", event.Type).
+		Int("attempt", event.Attempts+1).
+		Logger()
+
+	if event.Attempts+1 >= r.cfg.MaxAttempts {
+		logger.Error().Msg("Outbox event delivery failed, giving up")
+		errreport.Capture(ctx, err, "event_type", event.Type, "event_id", event.ID)
+		return models.OutboxResult{Err: err}
+	}
+
+	delay := jobBackoff(event.Attempts)
+	logger.Warn().Dur("retry_in", delay).Msg("Outbox event delivery failed, will retry")
+	return models.OutboxResult{Err: err, RetryAt: time.Now().Add(delay)}
+}
+
+func (r *OutboxRelay) deliver(ctx context.Context, event *models.OutboxEvent) error {
+	switch event.Type {
+	case OutboxPairCreated:
+		var e PairEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		notify := func(userID string) error {
+			return r.hub.NotifyPairCreated(userID, e.PairID, e.UserAID, e.UserBID, e.CreatedAt)
+		}
+		return r.deliverPairEvent(ctx, e, notify, r.pushService.SendPairCreatedNotification)
+
+	case OutboxPairDeleted:
+		var e PairEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return r.deliverPairEvent(ctx, e, r.hub.NotifyPairDeleted, r.pushService.SendPairDeletedNotification)
+
+	case OutboxPhotoUploaded:
+		var e PhotoUploadedEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		// Офлайн партнер увидит фото в ленте, пуш не нужен
+		if !r.hub.IsOnline(e.PartnerID) {
+			return nil
+		}
+		return r.hub.NotifyPartnerPhotoUploaded(e)
+
+	case OutboxDailyPrompt:
+		var e DailyPromptEvent
+		if err := json.Unmarshal(event.Payload, &e); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		// Повтор после ошибки доставит задание еще раз и первому партнеру
+		for _, userID := range []string{e.UserAID, e.UserBID} {
+			if err := r.hub.NotifyPhotoPrompt(userID, &e.Prompt); err == nil {
+				continue
+			}
+			err := r.push(ctx, userID, func(user *models.User) error {
+				return r.pushService.SendPhotoPromptNotification(user, &e.Prompt)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown outbox event type %q", event.Type)
+	}
+}
+
+// deliverPairEvent notifies the initiator's other sessions over WebSocket and
+// the partner over WebSocket, or by push if they are not connected
+func (r *OutboxRelay) deliverPairEvent(ctx context.Context, e PairEvent, notify func(userID string) error, push func(*models.User) error) error {
+	if r.hub.IsOnline(e.InitiatorID) {
+		if err := notify(e.InitiatorID); err != nil {
+			log.Error().Err(err).Str("user_id", e.InitiatorID).Msg("Failed to notify initiator about pair change")
+		}
+	}
+
+	partnerID := e.partnerID()
+	if err := notify(partnerID); err == nil {
+		return nil
+	}
+
+	log.Debug().Str("partner_id", partnerID).Msg("Partner not reachable via WebSocket, falling back to push")
+	return r.push(ctx, partnerID, push)
+}
+
+// push delivers an event the user didn't get over WebSocket by push. Users
+// without a push token or in quiet hours don't get it, which isn't retried.
+func (r *OutboxRelay) push(ctx context.Context, userID string, push func(*models.User) error) error {
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user for push notification: %w", err)
+	}
+
+	if err := push(user); err != nil {
+		if errors.Is(err, ErrNoPushToken) {
+			log.Warn().Str("user_id", userID).Msg("User has no push token, event not delivered")
+			return nil
+		}
+		if errors.Is(err, ErrPushSuppressed) || errors.Is(err, ErrPushMuted) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
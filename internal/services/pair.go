@@ -2,52 +2,75 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
 	"sync-photo-backend/internal/models"
-	"sync-photo-backend/internal/repository"
+	"sync-photo-backend/internal/tracing"
 
 	"github.com/google/uuid"
 )
 
 // PairService handles pair-related business logic
 type PairService struct {
-	pairRepo *repository.PairRepository
-	userRepo *repository.UserRepository
+	pairRepo PairRepository
+	userRepo UserRepository
+	eventLog *EventLog
 }
 
 // NewPairService creates a new pair service
-func NewPairService(pairRepo *repository.PairRepository, userRepo *repository.UserRepository) *PairService {
+func NewPairService(pairRepo PairRepository, userRepo UserRepository) *PairService {
 	return &PairService{
 		pairRepo: pairRepo,
 		userRepo: userRepo,
 	}
 }
 
+// WithEventLog records trigger_started events; pair_created and
+// pair_deleted are written by the pair repository
+func (s *PairService) WithEventLog(eventLog *EventLog) *PairService {
+	s.eventLog = eventLog
+	return s
+}
+
 // CreatePairRequest represents a request to create a pair
 type CreatePairRequest struct {
-	PartnerCode string `json:"partner_code"`
+	PartnerCode string `json:"partner_code" validate:"required,len=6"`
 }
 
-// CreatePair creates a new pair between two users
+// CreatePair creates a new pair between two users. If they were paired
+// before, the deleted pair is restored instead, with its ID and photos.
 func (s *PairService) CreatePair(ctx context.Context, userAID, partnerCode string) (*models.Pair, error) {
+	ctx, span := tracing.Start(ctx, "PairService.CreatePair")
+	defer span.End()
+
 	// Validate partner code
 	if len(partnerCode) != 6 {
-		return nil, fmt.Errorf("partner code must be 6 characters")
+		return nil, domain.ErrInvalidPartnerCode
 	}
 
-	// Get partner user by code
-	partnerUser, err := s.userRepo.GetByCode(ctx, partnerCode)
+	user, err := s.userRepo.GetByID(ctx, userAID)
 	if err != nil {
-		return nil, fmt.Errorf("partner not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Get partner user by code; users of other apps are never found
+	partnerUser, err := s.userRepo.GetByCode(ctx, user.AppID, partnerCode)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: %w", domain.ErrPartnerNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partner: %w", err)
 	}
 
 	userBID := partnerUser.ID
 
 	// Check if user is trying to pair with themselves
 	if userAID == userBID {
-		return nil, fmt.Errorf("cannot create pair with yourself")
+		return nil, domain.ErrSelfPair
 	}
 
 	// Check if user A is already in a pair
@@ -56,7 +79,7 @@ func (s *PairService) CreatePair(ctx context.Context, userAID, partnerCode strin
 		return nil, fmt.Errorf("failed to check if user has pair: %w", err)
 	}
 	if hasPair {
-		return nil, fmt.Errorf("user is already in a pair")
+		return nil, domain.ErrAlreadyPaired
 	}
 
 	// Check if partner is already in a pair
@@ -65,55 +88,158 @@ func (s *PairService) CreatePair(ctx context.Context, userAID, partnerCode strin
 		return nil, fmt.Errorf("failed to check if partner has pair: %w", err)
 	}
 	if partnerHasPair {
-		return nil, fmt.Errorf("partner is already in a pair")
+		return nil, domain.ErrPartnerAlreadyPaired
 	}
 
+	initiatorID := userAID
+
 	// Create pair (user_a_id should be lexicographically smaller to ensure consistency)
 	if userAID > userBID {
 		userAID, userBID = userBID, userAID
 	}
 
-	pair := &models.Pair{
-		ID:        uuid.New().String(),
-		UserAID:   userAID,
-		UserBID:   userBID,
-		CreatedAt: time.Now(),
+	// Пара с тем же партнером восстанавливается вместе с архивом фото
+	pair, err := s.pairRepo.GetByUsers(ctx, userAID, userBID)
+	restored := err == nil
+	if err != nil && !errors.Is(err, domain.ErrPairNotFound) {
+		return nil, fmt.Errorf("failed to get previous pair: %w", err)
+	}
+	if !restored {
+		pair = &models.Pair{
+			ID:        uuid.New().String(),
+			AppID:     user.AppID,
+			UserAID:   userAID,
+			UserBID:   userBID,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	domainEvent, err := newDomainEvent(EventPairCreated, pair.AppID, initiatorID, pair.ID, PairPayload{
+		PairID:      pair.ID,
+		UserAID:     pair.UserAID,
+		UserBID:     pair.UserBID,
+		InitiatorID: initiatorID,
+		Restored:    restored,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.pairRepo.Create(ctx, pair); err != nil {
+	// Уведомления партнерам доставит outbox relay после коммита
+	event, err := newOutboxEvent(OutboxPairCreated, PairEvent{
+		PairID:      pair.ID,
+		UserAID:     pair.UserAID,
+		UserBID:     pair.UserBID,
+		InitiatorID: initiatorID,
+		CreatedAt:   pair.CreatedAt,
+		Restored:    restored,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if restored {
+		if err := s.pairRepo.Restore(ctx, pair, domainEvent, event); err != nil {
+			return nil, fmt.Errorf("failed to restore pair: %w", err)
+		}
+		return pair, nil
+	}
+	if err := s.pairRepo.Create(ctx, pair, domainEvent, event); err != nil {
 		return nil, fmt.Errorf("failed to create pair: %w", err)
 	}
 
 	return pair, nil
 }
 
-// DeletePair deletes a pair if the user is a member
+// DeletePair deletes a pair if the user is a member. The pair stays in
+// both users' history and its photos are kept.
 func (s *PairService) DeletePair(ctx context.Context, pairID, userID string) error {
+	ctx, span := tracing.Start(ctx, "PairService.DeletePair")
+	defer span.End()
+
 	// Get pair
 	pair, err := s.pairRepo.GetByID(ctx, pairID)
 	if err != nil {
-		return fmt.Errorf("pair not found: %w", err)
+		return fmt.Errorf("failed to get pair: %w", err)
 	}
 
 	// Check if user is a member of the pair
 	if pair.UserAID != userID && pair.UserBID != userID {
-		return fmt.Errorf("user is not a member of this pair")
+		return domain.ErrNotPairMember
+	}
+
+	domainEvent, err := newDomainEvent(EventPairDeleted, pair.AppID, userID, pair.ID, PairPayload{
+		PairID:      pair.ID,
+		UserAID:     pair.UserAID,
+		UserBID:     pair.UserBID,
+		InitiatorID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	event, err := newOutboxEvent(OutboxPairDeleted, PairEvent{
+		PairID:      pair.ID,
+		UserAID:     pair.UserAID,
+		UserBID:     pair.UserBID,
+		InitiatorID: userID,
+		CreatedAt:   pair.CreatedAt,
+	})
+	if err != nil {
+		return err
 	}
 
 	// Delete pair
-	if err := s.pairRepo.Delete(ctx, pairID); err != nil {
+	if err := s.pairRepo.Delete(ctx, pairID, domainEvent, event); err != nil {
 		return fmt.Errorf("failed to delete pair: %w", err)
 	}
 
 	return nil
 }
 
-// GetPairByUserID gets the pair for a user
+// RecordTrigger records a trigger_started event after take_photo was sent
+// to both partners. timestamp is the client's, 0 if it didn't send one.
+func (s *PairService) RecordTrigger(ctx context.Context, pair *models.Pair, initiatorID, partnerID, sessionID string, timestamp int64) error {
+	return s.eventLog.Append(ctx, EventTriggerStarted, pair.AppID, initiatorID, pair.ID, TriggerStartedPayload{
+		PairID:      pair.ID,
+		InitiatorID: initiatorID,
+		PartnerID:   partnerID,
+		SessionID:   sessionID,
+		Timestamp:   timestamp,
+	})
+}
+
+// GetPairByUserID gets the pair for a user, domain.ErrNotInPair if there is none
 func (s *PairService) GetPairByUserID(ctx context.Context, userID string) (*models.Pair, error) {
-	return s.pairRepo.GetByUserID(ctx, userID)
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err == nil {
+		errreport.SetPair(ctx, pair.ID)
+	}
+	return pair, err
+}
+
+// GetPairHistory returns the user's current and deleted pairs, newest first
+func (s *PairService) GetPairHistory(ctx context.Context, userID string) ([]*models.Pair, error) {
+	pairs, err := s.pairRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair history: %w", err)
+	}
+	return pairs, nil
 }
 
 // GetPairByID gets a pair by ID
 func (s *PairService) GetPairByID(ctx context.Context, pairID string) (*models.Pair, error) {
 	return s.pairRepo.GetByID(ctx, pairID)
 }
+
+// pairOfUser returns the user's pair, domain.ErrNotInPair if there is none
+func pairOfUser(ctx context.Context, pairRepo PairRepository, userID string) (*models.Pair, error) {
+	pair, err := pairRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrPairNotFound) {
+		return nil, fmt.Errorf("%w: %w", domain.ErrNotInPair, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair: %w", err)
+	}
+	return pair, nil
+}
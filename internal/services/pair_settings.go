@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/tracing"
+)
+
+// ErrInvalidPairSettings is returned when a pair settings update has a value
+// out of range
+var ErrInvalidPairSettings = errors.New("invalid pair settings")
+
+// Bounds of the countdown a pair may choose; capture.countdown has the same
+// maximum
+const (
+	minPairCountdown = time.Second
+	maxPairCountdown = 30 * time.Second
+)
+
+// PairSettingsRequest is the body of PATCH /api/v1/pairs/me/settings.
+// Omitted fields keep their value. Empty mute_start and mute_end turn
+// muting off, zero countdown_ms and empty timezone restore the defaults.
+type PairSettingsRequest struct {
+	MuteStart     *string `json:"mute_start"`
+	MuteEnd       *string `json:"mute_end"`
+	CountdownMS   *int64  `json:"countdown_ms"`
+	Timezone      *string `json:"timezone"`
+	AutoComposite *bool   `json:"auto_composite"`
+}
+
+// validate checks the values the request sets
+func (r PairSettingsRequest) validate() error {
+	if (r.MuteStart == nil) != (r.MuteEnd == nil) {
+		return fmt.Errorf("%w: mute_start and mute_end must be set together", ErrInvalidPairSettings)
+	}
+	if r.MuteStart != nil && (*r.MuteStart == "") != (*r.MuteEnd == "") {
+		return fmt.Errorf("%w: mute_start and mute_end must be empty together", ErrInvalidPairSettings)
+	}
+	if r.MuteStart != nil && *r.MuteStart != "" {
+		if _, err := time.Parse(clockLayout, *r.MuteStart); err != nil {
+			return fmt.Errorf("%w: mute_start must be in HH:MM format", ErrInvalidPairSettings)
+		}
+		if _, err := time.Parse(clockLayout, *r.MuteEnd); err != nil {
+			return fmt.Errorf("%w: mute_end must be in HH:MM format", ErrInvalidPairSettings)
+		}
+	}
+	if ms := r.CountdownMS; ms != nil && *ms != 0 {
+		countdown := time.Duration(*ms) * time.Millisecond
+		if countdown < minPairCountdown || countdown > maxPairCountdown {
+			return fmt.Errorf("%w: countdown_ms must be between %d and %d",
+				ErrInvalidPairSettings, minPairCountdown.Milliseconds(), maxPairCountdown.Milliseconds())
+		}
+	}
+	if r.Timezone != nil && *r.Timezone != "" {
+		if _, err := time.LoadLocation(*r.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone: %s", ErrInvalidPairSettings, *r.Timezone)
+		}
+	}
+	return nil
+}
+
+// apply sets the fields of the request on settings
+func (r PairSettingsRequest) apply(settings *models.PairSettings) {
+	if r.MuteStart != nil {
+		settings.MuteStart, settings.MuteEnd = nil, nil
+		if *r.MuteStart != "" {
+			settings.MuteStart, settings.MuteEnd = r.MuteStart, r.MuteEnd
+		}
+	}
+	if r.CountdownMS != nil {
+		settings.Countdown = nil
+		if *r.CountdownMS != 0 {
+			countdown := time.Duration(*r.CountdownMS) * time.Millisecond
+			settings.Countdown = &countdown
+		}
+	}
+	if r.Timezone != nil {
+		settings.Timezone = nil
+		if *r.Timezone != "" {
+			settings.Timezone = r.Timezone
+		}
+	}
+	if r.AutoComposite != nil {
+		settings.AutoComposite = *r.AutoComposite
+	}
+}
+
+// PairOptions are the settings of a pair, defaults resolved
+type PairOptions struct {
+	// MuteStart and MuteEnd are the local "HH:MM" in Timezone between
+	// which pushes to both partners are held; nil when muting is off
+	MuteStart *string `json:"mute_start"`
+	MuteEnd   *string `json:"mute_end"`
+	// CountdownMS is the countdown before a capture
+	CountdownMS int64 `json:"countdown_ms"`
+	// Timezone is the zone of the mute hours and the daily prompt
+	Timezone      string `json:"timezone"`
+	AutoComposite bool   `json:"auto_composite"`
+}
+
+// PairSettingsService manages the settings partners share: mute hours held
+// by PushService, the countdown of WSHub.CaptureAt, the timezone of daily
+// prompts and whether CompositorService stitches moments
+type PairSettingsService struct {
+	repo     PairSettingsRepository
+	pairRepo PairRepository
+	userRepo UserRepository
+	// countdown is capture.countdown, the default of the pairs
+	countdown time.Duration
+}
+
+// NewPairSettingsService creates a pair settings service; countdown is the
+// default countdown of pairs, as configured in capture.countdown
+func NewPairSettingsService(repo PairSettingsRepository, pairRepo PairRepository, userRepo UserRepository, countdown time.Duration) *PairSettingsService {
+	return &PairSettingsService{
+		repo:      repo,
+		pairRepo:  pairRepo,
+		userRepo:  userRepo,
+		countdown: countdown,
+	}
+}
+
+// Options returns the settings of the user's pair
+func (s *PairSettingsService) Options(ctx context.Context, userID string) (*PairOptions, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.Get(ctx, pair.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.options(ctx, pair, settings)
+}
+
+// Update changes the settings of the user's pair. A concurrent change by
+// the partner is not lost: the update is applied on top of it.
+func (s *PairSettingsService) Update(ctx context.Context, userID string, req PairSettingsRequest) (*PairOptions, error) {
+	ctx, span := tracing.Start(ctx, "PairSettingsService.Update")
+	defer span.End()
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.update(ctx, pair.ID, req.apply)
+	if err != nil {
+		return nil, err
+	}
+	return s.options(ctx, pair, settings)
+}
+
+// Settings returns the pair's settings as stored, nil fields meaning the
+// defaults
+func (s *PairSettingsService) Settings(ctx context.Context, pairID string) (*models.PairSettings, error) {
+	return s.repo.Get(ctx, pairID)
+}
+
+// Muted reports whether the mute hours of the user's pair hold pushes at t.
+// A user without a pair is never muted.
+func (s *PairSettingsService) Muted(ctx context.Context, userID string, t time.Time) (bool, error) {
+	pair, err := s.pairRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrPairNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get pair: %w", err)
+	}
+	settings, err := s.repo.Get(ctx, pair.ID)
+	if err != nil {
+		return false, err
+	}
+	if settings.MuteStart == nil || settings.MuteEnd == nil {
+		return false, nil
+	}
+	timezone, err := s.timezone(ctx, pair, settings)
+	if err != nil {
+		return false, err
+	}
+	return inWindow(*settings.MuteStart, *settings.MuteEnd, timezone, t), nil
+}
+
+// setTimezone changes the pair's timezone
+func (s *PairSettingsService) setTimezone(ctx context.Context, pairID, timezone string) (*models.PairSettings, error) {
+	return s.update(ctx, pairID, PairSettingsRequest{Timezone: &timezone}.apply)
+}
+
+// update applies change to the pair's settings and saves them, retrying on
+// the new settings if they changed since they were read
+func (s *PairSettingsService) update(ctx context.Context, pairID string, change func(*models.PairSettings)) (*models.PairSettings, error) {
+	for attempt := 1; ; attempt++ {
+		settings, err := s.repo.Get(ctx, pairID)
+		if err != nil {
+			return nil, err
+		}
+
+		readAt := settings.UpdatedAt
+		change(settings)
+		err = s.repo.Save(ctx, settings, readAt)
+		if errors.Is(err, domain.ErrPreconditionFailed) && attempt < settingsAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return settings, nil
+	}
+}
+
+// options resolves the defaults of the pair's settings
+func (s *PairSettingsService) options(ctx context.Context, pair *models.Pair, settings *models.PairSettings) (*PairOptions, error) {
+	timezone, err := s.timezone(ctx, pair, settings)
+	if err != nil {
+		return nil, err
+	}
+	countdown := s.countdown
+	if settings.Countdown != nil {
+		countdown = *settings.Countdown
+	}
+	return &PairOptions{
+		MuteStart:     settings.MuteStart,
+		MuteEnd:       settings.MuteEnd,
+		CountdownMS:   countdown.Milliseconds(),
+		Timezone:      timezone,
+		AutoComposite: settings.AutoComposite,
+	}, nil
+}
+
+// timezone returns the pair's timezone, that of user A unless the pair
+// chose one
+func (s *PairSettingsService) timezone(ctx context.Context, pair *models.Pair, settings *models.PairSettings) (string, error) {
+	if settings.Timezone != nil {
+		return *settings.Timezone, nil
+	}
+	userA, err := s.userRepo.GetByID(ctx, pair.UserAID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return userA.Timezone, nil
+}
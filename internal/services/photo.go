@@ -2,68 +2,126 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 	"time"
 
+	appconfig "sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/imagemeta"
+	"sync-photo-backend/internal/metrics"
 	"sync-photo-backend/internal/models"
-	"sync-photo-backend/internal/repository"
+	"sync-photo-backend/internal/tracing"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // PhotoService handles photo-related business logic
 type PhotoService struct {
-	photoRepo *repository.PhotoRepository
-	pairRepo  *repository.PairRepository
-	s3Client  *s3.Client
-	s3Bucket  string
-	endpoint  string
+	photoRepo PhotoRepository
+	pairRepo  PairRepository
+	store     ObjectStore
+	guard     *storageGuard
+	// prefixes maps app IDs to their S3 key prefix
+	prefixes map[string]string
+	eventLog *EventLog
+	// sessions is nil unless WithSyncSessions was called
+	sessions *SyncSessionService
+	// reactions is nil unless WithReactions was called
+	reactions *ReactionService
+	// prompts is nil unless WithPrompts was called
+	prompts *PromptService
+	// viewURLTTL is the lifetime of signed view URLs; zero serves public URLs
+	viewURLTTL time.Duration
+	// maxUploadSize bounds the size of uploaded photos in bytes
+	maxUploadSize int64
+	// exportConcurrency bounds the downloads of an export, see WithExportConcurrency
+	exportConcurrency int
 }
 
-// NewPhotoService creates a new photo service
+// NewPhotoService creates a new photo service keeping photo files in store.
+// Every storage call is bounded by storageTimeout, retried up to maxAttempts
+// times and guarded by a circuit breaker.
 func NewPhotoService(
-	photoRepo *repository.PhotoRepository,
-	pairRepo *repository.PairRepository,
-	awsRegion, s3Bucket, accessKey, secretKey, endpoint string,
-) (*PhotoService, error) {
-	// Создать статические credentials
-	creds := credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")
+	photoRepo PhotoRepository,
+	pairRepo PairRepository,
+	store ObjectStore,
+	storageTimeout time.Duration,
+	maxAttempts, breakerThreshold int,
+	breakerCooldown time.Duration,
+) *PhotoService {
+	return &PhotoService{
+		photoRepo:         photoRepo,
+		pairRepo:          pairRepo,
+		store:             store,
+		guard:             newStorageGuard(storageTimeout, maxAttempts, breakerThreshold, breakerCooldown),
+		maxUploadSize:     defaultMaxUploadSize,
+		exportConcurrency: defaultExportConcurrency,
+	}
+}
 
-	// Настроить AWS config со статическими credentials
-	cfg, err := config.LoadDefaultConfig(context.Background(),
-		config.WithRegion(awsRegion),
-		config.WithCredentialsProvider(creds),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+// WithApps stores photos of white-label apps under their S3 prefixes
+func (s *PhotoService) WithApps(apps map[string]appconfig.AppConfig) *PhotoService {
+	s.prefixes = make(map[string]string, len(apps))
+	for id, app := range apps {
+		s.prefixes[id] = app.S3Prefix
 	}
+	return s
+}
 
-	// Определить протокол для endpoint
-	endpointURL := "https://" + endpoint
+// WithEventLog records moment_completed events; photo_confirmed is written
+// by the photo repository
+func (s *PhotoService) WithEventLog(eventLog *EventLog) *PhotoService {
+	s.eventLog = eventLog
+	return s
+}
 
-	// Создать S3 client с кастомным endpoint
-	s3Client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(endpointURL)
-		o.UsePathStyle = true // Важно для Beget S3
-	})
+// WithMaxUploadSize bounds the size of uploaded photos, as configured in
+// uploads.max_size_mb
+func (s *PhotoService) WithMaxUploadSize(bytes int64) *PhotoService {
+	s.maxUploadSize = bytes
+	return s
+}
 
-	return &PhotoService{
-		photoRepo: photoRepo,
-		pairRepo:  pairRepo,
-		s3Client:  s3Client,
-		s3Bucket:  s3Bucket,
-		endpoint:  endpoint,
-	}, nil
+// WithSyncSessions lets uploads name the sync session of their trigger;
+// confirming them completes the session
+func (s *PhotoService) WithSyncSessions(sessions *SyncSessionService) *PhotoService {
+	s.sessions = sessions
+	return s
+}
+
+// WithReactions fills in the reaction and comment counts of listed photos
+func (s *PhotoService) WithReactions(reactions *ReactionService) *PhotoService {
+	s.reactions = reactions
+	return s
+}
+
+// WithPrompts counts confirmed photos towards the pair's daily prompt
+func (s *PhotoService) WithPrompts(prompts *PromptService) *PhotoService {
+	s.prompts = prompts
+	return s
+}
+
+// WithViewURLs returns photos with download URLs signed for ttl, so they
+// can be viewed from a private bucket. A non-positive ttl serves the
+// public URLs of the objects.
+func (s *PhotoService) WithViewURLs(ttl time.Duration) *PhotoService {
+	s.viewURLTTL = ttl
+	return s
 }
 
 // UploadRequest represents a request to get a pre-signed URL
 type UploadRequest struct {
-	Filename    string `json:"filename"`
-	ContentType string `json:"content_type"`
+	Filename    string `json:"filename" validate:"required,max=255"`
+	ContentType string `json:"content_type" validate:"omitempty,max=100"`
+	// SizeBytes is the size of the file; the upload URL accepts only this
+	// length
+	SizeBytes int64 `json:"size_bytes" validate:"required,min=1"`
+	// SessionID is the session_id of the take_photo the photo is taken for
+	SessionID string `json:"session_id" validate:"omitempty,uuid"`
 }
 
 // UploadResponse represents the response with pre-signed URL
@@ -73,79 +131,500 @@ type UploadResponse struct {
 	ExpiresIn int    `json:"expires_in"`
 }
 
-// GetPreSignedURL generates a pre-signed URL for uploading a photo
-func (s *PhotoService) GetPreSignedURL(ctx context.Context, userID, filename, contentType string) (*UploadResponse, error) {
+// uploadURLTTL is the lifetime of upload URLs; an upload not started by then
+// can only be abandoned
+const uploadURLTTL = 5 * time.Minute
+
+// defaultMaxUploadSize bounds uploads unless WithMaxUploadSize was called
+const defaultMaxUploadSize = 25 << 20
+
+// ErrUploadSizeRequired is returned when an upload URL is requested without
+// the size of the file, which the URL is signed for
+var ErrUploadSizeRequired = errors.New("size_bytes is required")
+
+// photoExtensions maps the content types photos may be uploaded as to the
+// extension of their object keys
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/heic": ".heic",
+	"image/webp": ".webp",
+}
+
+// photoExtension returns the key extension of a photo content type,
+// domain.ErrUnsupportedPhotoType for types photos can't be uploaded as
+func photoExtension(contentType string) (string, error) {
+	ext, ok := photoExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("%w: %q, expected image/jpeg, image/png, image/heic or image/webp", domain.ErrUnsupportedPhotoType, contentType)
+	}
+	return ext, nil
+}
+
+// GetPreSignedURL generates a pre-signed URL for uploading a photo of size
+// bytes. The URL accepts only uploads of that length.
+func (s *PhotoService) GetPreSignedURL(ctx context.Context, userID, filename, contentType string, size int64, sessionID string) (*UploadResponse, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.GetPreSignedURL")
+	defer span.End()
+
+	ext, err := photoExtension(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, ErrUploadSizeRequired
+	}
+	if size > s.maxUploadSize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d MB", domain.ErrPhotoTooLarge, size, s.maxUploadSize>>20)
+	}
+
 	// Get user's pair
-	pair, err := s.pairRepo.GetByUserID(ctx, userID)
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
 	if err != nil {
-		return nil, fmt.Errorf("user is not in a pair: %w", err)
+		return nil, err
+	}
+
+	var session *string
+	if sessionID != "" {
+		if s.sessions == nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrSyncSessionNotFound, sessionID)
+		}
+		if _, err := s.sessions.openSessionOfPair(ctx, pair, sessionID); err != nil {
+			return nil, err
+		}
+		session = &sessionID
 	}
 
 	// Generate photo ID
 	photoID := uuid.New().String()
 
-	// Generate S3 key: {pair_id}/{photo_id}.jpg
-	s3Key := fmt.Sprintf("%s/%s.jpg", pair.ID, photoID)
+	s3Key := s.objectKey(pair, photoID, ext)
 
 	// Create pre-signed URL request
-	presignClient := s3.NewPresignClient(s.s3Client)
-	request, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.s3Bucket),
-		Key:         aws.String(s3Key),
-		ContentType: aws.String(contentType),
-	}, func(opts *s3.PresignOptions) {
-		opts.Expires = 5 * time.Minute // 5 minutes
+	var uploadURL string
+	err = s.guard.do(ctx, "PresignPutObject", func(ctx context.Context) error {
+		var err error
+		uploadURL, err = s.store.PresignPut(ctx, s3Key, contentType, size, uploadURLTTL)
+		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate pre-signed URL: %w", err)
 	}
 
-	// Create photo record in DB with placeholder URL (will be updated after upload)
-	// Для Beget S3 URL формат: https://endpoint/bucket/key
-	s3URL := fmt.Sprintf("https://%s/%s/%s", s.endpoint, s.s3Bucket, s3Key)
+	// Запись создается до загрузки; CompleteUpload делает фото доступным
 	photo := &models.Photo{
-		ID:        photoID,
-		PairID:    pair.ID,
-		UserID:    userID,
-		S3URL:     s3URL,
-		TakenAt:   time.Now(),
-		CreatedAt: time.Now(),
+		ID:          photoID,
+		PairID:      pair.ID,
+		UserID:      userID,
+		S3Key:       s3Key,
+		SessionID:   session,
+		ContentType: contentType,
+		TakenAt:     time.Now().UTC(),
+		CreatedAt:   time.Now().UTC(),
 	}
 
 	if err := s.photoRepo.Create(ctx, photo); err != nil {
 		return nil, fmt.Errorf("failed to create photo record: %w", err)
 	}
+	metrics.PhotoUploads.WithLabelValues("started").Inc()
 
 	return &UploadResponse{
-		UploadURL: request.URL,
+		UploadURL: uploadURL,
 		PhotoID:   photoID,
-		ExpiresIn: 300, // 5 minutes in seconds
+		ExpiresIn: int(uploadURLTTL.Seconds()),
 	}, nil
 }
 
-// UpdatePhotoS3URL updates the S3 URL after upload
-func (s *PhotoService) UpdatePhotoS3URL(ctx context.Context, photoID, s3URL string) error {
-	return s.photoRepo.UpdateS3URL(ctx, photoID, s3URL)
+// StorePhoto uploads photo bytes from the server side and creates the photo record
+func (s *PhotoService) StorePhoto(ctx context.Context, pair *models.Pair, userID string, data []byte, contentType string, takenAt time.Time) (*models.Photo, error) {
+	ext, err := photoExtension(contentType)
+	if err != nil {
+		return nil, err
+	}
+	photoID := uuid.New().String()
+	s3Key := s.objectKey(pair, photoID, ext)
+
+	err = s.guard.do(ctx, "PutObject", func(ctx context.Context) error {
+		return s.store.Put(ctx, s3Key, data, contentType)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload photo: %w", err)
+	}
+
+	now := time.Now().UTC()
+	meta := imagemeta.Read(data)
+	photo := s.withURL(&models.Photo{
+		ID:            photoID,
+		PairID:        pair.ID,
+		UserID:        userID,
+		S3Key:         s3Key,
+		SizeBytes:     int64(len(data)),
+		ContentType:   contentType,
+		Width:         meta.Width,
+		Height:        meta.Height,
+		DeviceTakenAt: meta.TakenAt,
+		UploadedAt:    &now,
+		TakenAt:       takenAt.UTC(),
+		CreatedAt:     now,
+	})
+	event, err := photoConfirmedEvent(pair, photo)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.photoRepo.Create(ctx, photo, event); err != nil {
+		return nil, fmt.Errorf("failed to create photo record: %w", err)
+	}
+	s.recordMoment(ctx, pair, photo)
+
+	return photo, nil
+}
+
+// objectKey returns the S3 key for a photo: {app_prefix}{pair_id}/{photo_id}{ext},
+// ext being that of its content type. The default app has no prefix, so
+// its keys are unchanged.
+func (s *PhotoService) objectKey(pair *models.Pair, photoID, ext string) string {
+	return fmt.Sprintf("%s%s/%s%s", s.prefixes[pair.AppID], pair.ID, photoID, ext)
+}
+
+// compositeKey returns the S3 key for a composite:
+// {app_prefix}{pair_id}/composites/{composite_id}.jpg
+func (s *PhotoService) compositeKey(pair *models.Pair, compositeID string) string {
+	return fmt.Sprintf("%s%s/composites/%s.jpg", s.prefixes[pair.AppID], pair.ID, compositeID)
+}
+
+// readObject downloads an object with the retries and circuit breaker of
+// the photo store
+func (s *PhotoService) readObject(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.guard.do(ctx, "GetObject", func(ctx context.Context) error {
+		var err error
+		data, err = s.store.Get(ctx, key)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// writeObject uploads an object with the retries and circuit breaker of
+// the photo store
+func (s *PhotoService) writeObject(ctx context.Context, key string, data []byte, contentType string) error {
+	err := s.guard.do(ctx, "PutObject", func(ctx context.Context) error {
+		return s.store.Put(ctx, key, data, contentType)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// deleteObjects deletes objects with the retries and circuit breaker of the
+// photo store; missing objects are skipped
+func (s *PhotoService) deleteObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		err := s.guard.do(ctx, "DeleteObject", func(ctx context.Context) error {
+			return s.store.Delete(ctx, key)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// pairObjectKeys returns the object keys of all the pair's photos, pending
+// uploads included
+func (s *PhotoService) pairObjectKeys(ctx context.Context, pairID string) ([]string, error) {
+	return s.photoRepo.ListKeysByPair(ctx, pairID)
+}
+
+// CompleteUpload makes the user's photo available once its object is in
+// storage: the object must exist, be non-empty and be the image declared
+// for the upload. Its size and content type are recorded and the partner
+// is notified. Completing a photo again returns it unchanged.
+func (s *PhotoService) CompleteUpload(ctx context.Context, userID, photoID string) (*models.Photo, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.CompleteUpload")
+	defer span.End()
+
+	// Чужое фото отклоняем до обращения к хранилищу
+	photo, err := s.photoRepo.GetByID(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	if photo.UserID != userID {
+		return nil, domain.ErrNotPhotoOwner
+	}
+	if photo.UploadedAt != nil {
+		return s.withViewURL(ctx, photo)
+	}
+
+	upload, err := s.verifyUpload(ctx, photo)
+	if err != nil {
+		metrics.PhotoUploads.WithLabelValues("rejected").Inc()
+		return nil, err
+	}
+
+	var pair *models.Pair
+	photo, completed, err := s.photoRepo.Confirm(ctx, photoID, upload, func(photo *models.Photo) (*models.DomainEvent, []*models.OutboxEvent, error) {
+		var err error
+		if pair, err = s.pairRepo.GetByID(ctx, photo.PairID); err != nil {
+			return nil, nil, fmt.Errorf("failed to get pair: %w", err)
+		}
+		s.withURL(photo)
+		event, err := photoConfirmedEvent(pair, photo)
+		if err != nil {
+			return nil, nil, err
+		}
+		notify, err := photoUploadedOutboxEvent(pair, photo)
+		if err != nil {
+			return nil, nil, err
+		}
+		return event, []*models.OutboxEvent{notify}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		metrics.PhotoUploads.WithLabelValues("completed").Inc()
+		s.recordMoment(ctx, pair, photo)
+		if photo.SessionID != nil && s.sessions != nil {
+			s.sessions.photoConfirmed(ctx, pair, photo)
+		}
+		if s.prompts != nil {
+			s.prompts.photoConfirmed(ctx, pair, photo)
+		}
+	}
+	return s.withViewURL(ctx, photo)
+}
+
+// verifyUpload checks the photo's object in storage and returns what
+// storage reports about it
+func (s *PhotoService) verifyUpload(ctx context.Context, photo *models.Photo) (models.PhotoUpload, error) {
+	var size int64
+	var contentType string
+	err := s.guard.do(ctx, "HeadObject", func(ctx context.Context) error {
+		var err error
+		size, contentType, err = s.store.Stat(ctx, photo.S3Key)
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return models.PhotoUpload{}, domain.ErrPhotoNotUploaded
+	}
+	if err != nil {
+		return models.PhotoUpload{}, fmt.Errorf("failed to check uploaded photo: %w", err)
+	}
+
+	if size == 0 {
+		return models.PhotoUpload{}, fmt.Errorf("%w: file is empty", domain.ErrInvalidPhotoUpload)
+	}
+	// Dev-хранилище не проверяет длину, подписанную в URL
+	if size > s.maxUploadSize {
+		return models.PhotoUpload{}, fmt.Errorf("%w: file is larger than %d MB", domain.ErrInvalidPhotoUpload, s.maxUploadSize>>20)
+	}
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return models.PhotoUpload{}, fmt.Errorf("%w: %s is not an image", domain.ErrInvalidPhotoUpload, contentType)
+	}
+	if contentType != "" && photo.ContentType != "" && !strings.EqualFold(contentType, photo.ContentType) {
+		return models.PhotoUpload{}, fmt.Errorf("%w: uploaded as %s, declared as %s", domain.ErrInvalidPhotoUpload, contentType, photo.ContentType)
+	}
+	// Хранилище не знает тип (dev, HEIC) - остается заявленный
+	if contentType == "" {
+		contentType = photo.ContentType
+	}
+
+	meta := s.readMetadata(ctx, photo)
+	return models.PhotoUpload{
+		SizeBytes:     size,
+		ContentType:   contentType,
+		Width:         meta.Width,
+		Height:        meta.Height,
+		DeviceTakenAt: meta.TakenAt,
+		UploadedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// readMetadata reads the dimensions and EXIF capture time from the head of
+// the photo's object. They are optional, so a failed read only leaves them
+// empty instead of failing the upload.
+func (s *PhotoService) readMetadata(ctx context.Context, photo *models.Photo) imagemeta.Metadata {
+	var head []byte
+	err := s.guard.do(ctx, "GetObject", func(ctx context.Context) error {
+		var err error
+		head, err = s.store.GetPrefix(ctx, photo.S3Key, imagemeta.HeadSize)
+		return err
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("photo_id", photo.ID).Msg("Failed to read photo metadata")
+		return imagemeta.Metadata{}
+	}
+	return imagemeta.Read(head)
+}
+
+// withURL fills in the public URL of the photo's object, which doesn't
+// expire and goes into events. Only the key is stored, so clients can't
+// point a photo at an arbitrary URL.
+func (s *PhotoService) withURL(photo *models.Photo) *models.Photo {
+	photo.S3URL = s.store.URL(photo.S3Key)
+	return photo
+}
+
+// GetPresignedViewURL returns the URL clients download the object from:
+// signed for the view URL TTL, or the public URL without one. Signing
+// doesn't call S3, so it bypasses the circuit breaker.
+func (s *PhotoService) GetPresignedViewURL(ctx context.Context, key string) (string, error) {
+	if s.viewURLTTL <= 0 {
+		return s.store.URL(key), nil
+	}
+	viewURL, err := s.store.PresignGet(ctx, key, s.viewURLTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate view URL: %w", err)
+	}
+	return viewURL, nil
+}
+
+// withViewURL fills in the view URL of a photo returned to its client
+func (s *PhotoService) withViewURL(ctx context.Context, photo *models.Photo) (*models.Photo, error) {
+	if err := s.withViewURLs(ctx, []*models.Photo{photo}); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// withViewURLs fills in the view URLs of the photos returned to clients
+func (s *PhotoService) withViewURLs(ctx context.Context, photos []*models.Photo) error {
+	for _, photo := range photos {
+		viewURL, err := s.GetPresignedViewURL(ctx, photo.S3Key)
+		if err != nil {
+			return err
+		}
+		photo.S3URL = viewURL
+	}
+	return nil
+}
+
+// withCounts fills in the reaction and comment counts of listed photos
+func (s *PhotoService) withCounts(ctx context.Context, photos []*models.Photo) error {
+	if s.reactions == nil {
+		return nil
+	}
+	return s.reactions.counts(ctx, photos)
+}
+
+// photoUploadedOutboxEvent builds the notification of the uploader's partner
+func photoUploadedOutboxEvent(pair *models.Pair, photo *models.Photo) (*models.OutboxEvent, error) {
+	partnerID := pair.UserAID
+	if partnerID == photo.UserID {
+		partnerID = pair.UserBID
+	}
+	e := PhotoUploadedEvent{
+		PhotoID:    photo.ID,
+		PairID:     photo.PairID,
+		UserID:     photo.UserID,
+		PartnerID:  partnerID,
+		UploadedAt: *photo.UploadedAt,
+	}
+	if photo.SessionID != nil {
+		e.SessionID = *photo.SessionID
+	}
+	return newOutboxEvent(OutboxPhotoUploaded, e)
+}
+
+func photoConfirmedEvent(pair *models.Pair, photo *models.Photo) (*models.DomainEvent, error) {
+	return newDomainEvent(EventPhotoConfirmed, pair.AppID, photo.UserID, pair.ID, PhotoConfirmedPayload{
+		PhotoID: photo.ID,
+		PairID:  photo.PairID,
+		UserID:  photo.UserID,
+		S3URL:   photo.S3URL,
+		TakenAt: photo.TakenAt,
+	})
+}
+
+// recordMoment records moment_completed when this photo is the user's first
+// in a moment the partner has already contributed to. Errors are logged and
+// never fail the upload.
+func (s *PhotoService) recordMoment(ctx context.Context, pair *models.Pair, photo *models.Photo) {
+	photos, err := s.photoRepo.ListAround(ctx, pair.ID, photo.TakenAt, models.MomentWindow)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("photo_id", photo.ID).Msg("Failed to get moment photos")
+		return
+	}
+	own, partner := 0, 0
+	photoIDs := make([]string, 0, len(photos))
+	for _, p := range photos {
+		if p.UserID == photo.UserID {
+			own++
+		} else {
+			partner++
+		}
+		photoIDs = append(photoIDs, p.ID)
+	}
+	// Повторные снимки в том же моменте не завершают его еще раз
+	if own != 1 || partner == 0 {
+		return
+	}
+	err = s.eventLog.Append(ctx, EventMomentCompleted, pair.AppID, photo.UserID, pair.ID, MomentCompletedPayload{
+		PairID:   pair.ID,
+		PhotoIDs: photoIDs,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("photo_id", photo.ID).Msg("Failed to record moment_completed")
+	}
 }
 
 // GetPhotosByPair retrieves photos for a pair with pagination
 func (s *PhotoService) GetPhotosByPair(ctx context.Context, userID string, limit, offset int) ([]*models.Photo, int, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.GetPhotosByPair")
+	defer span.End()
+
 	// Get user's pair
-	pair, err := s.pairRepo.GetByUserID(ctx, userID)
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
 	if err != nil {
-		return nil, 0, fmt.Errorf("user is not in a pair: %w", err)
+		return nil, 0, err
 	}
 
-	// Validate limit
-	if limit <= 0 {
-		limit = 50
+	limit = PhotoPageLimits.Clamp(limit)
+	offset = max(offset, 0)
+
+	photos, total, err := s.photoRepo.GetByPairID(ctx, pair.ID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.withViewURLs(ctx, photos); err != nil {
+		return nil, 0, err
 	}
-	if limit > 100 {
-		limit = 100
+	if err := s.withCounts(ctx, photos); err != nil {
+		return nil, 0, err
 	}
-	if offset < 0 {
-		offset = 0
+	return photos, total, nil
+}
+
+// StorageUsage counts the stored objects and their total size
+func (s *PhotoService) StorageUsage(ctx context.Context) (objects, bytes int64, err error) {
+	err = s.guard.do(ctx, "ListObjectsV2", func(ctx context.Context) error {
+		var err error
+		objects, bytes, err = s.store.Usage(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to measure storage usage: %w", err)
 	}
+	return objects, bytes, nil
+}
 
-	return s.photoRepo.GetByPairID(ctx, pair.ID, limit, offset)
+// CheckStorage verifies that the object store is reachable
+func (s *PhotoService) CheckStorage(ctx context.Context) error {
+	if err := s.guard.do(ctx, "HeadBucket", s.store.Probe); err != nil {
+		return fmt.Errorf("failed to reach storage: %w", err)
+	}
+	return nil
+}
+
+// ProbeStorage is CheckStorage without retries and the circuit breaker, so
+// repeated startup checks don't open the breaker before serving begins
+func (s *PhotoService) ProbeStorage(ctx context.Context) error {
+	if err := s.store.Probe(ctx); err != nil {
+		return fmt.Errorf("failed to reach storage: %w", err)
+	}
+	return nil
 }
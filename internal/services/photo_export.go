@@ -0,0 +1,245 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"time"
+
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/tracing"
+)
+
+// defaultExportConcurrency bounds the downloads of an export unless
+// WithExportConcurrency was called
+const defaultExportConcurrency = 4
+
+// exportBatchSize is how many photos are listed at a time for an export
+const exportBatchSize = 500
+
+// ExportFilter selects the photos of an export by taken_at; zero bounds
+// are open
+type ExportFilter struct {
+	From time.Time // inclusive
+	To   time.Time // exclusive
+}
+
+// ExportManifest is the manifest.json at the end of an export archive
+type ExportManifest struct {
+	PairID     string          `json:"pair_id"`
+	ExportedAt time.Time       `json:"exported_at"`
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+	Photos     []ExportedPhoto `json:"photos"`
+}
+
+// ExportedPhoto describes one photo of an export archive
+type ExportedPhoto struct {
+	// File is the photo's path in the archive
+	File          string     `json:"file"`
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	SessionID     *string    `json:"session_id,omitempty"`
+	TakenAt       time.Time  `json:"taken_at"`
+	DeviceTakenAt *time.Time `json:"device_taken_at,omitempty"`
+	UploadedAt    *time.Time `json:"uploaded_at,omitempty"`
+	ContentType   string     `json:"content_type,omitempty"`
+	SizeBytes     int64      `json:"size_bytes,omitempty"`
+	Width         int        `json:"width,omitempty"`
+	Height        int        `json:"height,omitempty"`
+}
+
+// PhotoExport is an album export ready to be written, see PhotoService.Export
+type PhotoExport struct {
+	s      *PhotoService
+	pair   *models.Pair
+	filter ExportFilter
+	// photos are oldest first
+	photos []*models.Photo
+}
+
+// WithExportConcurrency bounds how many photos of one export are
+// downloaded at a time, as configured in exports.concurrency
+func (s *PhotoService) WithExportConcurrency(n int) *PhotoService {
+	s.exportConcurrency = n
+	return s
+}
+
+// Export selects the uploaded photos of the user's pair for an album
+// export. Failures like a missing pair are returned here, before anything
+// is written; the archive itself is written by WriteZip.
+func (s *PhotoService) Export(ctx context.Context, userID string, filter ExportFilter) (*PhotoExport, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.Export")
+	defer span.End()
+
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Лента идет от новых к старым, начиная с верхней границы
+	var photos []*models.Photo
+	position := PhotoCursor{TakenAt: filter.To}
+list:
+	for {
+		batch, err := s.photoRepo.ListByPairBefore(ctx, pair.ID, position.TakenAt, position.ID, exportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, photo := range batch {
+			if !filter.From.IsZero() && photo.TakenAt.Before(filter.From) {
+				break list
+			}
+			photos = append(photos, photo)
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		position = *cursorOf(batch[len(batch)-1])
+	}
+	slices.Reverse(photos)
+	return &PhotoExport{s: s, pair: pair, filter: filter, photos: photos}, nil
+}
+
+// Len returns the number of photos in the export
+func (e *PhotoExport) Len() int {
+	return len(e.photos)
+}
+
+// WriteZip writes the export as a ZIP archive: the photos under photos/,
+// oldest first, and manifest.json describing them. Photos are downloaded
+// a few at a time ahead of the writer, so memory holds at most that many.
+// The archive is only valid if WriteZip returns nil.
+func (e *PhotoExport) WriteZip(ctx context.Context, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "PhotoExport.WriteZip")
+	defer span.End()
+
+	archive := zip.NewWriter(w)
+	manifest := ExportManifest{
+		PairID:     e.pair.ID,
+		ExportedAt: time.Now().UTC(),
+		Photos:     make([]ExportedPhoto, 0, len(e.photos)),
+	}
+	if !e.filter.From.IsZero() {
+		manifest.From = &e.filter.From
+	}
+	if !e.filter.To.IsZero() {
+		manifest.To = &e.filter.To
+	}
+
+	err := e.download(ctx, func(photo *models.Photo, data []byte) error {
+		exported := exportedPhoto(photo)
+		modified := photo.TakenAt
+		if photo.DeviceTakenAt != nil {
+			modified = *photo.DeviceTakenAt
+		}
+		// Фото уже сжаты, повторное сжатие только тратит CPU
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     exported.File,
+			Method:   zip.Store,
+			Modified: modified,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to export: %w", exported.File, err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s to export: %w", exported.File, err)
+		}
+		manifest.Photos = append(manifest.Photos, exported)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	entry, err := archive.Create("manifest.json")
+	if err != nil {
+		return fmt.Errorf("failed to add export manifest: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write export manifest: %w", err)
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish export: %w", err)
+	}
+	return nil
+}
+
+// download passes the export's photos with their files to write in
+// order. Up to exportConcurrency downloads run ahead of write; the first
+// failed download or write stops the rest.
+func (e *PhotoExport) download(ctx context.Context, write func(*models.Photo, []byte) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := e.s.exportConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultExportConcurrency
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	results := make([]chan result, len(e.photos))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	// Слот освобождается, когда фото записано, а не скачано
+	slots := make(chan struct{}, concurrency)
+	go func() {
+		for i, photo := range e.photos {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				data, err := e.s.readObject(ctx, photo.S3Key)
+				results[i] <- result{data: data, err: err}
+			}()
+		}
+	}()
+
+	for i, photo := range e.photos {
+		var r result
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return r.err
+		}
+		if err := write(photo, r.data); err != nil {
+			return err
+		}
+		<-slots
+	}
+	return nil
+}
+
+// exportedPhoto describes the photo in the manifest
+func exportedPhoto(photo *models.Photo) ExportedPhoto {
+	// Имя сортируется по времени съемки и не зависит от часового пояса
+	name := photo.TakenAt.UTC().Format("2006-01-02_150405") + "_" + photo.ID + path.Ext(photo.S3Key)
+	return ExportedPhoto{
+		File:          "photos/" + name,
+		ID:            photo.ID,
+		UserID:        photo.UserID,
+		SessionID:     photo.SessionID,
+		TakenAt:       photo.TakenAt,
+		DeviceTakenAt: photo.DeviceTakenAt,
+		UploadedAt:    photo.UploadedAt,
+		ContentType:   photo.ContentType,
+		SizeBytes:     photo.SizeBytes,
+		Width:         photo.Width,
+		Height:        photo.Height,
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+	"sync-photo-backend/internal/tracing"
+	"sync-photo-backend/internal/validation"
+)
+
+// momentBatchSize is how many photos are read at a time when grouping moments
+const momentBatchSize = 200
+
+var (
+	// PhotoPageLimits bound the pages of the offset-paginated photo list
+	PhotoPageLimits = pagination.Limits{Default: 50, Max: 100}
+	// FeedLimits bound the pages of the photo feed and the activity feed
+	FeedLimits = pagination.Limits{Default: 50, Max: 100}
+	// MomentLimits bound the pages of moments
+	MomentLimits = pagination.Limits{Default: 20, Max: 50}
+)
+
+// ErrInvalidCursor is returned for cursors not issued by ListPhotos,
+// ListMoments or EventLog.Activity
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PhotoCursor is a position in a pair's photo feed, which is ordered by
+// taken_at and then id, newest first. The zero cursor is the start of the feed.
+type PhotoCursor struct {
+	TakenAt time.Time
+	ID      string
+}
+
+// IsZero reports whether the cursor points at the start of the feed
+func (c PhotoCursor) IsZero() bool {
+	return c.TakenAt.IsZero()
+}
+
+// String encodes the cursor as an opaque token for clients
+func (c PhotoCursor) String() string {
+	raw := strconv.FormatInt(c.TakenAt.UnixMicro(), 10) + "_" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParsePhotoCursor decodes a token made by PhotoCursor.String; an empty
+// token is the start of the feed
+func ParsePhotoCursor(token string) (PhotoCursor, error) {
+	if token == "" {
+		return PhotoCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PhotoCursor{}, ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), "_")
+	if !ok || !validation.IsUUID(id) {
+		return PhotoCursor{}, ErrInvalidCursor
+	}
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return PhotoCursor{}, ErrInvalidCursor
+	}
+	// Время в БД и в ответах API всегда в UTC
+	return PhotoCursor{TakenAt: time.UnixMicro(unixMicro).UTC(), ID: id}, nil
+}
+
+func cursorOf(photo *models.Photo) *PhotoCursor {
+	return &PhotoCursor{TakenAt: photo.TakenAt, ID: photo.ID}
+}
+
+// ListPhotos returns up to limit photos of the user's pair after the cursor.
+// The returned cursor is nil on the last page.
+func (s *PhotoService) ListPhotos(ctx context.Context, userID string, after PhotoCursor, limit int) ([]*models.Photo, *PhotoCursor, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.ListPhotos")
+	defer span.End()
+
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit = FeedLimits.Clamp(limit)
+
+	// Лишняя строка показывает, есть ли следующая страница
+	photos, err := s.photoRepo.ListByPairBefore(ctx, pair.ID, after.TakenAt, after.ID, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.withViewURLs(ctx, photos); err != nil {
+		return nil, nil, err
+	}
+	var next *PhotoCursor
+	if len(photos) > limit {
+		photos = photos[:limit]
+		next = cursorOf(photos[limit-1])
+	}
+	if err := s.withCounts(ctx, photos); err != nil {
+		return nil, nil, err
+	}
+	return photos, next, nil
+}
+
+// ListMoments returns up to limit moments of the user's pair after the
+// cursor, newest first. Consecutive photos no more than
+// models.MomentWindow apart belong to the same moment. The returned
+// cursor is nil on the last page.
+func (s *PhotoService) ListMoments(ctx context.Context, userID string, after PhotoCursor, limit int) ([]*models.Moment, *PhotoCursor, error) {
+	ctx, span := tracing.Start(ctx, "PhotoService.ListMoments")
+	defer span.End()
+
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit = MomentLimits.Clamp(limit)
+
+	moments := []*models.Moment{}
+	var current *models.Moment
+	position := after
+	for {
+		photos, err := s.photoRepo.ListByPairBefore(ctx, pair.ID, position.TakenAt, position.ID, momentBatchSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.withViewURLs(ctx, photos); err != nil {
+			return nil, nil, err
+		}
+		if err := s.withCounts(ctx, photos); err != nil {
+			return nil, nil, err
+		}
+
+		for _, photo := range photos {
+			if current != nil && current.StartedAt.Sub(photo.TakenAt) <= models.MomentWindow {
+				current.Photos = append(current.Photos, photo)
+				current.StartedAt = photo.TakenAt
+				current.ID = photo.ID
+				continue
+			}
+
+			// Фото вне окна закрывает текущий момент
+			if current != nil {
+				moments = append(moments, finishMoment(current))
+				if len(moments) == limit {
+					last := current.Photos[len(current.Photos)-1]
+					return moments, cursorOf(last), nil
+				}
+			}
+			current = &models.Moment{
+				ID:        photo.ID,
+				Photos:    []*models.Photo{photo},
+				StartedAt: photo.TakenAt,
+				EndedAt:   photo.TakenAt,
+			}
+		}
+
+		if len(photos) < momentBatchSize {
+			break
+		}
+		position = *cursorOf(photos[len(photos)-1])
+	}
+
+	if current != nil {
+		moments = append(moments, finishMoment(current))
+	}
+	return moments, nil, nil
+}
+
+// finishMoment marks the moment complete if both partners took a photo
+func finishMoment(m *models.Moment) *models.Moment {
+	for _, photo := range m.Photos {
+		if photo.UserID != m.Photos[0].UserID {
+			m.Complete = true
+			break
+		}
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"strconv"
+	"time"
+)
+
+// Version identifies the state of a record by its updated_at; v2 sends it
+// as the ETag
+func Version(updatedAt time.Time) string {
+	return strconv.FormatInt(updatedAt.UnixMicro(), 10)
+}
+
+// Precondition is the state a client expects a record to be in before it
+// changes it, so edits made from two devices don't overwrite each other.
+// The zero value allows any state.
+type Precondition struct {
+	// Version is the Version the client read, "" to skip the check
+	Version string
+	// UnmodifiedSince rejects records updated after it; HTTP dates have
+	// second precision. Zero skips the check.
+	UnmodifiedSince time.Time
+}
+
+// IsZero reports whether p allows any state
+func (p Precondition) IsZero() bool {
+	return p.Version == "" && p.UnmodifiedSince.IsZero()
+}
+
+// Allows reports whether a record last updated at updatedAt meets p
+func (p Precondition) Allows(updatedAt time.Time) bool {
+	if p.Version != "" && p.Version != Version(updatedAt) {
+		return false
+	}
+	if !p.UnmodifiedSince.IsZero() && updatedAt.Truncate(time.Second).After(p.UnmodifiedSince) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// promptBatchSize is how many due pairs are prompted at a time
+const promptBatchSize = 100
+
+// PromptSettingsRequest is the body of PUT /api/v1/pairs/me/prompt. Empty
+// time restores the default. Timezone changes the timezone of the whole
+// pair, mute hours included, see PairSettingsService; empty keeps it.
+type PromptSettingsRequest struct {
+	Enabled  *bool  `json:"enabled" validate:"required"`
+	Time     string `json:"time" validate:"omitempty,datetime=15:04"`
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+}
+
+// PromptSchedule is when a pair gets its daily prompt, defaults resolved
+type PromptSchedule struct {
+	Enabled bool `json:"enabled"`
+	// Time is the local "HH:MM" of the prompt in Timezone
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+}
+
+// Streak sums up how a pair keeps up with its daily prompts
+type Streak struct {
+	// Current counts the prompts completed in a row on consecutive days up
+	// to the latest one; a latest prompt still open doesn't break it
+	Current int `json:"current"`
+	// Longest is the best Current the pair ever had
+	Longest int `json:"longest"`
+	// Completed counts all prompts completed by both partners
+	Completed int `json:"completed"`
+	// Prompt is the latest prompt, nil before the first one
+	Prompt *models.DailyPrompt `json:"prompt"`
+}
+
+// DailyPromptEvent is the payload of daily_prompt outbox events
+type DailyPromptEvent struct {
+	UserAID string             `json:"user_a_id"`
+	UserBID string             `json:"user_b_id"`
+	Prompt  models.DailyPrompt `json:"prompt"`
+}
+
+// PromptService sends every pair a daily photo_prompt at its local prompt
+// time and records whether both partners answered it with a photo
+type PromptService struct {
+	repo         PromptRepository
+	pairRepo     PairRepository
+	pairSettings *PairSettingsService
+	hub          *WSHub
+	cfg          config.PromptsConfig
+}
+
+// NewPromptService creates a prompt service; prompts go out through the
+// outbox at the local time of pairSettings, completions are announced
+// through hub
+func NewPromptService(repo PromptRepository, pairRepo PairRepository, pairSettings *PairSettingsService, hub *WSHub, cfg config.PromptsConfig) *PromptService {
+	return &PromptService{
+		repo:         repo,
+		pairRepo:     pairRepo,
+		pairSettings: pairSettings,
+		hub:          hub,
+		cfg:          cfg,
+	}
+}
+
+// Run sends the prompts that are due every prompts.check_interval until
+// ctx is cancelled. Every instance may run it; a pair gets one prompt a day.
+func (s *PromptService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := s.SendDue(ctx, time.Now())
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to send daily prompts")
+			}
+			if sent > 0 {
+				log.Info().Int("pairs", sent).Msg("Daily prompts sent")
+			}
+		}
+	}
+}
+
+// SendDue sends today's prompt to every pair past its local prompt time at
+// now that didn't get it yet, and returns how many it sent
+func (s *PromptService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		due, err := s.repo.ListDue(ctx, now, s.cfg.Time, promptBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		for _, d := range due {
+			created, err := s.send(ctx, d, now)
+			if err != nil {
+				return sent, err
+			}
+			if created {
+				sent++
+			}
+		}
+		if len(due) < promptBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// send stores the pair's prompt for the day with the outbox event
+// delivering it; false if another instance got there first
+func (s *PromptService) send(ctx context.Context, due *models.DuePrompt, now time.Time) (bool, error) {
+	prompt := &models.DailyPrompt{
+		ID:        uuid.New().String(),
+		PairID:    due.Pair.ID,
+		Day:       due.Day,
+		Prompt:    s.pick(due.Pair.ID, due.Day),
+		SentAt:    now.UTC(),
+		ExpiresAt: now.Add(s.cfg.Window).UTC(),
+	}
+	event, err := newOutboxEvent(OutboxDailyPrompt, DailyPromptEvent{
+		UserAID: due.Pair.UserAID,
+		UserBID: due.Pair.UserBID,
+		Prompt:  *prompt,
+	})
+	if err != nil {
+		return false, err
+	}
+	return s.repo.Create(ctx, prompt, event)
+}
+
+// pick chooses the prompt of the day. Pairs start at different points of
+// the list and go through all of it before a prompt repeats.
+func (s *PromptService) pick(pairID string, day time.Time) string {
+	h := fnv.New32a()
+	h.Write([]byte(pairID))
+	n := uint64(h.Sum32()) + uint64(day.Unix()/int64(24*time.Hour/time.Second))
+	return s.cfg.List[n%uint64(len(s.cfg.List))]
+}
+
+// Schedule returns when the user's pair gets its prompt
+func (s *PromptService) Schedule(ctx context.Context, userID string) (*PromptSchedule, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.GetSettings(ctx, pair.ID)
+	if err != nil {
+		return nil, err
+	}
+	pairSettings, err := s.pairSettings.Settings(ctx, pair.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.schedule(ctx, pair, settings, pairSettings)
+}
+
+// UpdateSchedule changes when the user's pair gets its prompt. The change
+// applies from the next local day if today's prompt was already sent.
+func (s *PromptService) UpdateSchedule(ctx context.Context, userID string, req PromptSettingsRequest) (*PromptSchedule, error) {
+	ctx, span := tracing.Start(ctx, "PromptService.UpdateSchedule")
+	defer span.End()
+
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.PromptSettings{
+		PairID:    pair.ID,
+		Enabled:   *req.Enabled,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if req.Time != "" {
+		settings.Time = &req.Time
+	}
+	if err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	// Часовой пояс общий для пары: без timezone в запросе он не меняется
+	var pairSettings *models.PairSettings
+	if req.Timezone != "" {
+		pairSettings, err = s.pairSettings.setTimezone(ctx, pair.ID, req.Timezone)
+	} else {
+		pairSettings, err = s.pairSettings.Settings(ctx, pair.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.schedule(ctx, pair, settings, pairSettings)
+}
+
+// schedule resolves the defaults of the pair's settings
+func (s *PromptService) schedule(ctx context.Context, pair *models.Pair, settings *models.PromptSettings, pairSettings *models.PairSettings) (*PromptSchedule, error) {
+	schedule := &PromptSchedule{Enabled: settings.Enabled, Time: s.cfg.Time}
+	if settings.Time != nil {
+		schedule.Time = *settings.Time
+	}
+	timezone, err := s.pairSettings.timezone(ctx, pair, pairSettings)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Timezone = timezone
+	return schedule, nil
+}
+
+// Streak returns the streak of the user's pair
+func (s *PromptService) Streak(ctx context.Context, userID string) (*Streak, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.streak(ctx, pair.ID, time.Now())
+}
+
+func (s *PromptService) streak(ctx context.Context, pairID string, now time.Time) (*Streak, error) {
+	prompts, err := s.repo.ListByPair(ctx, pairID)
+	if err != nil {
+		return nil, err
+	}
+	streak := streakOf(prompts, now)
+	return &streak, nil
+}
+
+// photoConfirmed counts the photo towards the prompt open when it was
+// uploaded; once both partners answered it, both get
+// photo_prompt_completed. Errors are logged and never fail the upload.
+func (s *PromptService) photoConfirmed(ctx context.Context, pair *models.Pair, photo *models.Photo) {
+	prompt, completed, err := s.repo.Complete(ctx, pair.ID, photo.UserID == pair.UserAID, *photo.UploadedAt)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("photo_id", photo.ID).Msg("Failed to complete daily prompt")
+		return
+	}
+	if !completed {
+		return
+	}
+
+	streak, err := s.streak(ctx, pair.ID, time.Now())
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("pair_id", pair.ID).Msg("Failed to get streak")
+		return
+	}
+	streak.Prompt = prompt
+	message := WSMessage{Type: "photo_prompt_completed", Data: streak}
+	for _, userID := range []string{pair.UserAID, pair.UserBID} {
+		if err := s.hub.SendToUser(userID, message); err != nil {
+			log.Ctx(ctx).Debug().Err(err).Str("user_id", userID).Msg("Failed to send photo_prompt_completed")
+		}
+	}
+}
+
+// streakOf sums up prompts given newest first
+func streakOf(prompts []*models.DailyPrompt, now time.Time) Streak {
+	var streak Streak
+	if len(prompts) == 0 {
+		return streak
+	}
+	streak.Prompt = prompts[0]
+
+	// Открытое задание еще можно выполнить, серия не прерывается
+	start := 0
+	if prompts[0].CompletedAt == nil && prompts[0].ExpiresAt.After(now) {
+		start = 1
+	}
+	for i := start; i < len(prompts); i++ {
+		if prompts[i].CompletedAt == nil {
+			break
+		}
+		if i > start && !consecutive(prompts[i], prompts[i-1]) {
+			break
+		}
+		streak.Current++
+	}
+
+	run := 0
+	for i := len(prompts) - 1; i >= 0; i-- {
+		prompt := prompts[i]
+		switch {
+		case prompt.CompletedAt == nil:
+			run = 0
+			continue
+		case run > 0 && consecutive(prompts[i+1], prompt):
+			run++
+		default:
+			run = 1
+		}
+		streak.Completed++
+		streak.Longest = max(streak.Longest, run)
+	}
+	return streak
+}
+
+// consecutive reports whether next was sent the local day after prev
+func consecutive(prev, next *models.DailyPrompt) bool {
+	return prev.Day.AddDate(0, 0, 1).Equal(next.Day)
+}
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/models"
 
 	"github.com/sideshow/apns2"
 	"github.com/sideshow/apns2/payload"
@@ -12,17 +16,125 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// PushService handles sending push notifications via APNs
-type PushService struct {
+// ErrPushSuppressed is returned when a push is not sent because the
+// recipient is in quiet hours
+var ErrPushSuppressed = errors.New("push suppressed by quiet hours")
+
+// ErrPushMuted is returned when a push is not sent because of the mute
+// hours of the recipient's pair
+var ErrPushMuted = errors.New("push suppressed by pair mute hours")
+
+// ErrNoPushToken is returned when the recipient has not registered a push token
+var ErrNoPushToken = errors.New("user has no push token")
+
+// Notification types used for push delivery analytics
+const (
+	PushTypeCall        = "call"
+	PushTypeTakePhoto   = "take_photo"
+	PushTypePairCreated = "pair_created"
+	PushTypePairDeleted = "pair_deleted"
+	PushTypePhotoPrompt = "photo_prompt"
+	PushTypeTest        = "test"
+)
+
+const providerAPNs = "apns"
+
+const (
+	// pushTimeout bounds a single request to the push provider
+	pushTimeout = 10 * time.Second
+	// pairMuteTimeout bounds the lookup of the recipient's pair mute hours
+	pairMuteTimeout = 2 * time.Second
+)
+
+// apnsTarget is an APNs client with the bundle it pushes to
+type apnsTarget struct {
 	client   *apns2.Client
 	bundleID string
 }
 
-// NewPushService creates a new push service with token-based APNs auth
-func NewPushService(cfg config.APNsConfig) (*PushService, error) {
+// PushService handles sending push notifications via APNs
+type PushService struct {
+	apns apnsTarget
+	// apps holds targets of white-label apps with their own credentials;
+	// other apps use apns
+	apps       map[string]apnsTarget
+	stats      *PushStats
+	dispatcher *pushDispatcher
+
+	// Настройки доставки trigger push-уведомлений
+	triggerPriority int
+	triggerLevel    payload.EInterruptionLevel
+	// pairSettings holds pushes in the mute hours of pairs, see
+	// WithPairSettings
+	pairSettings *PairSettingsService
+}
+
+// NewPushService creates a new push service with token-based APNs auth.
+// Pushes are delivered asynchronously; call Start before sending.
+func NewPushService(cfg config.APNsConfig, pushCfg config.PushConfig) (*PushService, error) {
+	target, err := newAPNsTarget(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newPushService(target, pushCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Bool("production", cfg.Production).
+		Str("bundle_id", cfg.BundleID).
+		Str("trigger_priority", pushCfg.TriggerPriority).
+		Str("trigger_interruption_level", pushCfg.TriggerInterruptionLevel).
+		Msg("APNs push service initialized")
+
+	return s, nil
+}
+
+// NewLogPushService creates a push service that logs notifications instead
+// of sending them, for dev mode without APNs credentials
+func NewLogPushService(pushCfg config.PushConfig) (*PushService, error) {
+	s, err := newPushService(apnsTarget{}, pushCfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Warn().Msg("APNs disabled, push notifications are only logged")
+	return s, nil
+}
+
+func newPushService(target apnsTarget, pushCfg config.PushConfig) (*PushService, error) {
+	triggerPriority, err := parsePushPriority(pushCfg.TriggerPriority)
+	if err != nil {
+		return nil, err
+	}
+	triggerLevel, err := parseInterruptionLevel(pushCfg.TriggerInterruptionLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &PushService{
+		apns:            target,
+		stats:           NewPushStats(),
+		triggerPriority: triggerPriority,
+		triggerLevel:    triggerLevel,
+	}
+	s.dispatcher = newPushDispatcher(
+		pushCfg.Workers,
+		pushCfg.QueueSize,
+		pushCfg.BatchSize,
+		pushCfg.FlushInterval,
+		s.deliver,
+	)
+
+	return s, nil
+}
+
+// newAPNsTarget creates a token-based APNs client for the credentials
+func newAPNsTarget(cfg config.APNsConfig) (apnsTarget, error) {
 	authKey, err := token.AuthKeyFromFile(cfg.KeyPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load APNs auth key from %s: %w", cfg.KeyPath, err)
+		return apnsTarget{}, fmt.Errorf("failed to load APNs auth key from %s: %w", cfg.KeyPath, err)
 	}
 
 	tkn := &token.Token{
@@ -31,58 +143,280 @@ func NewPushService(cfg config.APNsConfig) (*PushService, error) {
 		TeamID:  cfg.TeamID,
 	}
 
-	var client *apns2.Client
+	client := apns2.NewTokenClient(tkn)
 	if cfg.Production {
-		client = apns2.NewTokenClient(tkn).Production()
+		client = client.Production()
 	} else {
-		client = apns2.NewTokenClient(tkn).Development()
+		client = client.Development()
 	}
+	return apnsTarget{client: client, bundleID: cfg.BundleID}, nil
+}
 
-	log.Info().
-		Bool("production", cfg.Production).
-		Str("bundle_id", cfg.BundleID).
-		Msg("APNs push service initialized")
+// WithApps creates APNs clients for white-label apps whose credentials
+// differ from the base ones. base must be the section NewPushService got.
+func (s *PushService) WithApps(base config.APNsConfig, apps map[string]config.AppConfig) (*PushService, error) {
+	s.apps = make(map[string]apnsTarget)
+	for id, app := range apps {
+		if app.APNs == base {
+			continue
+		}
+		target, err := newAPNsTarget(app.APNs)
+		if err != nil {
+			return nil, fmt.Errorf("app %s: %w", id, err)
+		}
+		s.apps[id] = target
+		log.Info().
+			Str("app_id", id).
+			Bool("production", app.APNs.Production).
+			Str("bundle_id", app.APNs.BundleID).
+			Msg("APNs client for app initialized")
+	}
+	return s, nil
+}
 
-	return &PushService{
-		client:   client,
-		bundleID: cfg.BundleID,
-	}, nil
+// WithPairSettings holds pushes during the mute hours of the recipient's
+// pair like during their own quiet hours
+func (s *PushService) WithPairSettings(pairSettings *PairSettingsService) *PushService {
+	s.pairSettings = pairSettings
+	return s
 }
 
-// SendCallNotification sends a "partner is calling" push notification
-func (s *PushService) SendCallNotification(pushToken string) error {
+// target returns the APNs client for the app
+func (s *PushService) target(appID string) apnsTarget {
+	if target, ok := s.apps[appID]; ok {
+		return target
+	}
+	return s.apns
+}
+
+// Start launches the push dispatch workers
+func (s *PushService) Start() {
+	s.dispatcher.start()
+}
+
+// Shutdown stops accepting pushes and drains the dispatch queue
+func (s *PushService) Shutdown(ctx context.Context) error {
+	return s.dispatcher.shutdown(ctx)
+}
+
+// Stats returns push delivery counters per notification type and provider
+func (s *PushService) Stats() []PushStat {
+	return s.stats.Snapshot()
+}
+
+// SendCallNotification sends a "partner is calling" push notification.
+// Calls are triggers, so they bypass quiet hours only when the recipient
+// opted in to always allow partner triggers, and use the configured priority
+// and interruption level for users who opted in to time-sensitive triggers.
+func (s *PushService) SendCallNotification(recipient *models.User) error {
 	p := payload.NewPayload().
 		AlertTitle("TwoPic").
 		AlertBody("Тебя ждут в TwoPic! Открой приложение 📸").
 		Sound("default").
 		MutableContent()
 
-	return s.send(pushToken, p)
+	if recipient.TimeSensitive {
+		p.InterruptionLevel(s.triggerLevel)
+	}
+
+	return s.sendToUser(PushTypeCall, recipient, p, s.triggerPriority, recipient.AlwaysAllowTriggers)
+}
+
+// SendTakePhotoNotification sends a trigger_photo the partner missed while
+// offline. The payload carries the take_photo fields, so the app can show
+// the same capture screen as for the WebSocket message. Quiet hours,
+// priority and interruption level work as for calls.
+func (s *PushService) SendTakePhotoNotification(recipient *models.User, initiatorID, sessionID string, timestamp int64) error {
+	p := payload.NewPayload().
+		AlertTitle("TwoPic").
+		AlertBody("Партнер делает фото — присоединяйся! 📸").
+		Sound("default").
+		MutableContent().
+		Custom("type", "take_photo").
+		Custom("initiator_id", initiatorID).
+		Custom("timestamp", timestamp)
+	if sessionID != "" {
+		p.Custom("session_id", sessionID)
+	}
+
+	if recipient.TimeSensitive {
+		p.InterruptionLevel(s.triggerLevel)
+	}
+
+	return s.sendToUser(PushTypeTakePhoto, recipient, p, s.triggerPriority, recipient.AlwaysAllowTriggers)
+}
+
+// SendPairCreatedNotification sends a push when someone paired with the user
+func (s *PushService) SendPairCreatedNotification(recipient *models.User) error {
+	p := payload.NewPayload().
+		AlertTitle("TwoPic").
+		AlertBody("У тебя новая пара! Открой приложение 💞").
+		Sound("default")
+
+	return s.sendToUser(PushTypePairCreated, recipient, p, 0, false)
 }
 
 // SendPairDeletedNotification sends a push when the pair has been broken
-func (s *PushService) SendPairDeletedNotification(pushToken string) error {
+func (s *PushService) SendPairDeletedNotification(recipient *models.User) error {
 	p := payload.NewPayload().
 		AlertTitle("TwoPic").
 		AlertBody("Твоя пара была разорвана 💔").
 		Sound("default")
 
-	return s.send(pushToken, p)
+	return s.sendToUser(PushTypePairDeleted, recipient, p, 0, false)
+}
+
+// SendPhotoPromptNotification sends the daily prompt to a user who wasn't
+// connected when it went out. It is not a trigger, so quiet hours hold it.
+func (s *PushService) SendPhotoPromptNotification(recipient *models.User, prompt *models.DailyPrompt) error {
+	p := payload.NewPayload().
+		AlertTitle("Задание дня 📸").
+		AlertBody(prompt.Prompt).
+		Sound("default").
+		Custom("type", "photo_prompt").
+		Custom("prompt_id", prompt.ID)
+
+	return s.sendToUser(PushTypePhotoPrompt, recipient, p, 0, false)
+}
+
+// SendTestNotification sends a sample push through the regular dispatch
+// pipeline and waits for the provider result. Quiet hours are ignored since
+// the user explicitly asked for it.
+func (s *PushService) SendTestNotification(ctx context.Context, recipient *models.User) error {
+	if recipient.PushToken == nil || *recipient.PushToken == "" {
+		return ErrNoPushToken
+	}
+
+	p := payload.NewPayload().
+		AlertTitle("TwoPic").
+		AlertBody("Тестовое уведомление ✅").
+		Sound("default")
+
+	job := pushJob{
+		kind:     PushTypeTest,
+		provider: providerAPNs,
+		app:      recipient.AppID,
+		token:    *recipient.PushToken,
+		payload:  p,
+		result:   make(chan error, 1),
+	}
+	if err := s.dispatcher.enqueue(job); err != nil {
+		s.stats.Record(PushTypeTest, providerAPNs, pushResultFailed)
+		return err
+	}
+
+	select {
+	case err := <-job.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendToUser checks the recipient's push token, quiet hours and the mute
+// hours of their pair before sending. Non-critical pushes inside either
+// are dropped. A zero priority leaves the APNs default.
+func (s *PushService) sendToUser(kind string, recipient *models.User, p *payload.Payload, priority int, critical bool) error {
+	if recipient.PushToken == nil || *recipient.PushToken == "" {
+		return ErrNoPushToken
+	}
+
+	now := time.Now()
+	if !critical && InQuietHours(recipient, now) {
+		return s.suppress(kind, recipient, ErrPushSuppressed)
+	}
+	if !critical && s.pairMuted(recipient, now) {
+		return s.suppress(kind, recipient, ErrPushMuted)
+	}
+
+	return s.send(kind, recipient.AppID, *recipient.PushToken, p, priority)
+}
+
+// suppress records a push dropped with err and returns it
+func (s *PushService) suppress(kind string, recipient *models.User, err error) error {
+	log.Debug().
+		Err(err).
+		Str("user_id", recipient.ID).
+		Str("type", kind).
+		Msg("Push suppressed")
+	s.stats.Record(kind, providerAPNs, pushResultSuppressed)
+	return err
+}
+
+// pairMuted reports whether the mute hours of the recipient's pair hold
+// pushes at now; a failed lookup doesn't hold them
+func (s *PushService) pairMuted(recipient *models.User, now time.Time) bool {
+	if s.pairSettings == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pairMuteTimeout)
+	defer cancel()
+
+	muted, err := s.pairSettings.Muted(ctx, recipient.ID, now)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", recipient.ID).Msg("Failed to check pair mute hours")
+		return false
+	}
+	return muted
 }
 
-func (s *PushService) send(pushToken string, p *payload.Payload) error {
+// send enqueues a push for asynchronous delivery
+func (s *PushService) send(kind, appID, pushToken string, p *payload.Payload, priority int) error {
+	job := pushJob{
+		kind:     kind,
+		provider: providerAPNs,
+		app:      appID,
+		token:    pushToken,
+		payload:  p,
+		priority: priority,
+	}
+	if err := s.dispatcher.enqueue(job); err != nil {
+		s.stats.Record(kind, providerAPNs, pushResultFailed)
+		return err
+	}
+	return nil
+}
+
+// deliver sends a single push to the provider; called by dispatch workers
+func (s *PushService) deliver(job pushJob) error {
+	kind, pushToken := job.kind, job.token
+	target := s.target(job.app)
+
 	notification := &apns2.Notification{
 		DeviceToken: pushToken,
-		Topic:       s.bundleID,
-		Payload:     p,
+		Topic:       target.bundleID,
+		Payload:     job.payload,
+		Priority:    job.priority,
 	}
 
-	res, err := s.client.Push(notification)
+	s.stats.Record(kind, providerAPNs, pushResultSent)
+
+	if target.client == nil {
+		s.stats.Record(kind, providerAPNs, pushResultDelivered)
+		log.Info().
+			Str("type", kind).
+			Str("app_id", job.app).
+			Interface("payload", job.payload).
+			Msg("Push notification logged instead of sent")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	res, err := target.client.PushWithContext(ctx, notification)
 	if err != nil {
+		s.stats.Record(kind, providerAPNs, pushResultFailed)
+		log.Error().
+			Err(err).
+			Str("type", kind).
+			Msg("Failed to send push notification")
 		return fmt.Errorf("failed to send push notification: %w", err)
 	}
 
 	if !res.Sent() {
+		s.stats.Record(kind, providerAPNs, pushResultFailed)
 		log.Warn().
 			Int("status", res.StatusCode).
 			Str("reason", res.Reason).
@@ -91,9 +425,37 @@ func (s *PushService) send(pushToken string, p *payload.Payload) error {
 		return fmt.Errorf("push notification not sent: %s (status %d)", res.Reason, res.StatusCode)
 	}
 
+	s.stats.Record(kind, providerAPNs, pushResultDelivered)
+
 	log.Debug().
 		Str("apns_id", res.ApnsID).
+		Str("type", kind).
 		Msg("Push notification sent successfully")
 
 	return nil
 }
+
+// parsePushPriority converts a configured priority name to an APNs priority
+func parsePushPriority(priority string) (int, error) {
+	switch priority {
+	case "high":
+		return apns2.PriorityHigh, nil
+	case "normal":
+		return apns2.PriorityLow, nil
+	default:
+		return 0, fmt.Errorf("unknown push priority: %s", priority)
+	}
+}
+
+// parseInterruptionLevel validates a configured APNs interruption level
+func parseInterruptionLevel(level string) (payload.EInterruptionLevel, error) {
+	switch l := payload.EInterruptionLevel(level); l {
+	case payload.InterruptionLevelPassive,
+		payload.InterruptionLevelActive,
+		payload.InterruptionLevelTimeSensitive,
+		payload.InterruptionLevelCritical:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown push interruption level: %s", level)
+	}
+}
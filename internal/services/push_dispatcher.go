@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sideshow/apns2/payload"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrPushQueueFull is returned when the dispatch queue can't accept more pushes
+var ErrPushQueueFull = errors.New("push queue is full")
+
+// ErrPushDispatcherStopped is returned when a push is enqueued after shutdown
+var ErrPushDispatcherStopped = errors.New("push dispatcher is stopped")
+
+// pushJob is a single push notification waiting for delivery
+type pushJob struct {
+	kind     string
+	provider string
+	app      string // white-label app of the recipient
+	token    string
+	payload  *payload.Payload
+	priority int
+	// result receives the delivery outcome if set (buffered, capacity 1)
+	result chan error
+}
+
+// pushDispatcher delivers pushes asynchronously through a worker pool.
+// Jobs are grouped into batches per provider, so a slow provider call
+// never blocks request handlers.
+type pushDispatcher struct {
+	mu      sync.RWMutex
+	stopped bool
+
+	queue         chan pushJob
+	batches       chan []pushJob
+	batchSize     int
+	flushInterval time.Duration
+	workers       int
+	deliver       func(pushJob) error
+
+	wg sync.WaitGroup
+}
+
+// newPushDispatcher creates a dispatcher; call start to launch workers
+func newPushDispatcher(workers, queueSize, batchSize int, flushInterval time.Duration, deliver func(pushJob) error) *pushDispatcher {
+	return &pushDispatcher{
+		queue:         make(chan pushJob, queueSize),
+		batches:       make(chan []pushJob, workers),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		workers:       workers,
+		deliver:       deliver,
+	}
+}
+
+// start launches the batching collector and delivery workers
+func (d *pushDispatcher) start() {
+	d.wg.Add(1)
+	go d.collect()
+
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// enqueue adds a job to the queue without blocking
+func (d *pushDispatcher) enqueue(job pushJob) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.stopped {
+		return ErrPushDispatcherStopped
+	}
+
+	select {
+	case d.queue <- job:
+		return nil
+	default:
+		return ErrPushQueueFull
+	}
+}
+
+// collect groups queued jobs into per-provider batches
+func (d *pushDispatcher) collect() {
+	defer d.wg.Done()
+	defer close(d.batches)
+
+	pending := make(map[string][]pushJob)
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	flush := func(provider string) {
+		if len(pending[provider]) == 0 {
+			return
+		}
+		d.batches <- pending[provider]
+		delete(pending, provider)
+	}
+
+	for {
+		select {
+		case job, ok := <-d.queue:
+			if !ok {
+				// Очередь закрыта - отправить все накопленные пачки
+				for provider := range pending {
+					flush(provider)
+				}
+				return
+			}
+			pending[job.provider] = append(pending[job.provider], job)
+			if len(pending[job.provider]) >= d.batchSize {
+				flush(job.provider)
+			}
+		case <-ticker.C:
+			for provider := range pending {
+				flush(provider)
+			}
+		}
+	}
+}
+
+// work delivers batches until the batch channel is closed
+func (d *pushDispatcher) work() {
+	defer d.wg.Done()
+
+	for batch := range d.batches {
+		for _, job := range batch {
+			err := d.deliver(job)
+			if job.result != nil {
+				job.result <- err
+			}
+		}
+	}
+}
+
+// shutdown stops accepting jobs and waits until queued pushes are delivered
+func (d *pushDispatcher) shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return nil
+	}
+	d.stopped = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info().Msg("Push dispatcher drained")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
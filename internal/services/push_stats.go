@@ -0,0 +1,88 @@
+package services
+
+import (
+	"sort"
+	"sync"
+
+	"sync-photo-backend/internal/metrics"
+)
+
+// Push delivery results
+const (
+	pushResultSent       = "sent"
+	pushResultDelivered  = "delivered"
+	pushResultFailed     = "failed"
+	pushResultSuppressed = "suppressed"
+)
+
+// PushStat holds delivery counters for one notification type and provider
+type PushStat struct {
+	Type       string `json:"type"`
+	Provider   string `json:"provider"`
+	Sent       int64  `json:"sent"`
+	Delivered  int64  `json:"delivered"`
+	Failed     int64  `json:"failed"`
+	Suppressed int64  `json:"suppressed"`
+}
+
+type pushStatKey struct {
+	kind     string
+	provider string
+}
+
+// PushStats aggregates push delivery counters in memory and mirrors them to metrics
+type PushStats struct {
+	mu    sync.Mutex
+	stats map[pushStatKey]*PushStat
+}
+
+// NewPushStats creates an empty push stats collector
+func NewPushStats() *PushStats {
+	return &PushStats{
+		stats: make(map[pushStatKey]*PushStat),
+	}
+}
+
+// Record increments the counter for the given result
+func (s *PushStats) Record(kind, provider, result string) {
+	metrics.PushNotifications.WithLabelValues(kind, provider, result).Inc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pushStatKey{kind: kind, provider: provider}
+	stat, exists := s.stats[key]
+	if !exists {
+		stat = &PushStat{Type: kind, Provider: provider}
+		s.stats[key] = stat
+	}
+
+	switch result {
+	case pushResultSent:
+		stat.Sent++
+	case pushResultDelivered:
+		stat.Delivered++
+	case pushResultFailed:
+		stat.Failed++
+	case pushResultSuppressed:
+		stat.Suppressed++
+	}
+}
+
+// Snapshot returns a copy of all counters sorted by type and provider
+func (s *PushStats) Snapshot() []PushStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]PushStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Provider < result[j].Provider
+	})
+	return result
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+const clockLayout = "15:04"
+
+// ValidateQuietHours checks quiet hours settings supplied by a client.
+// Both start and end must be set together, in "HH:MM" format.
+func ValidateQuietHours(start, end *string, timezone string) error {
+	if (start == nil) != (end == nil) {
+		return fmt.Errorf("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if start != nil {
+		if _, err := time.Parse(clockLayout, *start); err != nil {
+			return fmt.Errorf("quiet_hours_start must be in HH:MM format")
+		}
+		if _, err := time.Parse(clockLayout, *end); err != nil {
+			return fmt.Errorf("quiet_hours_end must be in HH:MM format")
+		}
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("unknown timezone: %s", timezone)
+	}
+	return nil
+}
+
+// InQuietHours reports whether t falls inside the user's quiet hours window.
+// Windows that wrap midnight (e.g. 22:00-07:00) are supported.
+func InQuietHours(user *models.User, t time.Time) bool {
+	if user.QuietHoursStart == nil || user.QuietHoursEnd == nil {
+		return false
+	}
+	return inWindow(*user.QuietHoursStart, *user.QuietHoursEnd, user.Timezone, t)
+}
+
+// inWindow reports whether t falls between the local "HH:MM" times start
+// and end in timezone, UTC if it is unknown. Windows that wrap midnight
+// are supported.
+func inWindow(startTime, endTime, timezone string, t time.Time) bool {
+	start, err := time.Parse(clockLayout, startTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockLayout, endTime)
+	if err != nil {
+		return false
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+
+	now := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from == to {
+		return false
+	}
+	if from < to {
+		return now >= from && now < to
+	}
+	// Окно переходит через полночь
+	return now >= from || now < to
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// CommentLimits bound the pages of photo comments
+var CommentLimits = pagination.Limits{Default: 50, Max: 100}
+
+// ReactionRequest is the body of POST /api/v1/photos/{photo_id}/reactions
+type ReactionRequest struct {
+	Emoji string `json:"emoji" validate:"required,max=16,emoji"`
+}
+
+// CommentRequest is the body of POST /api/v1/photos/{photo_id}/comments
+type CommentRequest struct {
+	Text string `json:"text" validate:"required,notblank,max=1000"`
+}
+
+// ReactionService lets partners react to and comment on the photos of
+// their pair. The partner gets photo_reaction and photo_comment over the
+// hub; offline partners see the counts in the photo listings.
+type ReactionService struct {
+	repo      ReactionRepository
+	photoRepo PhotoRepository
+	pairRepo  PairRepository
+	hub       *WSHub
+}
+
+// NewReactionService creates a reaction service notifying partners through hub
+func NewReactionService(repo ReactionRepository, photoRepo PhotoRepository, pairRepo PairRepository, hub *WSHub) *ReactionService {
+	return &ReactionService{
+		repo:      repo,
+		photoRepo: photoRepo,
+		pairRepo:  pairRepo,
+		hub:       hub,
+	}
+}
+
+// AddReaction puts the emoji on a photo of the user's pair. Putting the
+// same emoji again changes nothing and returns nil.
+func (s *ReactionService) AddReaction(ctx context.Context, userID, photoID, emoji string) (*models.Reaction, error) {
+	pair, err := s.photoOfPair(ctx, userID, photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := &models.Reaction{
+		ID:        uuid.New().String(),
+		PhotoID:   photoID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now().UTC(),
+	}
+	created, err := s.repo.AddReaction(ctx, reaction)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+	s.notifyPartner(ctx, pair, userID, WSMessage{Type: "photo_reaction", PhotoID: photoID, Data: reaction})
+	return reaction, nil
+}
+
+// AddComment leaves a comment on a photo of the user's pair
+func (s *ReactionService) AddComment(ctx context.Context, userID, photoID, text string) (*models.Comment, error) {
+	pair, err := s.photoOfPair(ctx, userID, photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		ID:        uuid.New().String(),
+		PhotoID:   photoID,
+		UserID:    userID,
+		Text:      strings.TrimSpace(text),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.AddComment(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.notifyPartner(ctx, pair, userID, WSMessage{Type: "photo_comment", PhotoID: photoID, Data: comment})
+	return comment, nil
+}
+
+// ListReactions returns the reactions on a photo of the user's pair,
+// oldest first
+func (s *ReactionService) ListReactions(ctx context.Context, userID, photoID string) ([]*models.Reaction, error) {
+	if _, err := s.photoOfPair(ctx, userID, photoID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListReactions(ctx, photoID)
+}
+
+// ListComments returns a page of the comments on a photo of the user's
+// pair, oldest first, and their total
+func (s *ReactionService) ListComments(ctx context.Context, userID, photoID string, limit, offset int) ([]*models.Comment, int, error) {
+	if _, err := s.photoOfPair(ctx, userID, photoID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListComments(ctx, photoID, CommentLimits.Clamp(limit), max(offset, 0))
+}
+
+// photoOfPair returns the user's pair if the uploaded photo belongs to
+// it. Photos of other pairs and pending uploads are reported missing, so
+// users can't tell them from photos that don't exist.
+func (s *ReactionService) photoOfPair(ctx context.Context, userID, photoID string) (*models.Pair, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	photo, err := s.photoRepo.GetByID(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	if photo.PairID != pair.ID || photo.UploadedAt == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPhotoNotFound, photoID)
+	}
+	return pair, nil
+}
+
+// notifyPartner sends message to the user's partner. Errors are logged
+// and never fail the request; the partner sees the counts in the listings.
+func (s *ReactionService) notifyPartner(ctx context.Context, pair *models.Pair, userID string, message WSMessage) {
+	partnerID := pair.UserAID
+	if partnerID == userID {
+		partnerID = pair.UserBID
+	}
+	if err := s.hub.SendToUser(partnerID, message); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Str("user_id", partnerID).Str("photo_id", message.PhotoID).Msgf("Failed to send %s", message.Type)
+	}
+}
+
+// counts fills in the reaction and comment counts of the photos
+func (s *ReactionService) counts(ctx context.Context, photos []*models.Photo) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	photoIDs := make([]string, len(photos))
+	for i, photo := range photos {
+		photoIDs[i] = photo.ID
+	}
+	counts, err := s.repo.Counts(ctx, photoIDs)
+	if err != nil {
+		return err
+	}
+	for _, photo := range photos {
+		photo.ReactionCount = counts[photo.ID].Reactions
+		photo.CommentCount = counts[photo.ID].Comments
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package services
+
+//go:generate go tool mockgen -source=repositories.go -destination=mocks/repositories.go -package=mocks
+
+import (
+	"context"
+	"time"
+
+	"sync-photo-backend/internal/models"
+)
+
+// UnitOfWork makes flows spanning several repositories atomic: the calls
+// made with the context passed to fn commit together if fn returns nil and
+// roll back otherwise. The pgx implementation is repository.TxManager.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserRepository is the user storage used by services; the pgx
+// implementation is repository.UserRepository
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User, events ...*models.DomainEvent) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	// GetForUpdate reads the user and locks the row until the transaction
+	// in ctx ends
+	GetForUpdate(ctx context.Context, id string) (*models.User, error)
+	// GetByCode finds a user of the app by pairing code
+	GetByCode(ctx context.Context, appID, code string) (*models.User, error)
+	CodeExists(ctx context.Context, code string) (bool, error)
+	UpdatePushToken(ctx context.Context, userID string, pushToken *string) error
+	UpdateQuietHours(ctx context.Context, userID string, start, end *string, timezone string, alwaysAllowTriggers bool) error
+	UpdateTimeSensitiveTriggers(ctx context.Context, userID string, enabled bool) error
+	UpdateLocale(ctx context.Context, userID string, locale *string) error
+	// UpdateSettings writes the settings if the user is still at updatedAt,
+	// domain.ErrPreconditionFailed otherwise
+	UpdateSettings(ctx context.Context, user *models.User, updatedAt time.Time) error
+	// SoftDelete marks the user deleted; Restore undoes it until Purge
+	SoftDelete(ctx context.Context, userID string, deletedAt time.Time) error
+	Restore(ctx context.Context, userID string) error
+	// Purge removes the user if it was deleted no later than deletedBefore
+	Purge(ctx context.Context, userID string, deletedBefore time.Time) (bool, error)
+	// Anonymize scrubs such a user instead, keeping its pairs and photos
+	Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error)
+	// TouchLastSeen moves the last seen time forward, never back
+	TouchLastSeen(ctx context.Context, userID string, at time.Time) error
+	// LastSeen reads the last seen time from storage; cached users from
+	// GetByID don't carry it
+	LastSeen(ctx context.Context, userID string) (*time.Time, error)
+}
+
+// PairRepository is the pair storage used by services; the pgx
+// implementation is repository.PairRepository
+type PairRepository interface {
+	// Create stores the pair with its domain event and outbox events atomically
+	Create(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error
+	GetByID(ctx context.Context, id string) (*models.Pair, error)
+	GetByUserID(ctx context.Context, userID string) (*models.Pair, error)
+	// GetByUsers returns the pair of two users even if it was deleted
+	GetByUsers(ctx context.Context, userAID, userBID string) (*models.Pair, error)
+	// ListByUserID returns the user's pairs, deleted ones included, newest first
+	ListByUserID(ctx context.Context, userID string) ([]*models.Pair, error)
+	// Delete marks the pair deleted with its domain event and outbox events atomically
+	Delete(ctx context.Context, id string, event *models.DomainEvent, outbox ...*models.OutboxEvent) error
+	// Restore undeletes the pair with its domain event and outbox events atomically
+	Restore(ctx context.Context, pair *models.Pair, event *models.DomainEvent, outbox ...*models.OutboxEvent) error
+	UserHasPair(ctx context.Context, userID string) (bool, error)
+}
+
+// PhotoRepository is the photo storage used by services; the pgx
+// implementation is repository.PhotoRepository
+type PhotoRepository interface {
+	Create(ctx context.Context, photo *models.Photo, events ...*models.DomainEvent) error
+	GetByID(ctx context.Context, id string) (*models.Photo, error)
+	GetByPairID(ctx context.Context, pairID string, limit, offset int) ([]*models.Photo, int, error)
+	// ListByPairBefore pages through the pair's photos, newest first
+	ListByPairBefore(ctx context.Context, pairID string, takenAt time.Time, id string, limit int) ([]*models.Photo, error)
+	// Confirm records the upload of a pending photo with the events confirmed
+	// builds from it; false means the photo was uploaded before
+	Confirm(
+		ctx context.Context,
+		photoID string,
+		upload models.PhotoUpload,
+		confirmed func(*models.Photo) (*models.DomainEvent, []*models.OutboxEvent, error),
+	) (*models.Photo, bool, error)
+	ListAround(ctx context.Context, pairID string, takenAt time.Time, window time.Duration) ([]*models.Photo, error)
+	// DeletePending deletes abandoned uploads created before createdBefore
+	// whose objects removed deleted, see repository.PhotoRepository
+	DeletePending(ctx context.Context, createdBefore time.Time, limit int, removed func([]*models.Photo) []string) (int, error)
+	// ListKeysByPair returns the object keys of the pair's photos, pending included
+	ListKeysByPair(ctx context.Context, pairID string) ([]string, error)
+}
+
+// CompositeRepository stores stitched moment photos
+type CompositeRepository interface {
+	// Create returns false if a composite with the same ID already exists
+	Create(ctx context.Context, composite *models.Composite) (bool, error)
+	ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.Composite, int, error)
+	ListKeysByPair(ctx context.Context, pairID string) ([]string, error)
+}
+
+// SyncSessionRepository stores sync sessions; reads fill in their photos
+type SyncSessionRepository interface {
+	Create(ctx context.Context, session *models.SyncSession) error
+	GetByID(ctx context.Context, id string) (*models.SyncSession, error)
+	ListByPair(ctx context.Context, pairID string, limit, offset int) ([]*models.SyncSession, int, error)
+	// AttachPhoto returns true only for the call that completed the session
+	AttachPhoto(ctx context.Context, sessionID string, photo *models.Photo, completedAt time.Time) (bool, error)
+	// MarkMissed returns true only if it marked an incomplete session missed
+	MarkMissed(ctx context.Context, id string, missedAt time.Time) (bool, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// ReactionRepository stores reactions and comments on photos
+type ReactionRepository interface {
+	// AddReaction returns false if the user already put the emoji on the photo
+	AddReaction(ctx context.Context, reaction *models.Reaction) (bool, error)
+	AddComment(ctx context.Context, comment *models.Comment) error
+	ListReactions(ctx context.Context, photoID string) ([]*models.Reaction, error)
+	ListComments(ctx context.Context, photoID string, limit, offset int) ([]*models.Comment, int, error)
+	// Counts leaves photos without reactions and comments out of the map
+	Counts(ctx context.Context, photoIDs []string) (map[string]models.PhotoCounts, error)
+}
+
+// PromptRepository stores the daily photo prompts of pairs and their settings
+type PromptRepository interface {
+	// GetSettings returns enabled settings with default times for pairs
+	// that never saved any
+	GetSettings(ctx context.Context, pairID string) (*models.PromptSettings, error)
+	SaveSettings(ctx context.Context, settings *models.PromptSettings) error
+	// ListDue returns pairs past their local prompt time without a prompt
+	// for that local day
+	ListDue(ctx context.Context, now time.Time, defaultTime string, limit int) ([]*models.DuePrompt, error)
+	// Create returns false if the pair already has a prompt for the day
+	Create(ctx context.Context, prompt *models.DailyPrompt, outbox ...*models.OutboxEvent) (bool, error)
+	// Complete returns the prompt open at at, nil if there is none, and
+	// whether the user's photo completed it for both partners
+	Complete(ctx context.Context, pairID string, userA bool, at time.Time) (*models.DailyPrompt, bool, error)
+	// ListByPair returns the pair's prompts, newest first
+	ListByPair(ctx context.Context, pairID string) ([]*models.DailyPrompt, error)
+}
+
+// PairSettingsRepository stores the settings partners share
+type PairSettingsRepository interface {
+	// Get returns the defaults with a zero UpdatedAt for pairs that never
+	// saved any
+	Get(ctx context.Context, pairID string) (*models.PairSettings, error)
+	// Save writes the settings if they are still at updatedAt,
+	// domain.ErrPreconditionFailed otherwise
+	Save(ctx context.Context, settings *models.PairSettings, updatedAt time.Time) error
+}
+
+// RefreshTokenRepository stores the refresh tokens of sessions
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	// GetByHash returns domain.ErrInvalidRefreshToken for unknown tokens
+	GetByHash(ctx context.Context, hash []byte) (*models.RefreshToken, error)
+	// Rotate revokes the current token and stores the next one atomically;
+	// false means the current token was already revoked
+	Rotate(ctx context.Context, currentID string, next *models.RefreshToken) (bool, error)
+	RevokeSession(ctx context.Context, sessionID string, revokedAt time.Time) error
+	// RevokeUser revokes the tokens of every session of the user
+	RevokeUser(ctx context.Context, userID string, revokedAt time.Time) error
+	SessionActive(ctx context.Context, sessionID string, now time.Time) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DomainEventRepository is the domain event log used by EventLog
+type DomainEventRepository interface {
+	Append(ctx context.Context, events ...*models.DomainEvent) error
+	ListByPair(ctx context.Context, pairID string, beforeSeq int64, limit int) ([]*models.DomainEvent, error)
+	EnsureConsumer(ctx context.Context, name string) error
+	// Consume passes the consumer's next settled events to handle in log order
+	Consume(ctx context.Context, name string, settle time.Duration, limit int, handle func(*models.DomainEvent) error) (int, error)
+}
+
+// OutboxRepository is the notification outbox used by OutboxRelay
+type OutboxRepository interface {
+	Process(ctx context.Context, limit int, handle func(*models.OutboxEvent) models.OutboxResult) (int, error)
+	DeleteProcessed(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserEventRepository keeps the WebSocket events of users until their
+// clients ack them, see WSHub.WithEventReplay
+type UserEventRepository interface {
+	// Append sets the seq of the stored event
+	Append(ctx context.Context, event *models.UserEvent) error
+	ListPending(ctx context.Context, userID string, since time.Time) ([]*models.UserEvent, error)
+	// Ack marks the user's events up to seq acked
+	Ack(ctx context.Context, userID string, seq int64) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// JobRepository is the background job queue used by JobRunner
+type JobRepository interface {
+	// Enqueue returns false if a job with the same ID already exists
+	Enqueue(ctx context.Context, job *models.Job) (bool, error)
+	// Claim returns nil if no job is due
+	Claim(ctx context.Context) (*models.Job, error)
+	Complete(ctx context.Context, id string) error
+	Retry(ctx context.Context, id, lastError string, runAt time.Time) error
+	Fail(ctx context.Context, id, lastError string) error
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+	List(ctx context.Context, status string, limit int) ([]*models.Job, error)
+}
+
+// IdempotencyRepository stores responses to requests with an Idempotency-Key
+type IdempotencyRepository interface {
+	// Reserve returns false if a record newer than expiredBefore exists
+	Reserve(ctx context.Context, userID, key, requestHash string, expiredBefore time.Time) (bool, error)
+	Get(ctx context.Context, userID, key string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, userID, key string, statusCode int, headers map[string]string, body []byte) error
+	Delete(ctx context.Context, userID, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// StatsRepository stores usage activity and daily rollups
+type StatsRepository interface {
+	RecordActivity(ctx context.Context, userID string, day time.Time) error
+	RecordConnectionPeak(ctx context.Context, day time.Time, instanceID string, peak int) error
+	Rollup(ctx context.Context, day time.Time, storageObjects, storageBytes int64) (*models.DailyStats, error)
+	List(ctx context.Context, days int) ([]*models.DailyStats, error)
+	DeleteActivityBefore(ctx context.Context, day time.Time) (int64, error)
+}
+
+// EventRepository stores client analytics events
+type EventRepository interface {
+	CreateBatch(ctx context.Context, events []*models.ClientEvent) error
+	DeleteReceivedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository stores webhooks and their delivery log
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	Update(ctx context.Context, webhook *models.Webhook) error
+	// GetByID returns domain.ErrWebhookNotFound for unknown IDs
+	GetByID(ctx context.Context, id string) (*models.Webhook, error)
+	List(ctx context.Context) ([]*models.Webhook, error)
+	ListSubscribed(ctx context.Context, eventType, appID string) ([]*models.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetDelivery returns domain.ErrWebhookDeliveryNotFound for unknown IDs
+	GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id, status string, responseStatus *int, lastError *string) error
+	ListDeliveries(ctx context.Context, webhookID, status string, limit int) ([]*models.WebhookDelivery, error)
+	DeleteDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
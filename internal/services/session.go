@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// refreshTokenBytes is the entropy of a refresh token
+const refreshTokenBytes = 32
+
+// Tokens are the credentials a refresh returns
+type Tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// WithSessions makes new users sign in with short-lived access tokens and a
+// refresh token stored in repo. Access tokens carry their session, so a
+// logout revokes them before they expire. Tokens issued without sessions
+// stay valid.
+func (s *UserService) WithSessions(repo RefreshTokenRepository, cfg config.JWTConfig) *UserService {
+	s.sessions = repo
+	s.accessTTL = cfg.AccessTTL
+	s.refreshTTL = cfg.RefreshTTL
+	return s
+}
+
+// startSession stores the first refresh token of a new session and returns it
+func (s *UserService) startSession(ctx context.Context, sessionID, userID string) (string, error) {
+	value, token, err := s.newRefreshToken(sessionID, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.sessions.Create(ctx, token); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// newRefreshToken generates a refresh token of the session and returns its
+// value along with the record storing its hash
+func (s *UserService) newRefreshToken(sessionID, userID string) (string, *models.RefreshToken, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now().UTC()
+	return value, &models.RefreshToken{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		UserID:    userID,
+		TokenHash: hashRefreshToken(value),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+func hashRefreshToken(value string) []byte {
+	hash := sha256.Sum256([]byte(value))
+	return hash[:]
+}
+
+// Refresh exchanges a refresh token for a new access token and the next
+// refresh token of the session; the presented one can't be used again.
+// Presenting an already rotated token means it leaked, so the whole
+// session is revoked.
+func (s *UserService) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	if s.sessions == nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	current, err := s.sessions.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	logger := log.Ctx(ctx).With().Str("user_id", current.UserID).Str("session_id", current.SessionID).Logger()
+
+	if current.RevokedAt != nil {
+		logger.Warn().Msg("Revoked refresh token reused, revoking the session")
+		if err := s.sessions.RevokeSession(ctx, current.SessionID, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if !current.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := s.ActiveUser(ctx, current.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, next, err := s.newRefreshToken(current.SessionID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.sessions.Rotate(ctx, current.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Параллельный refresh тем же токеном уже выдал следующий
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	token, err := s.GenerateJWT(user.ID, user.AppID, current.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug().Msg("Session refreshed")
+
+	return &Tokens{
+		Token:        token,
+		RefreshToken: value,
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Logout revokes the session of the refresh token, its access tokens
+// included. Logging out of an ended session succeeds.
+func (s *UserService) Logout(ctx context.Context, refreshToken string) error {
+	if s.sessions == nil {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	current, err := s.sessions.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeSession(ctx, current.SessionID, time.Now().UTC()); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Str("user_id", current.UserID).Str("session_id", current.SessionID).Msg("Session ended")
+	return nil
+}
+
+// SessionActive reports whether the session of the token is still active.
+// Tokens issued without sessions can't be revoked and are always active.
+func (s *UserService) SessionActive(ctx context.Context, claims *UserClaims) (bool, error) {
+	if claims.SessionID == "" || s.sessions == nil {
+		return true, nil
+	}
+	return s.sessions.SessionActive(ctx, claims.SessionID, time.Now())
+}
+
+// RunSessionCleanup periodically deletes expired refresh tokens until ctx
+// is cancelled. Rotated tokens are kept until they expire so their reuse
+// is detected.
+func (s *UserService) RunSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.sessions.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete expired refresh tokens")
+				continue
+			}
+			if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Expired refresh tokens deleted")
+			}
+		}
+	}
+}
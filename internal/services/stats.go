@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// statsRollupJob is the job kind rolling up one day of statistics
+	statsRollupJob = "stats_rollup"
+	// statsRollupDelay is how long after midnight UTC the previous day is rolled up
+	statsRollupDelay = 10 * time.Minute
+	// statsSampleEvery is how often connection peaks are saved and metrics refreshed
+	statsSampleEvery = time.Minute
+	// statsRawRetention keeps raw activity long enough to recompute weekly actives
+	statsRawRetention = 35 * 24 * time.Hour
+	statsDayLayout    = "2006-01-02"
+)
+
+// statsRollupPayload is the payload of a stats_rollup job
+type statsRollupPayload struct {
+	Day string `json:"day"`
+}
+
+// StatsService records usage and maintains the nightly statistics rollup
+type StatsService struct {
+	repo         StatsRepository
+	photoService *PhotoService
+	hub          *WSHub
+	jobRunner    *JobRunner
+	instanceID   string
+
+	// active holds users already recorded as active on activeDay, so each
+	// instance writes at most once per user per day
+	mu        sync.Mutex
+	activeDay string
+	active    map[string]struct{}
+}
+
+// NewStatsService creates a stats service and registers the rollup job handler
+func NewStatsService(
+	repo StatsRepository,
+	photoService *PhotoService,
+	hub *WSHub,
+	jobRunner *JobRunner,
+	instanceID string,
+) *StatsService {
+	s := &StatsService{
+		repo:         repo,
+		photoService: photoService,
+		hub:          hub,
+		jobRunner:    jobRunner,
+		instanceID:   instanceID,
+		active:       make(map[string]struct{}),
+	}
+	jobRunner.Register(statsRollupJob, s.rollup)
+	return s
+}
+
+// RecordActivity marks the user as active today. Errors are logged and
+// never fail the caller's request.
+func (s *StatsService) RecordActivity(ctx context.Context, userID string) {
+	today := utcDay(time.Now())
+	key := today.Format(statsDayLayout)
+
+	s.mu.Lock()
+	if s.activeDay != key {
+		s.activeDay = key
+		s.active = make(map[string]struct{})
+	}
+	_, seen := s.active[userID]
+	s.active[userID] = struct{}{}
+	s.mu.Unlock()
+
+	if seen {
+		return
+	}
+
+	if err := s.repo.RecordActivity(ctx, userID, today); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Failed to record user activity")
+
+		s.mu.Lock()
+		if s.activeDay == key {
+			delete(s.active, userID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Schedule enqueues the rollups of yesterday and today. Every instance calls
+// it on startup; the jobs are created once.
+func (s *StatsService) Schedule(ctx context.Context) error {
+	today := utcDay(time.Now())
+	if err := s.scheduleRollup(ctx, today.AddDate(0, 0, -1)); err != nil {
+		return err
+	}
+	return s.scheduleRollup(ctx, today)
+}
+
+// List returns the rollups of the last days, newest first
+func (s *StatsService) List(ctx context.Context, days int) ([]*models.DailyStats, error) {
+	return s.repo.List(ctx, days)
+}
+
+// Run saves this instance's WebSocket connection peak and refreshes the
+// statistics metrics until ctx is cancelled
+func (s *StatsService) Run(ctx context.Context) {
+	s.refreshMetrics(ctx)
+
+	ticker := time.NewTicker(statsSampleEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.savePeak(ctx)
+			s.refreshMetrics(ctx)
+		}
+	}
+}
+
+// savePeak stores the local connection peak since the previous sample
+func (s *StatsService) savePeak(ctx context.Context) {
+	peak := s.hub.TakeConnectionPeak()
+	if err := s.repo.RecordConnectionPeak(ctx, utcDay(time.Now()), s.instanceID, peak); err != nil {
+		log.Error().Err(err).Msg("Failed to save WebSocket connection peak")
+	}
+}
+
+// refreshMetrics publishes the latest rollup; every instance reports the same values
+func (s *StatsService) refreshMetrics(ctx context.Context) {
+	latest, err := s.repo.List(ctx, 1)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load latest stats")
+		return
+	}
+	if len(latest) == 0 {
+		return
+	}
+
+	stats := latest[0]
+	metrics.ActiveUsers.WithLabelValues("daily").Set(float64(stats.DailyActiveUsers))
+	metrics.ActiveUsers.WithLabelValues("weekly").Set(float64(stats.WeeklyActiveUsers))
+	metrics.DailyNewPairs.Set(float64(stats.NewPairs))
+	metrics.DailyMomentsCompleted.Set(float64(stats.MomentsCompleted))
+	metrics.StorageUsedBytes.Set(float64(stats.StorageBytes))
+	metrics.DailyWSPeakConnections.Set(float64(stats.WSPeakConnections))
+}
+
+// scheduleRollup enqueues the rollup of day shortly after the day ends
+func (s *StatsService) scheduleRollup(ctx context.Context, day time.Time) error {
+	key := day.Format(statsDayLayout)
+	runAt := day.AddDate(0, 0, 1).Add(statsRollupDelay)
+	if _, err := s.jobRunner.EnqueueOnce(ctx, statsRollupJob, key, statsRollupPayload{Day: key}, runAt); err != nil {
+		return fmt.Errorf("failed to schedule stats rollup for %s: %w", key, err)
+	}
+	return nil
+}
+
+// rollup handles a stats_rollup job
+func (s *StatsService) rollup(ctx context.Context, payload json.RawMessage) error {
+	var p statsRollupPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid stats rollup payload: %w", err)
+	}
+	day, err := time.Parse(statsDayLayout, p.Day)
+	if err != nil {
+		return fmt.Errorf("invalid stats rollup day: %w", err)
+	}
+
+	// Следующая ночь планируется сразу, чтобы цепочка не прервалась из-за ошибки
+	if err := s.scheduleRollup(ctx, day.AddDate(0, 0, 1)); err != nil {
+		return err
+	}
+
+	objects, bytes, err := s.photoService.StorageUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats, err := s.repo.Rollup(ctx, day, objects, bytes)
+	if err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().
+		Str("day", p.Day).
+		Int("daily_active_users", stats.DailyActiveUsers).
+		Int("new_pairs", stats.NewPairs).
+		Int("moments_completed", stats.MomentsCompleted).
+		Msg("Stats rolled up")
+
+	deleted, err := s.repo.DeleteActivityBefore(ctx, day.Add(-statsRawRetention))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to delete old activity")
+	} else if deleted > 0 {
+		log.Ctx(ctx).Debug().Int64("deleted", deleted).Msg("Old activity deleted")
+	}
+
+	s.refreshMetrics(ctx)
+	return nil
+}
+
+// utcDay returns midnight UTC of the day containing t
+func utcDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrStorageUnavailable is returned without calling S3 while the circuit breaker is open
+var ErrStorageUnavailable = errors.New("storage is temporarily unavailable")
+
+const (
+	storageRetryBaseDelay = 100 * time.Millisecond
+	storageRetryMaxDelay  = 2 * time.Second
+)
+
+// storageRetryables decides which S3 errors are worth retrying: throttling,
+// 5xx responses and connection failures
+var storageRetryables = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops calling the storage endpoint after threshold
+// consecutive transient failures. After cooldown a single probe call is let
+// through; its outcome closes or reopens the circuit.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+}
+
+// newCircuitBreaker creates a closed circuit breaker
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	metrics.StorageCircuitOpen.Set(0)
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go to the storage endpoint
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		// Пока идет пробный запрос, остальные получают отказ сразу
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a call that reached the endpoint
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != breakerClosed {
+		b.setState(breakerClosed)
+	}
+}
+
+// failure records a call that failed with a transient error
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		if b.state != breakerOpen {
+			b.setState(breakerOpen)
+		}
+	}
+}
+
+// release frees the half-open probe slot when the call was abandoned by the caller
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// setState must be called with b.mu held
+func (b *circuitBreaker) setState(state breakerState) {
+	log.Warn().
+		Str("from", b.state.String()).
+		Str("to", state.String()).
+		Int("failures", b.failures).
+		Msg("Storage circuit breaker state changed")
+	b.state = state
+	if state == breakerClosed {
+		metrics.StorageCircuitOpen.Set(0)
+	} else {
+		metrics.StorageCircuitOpen.Set(1)
+	}
+}
+
+// storageGuard runs S3 calls with per-attempt timeouts, retries with
+// jittered exponential backoff and a shared circuit breaker
+type storageGuard struct {
+	timeout     time.Duration
+	maxAttempts int
+	breaker     *circuitBreaker
+}
+
+// newStorageGuard creates a storage guard
+func newStorageGuard(timeout time.Duration, maxAttempts, breakerThreshold int, breakerCooldown time.Duration) *storageGuard {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &storageGuard{
+		timeout:     timeout,
+		maxAttempts: maxAttempts,
+		breaker:     newCircuitBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+// do runs op until it succeeds, fails with a permanent error or runs out of
+// attempts. Returns ErrStorageUnavailable if the circuit is open.
+func (g *storageGuard) do(ctx context.Context, name string, op func(ctx context.Context) error) error {
+	if !g.breaker.allow() {
+		metrics.StorageOperations.WithLabelValues(name, "rejected").Inc()
+		return ErrStorageUnavailable
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = g.attempt(ctx, op)
+		if err == nil {
+			g.breaker.success()
+			metrics.StorageOperations.WithLabelValues(name, "ok").Inc()
+			return nil
+		}
+
+		// Запрос отменил клиент - хранилище тут ни при чем
+		if ctx.Err() != nil {
+			g.breaker.release()
+			metrics.StorageOperations.WithLabelValues(name, "canceled").Inc()
+			return err
+		}
+
+		if !g.transient(err) {
+			// Endpoint ответил (например 403/404), значит он доступен
+			g.breaker.success()
+			metrics.StorageOperations.WithLabelValues(name, "error").Inc()
+			return err
+		}
+
+		if attempt >= g.maxAttempts {
+			break
+		}
+
+		delay := backoffDelay(attempt)
+		log.Ctx(ctx).Debug().
+			Err(err).
+			Str("operation", name).
+			Int("attempt", attempt).
+			Dur("delay", delay).
+			Msg("Transient storage error, retrying")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			g.breaker.release()
+			metrics.StorageOperations.WithLabelValues(name, "canceled").Inc()
+			return err
+		}
+	}
+
+	g.breaker.failure()
+	metrics.StorageOperations.WithLabelValues(name, "failed").Inc()
+	return fmt.Errorf("%s failed after %d attempts: %w", name, g.maxAttempts, err)
+}
+
+// attempt runs op once under the per-attempt timeout
+func (g *storageGuard) attempt(ctx context.Context, op func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	return op(ctx)
+}
+
+// transient reports whether err is worth retrying. Attempt timeouts count as
+// transient since they mean the endpoint is not answering.
+func (g *storageGuard) transient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return storageRetryables.IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// backoffDelay returns a jittered exponential delay before the next attempt
+func backoffDelay(attempt int) time.Duration {
+	delay := storageRetryBaseDelay << (attempt - 1)
+	if delay > storageRetryMaxDelay || delay <= 0 {
+		delay = storageRetryMaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+	"sync-photo-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// SyncSessionLimits bound the pages of sync sessions
+var SyncSessionLimits = pagination.Limits{Default: 20, Max: 50}
+
+// captureDeadlineJob is the job kind closing a countdown session whose
+// photos weren't uploaded in time
+const captureDeadlineJob = "capture_deadline"
+
+// captureDeadlinePayload is the payload of a capture_deadline job
+type captureDeadlinePayload struct {
+	SessionID string `json:"session_id"`
+}
+
+// captureMissedData is the data of capture_missed
+type captureMissedData struct {
+	// Missing are the users whose photo wasn't uploaded in time
+	Missing []string `json:"missing"`
+}
+
+// SyncSessionService correlates the photos partners take for one
+// trigger_photo. The trigger creates a session, take_photo carries its ID,
+// and uploads made with it become the halves of the session.
+type SyncSessionService struct {
+	repo     SyncSessionRepository
+	pairRepo PairRepository
+	photos   *PhotoService
+	hub      *WSHub
+	// jobRunner and uploadWindow are set by WithCaptureDeadline
+	jobRunner    *JobRunner
+	uploadWindow time.Duration
+}
+
+// NewSyncSessionService creates a sync session service announcing completed
+// sessions through hub
+func NewSyncSessionService(repo SyncSessionRepository, pairRepo PairRepository, photos *PhotoService, hub *WSHub) *SyncSessionService {
+	return &SyncSessionService{
+		repo:     repo,
+		pairRepo: pairRepo,
+		photos:   photos,
+		hub:      hub,
+	}
+}
+
+// WithCaptureDeadline gives both partners uploadWindow after a countdown
+// capture to upload their photos; a session still incomplete then is
+// missed, and both get capture_missed
+func (s *SyncSessionService) WithCaptureDeadline(jobRunner *JobRunner, uploadWindow time.Duration) *SyncSessionService {
+	s.jobRunner = jobRunner
+	s.uploadWindow = uploadWindow
+	jobRunner.Register(captureDeadlineJob, s.captureDeadline)
+	return s
+}
+
+// Start creates the session of a trigger_photo. timestamp is the trigger
+// time in Unix milliseconds; 0 means now. captureAt is the countdown
+// capture time, zero without a countdown.
+func (s *SyncSessionService) Start(ctx context.Context, pair *models.Pair, initiatorID string, timestamp int64, captureAt time.Time) (*models.SyncSession, error) {
+	now := time.Now().UTC()
+	triggeredAt := now
+	if timestamp != 0 {
+		triggeredAt = time.UnixMilli(timestamp).UTC()
+	}
+
+	session := &models.SyncSession{
+		ID:          uuid.New().String(),
+		PairID:      pair.ID,
+		InitiatorID: initiatorID,
+		TriggeredAt: triggeredAt,
+		CreatedAt:   now,
+		Photos:      []*models.Photo{},
+	}
+	if !captureAt.IsZero() && s.jobRunner != nil {
+		captureAt = captureAt.UTC()
+		deadline := captureAt.Add(s.uploadWindow)
+		session.CaptureAt, session.Deadline = &captureAt, &deadline
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	if session.Deadline == nil {
+		return session, nil
+	}
+
+	// Задание на случай, если сессия не завершится к сроку
+	_, err := s.jobRunner.EnqueueAt(ctx, captureDeadlineJob, captureDeadlinePayload{SessionID: session.ID}, *session.Deadline)
+	if err != nil {
+		if deleteErr := s.repo.Delete(ctx, session.ID); deleteErr != nil {
+			log.Ctx(ctx).Error().Err(deleteErr).Str("session_id", session.ID).Msg("Failed to delete sync session without deadline")
+		}
+		return nil, fmt.Errorf("failed to schedule capture deadline: %w", err)
+	}
+	return session, nil
+}
+
+// Cancel deletes the session of a trigger that reached nobody
+func (s *SyncSessionService) Cancel(ctx context.Context, sessionID string) error {
+	return s.repo.Delete(ctx, sessionID)
+}
+
+// Get returns a session of the user's pair with its photos
+func (s *SyncSessionService) Get(ctx context.Context, userID, sessionID string) (*models.SyncSession, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.sessionOfPair(ctx, pair, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.photos.withViewURLs(ctx, session.Photos); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListByUser returns a page of the sessions of the user's pair, newest
+// first, and their total
+func (s *SyncSessionService) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.SyncSession, int, error) {
+	pair, err := pairOfUser(ctx, s.pairRepo, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sessions, total, err := s.repo.ListByPair(ctx, pair.ID, SyncSessionLimits.Clamp(limit), max(offset, 0))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, session := range sessions {
+		if err := s.photos.withViewURLs(ctx, session.Photos); err != nil {
+			return nil, 0, err
+		}
+	}
+	return sessions, total, nil
+}
+
+// openSessionOfPair returns the session like sessionOfPair if photos can
+// still be uploaded for it, domain.ErrSyncSessionMissed after its deadline
+func (s *SyncSessionService) openSessionOfPair(ctx context.Context, pair *models.Pair, sessionID string) (*models.SyncSession, error) {
+	session, err := s.sessionOfPair(ctx, pair, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.MissedAt != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSyncSessionMissed, sessionID)
+	}
+	return session, nil
+}
+
+// sessionOfPair returns the session if it belongs to the pair, so users
+// can't tell sessions of other pairs from missing ones
+func (s *SyncSessionService) sessionOfPair(ctx context.Context, pair *models.Pair, sessionID string) (*models.SyncSession, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.PairID != pair.ID {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSyncSessionNotFound, sessionID)
+	}
+	return session, nil
+}
+
+// photoConfirmed makes a confirmed photo its owner's half of the session
+// and sends session_complete to both partners once the other half is
+// there. Errors are logged and never fail the upload.
+func (s *SyncSessionService) photoConfirmed(ctx context.Context, pair *models.Pair, photo *models.Photo) {
+	logger := log.Ctx(ctx).With().Str("session_id", *photo.SessionID).Str("photo_id", photo.ID).Logger()
+
+	completed, err := s.repo.AttachPhoto(ctx, *photo.SessionID, photo, time.Now().UTC())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to attach photo to sync session")
+		return
+	}
+	if !completed {
+		return
+	}
+
+	session, err := s.repo.GetByID(ctx, *photo.SessionID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get completed sync session")
+		return
+	}
+	if err := s.photos.withViewURLs(ctx, session.Photos); err != nil {
+		logger.Error().Err(err).Msg("Failed to sign sync session photos")
+		return
+	}
+	message := WSMessage{
+		Type:      "session_complete",
+		SessionID: session.ID,
+		Data:      session,
+	}
+	// Оффлайн-партнер увидит сессию в GET /api/v1/sessions
+	for _, userID := range []string{pair.UserAID, pair.UserBID} {
+		if err := s.hub.SendToUser(userID, message); err != nil {
+			logger.Debug().Err(err).Str("user_id", userID).Msg("Failed to send session_complete")
+		}
+	}
+	metrics.SyncSessions.WithLabelValues("completed").Inc()
+	logger.Info().Str("pair_id", pair.ID).Msg("Sync session completed")
+}
+
+// captureDeadline handles a capture_deadline job: a session still
+// incomplete is marked missed and both partners get capture_missed with
+// the users whose photo is missing. Sessions completed or deleted since
+// are left alone.
+func (s *SyncSessionService) captureDeadline(ctx context.Context, payload json.RawMessage) error {
+	var p captureDeadlinePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid capture deadline payload: %w", err)
+	}
+
+	missed, err := s.repo.MarkMissed(ctx, p.SessionID, time.Now().UTC())
+	if err != nil || !missed {
+		return err
+	}
+	metrics.SyncSessions.WithLabelValues("missed").Inc()
+
+	session, err := s.repo.GetByID(ctx, p.SessionID)
+	if err != nil {
+		return err
+	}
+	pair, err := s.pairRepo.GetByID(ctx, session.PairID)
+	if errors.Is(err, domain.ErrPairNotFound) {
+		// Пара удалена, сообщать некому
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	uploaded := make(map[string]bool, len(session.Photos))
+	for _, photo := range session.Photos {
+		uploaded[photo.UserID] = true
+	}
+	data := captureMissedData{Missing: []string{}}
+	for _, userID := range []string{pair.UserAID, pair.UserBID} {
+		if !uploaded[userID] {
+			data.Missing = append(data.Missing, userID)
+		}
+	}
+
+	logger := log.Ctx(ctx).With().Str("session_id", session.ID).Logger()
+	message := WSMessage{Type: "capture_missed", SessionID: session.ID, Data: data}
+	for _, userID := range []string{pair.UserAID, pair.UserBID} {
+		if err := s.hub.SendToUser(userID, message); err != nil {
+			logger.Debug().Err(err).Str("user_id", userID).Msg("Failed to send capture_missed")
+		}
+	}
+	logger.Info().Str("pair_id", pair.ID).Strs("missing", data.Missing).Msg("Sync session missed")
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+// reapBatchSize is how many abandoned uploads are deleted in one
+// transaction; their rows stay locked while the objects are deleted
+const reapBatchSize = 50
+
+// RunReaper periodically deletes photos whose upload wasn't completed
+// within ttl, with whatever was uploaded for them, until ctx is cancelled.
+// ttl must exceed the upload URL lifetime, see config.UploadsConfig.
+func (s *PhotoService) RunReaper(ctx context.Context, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reaped, err := s.ReapAbandonedUploads(ctx, time.Now().Add(-ttl))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to reap abandoned uploads")
+				continue
+			}
+			if reaped > 0 {
+				log.Info().Int("photos", reaped).Msg("Abandoned uploads reaped")
+			}
+		}
+	}
+}
+
+// ReapAbandonedUploads deletes the photos created before createdBefore
+// that were never completed, together with their objects, and returns how
+// many photos it deleted. A photo whose object can't be deleted is kept
+// for the next run.
+func (s *PhotoService) ReapAbandonedUploads(ctx context.Context, createdBefore time.Time) (int, error) {
+	total := 0
+	for {
+		failed := false
+		deleted, err := s.photoRepo.DeletePending(ctx, createdBefore, reapBatchSize, func(photos []*models.Photo) []string {
+			removed := make([]string, 0, len(photos))
+			for _, photo := range photos {
+				if err := s.deleteObject(ctx, photo.S3Key); err != nil {
+					log.Warn().Err(err).Str("photo_id", photo.ID).Msg("Failed to delete object of abandoned upload")
+					failed = true
+					continue
+				}
+				removed = append(removed, photo.ID)
+			}
+			return removed
+		})
+		total += deleted
+		metrics.UploadsReaped.WithLabelValues("photo").Add(float64(deleted))
+		if err != nil {
+			return total, err
+		}
+		// Неполная пачка — больше нечего удалять; при ошибках хранилища ждем следующего запуска
+		if deleted < reapBatchSize || failed {
+			return total, nil
+		}
+	}
+}
+
+// deleteObject deletes the object of an abandoned upload if the client
+// uploaded one
+func (s *PhotoService) deleteObject(ctx context.Context, key string) error {
+	var size int64
+	err := s.guard.do(ctx, "HeadObject", func(ctx context.Context) error {
+		var err error
+		size, _, err = s.store.Stat(ctx, key)
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.guard.do(ctx, "DeleteObject", func(ctx context.Context) error {
+		return s.store.Delete(ctx, key)
+	})
+	if err != nil {
+		return err
+	}
+	metrics.UploadsReaped.WithLabelValues("object").Inc()
+	metrics.UploadBytesReaped.Add(float64(size))
+	return nil
+}
@@ -3,35 +3,132 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"time"
 
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/i18n"
 	"sync-photo-backend/internal/models"
-	"sync-photo-backend/internal/repository"
+	"sync-photo-backend/internal/tracing"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 const (
 	codeLength = 6
 	codeChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// jwtExpDays is the lifetime of tokens issued without sessions
 	jwtExpDays = 365
+
+	// userPurgeJob is the job kind removing a deleted account for good
+	userPurgeJob = "user_purge"
+	// userObjectsJob is the job kind deleting the objects of a purged
+	// account, once the purge committed
+	userObjectsJob = "user_objects_delete"
+	// userObjectsBatch bounds the object keys of one user_objects_delete job
+	userObjectsBatch = 1000
+
+	// settingsAttempts bounds the retries of an unconditional settings
+	// update racing with other writes
+	settingsAttempts = 3
 )
 
+// userPurgePayload is the payload of a user_purge job
+type userPurgePayload struct {
+	UserID string `json:"user_id"`
+}
+
+// userObjectsPayload is the payload of a user_objects_delete job
+type userObjectsPayload struct {
+	UserID string   `json:"user_id"`
+	Keys   []string `json:"keys"`
+}
+
+// ErrUnknownApp is returned when a client names an app that isn't configured
+var ErrUnknownApp = errors.New("unknown app")
+
+// ErrUnsupportedLocale is returned for locales without a message catalog
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo  *repository.UserRepository
+	userRepo  UserRepository
 	jwtSecret string
+	// apps are the IDs users can be created in
+	apps map[string]bool
+
+	uow         UnitOfWork
+	jobRunner   *JobRunner
+	pairService *PairService
+	hub         *WSHub
+	photos      *PhotoService
+	compositor  *CompositorService
+	accounts    config.AccountsConfig
+
+	// sessions is nil unless WithSessions was called
+	sessions   RefreshTokenRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo *repository.UserRepository, jwtSecret string) *UserService {
+func NewUserService(userRepo UserRepository, jwtSecret string) *UserService {
 	return &UserService{
 		userRepo:  userRepo,
 		jwtSecret: jwtSecret,
+		apps:      map[string]bool{config.DefaultAppID: true},
+	}
+}
+
+// WithApps allows creating users in the configured white-label apps
+func (s *UserService) WithApps(apps map[string]config.AppConfig) *UserService {
+	s.apps = make(map[string]bool, len(apps))
+	for id := range apps {
+		s.apps[id] = true
 	}
+	s.apps[config.DefaultAppID] = true
+	return s
+}
+
+// WithAccountDeletion enables deleting accounts: deleted users are purged
+// by a user_purge job after cfg.RecoveryWindow, and their pair is deleted
+// through pairService so the partner is notified. uow makes marking and
+// purging a user atomic with the job and the pair. hub closes the
+// connection of a user deleting their account; photos and compositor
+// delete the objects of the user's pairs unless cfg.SharedContent is
+// "anonymize".
+func (s *UserService) WithAccountDeletion(
+	uow UnitOfWork,
+	jobRunner *JobRunner,
+	pairService *PairService,
+	hub *WSHub,
+	photos *PhotoService,
+	compositor *CompositorService,
+	cfg config.AccountsConfig,
+) *UserService {
+	s.uow = uow
+	s.jobRunner = jobRunner
+	s.pairService = pairService
+	s.hub = hub
+	s.photos = photos
+	s.compositor = compositor
+	s.accounts = cfg
+	jobRunner.Register(userPurgeJob, s.purge)
+	jobRunner.Register(userObjectsJob, s.deleteObjects)
+	return s
+}
+
+// recoveryWindow is how long a deleted account can be restored; zero
+// purges it right away
+func (s *UserService) recoveryWindow() time.Duration {
+	return max(s.accounts.RecoveryWindow, 0)
 }
 
 // GenerateUniqueCode generates a unique 6-character code
@@ -60,12 +157,28 @@ func generateCode() string {
 	return string(code)
 }
 
-// GenerateJWT generates a JWT token for a user
-func (s *UserService) GenerateJWT(userID string) (string, error) {
+// UserClaims identifies the user a token was issued to
+type UserClaims struct {
+	UserID string
+	AppID  string
+	// SessionID is empty in tokens issued before sessions, which can't be revoked
+	SessionID string
+}
+
+// GenerateJWT generates a JWT token for a user of the app. A token of a
+// session carries its ID as sid and expires after the access TTL; without
+// a session it is valid for jwtExpDays.
+func (s *UserService) GenerateJWT(userID, appID, sessionID string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().AddDate(0, 0, jwtExpDays).Unix(),
-		"iat":     time.Now().Unix(),
+		"app_id":  appID,
+		"exp":     now.AddDate(0, 0, jwtExpDays).Unix(),
+		"iat":     now.Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+		claims["exp"] = now.Add(s.accessTTL).Unix()
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -77,8 +190,9 @@ func (s *UserService) GenerateJWT(userID string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateJWT validates a JWT token and returns the user ID
-func (s *UserService) ValidateJWT(tokenString string) (string, error) {
+// ValidateJWT validates a JWT token and returns its claims. Tokens issued
+// before multi-tenancy have no app_id and belong to the default app.
+func (s *UserService) ValidateJWT(tokenString string) (*UserClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -87,28 +201,85 @@ func (s *UserService) ValidateJWT(tokenString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", fmt.Errorf("invalid token claims")
+		return nil, fmt.Errorf("invalid token claims")
 	}
 
 	userID, ok := claims["user_id"].(string)
 	if !ok {
-		return "", fmt.Errorf("user_id not found in token")
+		return nil, fmt.Errorf("user_id not found in token")
+	}
+
+	appID, _ := claims["app_id"].(string)
+	if appID == "" {
+		appID = config.DefaultAppID
+	}
+
+	sessionID, _ := claims["sid"].(string)
+
+	return &UserClaims{UserID: userID, AppID: appID, SessionID: sessionID}, nil
+}
+
+// GenerateAdminJWT generates a JWT that grants access to admin endpoints
+func (s *UserService) GenerateAdminJWT(subject string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"admin": true,
+		"sub":   subject,
+		"exp":   time.Now().Add(ttl).Unix(),
+		"iat":   time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidateAdminJWT validates an admin JWT and returns its subject
+func (s *UserService) ValidateAdminJWT(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return "", fmt.Errorf("invalid token")
+	}
+
+	if admin, _ := claims["admin"].(bool); !admin {
+		return "", fmt.Errorf("not an admin token")
 	}
 
-	return userID, nil
+	subject, _ := claims["sub"].(string)
+	return subject, nil
 }
 
-// CreateUser creates a new anonymous user
-func (s *UserService) CreateUser(ctx context.Context) (*models.User, error) {
+// CreateUser creates a new anonymous user of the app
+func (s *UserService) CreateUser(ctx context.Context, appID string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	if !s.apps[appID] {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownApp, appID)
+	}
+
 	// Generate unique code
 	code, err := s.GenerateUniqueCode(ctx)
 	if err != nil {
@@ -118,8 +289,14 @@ func (s *UserService) CreateUser(ctx context.Context) (*models.User, error) {
 	// Generate user ID
 	userID := uuid.New().String()
 
+	// С сессиями токен короткоживущий и продлевается refresh-токеном
+	var sessionID string
+	if s.sessions != nil {
+		sessionID = uuid.New().String()
+	}
+
 	// Generate JWT token
-	token, err := s.GenerateJWT(userID)
+	token, err := s.GenerateJWT(userID, appID, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
@@ -127,15 +304,29 @@ func (s *UserService) CreateUser(ctx context.Context) (*models.User, error) {
 	// Create user
 	user := &models.User{
 		ID:        userID,
+		AppID:     appID,
 		Code:      code,
 		Token:     token,
-		CreatedAt: time.Now(),
+		Timezone:  "UTC",
+		CreatedAt: time.Now().UTC(),
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	event, err := newDomainEvent(EventUserCreated, appID, userID, "", UserCreatedPayload{UserID: userID, AppID: appID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user, event); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	if sessionID != "" {
+		refreshToken, err := s.startSession(ctx, sessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		user.RefreshToken = refreshToken
+	}
+
 	return user, nil
 }
 
@@ -144,11 +335,361 @@ func (s *UserService) UpdatePushToken(ctx context.Context, userID string, pushTo
 	return s.userRepo.UpdatePushToken(ctx, userID, &pushToken)
 }
 
-// GetPushToken returns the push token for a user (nil if not set)
-func (s *UserService) GetPushToken(ctx context.Context, userID string) (*string, error) {
+// GetUser returns a user by ID
+func (s *UserService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// UpdateQuietHours updates quiet hours settings for a user
+func (s *UserService) UpdateQuietHours(ctx context.Context, userID string, start, end *string, timezone string, alwaysAllowTriggers bool) error {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if err := ValidateQuietHours(start, end, timezone); err != nil {
+		return err
+	}
+	return s.userRepo.UpdateQuietHours(ctx, userID, start, end, timezone, alwaysAllowTriggers)
+}
+
+// ActiveUser returns the user a valid token belongs to, domain.ErrUserDeleted
+// if the account is deleted
+func (s *UserService) ActiveUser(ctx context.Context, userID string) (*models.User, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	return user.PushToken, nil
+	if user.DeletedAt != nil {
+		return nil, domain.ErrUserDeleted
+	}
+	return user, nil
+}
+
+// DeleteUser deletes the account, ends all its sessions and closes its
+// WebSocket connection. Until it is purged after accounts.recovery_window
+// the user can't sign in, gets no pushes and can't be paired with, but
+// keeps the pair and photos so RestoreUser brings them back; the sessions
+// stay ended, so a restored account signs in again.
+func (s *UserService) DeleteUser(ctx context.Context, userID string) error {
+	deletedAt := time.Now().UTC()
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.SoftDelete(ctx, userID, deletedAt); err != nil {
+			return err
+		}
+		if s.sessions != nil {
+			if err := s.sessions.RevokeUser(ctx, userID, deletedAt); err != nil {
+				return err
+			}
+		}
+		_, err := s.jobRunner.EnqueueAt(ctx, userPurgeJob, userPurgePayload{UserID: userID}, deletedAt.Add(s.recoveryWindow()))
+		if err != nil {
+			return fmt.Errorf("failed to schedule user purge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Новые подключения и запросы отклоняются по deleted_at, открытое
+	// соединение закрывается явно
+	if err := s.hub.Disconnect(userID, WSCloseAccountDeleted, "account_deleted"); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("user_id", userID).Msg("Failed to close WebSocket of deleted user")
+	}
+	return nil
+}
+
+// RestoreUser restores a deleted account that hasn't been purged yet
+func (s *UserService) RestoreUser(ctx context.Context, userID string) error {
+	return s.userRepo.Restore(ctx, userID)
+}
+
+// RestoreAccount lets a user undo the deletion of their account within
+// accounts.recovery_window. The refresh token must be one the deletion
+// revoked, i.e. the current token of a session the user had then. The
+// account is restored and a new session started in one transaction; the
+// ended sessions stay ended.
+func (s *UserService) RestoreAccount(ctx context.Context, refreshToken string) (*Tokens, error) {
+	if s.sessions == nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	current, err := s.sessions.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if !current.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	var tokens *Tokens
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		// Блокировка, как в purgeUser: очистка либо уже прошла, либо
+		// увидит восстановленного пользователя
+		user, err := s.userRepo.GetForUpdate(ctx, current.UserID)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		// Удаление отзывает токены со своим deleted_at: отозванные раньше
+		// ротацией или выходом, а также удалением до прошлого
+		// восстановления, аккаунт не восстанавливают
+		if user.DeletedAt == nil || current.RevokedAt == nil || !current.RevokedAt.Equal(*user.DeletedAt) || s.purgeDue(user) {
+			return domain.ErrInvalidRefreshToken
+		}
+		if err := s.userRepo.Restore(ctx, user.ID); err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				return domain.ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		sessionID := uuid.New().String()
+		refreshToken, err := s.startSession(ctx, sessionID, user.ID)
+		if err != nil {
+			return err
+		}
+		token, err := s.GenerateJWT(user.ID, user.AppID, sessionID)
+		if err != nil {
+			return err
+		}
+		tokens = &Tokens{
+			Token:        token,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int(s.accessTTL.Seconds()),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Ctx(ctx).Info().Str("user_id", current.UserID).Msg("Deleted account restored by the user")
+	return tokens, nil
+}
+
+// errPurgeSkipped rolls back a purge of a user restored meanwhile
+var errPurgeSkipped = errors.New("user restored before purge")
+
+// purge handles a user_purge job. Users restored or deleted again since the
+// job was scheduled are left alone; a later job purges the latter.
+func (s *UserService) purge(ctx context.Context, payload json.RawMessage) error {
+	var p userPurgePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid user purge payload: %w", err)
+	}
+
+	// Пара удаляется вместе с пользователем или не удаляется вовсе
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.purgeUser(ctx, p.UserID)
+	})
+	if errors.Is(err, errPurgeSkipped) {
+		return nil
+	}
+	return err
+}
+
+// purgeDue reports whether the user is deleted and past the recovery window
+func (s *UserService) purgeDue(user *models.User) bool {
+	deletedBefore := time.Now().UTC().Add(-s.recoveryWindow())
+	return user.DeletedAt != nil && !user.DeletedAt.After(deletedBefore)
+}
+
+// purgeUser removes the user in the transaction in ctx. The user's row is
+// locked first, so a restore either commits before and the purge is
+// skipped, or waits and finds the user gone. Objects are deleted by
+// user_objects_delete jobs enqueued in the same transaction, so they go
+// only once the rows pointing at them are gone for good.
+func (s *UserService) purgeUser(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetForUpdate(ctx, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return errPurgeSkipped
+	}
+	if err != nil {
+		return err
+	}
+	if !s.purgeDue(user) {
+		return errPurgeSkipped
+	}
+
+	// Ключи читаются до удаления строк, которые на них указывают
+	var keys []string
+	if s.accounts.SharedContent != "anonymize" {
+		if keys, err = s.objectKeys(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	pair, err := s.pairService.GetPairByUserID(ctx, userID)
+	switch {
+	case err == nil:
+		if err := s.pairService.DeletePair(ctx, pair.ID, userID); err != nil {
+			return err
+		}
+	case !errors.Is(err, domain.ErrNotInPair):
+		return err
+	}
+
+	// Обезличенный пользователь остается, чтобы у партнера сохранились фото пары
+	purge := s.userRepo.Purge
+	if s.accounts.SharedContent == "anonymize" {
+		purge = s.userRepo.Anonymize
+	}
+	purged, err := purge(ctx, userID, time.Now().UTC().Add(-s.recoveryWindow()))
+	if err != nil {
+		return err
+	}
+	if !purged {
+		return errPurgeSkipped
+	}
+
+	for batch := range slices.Chunk(keys, userObjectsBatch) {
+		_, err := s.jobRunner.Enqueue(ctx, userObjectsJob, userObjectsPayload{UserID: userID, Keys: batch})
+		if err != nil {
+			return fmt.Errorf("failed to schedule object deletion: %w", err)
+		}
+	}
+	log.Ctx(ctx).Info().
+		Str("user_id", userID).
+		Str("shared_content", s.accounts.SharedContent).
+		Int("objects", len(keys)).
+		Msg("Deleted user purged")
+	return nil
+}
+
+// objectKeys returns the keys of the photos and composites of every pair
+// the user was in
+func (s *UserService) objectKeys(ctx context.Context, userID string) ([]string, error) {
+	pairs, err := s.pairService.GetPairHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, pair := range pairs {
+		photos, err := s.photos.pairObjectKeys(ctx, pair.ID)
+		if err != nil {
+			return nil, err
+		}
+		composites, err := s.compositor.pairObjectKeys(ctx, pair.ID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, photos...)
+		keys = append(keys, composites...)
+	}
+	return keys, nil
+}
+
+// deleteObjects handles a user_objects_delete job. Objects deleted before a
+// failed attempt are skipped by the retry.
+func (s *UserService) deleteObjects(ctx context.Context, payload json.RawMessage) error {
+	var p userObjectsPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid user objects payload: %w", err)
+	}
+	if err := s.photos.deleteObjects(ctx, p.Keys); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Str("user_id", p.UserID).Int("objects", len(p.Keys)).Msg("Objects of purged user removed")
+	return nil
+}
+
+// SavedLocale returns the supported locale the user saved; ok is false if
+// there is none
+func SavedLocale(user *models.User) (locale string, ok bool) {
+	if user.Locale == nil {
+		return "", false
+	}
+	return i18n.Negotiate("", *user.Locale)
+}
+
+// UpdateLocale sets the locale of the user's messages; "" clears it so
+// Accept-Language or English is used
+func (s *UserService) UpdateLocale(ctx context.Context, userID, locale string) error {
+	if locale == "" {
+		return s.userRepo.UpdateLocale(ctx, userID, nil)
+	}
+	if !i18n.IsSupported(locale) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedLocale, locale)
+	}
+	return s.userRepo.UpdateLocale(ctx, userID, &locale)
+}
+
+// QuietHours are the user's quiet hours; nil Start and End turn them off
+type QuietHours struct {
+	Start               *string
+	End                 *string
+	Timezone            string // UTC by default
+	AlwaysAllowTriggers bool
+}
+
+// SettingsUpdate holds the settings to change; nil fields keep their value
+type SettingsUpdate struct {
+	QuietHours    *QuietHours
+	TimeSensitive *bool
+	// Locale "" resets the locale to Accept-Language
+	Locale *string
+}
+
+// UpdateSettings applies update if the user meets pre and returns the
+// updated user. The write is conditional on the state it was computed from:
+// with a precondition a concurrent change fails it with
+// domain.ErrPreconditionFailed, without one it is retried on the new state.
+func (s *UserService) UpdateSettings(ctx context.Context, userID string, update SettingsUpdate, pre Precondition) (*models.User, error) {
+	if q := update.QuietHours; q != nil {
+		if q.Timezone == "" {
+			q.Timezone = "UTC"
+		}
+		if err := ValidateQuietHours(q.Start, q.End, q.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if l := update.Locale; l != nil && *l != "" && !i18n.IsSupported(*l) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLocale, *l)
+	}
+
+	for attempt := 1; ; attempt++ {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !pre.Allows(user.UpdatedAt) {
+			return nil, domain.ErrPreconditionFailed
+		}
+
+		readAt := user.UpdatedAt
+		update.apply(user)
+		err = s.userRepo.UpdateSettings(ctx, user, readAt)
+		if errors.Is(err, domain.ErrPreconditionFailed) && pre.IsZero() && attempt < settingsAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+}
+
+func (u SettingsUpdate) apply(user *models.User) {
+	if q := u.QuietHours; q != nil {
+		user.QuietHoursStart = q.Start
+		user.QuietHoursEnd = q.End
+		user.Timezone = q.Timezone
+		user.AlwaysAllowTriggers = q.AlwaysAllowTriggers
+	}
+	if u.TimeSensitive != nil {
+		user.TimeSensitive = *u.TimeSensitive
+	}
+	if u.Locale != nil {
+		user.Locale = u.Locale
+		if *u.Locale == "" {
+			user.Locale = nil
+		}
+	}
+}
+
+// UpdateTimeSensitiveTriggers sets the user's opt-in for time-sensitive trigger pushes
+func (s *UserService) UpdateTimeSensitiveTriggers(ctx context.Context, userID string, enabled bool) error {
+	return s.userRepo.UpdateTimeSensitiveTriggers(ctx, userID, enabled)
 }
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"sync-photo-backend/internal/config"
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Webhook event types
+const (
+	WebhookPairCreated     = "pair_created"
+	WebhookPairDeleted     = "pair_deleted"
+	WebhookTriggerStarted  = "trigger_started"
+	WebhookPhotoUploaded   = "photo_uploaded"
+	WebhookMomentCompleted = "moment_completed"
+)
+
+// WebhookEvents lists the events webhooks can subscribe to
+var WebhookEvents = []string{
+	WebhookPairCreated, WebhookPairDeleted, WebhookTriggerStarted, WebhookPhotoUploaded, WebhookMomentCompleted,
+}
+
+// webhookEventTypes maps domain events to the webhook events they are sent as.
+// Domain events missing here aren't sent to webhooks.
+var webhookEventTypes = map[string]string{
+	EventPairCreated:     WebhookPairCreated,
+	EventPairDeleted:     WebhookPairDeleted,
+	EventTriggerStarted:  WebhookTriggerStarted,
+	EventPhotoConfirmed:  WebhookPhotoUploaded,
+	EventMomentCompleted: WebhookMomentCompleted,
+}
+
+// WebhookConsumer is the name of the event log consumer dispatching webhooks
+const WebhookConsumer = "webhooks"
+
+// Headers sent with every webhook delivery
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	// webhookDeliveryJob is the job kind sending one delivery
+	webhookDeliveryJob = "webhook_delivery"
+	// webhookErrorBodyLimit is how much of a failed response is kept in the log
+	webhookErrorBodyLimit = 512
+	webhookCleanupEvery   = time.Hour
+)
+
+var (
+	// ErrInvalidWebhook is returned for webhooks with a bad URL or event list
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookNotFound is returned when a webhook doesn't exist
+	ErrWebhookNotFound = domain.ErrWebhookNotFound
+)
+
+// WebhookEvent is the JSON body POSTed to webhook endpoints
+type WebhookEvent struct {
+	// ID is the domain event's ID; it is the same for every webhook receiving
+	// it and for redeliveries, so receivers can deduplicate
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	AppID     string    `json:"app_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Data is the domain event's payload
+	Data json.RawMessage `json:"data"`
+}
+
+// webhookDeliveryPayload is the payload of a webhook_delivery job
+type webhookDeliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// WebhookService manages admin-configured webhooks and delivers domain
+// events from the event log to them. Deliveries are logged and retried with
+// backoff by the job runner.
+type WebhookService struct {
+	repo        WebhookRepository
+	jobRunner   *JobRunner
+	client      *http.Client
+	cfg         config.WebhooksConfig
+	maxAttempts int
+	// apps are the IDs a webhook can be limited to
+	apps map[string]bool
+}
+
+// NewWebhookService creates a webhook service and registers the delivery job handler
+func NewWebhookService(repo WebhookRepository, jobRunner *JobRunner, cfg config.WebhooksConfig, jobsCfg config.JobsConfig) *WebhookService {
+	s := &WebhookService{
+		repo:        repo,
+		jobRunner:   jobRunner,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		cfg:         cfg,
+		maxAttempts: jobsCfg.MaxAttempts,
+		apps:        map[string]bool{config.DefaultAppID: true},
+	}
+	jobRunner.Register(webhookDeliveryJob, s.deliver)
+	return s
+}
+
+// WithApps allows limiting webhooks to the configured white-label apps
+func (s *WebhookService) WithApps(apps map[string]config.AppConfig) *WebhookService {
+	for id := range apps {
+		s.apps[id] = true
+	}
+	return s
+}
+
+// WebhookInput holds the admin-editable fields of a webhook
+type WebhookInput struct {
+	URL     string
+	Events  []string
+	AppID   *string
+	Enabled bool
+}
+
+func (s *WebhookService) validate(in WebhookInput) error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if len(in.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	for _, event := range in.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	if in.AppID != nil && !s.apps[*in.AppID] {
+		return fmt.Errorf("%w: unknown app %q", ErrInvalidWebhook, *in.AppID)
+	}
+	return nil
+}
+
+// checkWebhookID rejects IDs that can't belong to a webhook before they reach the
+// uuid column
+func checkWebhookID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Create creates a webhook with a new signing secret. The returned webhook
+// is the only place the secret is shown.
+func (s *WebhookService) Create(ctx context.Context, in WebhookInput) (*models.Webhook, error) {
+	if err := s.validate(in); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	now := time.Now().UTC()
+	webhook := &models.Webhook{
+		ID:        uuid.New().String(),
+		URL:       in.URL,
+		Secret:    "whsec_" + hex.EncodeToString(secret),
+		Events:    in.Events,
+		AppID:     in.AppID,
+		Enabled:   in.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Update replaces the webhook's URL, events, app and enabled flag; the
+// secret is kept
+func (s *WebhookService) Update(ctx context.Context, id string, in WebhookInput) (*models.Webhook, error) {
+	if err := checkWebhookID(id); err != nil {
+		return nil, err
+	}
+	if err := s.validate(in); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.URL = in.URL
+	webhook.Events = in.Events
+	webhook.AppID = in.AppID
+	webhook.Enabled = in.Enabled
+	webhook.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// List returns all webhooks without their secrets
+func (s *WebhookService) List(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Delete deletes a webhook and its delivery log
+func (s *WebhookService) Delete(ctx context.Context, id string) error {
+	if err := checkWebhookID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Deliveries returns the webhook's delivery log, newest first
+func (s *WebhookService) Deliveries(ctx context.Context, webhookID, status string, limit int) ([]*models.WebhookDelivery, error) {
+	if err := checkWebhookID(webhookID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, status, AdminListLimits.Clamp(limit))
+}
+
+// Publish queues a domain event for every enabled webhook subscribed to it.
+// It is the event log consumer for webhooks: an error means nothing was
+// queued and the event is retried. Failing to queue one webhook's delivery
+// is only logged, so the others aren't sent twice.
+func (s *WebhookService) Publish(ctx context.Context, event *models.DomainEvent) error {
+	eventType, ok := webhookEventTypes[event.Type]
+	if !ok {
+		return nil
+	}
+
+	webhooks, err := s.repo.ListSubscribed(ctx, eventType, event.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to find webhooks for event: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(WebhookEvent{
+		ID:        event.ID,
+		Type:      eventType,
+		AppID:     event.AppID,
+		CreatedAt: event.OccurredAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	logger := log.Ctx(ctx).With().Str("event_type", eventType).Str("event_id", event.ID).Logger()
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			EventType: eventType,
+			Payload:   payload,
+			Status:    models.WebhookDeliveryPending,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			logger.Error().Err(err).Str("webhook_id", webhook.ID).Msg("Failed to record webhook delivery")
+			continue
+		}
+		if _, err := s.jobRunner.Enqueue(ctx, webhookDeliveryJob, webhookDeliveryPayload{DeliveryID: delivery.ID}); err != nil {
+			logger.Error().Err(err).Str("webhook_id", webhook.ID).Msg("Failed to enqueue webhook delivery")
+		}
+	}
+	return nil
+}
+
+// deliver sends one delivery; returning an error makes the job runner retry it
+func (s *WebhookService) deliver(ctx context.Context, raw json.RawMessage) error {
+	var p webhookDeliveryPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("failed to decode webhook delivery job: %w", err)
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, p.DeliveryID)
+	if err == nil {
+		var webhook *models.Webhook
+		if webhook, err = s.repo.GetByID(ctx, delivery.WebhookID); err == nil {
+			return s.attempt(ctx, webhook, delivery)
+		}
+	}
+	// Вебхук удален вместе с журналом, повторять нечего
+	if errors.Is(err, domain.ErrWebhookNotFound) || errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+		log.Ctx(ctx).Info().Str("delivery_id", p.DeliveryID).Msg("Webhook deleted, delivery dropped")
+		return nil
+	}
+	return err
+}
+
+// attempt posts the delivery and records the outcome in the delivery log
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+
+	responseStatus, err := s.post(ctx, webhook, delivery)
+	if err == nil {
+		return s.repo.RecordAttempt(ctx, delivery.ID, models.WebhookDeliveryDelivered, responseStatus, nil)
+	}
+
+	// Последняя попытка задачи - доставка окончательно не удалась
+	status := models.WebhookDeliveryPending
+	if delivery.Attempts+1 >= s.maxAttempts {
+		status = models.WebhookDeliveryFailed
+	}
+	lastError := err.Error()
+	if recordErr := s.repo.RecordAttempt(ctx, delivery.ID, status, responseStatus, &lastError); recordErr != nil {
+		log.Ctx(ctx).Error().Err(recordErr).Str("delivery_id", delivery.ID).Msg("Failed to record webhook delivery attempt")
+	}
+	return err
+}
+
+// post sends the signed payload and returns the response status, if any
+func (s *WebhookService) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (*int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TwoPic-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookSignatureHeader, "t="+timestamp+",v1="+SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	if status >= 200 && status < 300 {
+		return &status, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodyLimit))
+	return &status, fmt.Errorf("webhook endpoint responded %d: %s", status, bytes.TrimSpace(body))
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "{timestamp}.{body}" with the
+// webhook secret. Receivers recompute it from the X-Webhook-Signature
+// timestamp and the raw body.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunCleanup deletes deliveries older than the retention period until ctx is cancelled
+func (s *WebhookService) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(webhookCleanupEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteDeliveries(ctx, time.Now().Add(-s.cfg.Retention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete old webhook deliveries")
+			} else if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Old webhook deliveries deleted")
+			}
+		}
+	}
+}
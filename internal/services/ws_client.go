@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// wsSendQueueSize bounds the messages queued for a client; a client that
+	// falls this far behind is evicted
+	wsSendQueueSize = 64
+	// defaultPingInterval and defaultMaxMissedPongs are the heartbeat
+	// unless WSHub.WithHeartbeat was called
+	defaultPingInterval   = 30 * time.Second
+	defaultMaxMissedPongs = 2
+	// wsRTTWeight is the weight of a new round trip sample in the moving
+	// average of RTT
+	wsRTTWeight = 0.25
+)
+
+var (
+	// errSlowClient is returned when a client's send queue is full
+	errSlowClient = errors.New("client send queue is full")
+	// errClientClosed is returned when sending to a closed client
+	errClientClosed = errors.New("client is closed")
+)
+
+// wsHeartbeat is how the liveness of connections is checked: a ping every
+// interval, and a connection that left maxMissed pings in a row unanswered
+// is dropped
+type wsHeartbeat struct {
+	interval  time.Duration
+	maxMissed int
+}
+
+// readTimeout is how long a connection may stay silent, pongs included,
+// before reads fail; it backs up the pong count when writes stall too
+func (hb wsHeartbeat) readTimeout() time.Duration {
+	return hb.interval * time.Duration(hb.maxMissed+1)
+}
+
+// WSClient is a WebSocket connection registered in the hub. Messages are
+// queued and written by the client's own write pump, so any goroutine may
+// send to it; gorilla connections allow only one concurrent writer.
+type WSClient struct {
+	userID string
+	conn   *websocket.Conn
+	// protocol is the version negotiated in the handshake, see WSProtocolV2
+	protocol  int
+	heartbeat wsHeartbeat
+	send      chan []byte
+	// done is closed once the client is closed; the send channel itself
+	// stays open so senders never write to a closed channel
+	done chan struct{}
+	// closed is closed after the close frame is written and the
+	// connection closed
+	closed    chan struct{}
+	closeOnce sync.Once
+	// drainCode and drainText are the close frame written when the write
+	// pump reaches the sentinel queued by closeAfterQueued
+	drainCode int
+	drainText string
+	// rtt is the moving average of ping round trips in nanoseconds, zero
+	// until the first pong
+	rtt atomic.Int64
+	// missedPongs counts the pings sent since the last pong
+	missedPongs atomic.Int32
+	// lastSeen is when the client last sent a frame, pongs included, in
+	// unix nanoseconds
+	lastSeen atomic.Int64
+	// relays caps the ephemeral messages relayed to the partner, see
+	// WSHub.RelayToPartner
+	relays wsRelayLimiter
+	// storedMu guards lastSeq, the seq of the last stored event queued,
+	// and replaying, set until Replay sent the events stored while the
+	// user was away; stored events sent meanwhile are left to it
+	storedMu  sync.Mutex
+	lastSeq   int64
+	replaying bool
+}
+
+func newWSClient(userID string, conn *websocket.Conn, heartbeat wsHeartbeat) *WSClient {
+	protocol := WSProtocolV1
+	if conn.Subprotocol() == WSSubprotocolV2 {
+		protocol = WSProtocolV2
+	}
+	client := &WSClient{
+		userID:    userID,
+		conn:      conn,
+		protocol:  protocol,
+		heartbeat: heartbeat,
+		send:      make(chan []byte, wsSendQueueSize),
+		done:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	client.lastSeen.Store(time.Now().UnixNano())
+	return client
+}
+
+// UserID returns the ID of the connected user
+func (c *WSClient) UserID() string {
+	return c.userID
+}
+
+// Protocol returns the protocol version the client speaks
+func (c *WSClient) Protocol() int {
+	return c.protocol
+}
+
+// LastSeen returns when the client last sent a frame or answered a ping
+func (c *WSClient) LastSeen() time.Time {
+	return time.Unix(0, c.lastSeen.Load())
+}
+
+// RTT returns the estimated round trip time of the connection, zero if no
+// ping was answered yet
+func (c *WSClient) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// ReadPump reads messages until the connection fails or is closed and
+// passes each one to handle. Any frame, pongs included, proves the client
+// is alive and extends the read deadline; pongs also reset the count of
+// missed ones.
+func (c *WSClient) ReadPump(handle func(data []byte)) error {
+	timeout := c.heartbeat.readTimeout()
+	c.conn.SetReadDeadline(time.Now().Add(timeout))
+	c.conn.SetPongHandler(func(appData string) error {
+		now := time.Now()
+		c.missedPongs.Store(0)
+		c.lastSeen.Store(now.UnixNano())
+		c.observePong(appData, now)
+		return c.conn.SetReadDeadline(now.Add(timeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		c.lastSeen.Store(now.UnixNano())
+		c.conn.SetReadDeadline(now.Add(timeout))
+		handle(data)
+	}
+}
+
+// observePong updates the RTT from a pong echoing the send time of its
+// ping; pongs the server didn't ask for are ignored
+func (c *WSClient) observePong(appData string, now time.Time) {
+	sentAt, err := strconv.ParseInt(appData, 10, 64)
+	if err != nil {
+		return
+	}
+	sample := now.Sub(time.Unix(0, sentAt))
+	if sample < 0 || sample > c.heartbeat.readTimeout() {
+		return
+	}
+	if previous := c.rtt.Load(); previous != 0 {
+		sample = time.Duration(float64(previous) + wsRTTWeight*float64(int64(sample)-previous))
+	}
+	c.rtt.Store(int64(sample))
+}
+
+// takeSeq reports whether the stored event with seq is to be queued now:
+// not during the replay, which sends it, and not if it already went
+func (c *WSClient) takeSeq(seq int64) bool {
+	c.storedMu.Lock()
+	defer c.storedMu.Unlock()
+
+	if c.replaying || seq <= c.lastSeq {
+		return false
+	}
+	c.lastSeq = seq
+	return true
+}
+
+// enqueue queues a message without blocking; a full queue means the client
+// can't keep up
+func (c *WSClient) enqueue(data []byte) error {
+	select {
+	case <-c.done:
+		return errClientClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		return errSlowClient
+	}
+}
+
+// writePump writes queued messages and pings until the client is closed or
+// a write fails. It is the only goroutine writing data frames to the
+// connection. The first ping goes out right away, so the RTT is known
+// before the client can take part in a countdown. A client that missed
+// heartbeat.maxMissed pongs in a row is closed.
+func (c *WSClient) writePump() {
+	ticker := time.NewTicker(c.heartbeat.interval)
+	defer ticker.Stop()
+
+	if !c.ping() {
+		return
+	}
+
+	for {
+		select {
+		case data := <-c.send:
+			if data == nil {
+				c.close(c.drainCode, c.drainText)
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Debug().Err(err).Str("user_id", c.userID).Msg("Failed to write WebSocket message")
+				c.close(0, "")
+				return
+			}
+		case <-ticker.C:
+			if missed := int(c.missedPongs.Load()); missed >= c.heartbeat.maxMissed {
+				log.Info().Str("user_id", c.userID).Int("missed_pongs", missed).Msg("WebSocket client stopped answering pings")
+				metrics.WSHeartbeatTimeouts.Inc()
+				c.close(0, "")
+				return
+			}
+			if !c.ping() {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// ping writes a ping carrying its send time, which the pong echoes back
+// for the RTT; false if the write failed and the client was closed
+func (c *WSClient) ping() bool {
+	c.missedPongs.Add(1)
+	now := time.Now()
+	c.conn.SetWriteDeadline(now.Add(wsWriteWait))
+	if err := c.conn.WriteMessage(websocket.PingMessage, []byte(strconv.FormatInt(now.UnixNano(), 10))); err != nil {
+		log.Debug().Err(err).Str("user_id", c.userID).Msg("Failed to ping WebSocket client")
+		c.close(0, "")
+		return false
+	}
+	return true
+}
+
+// closeAfterQueued closes the connection with a close frame once the
+// messages queued so far are written. A client that can't take one more
+// message is closed right away.
+func (c *WSClient) closeAfterQueued(code int, text string) {
+	c.drainCode, c.drainText = code, text
+	// nil в очереди — сигнал write pump закрыть соединение
+	if err := c.enqueue(nil); err != nil {
+		c.close(code, text)
+	}
+}
+
+// close closes the connection, with a close frame unless code is zero. The
+// read pump fails right after, so the handler unregisters the client.
+func (c *WSClient) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if code != 0 {
+			// WriteControl может вызываться параллельно с write pump
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(code, text),
+				time.Now().Add(wsWriteWait))
+		}
+		c.conn.Close()
+		close(c.closed)
+	})
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/hubbackend"
+	"sync-photo-backend/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// clusterOpTimeout bounds a single call the hub makes to its cluster backend
+const clusterOpTimeout = 2 * time.Second
+
+// clusterEnvelope is a message routed to the instance holding a user's connection
+type clusterEnvelope struct {
+	UserID  string     `json:"user_id"`
+	Message *WSMessage `json:"message,omitempty"`
+	// Kick asks the instance to close the user's connection after they
+	// reconnected to another instance
+	Kick bool `json:"kick,omitempty"`
+	// CloseCode asks the instance to close the user's connection with this
+	// code and CloseText, see WSHub.Disconnect
+	CloseCode int    `json:"close_code,omitempty"`
+	CloseText string `json:"close_text,omitempty"`
+}
+
+// WithCluster enables multi-replica mode: presence is tracked by the
+// backend and messages for users connected to other replicas are routed
+// through it. Call RunCluster to start receiving routed messages.
+func (h *WSHub) WithCluster(backend hubbackend.Backend) *WSHub {
+	h.cluster = backend
+	return h
+}
+
+// RunCluster receives messages routed to this replica and keeps presence
+// of local connections alive until ctx is cancelled
+func (h *WSHub) RunCluster(ctx context.Context) {
+	if h.cluster == nil {
+		return
+	}
+
+	log.Info().Str("instance_id", h.cluster.InstanceID()).Msg("WebSocket hub joined cluster")
+
+	messages := h.cluster.Subscribe(ctx)
+	// Присутствие без срока жизни продлевать не нужно
+	var refresh <-chan time.Time
+	if interval := h.cluster.RefreshInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload, ok := <-messages:
+			if !ok {
+				return
+			}
+			h.handleClusterMessage(payload)
+		case <-refresh:
+			h.refreshPresence(ctx)
+		}
+	}
+}
+
+// handleClusterMessage delivers a routed message to a local connection
+func (h *WSHub) handleClusterMessage(payload []byte) {
+	defer errreport.Recover(context.Background(), "ws_hub.cluster_message")
+
+	var envelope clusterEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		log.Error().Err(err).Msg("Failed to decode routed WebSocket message")
+		return
+	}
+
+	h.mu.Lock()
+	client, exists := h.connections[envelope.UserID]
+	if exists && envelope.Kick {
+		// Пользователь переподключился к другой реплике
+		client.close(0, "")
+		delete(h.connections, envelope.UserID)
+		metrics.WSConnections.Set(float64(len(h.connections)))
+	}
+	h.mu.Unlock()
+
+	if !exists {
+		log.Debug().Str("user_id", envelope.UserID).Msg("Routed message for user no longer connected here")
+		return
+	}
+	if envelope.Kick {
+		log.Info().Str("user_id", envelope.UserID).Msg("WebSocket connection replaced by another instance")
+		return
+	}
+	if envelope.CloseCode != 0 {
+		client.close(envelope.CloseCode, envelope.CloseText)
+		log.Info().Str("user_id", envelope.UserID).Int("code", envelope.CloseCode).Msg("WebSocket connection closed")
+		return
+	}
+	if envelope.Message != nil {
+		h.write(client, *envelope.Message)
+	}
+}
+
+// presenceSync orders the presence updates of one user, see syncPresence
+type presenceSync struct {
+	mu sync.Mutex
+	// waiters counts the updates holding or waiting for mu
+	waiters int
+}
+
+// syncPresence claims the user's presence in the cluster if they are
+// connected here and releases it otherwise. It runs after Register and
+// Unregister changed the connections, without holding the hub lock, so a
+// slow backend only delays this user. Updates of a user run one at a time and each reads
+// the connections when its turn comes, so the last one to run stores the
+// current state: a release finishing after the claim of a newer connection
+// can't leave the user offline, nor a claim finishing after the release
+// leave them online.
+func (h *WSHub) syncPresence(userID string) {
+	if h.cluster == nil {
+		return
+	}
+
+	h.mu.Lock()
+	ps, ok := h.presence[userID]
+	if !ok {
+		ps = &presenceSync{}
+		h.presence[userID] = ps
+	}
+	ps.waiters++
+	h.mu.Unlock()
+
+	ps.mu.Lock()
+	h.mu.RLock()
+	_, connected := h.connections[userID]
+	h.mu.RUnlock()
+	if connected {
+		h.claimPresence(userID)
+	} else {
+		h.releasePresence(userID)
+	}
+	ps.mu.Unlock()
+
+	h.mu.Lock()
+	if ps.waiters--; ps.waiters == 0 {
+		delete(h.presence, userID)
+	}
+	h.mu.Unlock()
+}
+
+// claimPresence marks the user as connected to this instance and closes
+// their previous connection if it lives on another instance
+func (h *WSHub) claimPresence(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterOpTimeout)
+	defer cancel()
+
+	previous, err := h.cluster.ClaimPresence(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to store presence")
+		return
+	}
+	if previous != "" && previous != h.cluster.InstanceID() {
+		if err := h.publish(ctx, previous, clusterEnvelope{UserID: userID, Kick: true}); err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to close connection on another instance")
+		}
+	}
+}
+
+// releasePresence removes the user's presence if it belongs to this instance
+func (h *WSHub) releasePresence(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterOpTimeout)
+	defer cancel()
+
+	if err := h.cluster.ReleasePresence(ctx, userID); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to release presence")
+	}
+}
+
+// owner returns the instance holding the user's connection, or "" if offline
+func (h *WSHub) owner(userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clusterOpTimeout)
+	defer cancel()
+
+	return h.cluster.Owner(ctx, userID)
+}
+
+// route publishes a message to the instance holding the user's connection.
+// Delivery on the remote instance is fire-and-forget.
+func (h *WSHub) route(userID string, message WSMessage) error {
+	instanceID, err := h.owner(userID)
+	if err != nil {
+		return err
+	}
+	if instanceID == "" || instanceID == h.cluster.InstanceID() {
+		return fmt.Errorf("user %s is not connected", userID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterOpTimeout)
+	defer cancel()
+	return h.publish(ctx, instanceID, clusterEnvelope{UserID: userID, Message: &message})
+}
+
+// disconnectRemote asks the instance holding the user's connection to close it
+func (h *WSHub) disconnectRemote(userID string, code int, text string) error {
+	instanceID, err := h.owner(userID)
+	if err != nil {
+		return err
+	}
+	if instanceID == "" || instanceID == h.cluster.InstanceID() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterOpTimeout)
+	defer cancel()
+	return h.publish(ctx, instanceID, clusterEnvelope{UserID: userID, CloseCode: code, CloseText: text})
+}
+
+func (h *WSHub) publish(ctx context.Context, instanceID string, envelope clusterEnvelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal routed message: %w", err)
+	}
+	return h.cluster.Publish(ctx, instanceID, data)
+}
+
+// refreshPresence extends presence of all local connections
+func (h *WSHub) refreshPresence(ctx context.Context) {
+	h.mu.RLock()
+	userIDs := make([]string, 0, len(h.connections))
+	for userID := range h.connections {
+		userIDs = append(userIDs, userID)
+	}
+	h.mu.RUnlock()
+
+	if len(userIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clusterOpTimeout)
+	defer cancel()
+	if err := h.cluster.RefreshPresence(ctx, userIDs); err != nil {
+		log.Error().Err(err).Int("connections", len(userIDs)).Msg("Failed to refresh presence")
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sync-photo-backend/internal/hubbackend"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+// newTestReplicas returns hubs in cluster mode on one in-process network,
+// one per instance ID. Each hub receives routed messages until the test ends.
+func newTestReplicas(t *testing.T, instanceIDs ...string) (*hubbackend.MemoryNetwork, []*WSHub) {
+	t.Helper()
+
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	network := hubbackend.NewMemoryNetwork()
+	hubs := make([]*WSHub, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		hubs[i] = NewWSHub(nil).WithCluster(network.Backend(instanceID))
+		go hubs[i].RunCluster(ctx)
+	}
+	for _, instanceID := range instanceIDs {
+		for deadline := time.Now().Add(5 * time.Second); !network.Subscribed(instanceID); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("hub %s not subscribed", instanceID)
+			}
+		}
+	}
+	return network, hubs
+}
+
+// connectTestClient adds a connectionless client of the user to the hub,
+// as Register does, and returns it
+func connectTestClient(t *testing.T, hub *WSHub, userID string) *WSClient {
+	t.Helper()
+
+	client := &WSClient{
+		userID:    userID,
+		protocol:  WSProtocolV1,
+		heartbeat: hub.heartbeat,
+		send:      make(chan []byte, wsSendQueueSize),
+		done:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	hub.mu.Lock()
+	hub.connections[userID] = client
+	hub.mu.Unlock()
+	return client
+}
+
+// disconnectTestClient removes the client from the hub, as Unregister does
+func disconnectTestClient(hub *WSHub, client *WSClient) {
+	hub.mu.Lock()
+	delete(hub.connections, client.userID)
+	hub.mu.Unlock()
+}
+
+// assertOwner fails the test unless the user's presence belongs to the
+// instance want, "" meaning offline
+func assertOwner(t *testing.T, network *hubbackend.MemoryNetwork, userID, want string) {
+	t.Helper()
+
+	got, err := network.Backend("").Owner(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("presence of %s = %q, want %q", userID, got, want)
+	}
+}
+
+func TestSyncPresence(t *testing.T) {
+	network, hubs := newTestReplicas(t, "a")
+	hub := hubs[0]
+
+	client := connectTestClient(t, hub, "user")
+	hub.syncPresence("user")
+	assertOwner(t, network, "user", "a")
+
+	disconnectTestClient(hub, client)
+	hub.syncPresence("user")
+	assertOwner(t, network, "user", "")
+
+	// Обновление после отключения, дошедшее до Redis уже после
+	// переподключения, не снимает присутствие нового соединения
+	connectTestClient(t, hub, "user")
+	hub.syncPresence("user")
+	assertOwner(t, network, "user", "a")
+
+	hub.mu.RLock()
+	pending := len(hub.presence)
+	hub.mu.RUnlock()
+	if pending != 0 {
+		t.Errorf("%d presence updates left behind", pending)
+	}
+}
+
+// TestSyncPresenceConcurrent reconnects a user from many goroutines and
+// checks that the cluster ends up with the state of the last change
+func TestSyncPresenceConcurrent(t *testing.T) {
+	network, hubs := newTestReplicas(t, "a")
+	hub := hubs[0]
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := connectTestClient(t, hub, "user")
+			hub.syncPresence("user")
+			if i%2 == 0 {
+				disconnectTestClient(hub, client)
+				hub.syncPresence("user")
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := ""
+	if _, connected := hub.connections["user"]; connected {
+		want = "a"
+	}
+	assertOwner(t, network, "user", want)
+}
+
+// receive returns the next message queued for the client
+func receive(t *testing.T, client *WSClient) WSMessage {
+	t.Helper()
+
+	select {
+	case data := <-client.send:
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message routed to the client")
+		return WSMessage{}
+	}
+}
+
+// TestClusterRoute sends messages to a user connected to another replica
+func TestClusterRoute(t *testing.T) {
+	network, hubs := newTestReplicas(t, "a", "b")
+
+	client := connectTestClient(t, hubs[1], "user")
+	hubs[1].syncPresence("user")
+	assertOwner(t, network, "user", "b")
+
+	if !hubs[0].IsOnline("user") {
+		t.Error("user connected to b is offline on a")
+	}
+	if err := hubs[0].SendToUser("user", WSMessage{Type: "partner_online"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, client); msg.Type != "partner_online" {
+		t.Errorf("routed message type = %q, want partner_online", msg.Type)
+	}
+
+	disconnectTestClient(hubs[1], client)
+	hubs[1].syncPresence("user")
+	if hubs[0].IsOnline("user") {
+		t.Error("user disconnected from b is online on a")
+	}
+	if err := hubs[0].SendToUser("user", WSMessage{Type: "partner_online"}); err == nil {
+		t.Error("message to a disconnected user routed without error")
+	}
+}
+
+// testConn returns the server side of a WebSocket connection, for the
+// clients the hub closes
+func testConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return <-conns
+}
+
+// TestClusterKick reconnects a user to another replica: the new replica
+// takes the presence over and the old one drops its connection
+func TestClusterKick(t *testing.T) {
+	network, hubs := newTestReplicas(t, "a", "b")
+
+	old := connectTestClient(t, hubs[0], "user")
+	old.conn = testConn(t)
+	hubs[0].syncPresence("user")
+	connectTestClient(t, hubs[1], "user")
+	hubs[1].syncPresence("user")
+	assertOwner(t, network, "user", "b")
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		hubs[0].mu.RLock()
+		_, connected := hubs[0].connections["user"]
+		hubs[0].mu.RUnlock()
+		if !connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("connection on a not closed after the user reconnected to b")
+		}
+	}
+
+	// Продление на старой реплике не забирает присутствие обратно
+	hubs[0].refreshPresence(context.Background())
+	assertOwner(t, network, "user", "b")
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+// storedEventTypes are the messages that must not be lost when the user
+// reconnects a moment after they were sent. With WithEventReplay they are
+// kept until the client acks them and sent again on the next connection.
+var storedEventTypes = map[string]bool{
+	"pair_created":           true,
+	"pair_deleted":           true,
+	"photo_prompt":           true,
+	"photo_prompt_completed": true,
+}
+
+// WithEventReplay keeps the storedEventTypes messages sent to a user in
+// events, numbered by seq, until their client acks them. v2 clients ack
+// with an ack message, v1 clients can't and have them acked once queued.
+// Events not acked within retention are dropped.
+func (h *WSHub) WithEventReplay(events UserEventRepository, retention time.Duration) *WSHub {
+	h.events = events
+	h.eventRetention = retention
+	return h
+}
+
+// store keeps the message if it is a stored event and returns it with its
+// seq; it is sent without one if storing fails
+func (h *WSHub) store(userID string, message WSMessage) WSMessage {
+	if h.events == nil || !storedEventTypes[message.Type] {
+		return message
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Error().Err(err).Str("message_type", message.Type).Msg("Failed to encode WebSocket event")
+		return message
+	}
+	event := &models.UserEvent{
+		UserID:    userID,
+		Type:      message.Type,
+		Message:   data,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hubLookupTimeout)
+	defer cancel()
+	if err := h.events.Append(ctx, event); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Str("message_type", message.Type).Msg("Failed to store WebSocket event")
+		return message
+	}
+	message.Seq = event.Seq
+	return message
+}
+
+// Replay sends the client the events stored for its user that weren't
+// acked, in order, before any stored event sent from now on
+func (h *WSHub) Replay(ctx context.Context, client *WSClient) {
+	if h.events == nil {
+		return
+	}
+
+	client.storedMu.Lock()
+	defer client.storedMu.Unlock()
+	client.replaying = false
+
+	events, err := h.events.ListPending(ctx, client.userID, time.Now().Add(-h.eventRetention))
+	if err != nil {
+		log.Error().Err(err).Str("user_id", client.userID).Msg("Failed to list WebSocket events to replay")
+		return
+	}
+	for _, event := range events {
+		var message WSMessage
+		if err := json.Unmarshal(event.Message, &message); err != nil {
+			log.Error().Err(err).Int64("seq", event.Seq).Msg("Failed to decode stored WebSocket event")
+			continue
+		}
+		message.Seq = event.Seq
+		if err := h.queue(client, message); err != nil {
+			return
+		}
+		client.lastSeq = event.Seq
+		metrics.WSReplayedEvents.Inc()
+	}
+
+	if client.lastSeq != 0 && client.protocol != WSProtocolV2 {
+		h.autoAck(client.userID, client.lastSeq)
+	}
+	if len(events) > 0 {
+		log.Info().Str("user_id", client.userID).Int("events", len(events)).Msg("WebSocket events replayed")
+	}
+}
+
+// Ack marks the user's stored events up to seq as received
+func (h *WSHub) Ack(ctx context.Context, userID string, seq int64) error {
+	if h.events == nil {
+		return nil
+	}
+	return h.events.Ack(ctx, userID, seq)
+}
+
+// autoAck acks the events queued to a client that doesn't ack them itself
+func (h *WSHub) autoAck(userID string, seq int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), hubLookupTimeout)
+	defer cancel()
+	if err := h.events.Ack(ctx, userID, seq); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Int64("seq", seq).Msg("Failed to ack WebSocket events")
+	}
+}
+
+// RunEventCleanup periodically deletes stored events older than the
+// retention of WithEventReplay until ctx is cancelled
+func (h *WSHub) RunEventCleanup(ctx context.Context, interval time.Duration) {
+	if h.events == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := h.events.DeleteBefore(ctx, time.Now().Add(-h.eventRetention))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to delete expired WebSocket events")
+				continue
+			}
+			if deleted > 0 {
+				log.Debug().Int64("deleted", deleted).Msg("Expired WebSocket events deleted")
+			}
+		}
+	}
+}
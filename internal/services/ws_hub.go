@@ -2,84 +2,285 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"sync"
 	"time"
 
+	"sync-photo-backend/internal/domain"
+	"sync-photo-backend/internal/errreport"
+	"sync-photo-backend/internal/hubbackend"
+	"sync-photo-backend/internal/metrics"
+	"sync-photo-backend/internal/models"
+
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
 
+// hubLookupTimeout bounds database lookups made by the hub itself
+const hubLookupTimeout = 5 * time.Second
+
+// wsWriteWait bounds a single message write so a stalled client can't block
+// the hub; the connection itself has no server write timeout
+const wsWriteWait = 10 * time.Second
+
+// WSCloseMaintenance is the close code sent when the server enters
+// maintenance mode; clients should show a "back soon" screen instead of
+// reconnecting right away
+const WSCloseMaintenance = 4503
+
+// WSCloseUnauthorized is the close code sent when a connection without a
+// token in the handshake doesn't authenticate with its first message in
+// time or with a valid token
+const WSCloseUnauthorized = 4401
+
+// WSCloseAccountDeleted is the close code sent to a user who deleted their
+// account; clients should sign out instead of reconnecting
+const WSCloseAccountDeleted = 4403
+
+// WSCloseShutdown is the close code sent when the server shuts down; the
+// server_shutdown message before it says when to reconnect
+const WSCloseShutdown = websocket.CloseServiceRestart
+
+const (
+	// wsReconnectMin and wsReconnectJitter bound the reconnect delay hinted
+	// by server_shutdown; the jitter keeps clients of a stopping replica
+	// from reconnecting to the others all at once
+	wsReconnectMin    = time.Second
+	wsReconnectJitter = 4 * time.Second
+)
+
+const (
+	// defaultCountdown is the countdown before a synchronized capture,
+	// see WithCountdown
+	defaultCountdown = 3 * time.Second
+	// wsUnknownRTT is assumed for connections whose RTT isn't known here:
+	// those on other replicas and those that haven't answered a ping yet
+	wsUnknownRTT = 500 * time.Millisecond
+)
+
+// ErrHubShutdown is returned when registering a connection after Shutdown
+var ErrHubShutdown = errors.New("WebSocket hub is shutting down")
+
 // WSMessage represents a WebSocket message
 type WSMessage struct {
 	Type        string      `json:"type"`
+	ID          string      `json:"id,omitempty"` // v2 only: the client message this one answers
 	Timestamp   int64       `json:"timestamp,omitempty"`
+	CaptureAt   int64       `json:"capture_at,omitempty"` // unix milliseconds both partners take their photos at
 	InitiatorID string      `json:"initiator_id,omitempty"`
 	PhotoID     string      `json:"photo_id,omitempty"`
-	S3URL       string      `json:"s3_url,omitempty"`
+	SessionID   string      `json:"session_id,omitempty"`
+	S3URL       string      `json:"s3_url,omitempty"` // ignored; older clients still send it with photo_uploaded
 	Online      *bool       `json:"online,omitempty"`
+	State       string      `json:"state,omitempty"`        // ready_state and partner_ready_state
+	Seq         int64       `json:"seq,omitempty"`          // stored events, see WithEventReplay; ack carries the last one received
+	LastSeenAt  *time.Time  `json:"last_seen_at,omitempty"` // partner_status of an offline partner
 	Message     string      `json:"message,omitempty"`
+	Code        string      `json:"code,omitempty"` // set on errors; message is its localized text
 	Data        interface{} `json:"data,omitempty"`
 }
 
 // WSHub manages WebSocket connections
 type WSHub struct {
 	mu          sync.RWMutex
-	connections map[string]*websocket.Conn
+	connections map[string]*WSClient
 	pairService *PairService
+	// cluster is set in multi-replica mode, see WithCluster
+	cluster hubbackend.Backend
+	// presence serializes the presence updates of each user with one in
+	// flight, see syncPresence
+	presence map[string]*presenceSync
+	// peak is the highest number of local connections since TakeConnectionPeak
+	peak int
+	// dropRate is the share of outgoing messages dropped, see WithFaults
+	dropRate float64
+	// users stores last seen times, see WithLastSeen; with push it also
+	// delivers take_photo to offline users, see WithPushFallback
+	users UserRepository
+	push  *PushService
+	// shuttingDown rejects new connections once Shutdown was called
+	shuttingDown bool
+	// countdown is the shortest time from a trigger to its capture
+	countdown time.Duration
+	heartbeat wsHeartbeat
+	// events keeps the storedEventTypes messages until they are acked,
+	// see WithEventReplay
+	events         UserEventRepository
+	eventRetention time.Duration
 }
 
 // NewWSHub creates a new WebSocket hub
 func NewWSHub(pairService *PairService) *WSHub {
 	return &WSHub{
-		connections: make(map[string]*websocket.Conn),
+		connections: make(map[string]*WSClient),
+		presence:    make(map[string]*presenceSync),
 		pairService: pairService,
+		countdown:   defaultCountdown,
+		heartbeat:   wsHeartbeat{interval: defaultPingInterval, maxMissed: defaultMaxMissedPongs},
 	}
 }
 
-// Register registers a new WebSocket connection for a user
-func (h *WSHub) Register(userID string, conn *websocket.Conn) error {
+// WithHeartbeat pings connections every interval and drops those that
+// left maxMissed pings in a row unanswered, as configured in websocket
+func (h *WSHub) WithHeartbeat(interval time.Duration, maxMissed int) *WSHub {
+	h.heartbeat = wsHeartbeat{interval: interval, maxMissed: maxMissed}
+	return h
+}
+
+// WithLastSeen stores when each user's connection was last alive once it
+// ends; partner_status of an offline partner carries that time
+func (h *WSHub) WithLastSeen(users UserRepository) *WSHub {
+	h.users = users
+	return h
+}
+
+// WithCountdown sets the countdown before a synchronized capture, as
+// configured in capture.countdown
+func (h *WSHub) WithCountdown(countdown time.Duration) *WSHub {
+	h.countdown = countdown
+	return h
+}
+
+// WithFaults makes the hub silently drop a share of outgoing messages, as
+// configured in debug.faults, to exercise client recovery logic
+func (h *WSHub) WithFaults(dropRate float64) *WSHub {
+	h.dropRate = dropRate
+	return h
+}
+
+// WithPushFallback makes the hub deliver take_photo by push when the user
+// isn't connected or the write fails, so a trigger reaches an offline
+// partner. Other messages have no push counterpart and still fail.
+func (h *WSHub) WithPushFallback(users UserRepository, push *PushService) *WSHub {
+	h.users = users
+	h.push = push
+	return h
+}
+
+// Register registers a new WebSocket connection for a user and starts its
+// write pump. The caller runs the read pump and unregisters the client once
+// it returns.
+func (h *WSHub) Register(userID string, conn *websocket.Conn) (*WSClient, error) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	if h.shuttingDown {
+		h.mu.Unlock()
+		return nil, ErrHubShutdown
+	}
 
 	// Close existing connection if any
-	if existingConn, exists := h.connections[userID]; exists {
-		existingConn.Close()
+	if existing, exists := h.connections[userID]; exists {
+		existing.close(0, "")
 	}
 
-	h.connections[userID] = conn
+	client := newWSClient(userID, conn, h.heartbeat)
+	// Сохраненные события уходят после повтора, см. Replay
+	client.replaying = h.events != nil
+	go client.writePump()
+
+	h.connections[userID] = client
+	if len(h.connections) > h.peak {
+		h.peak = len(h.connections)
+	}
+	metrics.WSConnections.Set(float64(len(h.connections)))
+	h.mu.Unlock()
 
 	log.Info().Str("user_id", userID).Msg("WebSocket connection registered")
 
+	// Сообщения с других реплик идут сюда, только когда присутствие заявлено
+	h.syncPresence(userID)
+
 	// Notify partner about online status
-	go h.notifyPartnerStatus(userID, true)
+	go h.notifyPartnerStatus(userID, true, nil)
 
-	return nil
+	return client, nil
 }
 
-// Unregister removes a WebSocket connection for a user
-func (h *WSHub) Unregister(userID string) {
+// Unregister closes the client and removes it from the hub. A client
+// already replaced by a newer connection of the user, here or on another
+// replica, is only closed: the user is still online.
+func (h *WSHub) Unregister(client *WSClient) {
+	client.close(0, "")
+
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	userID := client.userID
+	if h.connections[userID] != client {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.connections, userID)
+	metrics.WSConnections.Set(float64(len(h.connections)))
+	h.mu.Unlock()
+
+	log.Info().Str("user_id", userID).Msg("WebSocket connection unregistered")
+
+	// evict вызывает Unregister из отправки сообщения другому пользователю,
+	// которую не должен задерживать Redis
+	go h.syncPresence(userID)
 
-	if conn, exists := h.connections[userID]; exists {
-		conn.Close()
-		delete(h.connections, userID)
-		log.Info().Str("user_id", userID).Msg("WebSocket connection unregistered")
+	// Последний кадр клиента, а не момент отключения: мертвое соединение
+	// замечается не сразу
+	go h.userOffline(userID, client.LastSeen().UTC())
+}
+
+// userOffline stores the last seen time of a user whose connection ended
+// and tells the partner
+func (h *WSHub) userOffline(userID string, lastSeen time.Time) {
+	defer errreport.Recover(context.Background(), "ws_hub.user_offline", "user_id", userID)
+
+	if h.users != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), hubLookupTimeout)
+		err := h.users.TouchLastSeen(ctx, userID, lastSeen)
+		cancel()
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to store last seen time")
+		}
 	}
+	h.notifyPartnerStatus(userID, false, &lastSeen)
+}
 
-	// Notify partner about offline status
-	go h.notifyPartnerStatus(userID, false)
+// evict disconnects a client that can't keep up with its messages, so one
+// stalled device can't hold an unbounded queue. The client reconnects and
+// catches up through the API.
+func (h *WSHub) evict(client *WSClient) {
+	log.Warn().Str("user_id", client.userID).Int("queue_size", wsSendQueueSize).Msg("Evicting slow WebSocket client")
+	metrics.WSSlowClientEvictions.Inc()
+	client.close(websocket.CloseTryAgainLater, "slow client")
+	h.Unregister(client)
 }
 
-// SendToUser sends a message to a specific user
+// SendToUser sends a message to a specific user, falling back to push
+// when configured, see WithPushFallback
 func (h *WSHub) SendToUser(userID string, message WSMessage) error {
+	message = h.store(userID, message)
+	err := h.sendToUser(userID, message)
+	if err == nil || !h.pushable(message) {
+		return err
+	}
+	if pushErr := h.sendPush(userID, message); pushErr != nil {
+		return fmt.Errorf("%w; push fallback: %w", err, pushErr)
+	}
+	return nil
+}
+
+// SendToClient sends a message to this connection of the user only, like
+// replies to the frame just read from it
+func (h *WSHub) SendToClient(client *WSClient, message WSMessage) error {
+	return h.write(client, message)
+}
+
+// sendToUser sends a message over the user's connection on this or another replica
+func (h *WSHub) sendToUser(userID string, message WSMessage) error {
 	h.mu.RLock()
-	conn, exists := h.connections[userID]
+	client, exists := h.connections[userID]
 	h.mu.RUnlock()
 
 	if !exists {
+		// Пользователь может быть подключен к другой реплике
+		if h.cluster != nil {
+			return h.route(userID, message)
+		}
 		log.Debug().
 			Str("user_id", userID).
 			Str("message_type", message.Type).
@@ -87,7 +288,57 @@ func (h *WSHub) SendToUser(userID string, message WSMessage) error {
 		return fmt.Errorf("user %s is not connected", userID)
 	}
 
-	data, err := json.Marshal(message)
+	return h.write(client, message)
+}
+
+// pushable reports whether the message can be delivered by push
+func (h *WSHub) pushable(message WSMessage) bool {
+	return h.push != nil && message.Type == "take_photo"
+}
+
+// sendPush delivers a pushable message to the user's device
+func (h *WSHub) sendPush(userID string, message WSMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hubLookupTimeout)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := h.push.SendTakePhotoNotification(user, message.InitiatorID, message.SessionID, message.Timestamp); err != nil {
+		return err
+	}
+
+	log.Debug().
+		Str("user_id", userID).
+		Str("message_type", message.Type).
+		Msg("WebSocket message delivered by push")
+	return nil
+}
+
+// write queues a message for a local connection, evicting the client if
+// its queue is full
+func (h *WSHub) write(client *WSClient, message WSMessage) error {
+	if message.Seq != 0 && !client.takeSeq(message.Seq) {
+		// Событие уже отправлено или уйдет при повторе
+		return nil
+	}
+	if err := h.queue(client, message); err != nil {
+		return err
+	}
+	if message.Seq != 0 && client.protocol != WSProtocolV2 {
+		h.autoAck(client.userID, message.Seq)
+	}
+	return nil
+}
+
+// queue encodes the message in the client's protocol and queues it
+func (h *WSHub) queue(client *WSClient, message WSMessage) error {
+	userID := client.userID
+	if message.Type == "take_photo" && message.CaptureAt != 0 && client.protocol == WSProtocolV2 {
+		message = countdownStart(message, client.RTT(), time.Now())
+	}
+	data, err := EncodeWSMessage(client.protocol, message)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -97,55 +348,149 @@ func (h *WSHub) SendToUser(userID string, message WSMessage) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if h.dropRate > 0 && rand.Float64() < h.dropRate {
+		log.Debug().Str("user_id", userID).Str("message_type", message.Type).Msg("Dropped WebSocket message (fault injection)")
+		return nil
+	}
+
+	if err := client.enqueue(data); err != nil {
 		log.Error().
 			Err(err).
 			Str("user_id", userID).
 			Str("message_type", message.Type).
-			Msg("Failed to write WebSocket message")
-		h.Unregister(userID)
+			Msg("Failed to queue WebSocket message")
+		if errors.Is(err, errSlowClient) {
+			h.evict(client)
+		}
 		return fmt.Errorf("failed to send message: %w", err)
 	}
+	metrics.WSMessages.WithLabelValues("sent", message.Type).Inc()
 
-	// Debug log with message details
+	// Только метаданные: содержимое сообщений (URL фото, data) в лог не
+	// попадает
 	logger := log.Debug().
 		Str("user_id", userID).
 		Str("message_type", message.Type)
-
-	// Add specific fields based on message type
-	if message.Timestamp != 0 {
-		logger = logger.Int64("timestamp", message.Timestamp)
+	if message.Seq != 0 {
+		logger = logger.Int64("seq", message.Seq)
 	}
-	if message.InitiatorID != "" {
-		logger = logger.Str("initiator_id", message.InitiatorID)
-	}
-	if message.PhotoID != "" {
-		logger = logger.Str("photo_id", message.PhotoID)
+	logger.Msg("WebSocket message queued")
+
+	return nil
+}
+
+// CloseAll closes every local connection with the given close code. The
+// handlers unregister the connections once their read pumps fail.
+func (h *WSHub) CloseAll(code int, text string) {
+	h.mu.RLock()
+	clients := make([]*WSClient, 0, len(h.connections))
+	for _, client := range h.connections {
+		clients = append(clients, client)
 	}
-	if message.S3URL != "" {
-		logger = logger.Str("s3_url", message.S3URL)
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		client.close(code, text)
 	}
-	if message.Online != nil {
-		logger = logger.Bool("online", *message.Online)
+	log.Info().Int("connections", len(clients)).Int("code", code).Msg("WebSocket connections closed")
+}
+
+// Disconnect closes the user's connection on this or another replica with
+// the given close code. The handler unregisters it once its read pump
+// fails; a user who isn't connected is ignored.
+func (h *WSHub) Disconnect(userID string, code int, text string) error {
+	h.mu.RLock()
+	client, exists := h.connections[userID]
+	h.mu.RUnlock()
+
+	if !exists {
+		if h.cluster != nil {
+			return h.disconnectRemote(userID, code, text)
+		}
+		return nil
 	}
-	if message.Message != "" {
-		logger = logger.Str("error_message", message.Message)
+	client.close(code, text)
+	log.Info().Str("user_id", userID).Int("code", code).Msg("WebSocket connection closed")
+	return nil
+}
+
+// Shutdown stops accepting connections and sends every client
+// server_shutdown with a jittered reconnect delay, then closes each
+// connection with WSCloseShutdown once its queued messages are written.
+// Connections still open when ctx expires are closed right away.
+func (h *WSHub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.shuttingDown = true
+	clients := make([]*WSClient, 0, len(h.connections))
+	for _, client := range h.connections {
+		clients = append(clients, client)
 	}
-	if message.Data != nil {
-		logger = logger.Interface("data", message.Data)
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		reconnectIn := wsReconnectMin + time.Duration(rand.Int64N(int64(wsReconnectJitter)))
+		h.write(client, WSMessage{
+			Type: "server_shutdown",
+			Data: map[string]int64{"reconnect_in_ms": reconnectIn.Milliseconds()},
+		})
+		client.closeAfterQueued(WSCloseShutdown, "server_shutdown")
 	}
 
-	logger.Msg("WebSocket message sent")
-
+	for i, client := range clients {
+		select {
+		case <-client.closed:
+		case <-ctx.Done():
+			for _, client := range clients[i:] {
+				client.close(WSCloseShutdown, "server_shutdown")
+			}
+			return fmt.Errorf("%d WebSocket connections were not drained: %w", len(clients)-i, ctx.Err())
+		}
+	}
+	log.Info().Int("connections", len(clients)).Msg("WebSocket connections drained")
 	return nil
 }
 
+// LastSeen returns when the user was last connected, nil if never or if
+// the hub doesn't store it, see WithLastSeen
+func (h *WSHub) LastSeen(ctx context.Context, userID string) (*time.Time, error) {
+	if h.users == nil {
+		return nil, nil
+	}
+	// Не GetByID: пользователи из кэша приходят без last_seen_at
+	lastSeen, err := h.users.LastSeen(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last seen: %w", err)
+	}
+	return lastSeen, nil
+}
+
 // IsOnline checks if a user is online
 func (h *WSHub) IsOnline(userID string) bool {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
 	_, exists := h.connections[userID]
-	return exists
+	h.mu.RUnlock()
+
+	if exists || h.cluster == nil {
+		return exists
+	}
+
+	instanceID, err := h.owner(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to check presence")
+		return false
+	}
+	return instanceID != ""
+}
+
+// TakeConnectionPeak returns the highest number of local connections since
+// the previous call and starts a new period from the current count
+func (h *WSHub) TakeConnectionPeak() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	peak := h.peak
+	h.peak = len(h.connections)
+	return peak
 }
 
 // GetPartnerID gets the partner ID for a user
@@ -156,9 +501,12 @@ func (h *WSHub) GetPartnerID(userID string) (string, error) {
 }
 
 // notifyPartnerStatus notifies the partner about online/offline status
-func (h *WSHub) notifyPartnerStatus(userID string, online bool) {
-	// Используем background context для получения пары
-	ctx := context.Background()
+func (h *WSHub) notifyPartnerStatus(userID string, online bool, lastSeenAt *time.Time) {
+	defer errreport.Recover(context.Background(), "ws_hub.notify_partner_status", "user_id", userID)
+
+	// Используем background context с таймаутом для получения пары
+	ctx, cancel := context.WithTimeout(context.Background(), hubLookupTimeout)
+	defer cancel()
 
 	// Получить пару пользователя
 	pair, err := h.pairService.GetPairByUserID(ctx, userID)
@@ -174,25 +522,61 @@ func (h *WSHub) notifyPartnerStatus(userID string, online bool) {
 	}
 
 	// Отправить уведомление партнеру
-	h.NotifyPartnerStatus(userID, partnerID, online)
+	h.NotifyPartnerStatus(userID, partnerID, online, lastSeenAt)
 }
 
-// TriggerPhoto handles trigger_photo message
-func (h *WSHub) TriggerPhoto(initiatorID, partnerID string, timestamp int64) error {
-	// Check if partner is online
-	if !h.IsOnline(partnerID) {
-		log.Debug().
-			Str("initiator_id", initiatorID).
-			Str("partner_id", partnerID).
-			Msg("Partner is offline, sending error to initiator")
+// CaptureAt returns when the users should take the photos of a trigger
+// made now: after the countdown of their pair's settings, the hub's unless
+// the pair chose one, and late enough for countdown_start to reach the
+// slowest of their connections first
+func (h *WSHub) CaptureAt(settings *models.PairSettings, userIDs ...string) time.Time {
+	lead := h.countdown
+	if settings != nil && settings.Countdown != nil {
+		lead = *settings.Countdown
+	}
+	for _, userID := range userIDs {
+		// Запас в целый RTT поверх времени доставки (RTT/2)
+		lead = max(lead, h.rtt(userID))
+	}
+	return time.Now().Add(lead)
+}
 
-		message := WSMessage{
-			Type:    "error",
-			Message: "Partner is offline",
-		}
-		return h.SendToUser(initiatorID, message)
+// rtt returns the RTT of the user's local connection, wsUnknownRTT if it
+// isn't known here
+func (h *WSHub) rtt(userID string) time.Duration {
+	h.mu.RLock()
+	client, exists := h.connections[userID]
+	h.mu.RUnlock()
+	if !exists || client.RTT() == 0 {
+		return wsUnknownRTT
 	}
+	return client.RTT()
+}
+
+// countdownData is the data of countdown_start
+type countdownData struct {
+	// DelayMS is how long the client waits before it takes the photo, the
+	// time to capture_at less the delivery time of this message
+	DelayMS int64 `json:"delay_ms"`
+}
 
+// countdownStart turns take_photo with a capture time into the
+// countdown_start a v2 client gets instead
+func countdownStart(message WSMessage, rtt time.Duration, now time.Time) WSMessage {
+	delay := time.UnixMilli(message.CaptureAt).Sub(now) - rtt/2
+	message.Type = "countdown_start"
+	message.Data = countdownData{DelayMS: max(delay, 0).Milliseconds()}
+	return message
+}
+
+// TriggerPhoto handles trigger_photo message. An offline partner gets
+// take_photo by push if the hub has a push fallback; if it can't be sent
+// either, TriggerPhoto returns domain.ErrPartnerOffline without sending
+// anything. take_photo carries the sync session ID, if any, for uploads.
+// With captureAt set, see CaptureAt, connected v2 clients get
+// countdown_start instead and v1 clients take_photo with capture_at; the
+// push to an offline partner has no countdown.
+func (h *WSHub) TriggerPhoto(initiatorID, partnerID, sessionID string, timestamp int64, captureAt time.Time) error {
 	// Use current timestamp if not provided
 	if timestamp == 0 {
 		timestamp = time.Now().UnixMilli()
@@ -202,8 +586,43 @@ func (h *WSHub) TriggerPhoto(initiatorID, partnerID string, timestamp int64) err
 	takePhotoMsg := WSMessage{
 		Type:        "take_photo",
 		InitiatorID: initiatorID,
+		SessionID:   sessionID,
 		Timestamp:   timestamp,
 	}
+	if !captureAt.IsZero() {
+		takePhotoMsg.CaptureAt = captureAt.UnixMilli()
+	}
+
+	// Check if partner is online
+	if !h.IsOnline(partnerID) {
+		if !h.pushable(takePhotoMsg) {
+			log.Debug().
+				Str("initiator_id", initiatorID).
+				Str("partner_id", partnerID).
+				Msg("Partner is offline")
+			return domain.ErrPartnerOffline
+		}
+		// Партнер снимет, когда откроет уведомление, поэтому без отсчета
+		takePhotoMsg.CaptureAt = 0
+		// Инициатор получает take_photo, только если push партнеру ушел
+		if err := h.sendPush(partnerID, takePhotoMsg); err != nil {
+			log.Debug().
+				Err(err).
+				Str("initiator_id", initiatorID).
+				Str("partner_id", partnerID).
+				Msg("Partner is offline and can't be reached by push")
+			return fmt.Errorf("%w: %w", domain.ErrPartnerOffline, err)
+		}
+		if err := h.sendToUser(initiatorID, takePhotoMsg); err != nil {
+			log.Error().Err(err).Str("user_id", initiatorID).Msg("Failed to send take_photo to initiator")
+		}
+		log.Info().
+			Str("initiator_id", initiatorID).
+			Str("partner_id", partnerID).
+			Int64("timestamp", timestamp).
+			Msg("Photo triggered, partner notified by push")
+		return nil
+	}
 
 	log.Debug().
 		Str("initiator_id", initiatorID).
@@ -211,7 +630,7 @@ func (h *WSHub) TriggerPhoto(initiatorID, partnerID string, timestamp int64) err
 		Int64("timestamp", timestamp).
 		Msg("Sending take_photo message to both users")
 
-	if err := h.SendToUser(initiatorID, takePhotoMsg); err != nil {
+	if err := h.sendToUser(initiatorID, takePhotoMsg); err != nil {
 		log.Error().Err(err).Str("user_id", initiatorID).Msg("Failed to send take_photo to initiator")
 	}
 
@@ -230,7 +649,7 @@ func (h *WSHub) TriggerPhoto(initiatorID, partnerID string, timestamp int64) err
 }
 
 // NotifyPartnerStatus notifies partner about online/offline status
-func (h *WSHub) NotifyPartnerStatus(userID, partnerID string, online bool) {
+func (h *WSHub) NotifyPartnerStatus(userID, partnerID string, online bool, lastSeenAt *time.Time) {
 	if partnerID == "" {
 		return
 	}
@@ -242,8 +661,9 @@ func (h *WSHub) NotifyPartnerStatus(userID, partnerID string, online bool) {
 		Msg("Notifying partner about status change")
 
 	message := WSMessage{
-		Type:   "partner_status",
-		Online: &online,
+		Type:       "partner_status",
+		Online:     &online,
+		LastSeenAt: lastSeenAt,
 	}
 
 	if err := h.SendToUser(partnerID, message); err != nil {
@@ -276,6 +696,41 @@ func (h *WSHub) NotifyPairCreated(partnerID string, pairID, userAID, userBID str
 	return h.SendToUser(partnerID, message)
 }
 
+// NotifyPartnerPhotoUploaded tells the partner that a photo of the pair
+// has been uploaded and can be viewed
+func (h *WSHub) NotifyPartnerPhotoUploaded(e PhotoUploadedEvent) error {
+	log.Debug().
+		Str("partner_id", e.PartnerID).
+		Str("photo_id", e.PhotoID).
+		Msg("Notifying partner about uploaded photo")
+
+	message := WSMessage{
+		Type:      "partner_photo_uploaded",
+		PhotoID:   e.PhotoID,
+		SessionID: e.SessionID,
+		Data: map[string]interface{}{
+			"user_id":     e.UserID,
+			"pair_id":     e.PairID,
+			"uploaded_at": e.UploadedAt,
+		},
+	}
+	return h.SendToUser(e.PartnerID, message)
+}
+
+// NotifyPhotoPrompt sends the user the daily prompt of their pair
+func (h *WSHub) NotifyPhotoPrompt(userID string, prompt *models.DailyPrompt) error {
+	log.Debug().
+		Str("user_id", userID).
+		Str("prompt_id", prompt.ID).
+		Msg("Sending daily prompt")
+
+	message := WSMessage{
+		Type: "photo_prompt",
+		Data: prompt,
+	}
+	return h.SendToUser(userID, message)
+}
+
 // NotifyPairDeleted notifies the partner when a pair is deleted
 func (h *WSHub) NotifyPairDeleted(partnerID string) error {
 	log.Debug().
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// benchClientCounts are the numbers of connected clients the hub
+// benchmarks run with
+var benchClientCounts = []int{1000, 10000}
+
+// benchUserID is the ID of the i-th benchmark user; users 2k and 2k+1 are
+// partners
+func benchUserID(i int) string {
+	return fmt.Sprintf("bench-user-%05d", i)
+}
+
+// newBenchHub returns a hub with n connected clients, v1 and v2 in turn,
+// whose queues are drained as fast as they fill. The clients have no
+// connection, so the benchmarks measure the hub and not the network;
+// ws-bench measures delivery over loopback.
+func newBenchHub(b *testing.B, n int) *WSHub {
+	b.Helper()
+
+	// Хаб пишет в лог каждый вызов, это искажает замер
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	b.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	hub := NewWSHub(nil)
+	for i := range n {
+		client := &WSClient{
+			userID:    benchUserID(i),
+			protocol:  WSProtocolV1 + i%2,
+			heartbeat: hub.heartbeat,
+			send:      make(chan []byte, wsSendQueueSize),
+			done:      make(chan struct{}),
+			closed:    make(chan struct{}),
+		}
+		go func() {
+			for {
+				select {
+				case <-client.send:
+				case <-client.done:
+					return
+				}
+			}
+		}()
+		b.Cleanup(func() { close(client.done) })
+		hub.connections[client.userID] = client
+	}
+	return hub
+}
+
+// runParallel calls fn with successive indexes from parallel goroutines and
+// fails the benchmark if any call failed. Indexes go round the clients, so
+// no queue falls behind and gets its client evicted.
+func runParallel(b *testing.B, fn func(i int) error) {
+	b.Helper()
+
+	var next, failed atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := fn(int(next.Add(1))); err != nil {
+				failed.Add(1)
+			}
+		}
+	})
+	b.StopTimer()
+	if n := failed.Load(); n > 0 {
+		b.Fatalf("%d of %d calls failed", n, b.N)
+	}
+}
+
+func BenchmarkSendToUser(b *testing.B) {
+	for _, n := range benchClientCounts {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			hub := newBenchHub(b, n)
+			message := WSMessage{Type: "partner_ready_state", State: "aiming"}
+
+			runParallel(b, func(i int) error {
+				message := message
+				message.Timestamp = time.Now().UnixMilli()
+				return hub.SendToUser(benchUserID(i%n), message)
+			})
+		})
+	}
+}
+
+func BenchmarkTriggerPhoto(b *testing.B) {
+	for _, n := range benchClientCounts {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			hub := newBenchHub(b, n)
+
+			runParallel(b, func(i int) error {
+				pair := i % (n / 2) * 2
+				now := time.Now()
+				return hub.TriggerPhoto(benchUserID(pair), benchUserID(pair+1), "", now.UnixMilli(), now.Add(defaultCountdown))
+			})
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCountdownStartFrames turns the golden take_photo into countdown_start
+// as the hub does for a v2 client and checks it encodes into the golden
+// countdown_start frames of both versions
+func TestCountdownStartFrames(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "handlers", "testdata", "ws_server_frames.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames []struct {
+		Name    string          `json:"name"`
+		Message WSMessage       `json:"message"`
+		V1      json.RawMessage `json:"v1"`
+		V2      json.RawMessage `json:"v2"`
+	}
+	if err := json.Unmarshal(data, &frames); err != nil {
+		t.Fatal(err)
+	}
+	golden := make(map[string]int, len(frames))
+	for i, frame := range frames {
+		golden[frame.Name] = i
+	}
+	takePhoto, ok := golden["take_photo"]
+	if !ok {
+		t.Fatal("no take_photo frame")
+	}
+	countdown, ok := golden["countdown_start"]
+	if !ok {
+		t.Fatal("no countdown_start frame")
+	}
+
+	// Сервер на golden-времени, доставка занимает половину RTT в 100 мс
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	message := countdownStart(frames[takePhoto].Message, 100*time.Millisecond, now)
+
+	for _, version := range []struct {
+		protocol int
+		want     json.RawMessage
+	}{
+		{WSProtocolV1, frames[countdown].V1},
+		{WSProtocolV2, frames[countdown].V2},
+	} {
+		encoded, err := EncodeWSMessage(version.protocol, message)
+		if err != nil {
+			t.Fatalf("EncodeWSMessage v%d: %v", version.protocol, err)
+		}
+		var got, want interface{}
+		if err := json.Unmarshal(encoded, &got); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(version.want, &want); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			var compact bytes.Buffer
+			json.Compact(&compact, version.want)
+			t.Errorf("v%d frame = %s\nwant       %s", version.protocol, encoded, compact.Bytes())
+		}
+	}
+}
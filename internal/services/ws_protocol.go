@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebSocket protocol versions. Clients opt in to v2 with the
+// WSSubprotocolV2 subprotocol; connections without it speak v1.
+const (
+	WSProtocolV1 = 1
+	WSProtocolV2 = 2
+	// WSSubprotocolV1 marks v1 connections. Only needed next to a token
+	// subprotocol: browsers drop connections that offered subprotocols
+	// when the server selects none.
+	WSSubprotocolV1 = "syncphoto.v1"
+	// WSSubprotocolV2 is the Sec-WebSocket-Protocol of v2 connections
+	WSSubprotocolV2 = "syncphoto.v2"
+	// WSTokenSubprotocolPrefix prefixes an access token offered as a
+	// Sec-WebSocket-Protocol, which keeps it out of URLs logged by
+	// proxies. The server never selects it, so it must be offered with
+	// WSSubprotocolV1 or WSSubprotocolV2.
+	WSTokenSubprotocolPrefix = "syncphoto.token."
+)
+
+// WSEnvelope is a v2 frame: the message type, the ID of the client message
+// it answers, if any, and the type's payload
+type WSEnvelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsPayload is the v2 payload of an outgoing WSMessage: its fields without
+// the type and ID, which go to the envelope
+type wsPayload struct {
+	Timestamp   int64       `json:"timestamp,omitempty"`
+	CaptureAt   int64       `json:"capture_at,omitempty"`
+	InitiatorID string      `json:"initiator_id,omitempty"`
+	PhotoID     string      `json:"photo_id,omitempty"`
+	SessionID   string      `json:"session_id,omitempty"`
+	Online      *bool       `json:"online,omitempty"`
+	State       string      `json:"state,omitempty"`
+	Seq         int64       `json:"seq,omitempty"`
+	LastSeenAt  *time.Time  `json:"last_seen_at,omitempty"`
+	Message     string      `json:"message,omitempty"`
+	Code        string      `json:"code,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+}
+
+// EncodeWSMessage encodes a message in the protocol version of a client,
+// as the hub writes it
+func EncodeWSMessage(protocol int, message WSMessage) ([]byte, error) {
+	if protocol != WSProtocolV2 {
+		return json.Marshal(message)
+	}
+
+	payload, err := json.Marshal(wsPayload{
+		Timestamp:   message.Timestamp,
+		CaptureAt:   message.CaptureAt,
+		InitiatorID: message.InitiatorID,
+		PhotoID:     message.PhotoID,
+		SessionID:   message.SessionID,
+		Online:      message.Online,
+		State:       message.State,
+		Seq:         message.Seq,
+		LastSeenAt:  message.LastSeenAt,
+		Message:     message.Message,
+		Code:        message.Code,
+		Data:        message.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	envelope := WSEnvelope{V: WSProtocolV2, Type: message.Type, ID: message.ID}
+	// Пустой payload (ack, pair_deleted) не передается
+	if string(payload) != "{}" {
+		envelope.Payload = payload
+	}
+	return json.Marshal(envelope)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// wsRelayLimit caps how often a connection relays a message type: burst
+// messages at once, then one every interval
+type wsRelayLimit struct {
+	burst int
+	every time.Duration
+}
+
+// wsEphemeralTypes are the client message types the hub relays to the
+// partner as partner_<type>, with their caps. They are neither stored nor
+// pushed: an offline partner misses them, and a lost one is superseded by
+// the next.
+var wsEphemeralTypes = map[string]wsRelayLimit{
+	"ready_state": {burst: 5, every: 500 * time.Millisecond},
+}
+
+// wsRelayLimiter enforces the relay caps of a connection. Each type has the
+// time its bucket is full again (GCRA), so the state is one time per type.
+type wsRelayLimiter struct {
+	mu   sync.Mutex
+	full map[string]time.Time
+}
+
+// allow takes a message of the type from the bucket at now; if it is
+// empty, it returns how long until it isn't
+func (l *wsRelayLimiter) allow(messageType string, limit wsRelayLimit, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	full := l.full[messageType]
+	if full.Before(now) {
+		full = now
+	}
+	// В корзине burst сообщений: пока она не опустела, запас времени
+	// до полной корзины меньше burst интервалов
+	if wait := full.Sub(now) - time.Duration(limit.burst-1)*limit.every; wait > 0 {
+		return wait
+	}
+	if l.full == nil {
+		l.full = make(map[string]time.Time)
+	}
+	l.full[messageType] = full.Add(limit.every)
+	return 0
+}
+
+// Relayable reports whether messages of the type are relayed to the
+// partner by RelayToPartner
+func Relayable(messageType string) bool {
+	_, ok := wsEphemeralTypes[messageType]
+	return ok
+}
+
+// RelayToPartner relays an ephemeral message of the user's connection on
+// this replica to their partner as partner_<type>, stamped with the server
+// time. A partner who isn't connected just doesn't get it. Over the cap of
+// the type nothing is relayed and it returns how long until the connection
+// may relay it again.
+func (h *WSHub) RelayToPartner(userID, partnerID string, message WSMessage) time.Duration {
+	limit, ok := wsEphemeralTypes[message.Type]
+	if !ok {
+		log.Error().Str("message_type", message.Type).Msg("Attempted to relay a non-ephemeral WebSocket message")
+		return 0
+	}
+
+	h.mu.RLock()
+	client, exists := h.connections[userID]
+	h.mu.RUnlock()
+	if !exists {
+		// Соединение уже закрыто
+		return 0
+	}
+
+	now := time.Now()
+	if wait := client.relays.allow(message.Type, limit, now); wait > 0 {
+		return wait
+	}
+
+	relayed := WSMessage{
+		Type:      "partner_" + message.Type,
+		Timestamp: now.UnixMilli(),
+		State:     message.State,
+	}
+	if err := h.sendToUser(partnerID, relayed); err != nil {
+		log.Debug().
+			Err(err).
+			Str("partner_id", partnerID).
+			Str("message_type", relayed.Type).
+			Msg("Ephemeral WebSocket message not relayed")
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package validation
+
+import (
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// isEmoji is the "emoji" rule: one emoji, possibly spelled with several
+// code points like skin tones, ZWJ families, flags and keycaps. It only
+// checks the kinds of characters, not that the sequence is one the
+// Unicode emoji list has.
+func isEmoji(fl validator.FieldLevel) bool {
+	symbol := false
+	for _, r := range fl.Field().String() {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Me, r):
+			// Пиктограммы, флаги и рамка кейкапа
+			symbol = true
+		case unicode.Is(unicode.Sk, r), unicode.Is(unicode.Mn, r), unicode.Is(unicode.Cf, r):
+			// Оттенки кожи, селекторы вариантов, ZWJ и теги флагов регионов
+		case r == '#' || r == '*' || (r >= '0' && r <= '9'):
+			// Основа кейкапа
+		default:
+			return false
+		}
+	}
+	return symbol
+}
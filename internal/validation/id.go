@@ -0,0 +1,24 @@
+package validation
+
+import (
+	"fmt"
+
+	"sync-photo-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// IsUUID reports whether s is a UUID in the canonical 36-character form
+// IDs are issued in
+func IsUUID(s string) bool {
+	return len(s) == 36 && uuid.Validate(s) == nil
+}
+
+// CheckID returns domain.ErrInvalidID naming the field if id isn't a UUID,
+// so malformed IDs are rejected before they reach the uuid columns
+func CheckID(field, id string) error {
+	if !IsUUID(id) {
+		return fmt.Errorf("%w: %s must be a UUID", domain.ErrInvalidID, field)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package validation checks request DTOs against their `validate` struct
+// tags (github.com/go-playground/validator) and reports field-level errors
+// named after the JSON or query field, so clients can point at the input.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// FieldError describes a field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Ошибки называют поле так, как его прислал клиент
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return fieldName(field)
+	})
+	// Теги регистрируются до первого использования, ошибка невозможна
+	_ = v.RegisterValidation("emoji", isEmoji)
+	_ = v.RegisterValidation("notblank", validators.NotBlank)
+	return v
+}
+
+// Struct validates v and returns its field errors, nil if v is valid or
+// isn't a struct
+func Struct(v interface{}) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(validate.Struct(v), &validationErrs) {
+		return nil
+	}
+
+	fieldErrs := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+	return fieldErrs
+}
+
+// Query fills the fields of the struct v points to that have a `query` tag
+// from the query parameters and validates it. Supported field types are
+// string, int and *int; absent or empty parameters leave the field unchanged.
+func Query(values url.Values, v interface{}) []FieldError {
+	target := reflect.ValueOf(v).Elem()
+	var fieldErrs []FieldError
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name, ok := field.Tag.Lookup("query")
+		if !ok || values.Get(name) == "" {
+			continue
+		}
+		if err := setField(target.Field(i), values.Get(name)); err != nil {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   name,
+				Rule:    "type",
+				Message: fmt.Sprintf("%s %s", name, err),
+			})
+		}
+	}
+	if fieldErrs != nil {
+		return fieldErrs
+	}
+	return Struct(v)
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.Set(reflect.ValueOf(&n))
+	default:
+		panic(fmt.Sprintf("validation: unsupported query field type %s", field.Type()))
+	}
+	return nil
+}
+
+// fieldName returns the JSON or query name of the field
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// message describes the failed rule in the words of the v1 error messages
+func message(fe validator.FieldError) string {
+	field, param := fe.Field(), fe.Param()
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "len":
+		if isString {
+			return fmt.Sprintf("%s must be %s characters", field, param)
+		}
+		return fmt.Sprintf("%s must have %s items", field, param)
+	case "min":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters", field, param)
+		}
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters", field, param)
+		}
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	case "datetime":
+		if param == "15:04" {
+			return field + " must be in HH:MM format"
+		}
+		return fmt.Sprintf("%s must match the %s layout", field, param)
+	case "notblank":
+		return field + " must not be blank"
+	case "emoji":
+		return field + " must be an emoji"
+	case "timezone":
+		return field + " must be an IANA time zone"
+	case "url", "http_url":
+		return field + " must be a valid URL"
+	default:
+		return field + " is invalid"
+	}
+}